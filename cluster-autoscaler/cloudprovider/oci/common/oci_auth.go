@@ -0,0 +1,133 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+const (
+	// notAuthenticatedErrorCode is the service error code returned by OCI when a request could not be authenticated.
+	notAuthenticatedErrorCode = "NotAuthenticated"
+	// authRefreshMaxAttempts is the maximum number of times a call is attempted: once, and once more after the
+	// signer was refreshed.
+	authRefreshMaxAttempts = 2
+)
+
+var (
+	// authRefreshRetryDelay is the time to wait after refreshing the signer before retrying a call that failed with
+	// an authentication error.
+	authRefreshRetryDelay = 1 * time.Second
+)
+
+// SignerRefresher refreshes the credentials used to sign the requests sent to OCI.
+type SignerRefresher func() error
+
+// IsAuthError returns true if the given error is an OCI authentication error (401 / NotAuthenticated), which
+// typically means the security token used to sign the request has expired.
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	err = errors.Cause(err)
+
+	serviceErr, ok := common.IsServiceError(err)
+	if !ok {
+		return false
+	}
+
+	return serviceErr.GetHTTPStatusCode() == http.StatusUnauthorized || serviceErr.GetCode() == notAuthenticatedErrorCode
+}
+
+// refreshableSigner signs requests with a signer which can be replaced while requests are being sent.
+type refreshableSigner struct {
+	mutex  sync.RWMutex
+	signer common.HTTPRequestSigner
+}
+
+// Sign signs the request with the current signer.
+func (s *refreshableSigner) Sign(r *http.Request) error {
+	s.mutex.RLock()
+	signer := s.signer
+	s.mutex.RUnlock()
+	return signer.Sign(r)
+}
+
+func (s *refreshableSigner) set(signer common.HTTPRequestSigner) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.signer = signer
+}
+
+// NewInstancePrincipalSignerRefresher returns a SignerRefresher that re-creates the instance principal configuration
+// provider, which fetches a new security token, and makes all the given clients sign their requests with a signer
+// based on it. The signers of the clients are wrapped right away, so it has to be called before the clients are
+// used, while refreshing is safe while requests are being sent.
+func NewInstancePrincipalSignerRefresher(clients ...*common.BaseClient) SignerRefresher {
+	signers := make([]*refreshableSigner, 0, len(clients))
+	for _, client := range clients {
+		signer := &refreshableSigner{signer: client.Signer}
+		client.Signer = signer
+		signers = append(signers, signer)
+	}
+	return func() error {
+		configProvider, err := auth.InstancePrincipalConfigurationProvider()
+		if err != nil {
+			return errors.Wrap(err, "unable to create instance principal configuration provider")
+		}
+		signer := common.DefaultRequestSigner(configProvider)
+		for _, s := range signers {
+			s.set(signer)
+		}
+		klog.V(4).Infof("refreshed instance principal signer for %d client(s)", len(signers))
+		return nil
+	}
+}
+
+// CallWithAuthRefresh calls fn and, if it fails with an authentication error, waits briefly, refreshes the signer and
+// calls fn once more, unless ctx is done first. Any other result of fn is returned immediately. If refresh is nil, fn
+// is called once.
+func CallWithAuthRefresh(ctx context.Context, refresh SignerRefresher, fn func() error) error {
+	if refresh == nil {
+		return fn()
+	}
+
+	backoff := wait.Backoff{
+		Duration: authRefreshRetryDelay,
+		Steps:    authRefreshMaxAttempts,
+	}
+
+	var err error
+	waitErr := wait.ExponentialBackoffWithContext(ctx, backoff, func(context.Context) (bool, error) {
+		if err != nil {
+			klog.Warningf("OCI request failed with an authentication error, refreshing signer before retrying: %v", err)
+			if refreshErr := refresh(); refreshErr != nil {
+				klog.Errorf("failed to refresh OCI signer: %v", refreshErr)
+				return true, nil
+			}
+		}
+		err = fn()
+		return !IsAuthError(err), nil
+	})
+	if waitErr != nil && err == nil {
+		// ctx was done before fn was called
+		return waitErr
+	}
+	if waitErr != nil && IsAuthError(err) {
+		klog.Errorf("OCI request still failing with an authentication error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
+)
+
+type mockServiceError struct {
+	statusCode int
+	code       string
+}
+
+func (e mockServiceError) Error() string           { return e.code }
+func (e mockServiceError) GetHTTPStatusCode() int  { return e.statusCode }
+func (e mockServiceError) GetMessage() string      { return e.code }
+func (e mockServiceError) GetCode() string         { return e.code }
+func (e mockServiceError) GetOpcRequestID() string { return "" }
+
+// mockUnauthenticatedClient fails the first failures calls with a 401 and succeeds afterwards.
+type mockUnauthenticatedClient struct {
+	failures     int
+	requestCount int
+}
+
+func (m *mockUnauthenticatedClient) GetInstancePool(context.Context, core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	m.requestCount++
+	if m.requestCount <= m.failures {
+		return core.GetInstancePoolResponse{}, mockServiceError{statusCode: http.StatusUnauthorized, code: notAuthenticatedErrorCode}
+	}
+	return core.GetInstancePoolResponse{}, nil
+}
+
+func TestIsAuthError(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil error":              {err: nil, expected: false},
+		"non service error":      {err: errors.New("boom"), expected: false},
+		"unauthorized":           {err: mockServiceError{statusCode: http.StatusUnauthorized}, expected: true},
+		"not authenticated code": {err: mockServiceError{statusCode: http.StatusBadRequest, code: notAuthenticatedErrorCode}, expected: true},
+		"too many requests":      {err: mockServiceError{statusCode: http.StatusTooManyRequests, code: "TooManyRequests"}, expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsAuthError(tc.err); got != tc.expected {
+				t.Errorf("got %v ; wanted %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCallWithAuthRefresh(t *testing.T) {
+	defer func(d time.Duration) { authRefreshRetryDelay = d }(authRefreshRetryDelay)
+	authRefreshRetryDelay = time.Millisecond
+
+	testCases := map[string]struct {
+		failures             int
+		expectedRequestCount int
+		expectedRefreshCount int
+		expectedErr          bool
+	}{
+		"401 once then success": {
+			failures:             1,
+			expectedRequestCount: 2,
+			expectedRefreshCount: 1,
+		},
+		"401 on every attempt": {
+			failures:             authRefreshMaxAttempts + 1,
+			expectedRequestCount: authRefreshMaxAttempts,
+			expectedRefreshCount: authRefreshMaxAttempts - 1,
+			expectedErr:          true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := &mockUnauthenticatedClient{failures: tc.failures}
+			refreshCount := 0
+			refresh := func() error {
+				refreshCount++
+				return nil
+			}
+
+			err := CallWithAuthRefresh(context.Background(), refresh, func() error {
+				_, err := client.GetInstancePool(context.Background(), core.GetInstancePoolRequest{})
+				return err
+			})
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("unexpected error result: %v", err)
+			}
+			if client.requestCount != tc.expectedRequestCount {
+				t.Errorf("got %d requests ; wanted %d", client.requestCount, tc.expectedRequestCount)
+			}
+			if refreshCount != tc.expectedRefreshCount {
+				t.Errorf("got %d signer refreshes ; wanted %d", refreshCount, tc.expectedRefreshCount)
+			}
+		})
+	}
+}
+
+func TestCallWithAuthRefreshContextDone(t *testing.T) {
+	defer func(d time.Duration) { authRefreshRetryDelay = d }(authRefreshRetryDelay)
+	authRefreshRetryDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &mockUnauthenticatedClient{failures: 1}
+	refreshCount := 0
+	refresh := func() error {
+		refreshCount++
+		return nil
+	}
+
+	called, done := make(chan struct{}), make(chan error)
+	go func() {
+		done <- CallWithAuthRefresh(ctx, refresh, func() error {
+			defer close(called)
+			_, err := client.GetInstancePool(ctx, core.GetInstancePoolRequest{})
+			return err
+		})
+	}()
+	<-called
+	cancel()
+
+	select {
+	case err := <-done:
+		if !IsAuthError(err) {
+			t.Errorf("got error %v ; wanted the authentication error", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the retry wait did not stop when the context was done")
+	}
+	if client.requestCount != 1 {
+		t.Errorf("got %d requests ; wanted 1", client.requestCount)
+	}
+	if refreshCount != 0 {
+		t.Errorf("got %d signer refreshes ; wanted 0", refreshCount)
+	}
+}
+
+// mockSigner counts the requests it signed.
+type mockSigner struct {
+	mutex sync.Mutex
+	count int
+}
+
+func (m *mockSigner) Sign(*http.Request) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.count++
+	return nil
+}
+
+func (m *mockSigner) signed() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.count
+}
+
+func TestNewInstancePrincipalSignerRefresherWrapsSigners(t *testing.T) {
+	original := &mockSigner{}
+	client := &common.BaseClient{Signer: original}
+
+	if refresh := NewInstancePrincipalSignerRefresher(client); refresh == nil {
+		t.Fatal("got nil refresher")
+	}
+	if _, ok := client.Signer.(*refreshableSigner); !ok {
+		t.Fatalf("got signer of type %T ; wanted *refreshableSigner", client.Signer)
+	}
+	if err := client.Signer.Sign(&http.Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if original.signed() != 1 {
+		t.Errorf("got %d requests signed by the original signer ; wanted 1", original.signed())
+	}
+}
+
+func TestRefreshableSignerConcurrentRefresh(t *testing.T) {
+	original, refreshed := &mockSigner{}, &mockSigner{}
+	signer := &refreshableSigner{signer: original}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := signer.Sign(&http.Request{}); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+	signer.set(refreshed)
+	wg.Wait()
+
+	if total := original.signed() + refreshed.signed(); total != 1000 {
+		t.Errorf("got %d signed requests ; wanted 1000", total)
+	}
+	if err := signer.Sign(&http.Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshed.signed() == 0 {
+		t.Error("requests are not signed by the refreshed signer")
+	}
+}
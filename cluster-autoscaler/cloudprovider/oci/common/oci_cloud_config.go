@@ -20,6 +20,7 @@ import (
 type CloudConfig struct {
 	Global struct {
 		RefreshInterval        time.Duration `gcfg:"refresh-interval"`
+		APITimeout             time.Duration `gcfg:"api-timeout"`
 		CompartmentID          string        `gcfg:"compartment-id"`
 		Region                 string        `gcfg:"region"`
 		UseInstancePrinciples  bool          `gcfg:"use-instance-principals"`
@@ -69,6 +70,16 @@ func CreateCloudConfig(cloudConfigPath string, configProvider common.Configurati
 		}
 	}
 
+	if cloudConfig.Global.APITimeout == 0 && os.Getenv(ipconsts.OciAPITimeoutEnvVar) != "" {
+		apiTimeout, err := time.ParseDuration(os.Getenv(ipconsts.OciAPITimeoutEnvVar))
+		if err != nil {
+			klog.Warningf("ignoring invalid OCI API timeout %q: %v", os.Getenv(ipconsts.OciAPITimeoutEnvVar), err)
+		} else {
+			klog.V(4).Infof("using an OCI API timeout of %v...", apiTimeout)
+			cloudConfig.Global.APITimeout = apiTimeout
+		}
+	}
+
 	// this env var is only relevant for instance pools
 	if os.Getenv(ipconsts.OciUseNonPoolMemberAnnotationEnvVar) == "true" {
 		cloudConfig.Global.UseNonMemberAnnotation = true
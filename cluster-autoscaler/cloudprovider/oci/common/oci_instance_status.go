@@ -0,0 +1,36 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"strings"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
+	"k8s.io/klog/v2"
+)
+
+// InstanceStatusFromLifecycleState maps the lifecycle state of an OCI compute instance to the status of an
+// autoscaler instance. It returns nil for instances which are gone or on their way out for good, i.e. stopped or
+// terminated, and for unknown states, so that they aren't counted in the size of their node group.
+// States are matched regardless of case, as instance pool members report them in a different case than instances.
+func InstanceStatusFromLifecycleState(instanceID string, state string) *cloudprovider.InstanceStatus {
+	switch core.InstanceLifecycleStateEnum(strings.ToUpper(state)) {
+	case core.InstanceLifecycleStateStopped, core.InstanceLifecycleStateTerminated:
+		klog.V(4).Infof("skipping instance is in stopped/terminated state: %q", instanceID)
+		return nil
+	case core.InstanceLifecycleStateCreatingImage, core.InstanceLifecycleStateStarting, core.InstanceLifecycleStateProvisioning, core.InstanceLifecycleStateMoving:
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}
+	// in case an instance is running, it could either be installing OKE software or become a Ready node.
+	// we do not know, but as we only need info if a node is stopped / terminated, we do not care
+	case core.InstanceLifecycleStateRunning:
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}
+	case core.InstanceLifecycleStateStopping, core.InstanceLifecycleStateTerminating:
+		return &cloudprovider.InstanceStatus{State: cloudprovider.InstanceDeleting}
+	default:
+		klog.Warningf("instance found in unhandled state: (%q = %v)", instanceID, state)
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func TestInstanceStatusFromLifecycleState(t *testing.T) {
+	// a nil state means the instance isn't reported
+	expected := map[core.InstanceLifecycleStateEnum]*cloudprovider.InstanceState{
+		core.InstanceLifecycleStateMoving:        stateOf(cloudprovider.InstanceCreating),
+		core.InstanceLifecycleStateProvisioning:  stateOf(cloudprovider.InstanceCreating),
+		core.InstanceLifecycleStateRunning:       stateOf(cloudprovider.InstanceRunning),
+		core.InstanceLifecycleStateStarting:      stateOf(cloudprovider.InstanceCreating),
+		core.InstanceLifecycleStateStopping:      stateOf(cloudprovider.InstanceDeleting),
+		core.InstanceLifecycleStateStopped:       nil,
+		core.InstanceLifecycleStateCreatingImage: stateOf(cloudprovider.InstanceCreating),
+		core.InstanceLifecycleStateTerminating:   stateOf(cloudprovider.InstanceDeleting),
+		core.InstanceLifecycleStateTerminated:    nil,
+	}
+	for _, state := range core.GetInstanceLifecycleStateEnumValues() {
+		want, found := expected[state]
+		if !found {
+			t.Errorf("no expectation for lifecycle state %q", state)
+			continue
+		}
+		assertInstanceState(t, string(state), want)
+	}
+
+	// instance pool members report their state in a different case
+	assertInstanceState(t, "Running", stateOf(cloudprovider.InstanceRunning))
+	assertInstanceState(t, "Terminating", stateOf(cloudprovider.InstanceDeleting))
+	assertInstanceState(t, "Stopped", nil)
+	assertInstanceState(t, "SOME_NEW_STATE", nil)
+}
+
+func stateOf(state cloudprovider.InstanceState) *cloudprovider.InstanceState {
+	return &state
+}
+
+func assertInstanceState(t *testing.T, lifecycleState string, want *cloudprovider.InstanceState) {
+	t.Helper()
+	status := InstanceStatusFromLifecycleState("ocid1.instance.oc1.test", lifecycleState)
+	if want == nil {
+		if status != nil {
+			t.Errorf("lifecycle state %q: expected the instance to be skipped, got state %v", lifecycleState, status.State)
+		}
+		return
+	}
+	if status == nil {
+		t.Errorf("lifecycle state %q: expected state %v, got the instance skipped", lifecycleState, *want)
+		return
+	}
+	if status.State != *want {
+		t.Errorf("lifecycle state %q: expected state %v, got %v", lifecycleState, *want, status.State)
+	}
+	if status.ErrorInfo != nil {
+		t.Errorf("lifecycle state %q: expected no error info, got %+v", lifecycleState, status.ErrorInfo)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	taintutil "k8s.io/kubernetes/pkg/util/taints"
+)
+
+// KubeletExtraArgsMetadataKey is the instance metadata key holding the extra flags the kubelet of a node is started with.
+const KubeletExtraArgsMetadataKey = "kubelet-extra-args"
+
+// the expressions account for flags starting with one or two dashes
+// and for whether the value was specified with an equal or a space
+var (
+	registerWithTaintsFlag = regexp.MustCompile(`-?-register-with-taints[= ](\S*)`)
+	nodeLabelsFlag         = regexp.MustCompile(`-?-node-labels[= ](\S*)`)
+)
+
+// GetRegisteredTaints returns the taints the kubelet registers the node with, as given by the
+// --register-with-taints flag of the kubelet extra args.
+func GetRegisteredTaints(kubeletArgs string) ([]apiv1.Taint, error) {
+	submatches := registerWithTaintsFlag.FindStringSubmatch(kubeletArgs)
+	// if we found a match, then the match indexes will be
+	// [0] regex match
+	// [1] taint info
+	if len(submatches) != 2 || submatches[1] == "" {
+		return []apiv1.Taint{}, nil
+	}
+	// deleteTaints don't mean anything in this context
+	addTaints, _, err := taintutil.ParseTaints(strings.Split(submatches[1], ","))
+	if err != nil {
+		return []apiv1.Taint{}, err
+	}
+	return addTaints, nil
+}
+
+// GetRegisteredLabels returns the labels the kubelet registers the node with, as given by the
+// --node-labels flag of the kubelet extra args.
+func GetRegisteredLabels(kubeletArgs string) (map[string]string, error) {
+	labels := map[string]string{}
+	submatches := nodeLabelsFlag.FindStringSubmatch(kubeletArgs)
+	if len(submatches) != 2 || submatches[1] == "" {
+		return labels, nil
+	}
+	for _, label := range strings.Split(submatches[1], ",") {
+		key, value, found := strings.Cut(label, "=")
+		if !found || key == "" {
+			return map[string]string{}, fmt.Errorf("invalid node label %q", label)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021-2023 Oracle and/or its affiliates.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetRegisteredLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		kubeletArgs string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "no kubelet extra args",
+			kubeletArgs: "",
+			want:        map[string]string{},
+		},
+		{
+			name:        "kubelet extra args without node-labels flag",
+			kubeletArgs: "--register-with-taints=testTaint1=hello:NoSchedule --address 0.0.0.0",
+			want:        map[string]string{},
+		},
+		{
+			name:        "node-labels flag using =",
+			kubeletArgs: "--node-labels=workload=batch,tier=gpu --address 0.0.0.0",
+			want:        map[string]string{"workload": "batch", "tier": "gpu"},
+		},
+		{
+			name:        "node-labels flag using space instead of =",
+			kubeletArgs: "-node-labels workload=batch",
+			want:        map[string]string{"workload": "batch"},
+		},
+		{
+			name:        "node-labels flag with bad label",
+			kubeletArgs: "--node-labels=workload=batch,tier",
+			want:        map[string]string{},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetRegisteredLabels(tt.kubeletArgs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetRegisteredLabels() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRegisteredLabels() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
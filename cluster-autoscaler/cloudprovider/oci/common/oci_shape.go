@@ -21,6 +21,7 @@ import (
 type ShapeGetter interface {
 	GetNodePoolShape(*oke.NodePool, int64) (*Shape, error)
 	GetInstancePoolShape(pool *core.InstancePool) (*Shape, error)
+	GetInstancePoolShapes(pool *core.InstancePool) ([]*Shape, error)
 	Refresh()
 }
 
@@ -36,15 +37,21 @@ type ShapeClientImpl struct {
 	ComputeMgmtClient core.ComputeManagementClient
 	// Can fetch shapes directly
 	ComputeClient core.ComputeClient
+	// Bounds each call, 0 leaves the calls without deadline
+	APITimeout time.Duration
 }
 
 // GetInstanceConfiguration gets the instance configuration.
 func (cc ShapeClientImpl) GetInstanceConfiguration(ctx context.Context, req core.GetInstanceConfigurationRequest) (core.GetInstanceConfigurationResponse, error) {
+	ctx, cancel := WithAPITimeout(ctx, cc.APITimeout)
+	defer cancel()
 	return cc.ComputeMgmtClient.GetInstanceConfiguration(ctx, req)
 }
 
 // ListShapes lists the shapes.
 func (cc ShapeClientImpl) ListShapes(ctx context.Context, req core.ListShapesRequest) (core.ListShapesResponse, error) {
+	ctx, cancel := WithAPITimeout(ctx, cc.APITimeout)
+	defer cancel()
 	return cc.ComputeClient.ListShapes(ctx, req)
 }
 
@@ -56,26 +63,37 @@ type Shape struct {
 	GPU                     int
 	MemoryInBytes           float32
 	EphemeralStorageInBytes float32
+	// Metadata is the instance metadata the instances of the shape are launched with, if known.
+	Metadata map[string]string
+	// CapacityReservationID is the compute capacity reservation the instances of the shape are launched into, if any.
+	CapacityReservationID string
 }
 
 // CreateShapeGetter creates a new oci shape getter.
 func CreateShapeGetter(shapeClient ShapeClient) ShapeGetter {
 	return &shapeGetterImpl{
-		shapeClient: shapeClient,
-		cache:       map[string]*Shape{},
+		shapeClient:       shapeClient,
+		cache:             map[string]*Shape{},
+		instancePoolCache: map[string][]*Shape{},
 	}
 }
 
 type shapeGetterImpl struct {
 	shapeClient ShapeClient
 	cache       map[string]*Shape
-	mu          sync.Mutex
+	// shapes of the instance pools, keyed by instance pool id
+	instancePoolCache map[string][]*Shape
+	mu                sync.Mutex
 }
 
 // Refresh clears out the cache to be populated again as the pool shapes are re-requested
 func (osf *shapeGetterImpl) Refresh() {
+	osf.mu.Lock()
+	defer osf.mu.Unlock()
+
 	// For now, just clear the cache
 	osf.cache = map[string]*Shape{}
+	osf.instancePoolCache = map[string][]*Shape{}
 }
 
 // GetNodePoolShape gets the shape by querying the node pool's configuration
@@ -141,17 +159,29 @@ func (osf *shapeGetterImpl) GetNodePoolShape(np *oke.NodePool, ephemeralStorage
 	return nil, fmt.Errorf("shape %q does not exist", shapeName)
 }
 
-// GetInstancePoolShape gets the shape by querying the instance pool's configuration
+// GetInstancePoolShape gets the shape by querying the instance pool's configuration. For instance pools configured
+// with more than one shape, the first (preferred) shape is returned.
 func (osf *shapeGetterImpl) GetInstancePoolShape(ip *core.InstancePool) (*Shape, error) {
+	shapes, err := osf.GetInstancePoolShapes(ip)
+	if err != nil {
+		return nil, err
+	}
+	return shapes[0], nil
+}
+
+// GetInstancePoolShapes gets all the shapes an instance pool can launch instances with, in the order of preference
+// of its instance configuration. Pools whose configuration has a single set of instance details return one shape.
+func (osf *shapeGetterImpl) GetInstancePoolShapes(ip *core.InstancePool) ([]*Shape, error) {
+	osf.mu.Lock()
+	defer osf.mu.Unlock()
 
 	// First, check instance pool shape cache
-	shape, ok := osf.cache[*ip.Id]
+	shapes, ok := osf.instancePoolCache[*ip.Id]
 	if ok {
-		return shape, nil
+		return shapes, nil
 	}
 
 	klog.V(5).Info("fetching shape configuration details for instance-pool " + *ip.Id)
-	shape = &Shape{}
 
 	instanceConfig, err := osf.shapeClient.GetInstanceConfiguration(context.Background(), core.GetInstanceConfigurationRequest{
 		InstanceConfigurationId: ip.InstanceConfigurationId,
@@ -164,9 +194,25 @@ func (osf *shapeGetterImpl) GetInstancePoolShape(ip *core.InstancePool) (*Shape,
 		return nil, fmt.Errorf("instance configuration details for instance %s has not been set", *ip.Id)
 	}
 
-	if instanceDetails, ok := instanceConfig.InstanceDetails.(core.ComputeInstanceDetails); ok {
+	var allInstanceDetails []core.ComputeInstanceDetails
+	switch instanceDetails := instanceConfig.InstanceDetails.(type) {
+	case core.ComputeInstanceDetails:
+		allInstanceDetails = []core.ComputeInstanceDetails{instanceDetails}
+	case core.ComputeInstanceOptions:
+		allInstanceDetails = instanceDetails.Options
+	default:
+		return nil, fmt.Errorf("(compute) instance configuration for instance-pool %s not found", *ip.Id)
+	}
+
+	// everyShape is only listed once, and only if one of the shapes is not flexible.
+	var everyShape []core.Shape
+	for _, instanceDetails := range allInstanceDetails {
+		if instanceDetails.LaunchDetails == nil {
+			continue
+		}
+		shape := &Shape{}
 		// flexible shape use details or look up the static shape details below.
-		if instanceDetails.LaunchDetails != nil && instanceDetails.LaunchDetails.ShapeConfig != nil {
+		if instanceDetails.LaunchDetails.ShapeConfig != nil {
 			if instanceDetails.LaunchDetails.Shape != nil {
 				shape.Name = *instanceDetails.LaunchDetails.Shape
 			}
@@ -178,29 +224,15 @@ func (osf *shapeGetterImpl) GetInstancePoolShape(ip *core.InstancePool) (*Shape,
 			if instanceDetails.LaunchDetails.ShapeConfig.MemoryInGBs != nil {
 				shape.MemoryInBytes = *instanceDetails.LaunchDetails.ShapeConfig.MemoryInGBs * 1024 * 1024 * 1024
 			}
-		} else {
-			// Fetch the shape object by name
-			var page *string
-			var everyShape []core.Shape
-			for {
-				// List all available shapes
-				lisShapesReq := core.ListShapesRequest{}
-				lisShapesReq.CompartmentId = instanceConfig.CompartmentId
-				lisShapesReq.Page = page
-				lisShapesReq.Limit = common.Int(50)
-
-				listShapes, err := osf.shapeClient.ListShapes(context.Background(), lisShapesReq)
+		} else if instanceDetails.LaunchDetails.Shape != nil {
+			if everyShape == nil {
+				everyShape, err = osf.listAllShapes(instanceConfig.CompartmentId)
 				if err != nil {
 					return nil, err
 				}
-
-				everyShape = append(everyShape, listShapes.Items...)
-
-				if page = listShapes.OpcNextPage; listShapes.OpcNextPage == nil {
-					break
-				}
 			}
 
+			// Fetch the shape object by name
 			for _, nextShape := range everyShape {
 				if *nextShape.Shape == *instanceDetails.LaunchDetails.Shape {
 					shape.Name = *nextShape.Shape
@@ -216,17 +248,53 @@ func (osf *shapeGetterImpl) GetInstancePoolShape(ip *core.InstancePool) (*Shape,
 				}
 			}
 		}
-	} else {
-		return nil, fmt.Errorf("(compute) instance configuration for instance-pool %s not found", *ip.Id)
+
+		// Didn't find a match
+		if shape.Name == "" {
+			return nil, fmt.Errorf("shape information for instance-pool %s not found", *ip.Id)
+		}
+		// The boot volume of the image holds the ephemeral storage of the node.
+		if imageDetails, ok := instanceDetails.LaunchDetails.SourceDetails.(core.InstanceConfigurationInstanceSourceViaImageDetails); ok && imageDetails.BootVolumeSizeInGBs != nil {
+			shape.EphemeralStorageInBytes = float32(*imageDetails.BootVolumeSizeInGBs) * 1024 * 1024 * 1024
+		}
+		shape.Metadata = instanceDetails.LaunchDetails.Metadata
+		if instanceDetails.LaunchDetails.CapacityReservationId != nil {
+			shape.CapacityReservationID = *instanceDetails.LaunchDetails.CapacityReservationId
+		}
+		shapes = append(shapes, shape)
 	}
 
-	// Didn't find a match
-	if shape.Name == "" {
+	if len(shapes) == 0 {
 		return nil, fmt.Errorf("shape information for instance-pool %s not found", *ip.Id)
 	}
 
-	osf.cache[*ip.Id] = shape
-	return shape, nil
+	osf.instancePoolCache[*ip.Id] = shapes
+	return shapes, nil
+}
+
+// listAllShapes lists all the shapes available in the given compartment.
+func (osf *shapeGetterImpl) listAllShapes(compartmentID *string) ([]core.Shape, error) {
+	var page *string
+	var everyShape []core.Shape
+	for {
+		// List all available shapes
+		lisShapesReq := core.ListShapesRequest{}
+		lisShapesReq.CompartmentId = compartmentID
+		lisShapesReq.Page = page
+		lisShapesReq.Limit = common.Int(50)
+
+		listShapes, err := osf.shapeClient.ListShapes(context.Background(), lisShapesReq)
+		if err != nil {
+			return nil, err
+		}
+
+		everyShape = append(everyShape, listShapes.Items...)
+
+		if page = listShapes.OpcNextPage; listShapes.OpcNextPage == nil {
+			break
+		}
+	}
+	return everyShape, nil
 }
 
 // getFloat32 is a helper to get a float32 pointer value or default to 0.
@@ -18,6 +18,9 @@ import (
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
 )
 
@@ -73,6 +76,40 @@ func NewRetryPolicyWithMaxAttempts(retryAttempts uint) *common.RetryPolicy {
 	return &policy
 }
 
+// NewRetryPolicyWithBackoff returns a RetryPolicy retrying the requests failing with a retryable error, e.g.
+// throttling, up to backoff.Steps attempts. The wait between attempts increases exponentially from backoff.Duration up
+// to backoff.Cap, and is jittered so that concurrent requests don't retry in lockstep.
+func NewRetryPolicyWithBackoff(backoff wait.Backoff) *common.RetryPolicy {
+	isRetryableOperation := func(r common.OCIOperationResponse) bool {
+		return IsRetryable(r.Error)
+	}
+
+	nextDuration := func(r common.OCIOperationResponse) time.Duration {
+		duration := time.Duration(float64(backoff.Duration) * math.Pow(backoff.Factor, float64(r.AttemptNumber-1)))
+		if backoff.Cap > 0 && duration > backoff.Cap {
+			duration = backoff.Cap
+		}
+		if backoff.Jitter > 0 {
+			duration = wait.Jitter(duration, backoff.Jitter)
+		}
+		return duration
+	}
+
+	policy := common.NewRetryPolicy(
+		uint(backoff.Steps), isRetryableOperation, nextDuration,
+	)
+	return &policy
+}
+
+// WithAPITimeout returns a context for a single OCI API call which is cancelled once the timeout passes, so that a
+// hung endpoint fails the call instead of stalling the autoscaler. A timeout of 0 leaves the call without deadline.
+func WithAPITimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // AnnotateNode adds an annotation to a new based on the key/value
 func AnnotateNode(kubeClient kubernetes.Interface, nodeName string, key string, value string) error {
 
@@ -226,3 +263,29 @@ func HasNodeGroupTags(nodeGroupAutoDiscoveryList []string) (bool, bool, error) {
 	}
 	return instancePoolTagsFound, nodePoolTagsFound, nil
 }
+
+// ValidateNodeGroupSizes checks that every node group has a non-negative min size that does not exceed its max size,
+// and a target size within these bounds. All offending node groups are reported in a single aggregated error.
+func ValidateNodeGroupSizes(nodeGroups []cloudprovider.NodeGroup) error {
+	var errs []error
+	for _, ng := range nodeGroups {
+		minSize, maxSize := ng.MinSize(), ng.MaxSize()
+		if minSize < 0 {
+			errs = append(errs, fmt.Errorf("node group %s: min size %d is negative", ng.Id(), minSize))
+			continue
+		}
+		if minSize > maxSize {
+			errs = append(errs, fmt.Errorf("node group %s: min size %d is greater than max size %d", ng.Id(), minSize, maxSize))
+			continue
+		}
+		size, err := ng.TargetSize()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node group %s: failed to get target size: %v", ng.Id(), err))
+			continue
+		}
+		if size < minSize || size > maxSize {
+			errs = append(errs, fmt.Errorf("node group %s: size %d is outside of bounds [%d, %d]", ng.Id(), size, minSize, maxSize))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -5,7 +5,11 @@ Copyright 2021-2023 Oracle and/or its affiliates.
 package common
 
 import (
+	"strings"
 	"testing"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 )
 
 func TestSetProviderID(t *testing.T) {
@@ -14,3 +18,25 @@ func TestSetProviderID(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestValidateNodeGroupSizes(t *testing.T) {
+	valid := testprovider.NewTestNodeGroup("ocid1.instancepool.oc1.phx.valid", 5, 1, 3, true, false, "", nil, nil)
+	if err := ValidateNodeGroupSizes([]cloudprovider.NodeGroup{valid}); err != nil {
+		t.Fatalf("unexpected error for valid node group: %v", err)
+	}
+
+	minAboveMax := testprovider.NewTestNodeGroup("ocid1.instancepool.oc1.phx.minabovemax", 1, 3, 2, true, false, "", nil, nil)
+	sizeAboveMax := testprovider.NewTestNodeGroup("ocid1.instancepool.oc1.phx.sizeabovemax", 5, 1, 7, true, false, "", nil, nil)
+	err := ValidateNodeGroupSizes([]cloudprovider.NodeGroup{valid, minAboveMax, sizeAboveMax})
+	if err == nil {
+		t.Fatal("expected error for invalid node groups")
+	}
+	for _, id := range []string{minAboveMax.Id(), sizeAboveMax.Id()} {
+		if !strings.Contains(err.Error(), id) {
+			t.Errorf("expected error to mention %s, got: %v", id, err)
+		}
+	}
+	if strings.Contains(err.Error(), valid.Id()) {
+		t.Errorf("expected error not to mention valid node group %s, got: %v", valid.Id(), err)
+	}
+}
@@ -22,6 +22,8 @@ const (
 	OciRegionEnvVar = "OCI_REGION"
 	// OciRefreshInterval indicates the rate at which the internal instance pool cache will be refreshed (by querying compute)
 	OciRefreshInterval = "OCI_REFRESH_INTERVAL"
+	// OciAPITimeoutEnvVar bounds the time a single OCI API call may take, no bound is applied by default
+	OciAPITimeoutEnvVar = "OCI_API_TIMEOUT"
 	// DefaultRefreshInterval is the default rate to refresh the cache
 	DefaultRefreshInterval = 5 * time.Minute
 	// ResourceGPU is the GPU resource type
@@ -51,6 +53,16 @@ const (
 	// InstanceIDUnfulfilled is the generic placeholder name for upcoming instances
 	InstanceIDUnfulfilled = "instance_placeholder"
 
+	// NodeTemplateLabelsTag is the freeform tag of an instance pool holding the comma separated key=value labels
+	// of its nodes, used to build template nodes while the pool is scaled to zero.
+	NodeTemplateLabelsTag = "cluster-autoscaler/node-template-labels"
+	// NodeTemplateTaintsTag is the freeform tag of an instance pool holding the comma separated key=value:Effect
+	// taints its nodes register with, used to build template nodes while the pool is scaled to zero.
+	NodeTemplateTaintsTag = "cluster-autoscaler/node-template-taints"
+	// OnDemandCapacityTag is the freeform tag of an instance pool launching into a capacity reservation holding the
+	// number of instances it may launch on demand on top of the reserved capacity.
+	OnDemandCapacityTag = "cluster-autoscaler/on-demand-capacity"
+
 	// OciInstancePoolIDNonPoolMember indicates a kubernetes node doesn't belong to any OCI Instance Pool.
 	OciInstancePoolIDNonPoolMember = "non_pool_member"
 )
@@ -151,34 +151,51 @@ func (ocp *OciCloudProvider) Refresh() error {
 
 // BuildOCI constructs the OciCloudProvider object that implements the could provider interface (InstancePoolManager).
 func BuildOCI(opts *coreoptions.AutoscalerOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	provider, err := buildOCI(opts, do, rl)
+	if err != nil {
+		klog.Fatalf("Could not create OCI cloud provider: %v", err)
+	}
+	return provider
+}
+
+func buildOCI(opts *coreoptions.AutoscalerOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) (cloudprovider.CloudProvider, error) {
 	common.EnableInstanceMetadataServiceLookup()
 	ocidType, err := ocicommon.GetAllPoolTypes(opts.NodeGroups)
 	if err != nil {
-		klog.Fatalf("Failed to get pool type: %v", err)
+		return nil, errors.Wrap(err, "failed to get pool type")
 	}
 	_, nodepoolTagsFound, err := ocicommon.HasNodeGroupTags(opts.NodeGroupAutoDiscovery)
 	if err != nil {
-		klog.Fatalf("Failed to get auto discovery tags: %v", err)
+		return nil, errors.Wrap(err, "failed to get auto discovery tags")
 	}
 	if strings.HasPrefix(ocidType, npconsts.OciNodePoolResourceIdent) && nodepoolTagsFound == true {
-		klog.Fatalf("-nodes and -node-group-auto-discovery parameters can not be used together.")
+		return nil, errors.New("-nodes and -node-group-auto-discovery parameters can not be used together")
 	} else if strings.HasPrefix(ocidType, npconsts.OciNodePoolResourceIdent) || nodepoolTagsFound == true {
 		manager, err := nodepools.CreateNodePoolManager(opts.CloudConfig, opts.NodeGroupAutoDiscovery, do, createKubeClient(opts.AutoscalingOptions))
 		if err != nil {
-			klog.Fatalf("Could not create OCI OKE cloud provider: %v", err)
+			return nil, errors.Wrap(err, "could not create OCI OKE cloud provider")
 		}
-		return nodepools.NewOciCloudProvider(manager, rl)
+		provider := nodepools.NewOciCloudProvider(manager, rl)
+		if err := ocicommon.ValidateNodeGroupSizes(provider.NodeGroups()); err != nil {
+			return nil, errors.Wrap(err, "invalid OCI node group configuration")
+		}
+		return provider, nil
 	}
 	// theoretically the only other possible value is no value (if no node groups are passed in)
 	// or instancepool, but either way, we'll just default to the instance pool implementation
 	ipManager, err := CreateInstancePoolManager(opts.CloudConfig, do, createKubeClient(opts.AutoscalingOptions))
 	if err != nil {
-		klog.Fatalf("Could not create OCI cloud provider: %v", err)
+		return nil, err
 	}
-	return &OciCloudProvider{
+	provider := &OciCloudProvider{
 		poolManager: ipManager,
 		rl:          rl,
 	}
+	// Fail fast on misconfigured node groups instead of surfacing the problem on scale up or down.
+	if err := ocicommon.ValidateNodeGroupSizes(provider.NodeGroups()); err != nil {
+		return nil, errors.Wrap(err, "invalid OCI node group configuration")
+	}
+	return provider, nil
 }
 
 func getKubeConfig(opts config.AutoscalingOptions) *rest.Config {
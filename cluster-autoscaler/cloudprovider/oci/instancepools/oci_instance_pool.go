@@ -27,9 +27,18 @@ type InstancePoolNodeGroup struct {
 	maxSize    int
 }
 
-// MaxSize returns maximum size of the instance-pool based node group.
+// MaxSize returns maximum size of the instance-pool based node group. The max size of a pool launching into capacity
+// reservations is lowered to the instances it can still launch, as launches beyond them would fail.
 func (ip *InstancePoolNodeGroup) MaxSize() int {
-	return ip.maxSize
+	capacity := ip.manager.GetInstancePoolCapacityReservation(*ip)
+	if capacity == nil {
+		return ip.maxSize
+	}
+	size, err := ip.manager.GetInstancePoolSize(*ip)
+	if err != nil {
+		return ip.maxSize
+	}
+	return min(ip.maxSize, size+capacity.Available())
 }
 
 // MinSize returns minimum size of the instance-pool based node group.
@@ -34,6 +34,8 @@ type ComputeMgmtClient interface {
 // ComputeClient wraps core.ComputeClient exposing the functions we actually require.
 type ComputeClient interface {
 	ListVnicAttachments(ctx context.Context, request core.ListVnicAttachmentsRequest) (core.ListVnicAttachmentsResponse, error)
+	GetComputeCapacityReservation(ctx context.Context, request core.GetComputeCapacityReservationRequest) (core.GetComputeCapacityReservationResponse, error)
+	ListComputeCapacityReservationInstances(ctx context.Context, request core.ListComputeCapacityReservationInstancesRequest) (core.ListComputeCapacityReservationInstancesResponse, error)
 }
 
 // VirtualNetworkClient wraps core.VirtualNetworkClient exposing the functions we actually require.
@@ -48,11 +50,118 @@ type WorkRequestClient interface {
 	ListWorkRequestErrors(context.Context, workrequests.ListWorkRequestErrorsRequest) (workrequests.ListWorkRequestErrorsResponse, error)
 }
 
+// apiTimeoutComputeMgmtClient bounds every call of the wrapped ComputeMgmtClient by a timeout, except
+// DetachInstancePoolInstance whose retries are bounded by terminateInstanceTimeout instead.
+type apiTimeoutComputeMgmtClient struct {
+	ComputeMgmtClient
+	timeout time.Duration
+}
+
+func (c *apiTimeoutComputeMgmtClient) GetInstancePool(ctx context.Context, req core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeMgmtClient.GetInstancePool(ctx, req)
+}
+
+func (c *apiTimeoutComputeMgmtClient) UpdateInstancePool(ctx context.Context, req core.UpdateInstancePoolRequest) (core.UpdateInstancePoolResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeMgmtClient.UpdateInstancePool(ctx, req)
+}
+
+func (c *apiTimeoutComputeMgmtClient) GetInstancePoolInstance(ctx context.Context, req core.GetInstancePoolInstanceRequest) (core.GetInstancePoolInstanceResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeMgmtClient.GetInstancePoolInstance(ctx, req)
+}
+
+func (c *apiTimeoutComputeMgmtClient) ListInstancePoolInstances(ctx context.Context, req core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeMgmtClient.ListInstancePoolInstances(ctx, req)
+}
+
+// apiTimeoutComputeClient bounds every call of the wrapped ComputeClient by a timeout.
+type apiTimeoutComputeClient struct {
+	ComputeClient
+	timeout time.Duration
+}
+
+func (c *apiTimeoutComputeClient) ListVnicAttachments(ctx context.Context, req core.ListVnicAttachmentsRequest) (core.ListVnicAttachmentsResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeClient.ListVnicAttachments(ctx, req)
+}
+
+func (c *apiTimeoutComputeClient) GetComputeCapacityReservation(ctx context.Context, req core.GetComputeCapacityReservationRequest) (core.GetComputeCapacityReservationResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeClient.GetComputeCapacityReservation(ctx, req)
+}
+
+func (c *apiTimeoutComputeClient) ListComputeCapacityReservationInstances(ctx context.Context, req core.ListComputeCapacityReservationInstancesRequest) (core.ListComputeCapacityReservationInstancesResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.ComputeClient.ListComputeCapacityReservationInstances(ctx, req)
+}
+
+// apiTimeoutVirtualNetworkClient bounds every call of the wrapped VirtualNetworkClient by a timeout.
+type apiTimeoutVirtualNetworkClient struct {
+	VirtualNetworkClient
+	timeout time.Duration
+}
+
+func (c *apiTimeoutVirtualNetworkClient) GetVnic(ctx context.Context, req core.GetVnicRequest) (core.GetVnicResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.VirtualNetworkClient.GetVnic(ctx, req)
+}
+
+// apiTimeoutWorkRequestClient bounds every call of the wrapped WorkRequestClient by a timeout.
+type apiTimeoutWorkRequestClient struct {
+	WorkRequestClient
+	timeout time.Duration
+}
+
+func (c *apiTimeoutWorkRequestClient) GetWorkRequest(ctx context.Context, req workrequests.GetWorkRequestRequest) (workrequests.GetWorkRequestResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.WorkRequestClient.GetWorkRequest(ctx, req)
+}
+
+func (c *apiTimeoutWorkRequestClient) ListWorkRequests(ctx context.Context, req workrequests.ListWorkRequestsRequest) (workrequests.ListWorkRequestsResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.WorkRequestClient.ListWorkRequests(ctx, req)
+}
+
+func (c *apiTimeoutWorkRequestClient) ListWorkRequestErrors(ctx context.Context, req workrequests.ListWorkRequestErrorsRequest) (workrequests.ListWorkRequestErrorsResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.WorkRequestClient.ListWorkRequestErrors(ctx, req)
+}
+
+var (
+	// terminateInstanceTimeout bounds the time spent terminating (detaching) a single instance, including retries.
+	terminateInstanceTimeout = 2 * time.Minute
+	// terminateInstanceRetryPolicy retries the termination of an instance with a jittered backoff when OCI throttles
+	// the requests. It replaces the retry policy of the client for these requests.
+	terminateInstanceRetryPolicy = ocicommon.NewRetryPolicyWithBackoff(wait.Backoff{
+		Duration: 1 * time.Second,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    6,
+		Cap:      30 * time.Second,
+	})
+)
+
 type instancePoolCache struct {
 	mu                   sync.Mutex
 	poolCache            map[string]*core.InstancePool
 	instanceSummaryCache map[string]*[]core.InstanceSummary
 	unownedInstances     map[ocicommon.OciRef]bool
+	// capacityReservationCache holds the capacity of instance pools bound by capacity reservations.
+	capacityReservationCache map[string]*CapacityReservation
 
 	computeManagementClient ComputeMgmtClient
 	computeClient           ComputeClient
@@ -62,14 +171,26 @@ type instancePoolCache struct {
 
 func newInstancePoolCache(computeManagementClient ComputeMgmtClient, computeClient ComputeClient, virtualNetworkClient VirtualNetworkClient, workRequestsClient WorkRequestClient) *instancePoolCache {
 	return &instancePoolCache{
-		poolCache:               map[string]*core.InstancePool{},
-		instanceSummaryCache:    map[string]*[]core.InstanceSummary{},
-		unownedInstances:        map[ocicommon.OciRef]bool{},
-		computeManagementClient: computeManagementClient,
-		computeClient:           computeClient,
-		virtualNetworkClient:    virtualNetworkClient,
-		workRequestsClient:      workRequestsClient,
+		poolCache:                map[string]*core.InstancePool{},
+		instanceSummaryCache:     map[string]*[]core.InstanceSummary{},
+		unownedInstances:         map[ocicommon.OciRef]bool{},
+		capacityReservationCache: map[string]*CapacityReservation{},
+		computeManagementClient:  computeManagementClient,
+		computeClient:            computeClient,
+		virtualNetworkClient:     virtualNetworkClient,
+		workRequestsClient:       workRequestsClient,
+	}
+}
+
+// setAPITimeout bounds the OCI API calls of the cache by the timeout. A timeout of 0 leaves the calls without deadline.
+func (c *instancePoolCache) setAPITimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
 	}
+	c.computeManagementClient = &apiTimeoutComputeMgmtClient{ComputeMgmtClient: c.computeManagementClient, timeout: timeout}
+	c.computeClient = &apiTimeoutComputeClient{ComputeClient: c.computeClient, timeout: timeout}
+	c.virtualNetworkClient = &apiTimeoutVirtualNetworkClient{VirtualNetworkClient: c.virtualNetworkClient, timeout: timeout}
+	c.workRequestsClient = &apiTimeoutWorkRequestClient{WorkRequestClient: c.workRequestsClient, timeout: timeout}
 }
 
 func (c *instancePoolCache) InstancePools() map[string]*core.InstancePool {
@@ -172,13 +293,17 @@ func (c *instancePoolCache) removeInstance(instancePool InstancePoolNodeGroup, i
 		// For an unfulfilled instance, reduce the target size of the instance pool and remove the placeholder instance from cache.
 		err = c.setSize(instancePool.Id(), *c.poolCache[instancePool.Id()].Size-1)
 	} else {
-		_, err = c.computeManagementClient.DetachInstancePoolInstance(context.Background(), core.DetachInstancePoolInstanceRequest{
+		ctx, cancel := context.WithTimeout(context.Background(), terminateInstanceTimeout)
+		defer cancel()
+		// Retry on throttling so a single 429 doesn't abort the whole scale-down.
+		_, err = c.computeManagementClient.DetachInstancePoolInstance(ctx, core.DetachInstancePoolInstanceRequest{
 			InstancePoolId: common.String(instancePool.Id()),
 			DetachInstancePoolInstanceDetails: core.DetachInstancePoolInstanceDetails{
 				InstanceId:      common.String(instanceID),
 				IsDecrementSize: common.Bool(true),
 				IsAutoTerminate: common.Bool(true),
 			},
+			RequestMetadata: common.RequestMetadata{RetryPolicy: terminateInstanceRetryPolicy},
 		})
 	}
 
@@ -335,6 +460,56 @@ func (c *instancePoolCache) setInstancePool(np *core.InstancePool) {
 	c.poolCache[*np.Id].Size = np.Size
 }
 
+// getComputeCapacityReservation fetches the compute capacity reservation.
+func (c *instancePoolCache) getComputeCapacityReservation(reservationID string) (*core.ComputeCapacityReservation, error) {
+	resp, err := c.computeClient.GetComputeCapacityReservation(context.Background(), core.GetComputeCapacityReservationRequest{
+		CapacityReservationId: common.String(reservationID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("GetComputeCapacityReservation() response %v", resp.ComputeCapacityReservation)
+	return &resp.ComputeCapacityReservation, nil
+}
+
+// listCapacityReservationInstances lists the instances launched into the compute capacity reservation.
+func (c *instancePoolCache) listCapacityReservationInstances(reservationID string) ([]core.CapacityReservationInstanceSummary, error) {
+	var instances []core.CapacityReservationInstanceSummary
+	var page *string
+	for {
+		resp, err := c.computeClient.ListComputeCapacityReservationInstances(context.Background(), core.ListComputeCapacityReservationInstancesRequest{
+			CapacityReservationId: common.String(reservationID),
+			Page:                  page,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		instances = append(instances, resp.Items...)
+
+		if page = resp.OpcNextPage; resp.OpcNextPage == nil {
+			break
+		}
+	}
+	return instances, nil
+}
+
+// getCapacityReservation returns the cached capacity of the instance pool, nil if it isn't bound by capacity reservations.
+func (c *instancePoolCache) getCapacityReservation(instancePoolID string) *CapacityReservation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.capacityReservationCache[instancePoolID]
+}
+
+// setCapacityReservation caches the capacity of the instance pool, nil if it isn't bound by capacity reservations.
+func (c *instancePoolCache) setCapacityReservation(instancePoolID string, capacity *CapacityReservation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacityReservationCache[instancePoolID] = capacity
+}
+
 func (c *instancePoolCache) getInstanceSummaries(poolID string) (*[]core.InstanceSummary, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -5,9 +5,11 @@ Copyright 2021-2023 Oracle and/or its affiliates.
 package instancepools
 
 import (
+	"context"
 	"fmt"
 	npconsts "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/nodepools/consts"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,9 +20,11 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	taintutil "k8s.io/kubernetes/pkg/util/taints"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 
@@ -51,12 +55,36 @@ type InstancePoolManager interface {
 	GetInstancePoolForInstance(instance ocicommon.OciRef) (*InstancePoolNodeGroup, error)
 	// GetInstancePoolTemplateNode returns a template node for InstancePool.
 	GetInstancePoolTemplateNode(ip InstancePoolNodeGroup) (*apiv1.Node, error)
+	// GetInstancePoolTemplateNodes returns a template node for each shape the InstancePool can launch.
+	GetInstancePoolTemplateNodes(ip InstancePoolNodeGroup) ([]*apiv1.Node, error)
 	// GetInstancePoolSize gets the InstancePool size.
 	GetInstancePoolSize(ip InstancePoolNodeGroup) (int, error)
 	// SetInstancePoolSize sets the InstancePool size.
 	SetInstancePoolSize(ip InstancePoolNodeGroup, size int) error
 	// DeleteInstances deletes the given instances. All instances must be controlled by the same InstancePool.
 	DeleteInstances(ip InstancePoolNodeGroup, instances []ocicommon.OciRef) error
+	// GetInstancePoolCapacityReservation returns the capacity the InstancePool can launch instances into as of the
+	// last refresh, nil if its capacity isn't bound by capacity reservations.
+	GetInstancePoolCapacityReservation(ip InstancePoolNodeGroup) *CapacityReservation
+}
+
+// CapacityReservation is the capacity an instance pool launching into compute capacity reservations can scale up by.
+type CapacityReservation struct {
+	// IDs of the compute capacity reservations the instance pool launches its instances into.
+	IDs []string
+	// Reserved is the number of instances reserved for the shapes of the instance pool.
+	Reserved int
+	// Used is the number of instances of those shapes launched into the reservations, by any instance pool or standalone.
+	Used int
+	// OnDemand is the number of instances the pool may launch on top of the reserved capacity.
+	OnDemand int
+	// OnDemandUsed is the number of instances of the pool launched on demand, outside of the reservations.
+	OnDemandUsed int
+}
+
+// Available returns the number of instances the instance pool can still launch.
+func (r *CapacityReservation) Available() int {
+	return max(r.Reserved-r.Used, 0) + max(r.OnDemand-r.OnDemandUsed, 0)
 }
 
 // InstancePoolManagerImpl is the implementation of an instance-pool based autoscaler on OCI.
@@ -69,6 +97,8 @@ type InstancePoolManagerImpl struct {
 	// All interactions with OCI's API should go through the poolCache.
 	instancePoolCache *instancePoolCache
 	kubeClient        kubernetes.Interface
+	// refreshes the request signer when OCI rejects a call as unauthenticated, nil if the signer can't be refreshed.
+	signerRefresher ocicommon.SignerRefresher
 }
 
 // CreateInstancePoolManager constructs the InstancePoolManager object.
@@ -76,6 +106,7 @@ func CreateInstancePoolManager(cloudConfigPath string, discoveryOpts cloudprovid
 
 	var err error
 	var configProvider common.ConfigurationProvider
+	useInstancePrincipals := false
 
 	clientConfig := common.CustomClientConfiguration{
 		RetryPolicy: ocicommon.NewRetryPolicy(),
@@ -95,6 +126,7 @@ func CreateInstancePoolManager(cloudConfigPath string, discoveryOpts cloudprovid
 		if err != nil {
 			return nil, err
 		}
+		useInstancePrincipals = true
 		// default to default provider
 	} else {
 		klog.Info("using default configuration provider")
@@ -135,9 +167,16 @@ func CreateInstancePoolManager(cloudConfigPath string, discoveryOpts cloudprovid
 	ipManager := &InstancePoolManagerImpl{
 		cfg:                 cloudConfig,
 		staticInstancePools: map[string]*InstancePoolNodeGroup{},
-		ShapeGetter:         ocicommon.CreateShapeGetter(ocicommon.ShapeClientImpl{ComputeMgmtClient: computeMgmtClient, ComputeClient: computeClient}),
-		instancePoolCache:   newInstancePoolCache(&computeMgmtClient, &computeClient, &networkClient, &workRequestClient),
-		kubeClient:          kubeClient,
+		ShapeGetter: ocicommon.CreateShapeGetter(ocicommon.ShapeClientImpl{ComputeMgmtClient: computeMgmtClient, ComputeClient: computeClient,
+			APITimeout: cloudConfig.Global.APITimeout}),
+		instancePoolCache: newInstancePoolCache(&computeMgmtClient, &computeClient, &networkClient, &workRequestClient),
+		kubeClient:        kubeClient,
+	}
+	ipManager.instancePoolCache.setAPITimeout(cloudConfig.Global.APITimeout)
+
+	if useInstancePrincipals {
+		ipManager.signerRefresher = ocicommon.NewInstancePrincipalSignerRefresher(&computeMgmtClient.BaseClient,
+			&computeClient.BaseClient, &networkClient.BaseClient, &workRequestClient.BaseClient)
 	}
 
 	// Contains all the specs from the args that give us the pools.
@@ -218,10 +257,15 @@ func (m *InstancePoolManagerImpl) forceRefresh() error {
 		return errors.New("instance pool manager does have a required config")
 	}
 	m.ShapeGetter.Refresh()
-	err := m.instancePoolCache.rebuild(m.staticInstancePools, *m.cfg)
+	err := ocicommon.CallWithAuthRefresh(context.Background(), m.signerRefresher, func() error {
+		return m.instancePoolCache.rebuild(m.staticInstancePools, *m.cfg)
+	})
 	if err != nil {
 		return err
 	}
+	for id := range m.staticInstancePools {
+		m.refreshCapacityReservations(id)
+	}
 
 	m.lastRefresh = time.Now()
 	klog.Infof("Refreshed instance-pool list, next refresh after %v", m.lastRefresh.Add(m.cfg.Global.RefreshInterval))
@@ -235,7 +279,14 @@ func (m *InstancePoolManagerImpl) forceRefreshInstancePool(instancePoolID string
 	}
 
 	if instancePoolCache, found := m.staticInstancePools[instancePoolID]; found {
-		return m.instancePoolCache.rebuild(map[string]*InstancePoolNodeGroup{instancePoolID: instancePoolCache}, *m.cfg)
+		err := ocicommon.CallWithAuthRefresh(context.Background(), m.signerRefresher, func() error {
+			return m.instancePoolCache.rebuild(map[string]*InstancePoolNodeGroup{instancePoolID: instancePoolCache}, *m.cfg)
+		})
+		if err != nil {
+			return err
+		}
+		m.refreshCapacityReservations(instancePoolID)
+		return nil
 	}
 	return errors.New("instance pool not found")
 }
@@ -266,35 +317,22 @@ func (m *InstancePoolManagerImpl) GetInstancePoolNodes(ip InstancePoolNodeGroup)
 
 	var providerInstances []cloudprovider.Instance
 	for _, instance := range *instanceSummaries {
-		status := &cloudprovider.InstanceStatus{}
-		switch *instance.State {
-		case string(core.InstanceLifecycleStateStopped), string(core.InstanceLifecycleStateTerminated):
-			klog.V(4).Infof("skipping instance is in stopped/terminated state: %q", *instance.Id)
-		case string(core.InstanceLifecycleStateRunning):
-			status.State = cloudprovider.InstanceRunning
-		case string(core.InstanceLifecycleStateCreatingImage):
-			status.State = cloudprovider.InstanceCreating
-		case string(core.InstanceLifecycleStateStarting):
-			status.State = cloudprovider.InstanceCreating
-		case string(core.InstanceLifecycleStateMoving):
-			status.State = cloudprovider.InstanceCreating
-		case string(core.InstanceLifecycleStateProvisioning):
-			status.State = cloudprovider.InstanceCreating
-		case string(core.InstanceLifecycleStateTerminating):
-			status.State = cloudprovider.InstanceDeleting
-		case string(core.InstanceLifecycleStateStopping):
-			status.State = cloudprovider.InstanceDeleting
-		case consts.InstanceStateUnfulfilled:
-			status.State = cloudprovider.InstanceCreating
-			status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
-				ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
-				ErrorCode:    consts.InstanceStateUnfulfilled,
-				ErrorMessage: "OCI cannot provision additional instances for this instance pool. Review quota and/or capacity.",
+		var status *cloudprovider.InstanceStatus
+		if *instance.State == consts.InstanceStateUnfulfilled {
+			status = &cloudprovider.InstanceStatus{
+				State: cloudprovider.InstanceCreating,
+				ErrorInfo: &cloudprovider.InstanceErrorInfo{
+					ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+					ErrorCode:    consts.InstanceStateUnfulfilled,
+					ErrorMessage: "OCI cannot provision additional instances for this instance pool. Review quota and/or capacity.",
+				},
 			}
+		} else {
+			status = ocicommon.InstanceStatusFromLifecycleState(*instance.Id, *instance.State)
 		}
 
 		// Instance not in a terminal or unknown state, ok to add.
-		if status.State != 0 {
+		if status != nil {
 			providerInstances = append(providerInstances, cloudprovider.Instance{
 				Id:     *instance.Id,
 				Status: status,
@@ -346,20 +384,42 @@ func (m *InstancePoolManagerImpl) GetInstancePoolForInstance(instanceDetails oci
 	return m.staticInstancePools[foundInstanceDetails.InstancePoolID], nil
 }
 
-// GetInstancePoolTemplateNode returns a template node for the InstancePool.
+// GetInstancePoolTemplateNode returns a template node for the InstancePool. When the InstancePool can launch more
+// than one shape, the template of the preferred shape is returned, as that's the shape new instances are launched
+// with while it has capacity.
 func (m *InstancePoolManagerImpl) GetInstancePoolTemplateNode(ip InstancePoolNodeGroup) (*apiv1.Node, error) {
+	nodes, err := m.GetInstancePoolTemplateNodes(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes[0], nil
+}
+
+// GetInstancePoolTemplateNodes returns a template node for each shape the InstancePool can launch, in the order of
+// preference of its instance configuration.
+func (m *InstancePoolManagerImpl) GetInstancePoolTemplateNodes(ip InstancePoolNodeGroup) ([]*apiv1.Node, error) {
 
 	instancePool, err := m.instancePoolCache.getInstancePool(ip.Id())
 	if err != nil {
 		return nil, err
 	}
 
-	node, err := m.buildNodeFromTemplate(instancePool)
+	shapes, err := m.ShapeGetter.GetInstancePoolShapes(instancePool)
 	if err != nil {
 		return nil, err
 	}
 
-	return node, nil
+	nodes := make([]*apiv1.Node, 0, len(shapes))
+	for _, shape := range shapes {
+		node, err := m.buildNodeFromTemplate(instancePool, shape)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
 }
 
 // GetInstancePoolSize gets the instance-pool size.
@@ -371,7 +431,9 @@ func (m *InstancePoolManagerImpl) GetInstancePoolSize(ip InstancePoolNodeGroup)
 func (m *InstancePoolManagerImpl) SetInstancePoolSize(np InstancePoolNodeGroup, size int) error {
 	klog.Infof("SetInstancePoolSize (%d) called on instance pool %s", size, np.Id())
 
-	setSizeErr := m.instancePoolCache.setSize(np.Id(), size)
+	setSizeErr := ocicommon.CallWithAuthRefresh(context.Background(), m.signerRefresher, func() error {
+		return m.instancePoolCache.setSize(np.Id(), size)
+	})
 	klog.V(5).Infof("SetInstancePoolSize was called: refreshing instance pool cache")
 	// refresh instance pool cache after update (regardless if there was an error or not)
 	_ = m.forceRefreshInstancePool(np.Id())
@@ -403,7 +465,140 @@ func (m *InstancePoolManagerImpl) DeleteInstances(instancePool InstancePoolNodeG
 	return nil
 }
 
-func (m *InstancePoolManagerImpl) buildNodeFromTemplate(instancePool *core.InstancePool) (*apiv1.Node, error) {
+// GetInstancePoolCapacityReservation returns the capacity reserved for the instance-pool as of the last refresh, nil
+// if its capacity isn't bound by capacity reservations. It doesn't call the OCI API.
+func (m *InstancePoolManagerImpl) GetInstancePoolCapacityReservation(ip InstancePoolNodeGroup) *CapacityReservation {
+	return m.instancePoolCache.getCapacityReservation(ip.Id())
+}
+
+// refreshCapacityReservations reads the capacity reserved for the given instance-pools into the cache. The capacity
+// of a pool which can't be read is kept as of the previous refresh.
+func (m *InstancePoolManagerImpl) refreshCapacityReservations(instancePoolIDs ...string) {
+	for _, id := range instancePoolIDs {
+		capacity, err := m.fetchCapacityReservation(id)
+		if err != nil {
+			klog.Warningf("instance-pool %s: unable to refresh capacity reservation: %v", id, err)
+			continue
+		}
+		m.instancePoolCache.setCapacityReservation(id, capacity)
+	}
+}
+
+// fetchCapacityReservation reads the capacity reserved for the instance-pool. The capacity is only bound when every
+// shape of the pool's instance configuration launches into a capacity reservation, otherwise nil is returned before
+// any reservation is read. Only the capacity reserved for the shapes of the pool counts. If the reservations can't be
+// read, a pool allowed to launch instances on demand falls back to that capacity, counting all its instances as
+// launched on demand.
+func (m *InstancePoolManagerImpl) fetchCapacityReservation(instancePoolID string) (*CapacityReservation, error) {
+	instancePool, err := m.instancePoolCache.getInstancePool(instancePoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := m.ShapeGetter.GetInstancePoolShapes(instancePool)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := &CapacityReservation{}
+	var shapeNames []string
+	for _, shape := range shapes {
+		if shape.CapacityReservationID == "" {
+			// Instances of this shape are launched on demand.
+			return nil, nil
+		}
+		if !slices.Contains(shapeNames, shape.Name) {
+			shapeNames = append(shapeNames, shape.Name)
+		}
+		if !slices.Contains(capacity.IDs, shape.CapacityReservationID) {
+			capacity.IDs = append(capacity.IDs, shape.CapacityReservationID)
+		}
+	}
+
+	if value := strings.TrimSpace(instancePool.FreeformTags[consts.OnDemandCapacityTag]); value != "" {
+		onDemand, err := strconv.Atoi(value)
+		if err != nil || onDemand < 0 {
+			klog.Warningf("instance-pool %q: ignoring invalid on-demand capacity %q", *instancePool.Id, value)
+		} else {
+			capacity.OnDemand = onDemand
+		}
+	}
+
+	instanceSummaries, err := m.instancePoolCache.getInstanceSummaries(instancePoolID)
+	if err != nil {
+		return nil, err
+	}
+	poolInstances := sets.New[string]()
+	for _, instance := range *instanceSummaries {
+		// Placeholders of instances the pool couldn't launch, and terminated instances, don't use any capacity.
+		if instance.Id == nil || instance.State == nil || *instance.State == consts.InstanceStateUnfulfilled ||
+			ocicommon.InstanceStatusFromLifecycleState(*instance.Id, *instance.State) == nil {
+			continue
+		}
+		poolInstances.Insert(*instance.Id)
+	}
+
+	reservedPoolInstances := sets.New[string]()
+	for _, reservationID := range capacity.IDs {
+		reserved, used, instances, err := m.getCapacityReservationUsage(reservationID, shapeNames)
+		if err != nil {
+			if capacity.OnDemand == 0 {
+				return nil, errors.Wrapf(err, "unable to get capacity reservation %s of instance-pool %s", reservationID, instancePoolID)
+			}
+			klog.Warningf("instance-pool %s: unable to get capacity reservation %s, falling back to the on-demand capacity: %v", instancePoolID, reservationID, err)
+			return &CapacityReservation{IDs: capacity.IDs, OnDemand: capacity.OnDemand, OnDemandUsed: poolInstances.Len()}, nil
+		}
+		capacity.Reserved += reserved
+		capacity.Used += used
+		reservedPoolInstances = reservedPoolInstances.Union(poolInstances.Intersection(instances))
+	}
+	capacity.OnDemandUsed = poolInstances.Len() - reservedPoolInstances.Len()
+
+	klog.V(4).Infof("instance-pool %s: capacity reservations %v reserve %d instances, %d used, %d of %d on demand used",
+		instancePoolID, capacity.IDs, capacity.Reserved, capacity.Used, capacity.OnDemandUsed, capacity.OnDemand)
+	return capacity, nil
+}
+
+// getCapacityReservationUsage returns the number of instances of the given shapes reserved and used in the capacity
+// reservation, and the IDs of the instances launched into it.
+func (m *InstancePoolManagerImpl) getCapacityReservationUsage(reservationID string, shapeNames []string) (int, int, sets.Set[string], error) {
+	var reservation *core.ComputeCapacityReservation
+	var instances []core.CapacityReservationInstanceSummary
+	err := ocicommon.CallWithAuthRefresh(context.Background(), m.signerRefresher, func() error {
+		var err error
+		if reservation, err = m.instancePoolCache.getComputeCapacityReservation(reservationID); err != nil {
+			return err
+		}
+		instances, err = m.instancePoolCache.listCapacityReservationInstances(reservationID)
+		return err
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	reserved, used := 0, 0
+	for _, config := range reservation.InstanceReservationConfigs {
+		if config.InstanceShape == nil || !slices.Contains(shapeNames, *config.InstanceShape) {
+			// The capacity reserved for other shapes can't be used by the pool.
+			continue
+		}
+		if config.ReservedCount != nil {
+			reserved += int(*config.ReservedCount)
+		}
+		if config.UsedCount != nil {
+			used += int(*config.UsedCount)
+		}
+	}
+	instanceIDs := sets.New[string]()
+	for _, instance := range instances {
+		if instance.Id != nil {
+			instanceIDs.Insert(*instance.Id)
+		}
+	}
+	return reserved, used, instanceIDs, nil
+}
+
+func (m *InstancePoolManagerImpl) buildNodeFromTemplate(instancePool *core.InstancePool, shape *ocicommon.Shape) (*apiv1.Node, error) {
 
 	node := apiv1.Node{}
 	nodeName := fmt.Sprintf("%s-%d", "inst", 555555)
@@ -425,12 +620,6 @@ func (m *InstancePoolManagerImpl) buildNodeFromTemplate(instancePool *core.Insta
 	node.Status = apiv1.NodeStatus{
 		Capacity: apiv1.ResourceList{},
 	}
-	shape, err := m.ShapeGetter.GetInstancePoolShape(instancePool)
-
-	if err != nil {
-		return nil, err
-	}
-
 	if shape.GPU > 0 {
 		node.Spec.Taints = append(node.Spec.Taints, apiv1.Taint{
 			Key:    "nvidia.com/gpu",
@@ -443,6 +632,9 @@ func (m *InstancePoolManagerImpl) buildNodeFromTemplate(instancePool *core.Insta
 	node.Status.Capacity[apiv1.ResourceCPU] = *resource.NewQuantity(int64(shape.CPU), resource.DecimalSI)
 	node.Status.Capacity[apiv1.ResourceMemory] = *resource.NewQuantity(int64(shape.MemoryInBytes), resource.DecimalSI)
 	node.Status.Capacity[consts.ResourceGPU] = *resource.NewQuantity(int64(shape.GPU), resource.DecimalSI)
+	if shape.EphemeralStorageInBytes > 0 {
+		node.Status.Capacity[apiv1.ResourceEphemeralStorage] = *resource.NewQuantity(int64(shape.EphemeralStorageInBytes), resource.DecimalSI)
+	}
 
 	node.Status.Allocatable = node.Status.Capacity
 
@@ -451,12 +643,61 @@ func (m *InstancePoolManagerImpl) buildNodeFromTemplate(instancePool *core.Insta
 		return nil, err
 	}
 
-	node.Labels = cloudprovider.JoinStringMaps(node.Labels, ocicommon.BuildGenericLabels(*instancePool.Id, nodeName, shape.Name, availabilityDomain))
+	// The kubelet registers the nodes with the labels and taints of its extra args in the instance metadata.
+	kubeletArgs := shape.Metadata[ocicommon.KubeletExtraArgsMetadataKey]
+	registeredLabels, err := ocicommon.GetRegisteredLabels(kubeletArgs)
+	if err != nil {
+		klog.Warningf("instance-pool %q: could not extract labels from the kubelet extra args: %v", *instancePool.Id, err)
+	}
+	registeredTaints, err := ocicommon.GetRegisteredTaints(kubeletArgs)
+	if err != nil {
+		klog.Warningf("instance-pool %q: could not extract taints from the kubelet extra args: %v", *instancePool.Id, err)
+	}
+
+	// Labels and taints configured on the pool apply on top of the generic ones, so that pods selecting
+	// them can trigger a scale-up of the pool even while it has no nodes.
+	templateLabels, templateTaints := getInstancePoolTemplateLabelsAndTaints(instancePool)
+	for _, taint := range append(registeredTaints, templateTaints...) {
+		if !taintutil.TaintExists(node.Spec.Taints, &taint) {
+			node.Spec.Taints = append(node.Spec.Taints, taint)
+		}
+	}
+	node.Labels = cloudprovider.JoinStringMaps(node.Labels, registeredLabels, ocicommon.BuildGenericLabels(*instancePool.Id, nodeName, shape.Name, availabilityDomain), templateLabels)
 
 	node.Status.Conditions = cloudprovider.BuildReadyConditions()
 	return &node, nil
 }
 
+// getInstancePoolTemplateLabelsAndTaints returns the labels and taints of the nodes of the instance pool, as
+// configured by its freeform tags. Invalid entries are skipped.
+func getInstancePoolTemplateLabelsAndTaints(ip *core.InstancePool) (map[string]string, []apiv1.Taint) {
+	labels := map[string]string{}
+	if value := strings.TrimSpace(ip.FreeformTags[consts.NodeTemplateLabelsTag]); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			key, labelValue, found := strings.Cut(strings.TrimSpace(entry), "=")
+			if !found || key == "" {
+				klog.Warningf("instance-pool %q: ignoring invalid node template label %q", *ip.Id, entry)
+				continue
+			}
+			labels[key] = labelValue
+		}
+	}
+
+	var taints []apiv1.Taint
+	if value := strings.TrimSpace(ip.FreeformTags[consts.NodeTemplateTaintsTag]); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			// Taints to remove, e.g. "key-", mean nothing for a new node.
+			added, _, err := taintutil.ParseTaints([]string{strings.TrimSpace(entry)})
+			if err != nil {
+				klog.Warningf("instance-pool %q: ignoring invalid node template taint %q: %v", *ip.Id, entry, err)
+				continue
+			}
+			taints = append(taints, added...)
+		}
+	}
+	return labels, taints
+}
+
 // getInstancePoolAvailabilityDomain determines the availability of the instance pool.
 // This breaks down if the customer specifies more than one placement configuration,
 // so best practices should be a node pool per AD if customers care about it during scheduling.
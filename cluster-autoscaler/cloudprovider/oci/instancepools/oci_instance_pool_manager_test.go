@@ -6,13 +6,18 @@ package instancepools
 
 import (
 	"context"
+	"errors"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/instancepools/consts"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/core"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/workrequests"
 	kubeletapis "k8s.io/kubelet/pkg/apis"
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
@@ -87,16 +92,31 @@ type mockComputeManagementClient struct {
 	listInstancePoolInstancesResponse  core.ListInstancePoolInstancesResponse
 	updateInstancePoolResponse         core.UpdateInstancePoolResponse
 	detachInstancePoolInstanceResponse core.DetachInstancePoolInstanceResponse
+	// errors returned, in order, by the first DetachInstancePoolInstance calls
+	detachInstancePoolInstanceErrs  []error
+	detachInstancePoolInstanceCount int
 }
 
+type mockServiceError struct {
+	statusCode int
+}
+
+func (e mockServiceError) Error() string           { return http.StatusText(e.statusCode) }
+func (e mockServiceError) GetHTTPStatusCode() int  { return e.statusCode }
+func (e mockServiceError) GetMessage() string      { return http.StatusText(e.statusCode) }
+func (e mockServiceError) GetCode() string         { return http.StatusText(e.statusCode) }
+func (e mockServiceError) GetOpcRequestID() string { return "" }
+
 type mockVirtualNetworkClient struct {
 	err             error
 	getVnicResponse core.GetVnicResponse
 }
 
 type mockComputeClient struct {
-	err                         error
-	listVnicAttachmentsResponse core.ListVnicAttachmentsResponse
+	err                                             error
+	listVnicAttachmentsResponse                     core.ListVnicAttachmentsResponse
+	getComputeCapacityReservationResponse           core.GetComputeCapacityReservationResponse
+	listComputeCapacityReservationInstancesResponse core.ListComputeCapacityReservationInstancesResponse
 }
 
 type mockWorkRequestClient struct {
@@ -119,6 +139,14 @@ func (m *mockComputeClient) ListVnicAttachments(ctx context.Context, request cor
 	return m.listVnicAttachmentsResponse, m.err
 }
 
+func (m *mockComputeClient) GetComputeCapacityReservation(context.Context, core.GetComputeCapacityReservationRequest) (core.GetComputeCapacityReservationResponse, error) {
+	return m.getComputeCapacityReservationResponse, m.err
+}
+
+func (m *mockComputeClient) ListComputeCapacityReservationInstances(context.Context, core.ListComputeCapacityReservationInstancesRequest) (core.ListComputeCapacityReservationInstancesResponse, error) {
+	return m.listComputeCapacityReservationInstancesResponse, m.err
+}
+
 func (m *mockVirtualNetworkClient) GetVnic(context.Context, core.GetVnicRequest) (core.GetVnicResponse, error) {
 	return m.getVnicResponse, m.err
 }
@@ -139,8 +167,23 @@ func (m *mockComputeManagementClient) GetInstancePoolInstance(context.Context, c
 	return m.getInstancePoolInstanceResponse, m.err
 }
 
-func (m *mockComputeManagementClient) DetachInstancePoolInstance(context.Context, core.DetachInstancePoolInstanceRequest) (core.DetachInstancePoolInstanceResponse, error) {
-	return m.detachInstancePoolInstanceResponse, m.err
+func (m *mockComputeManagementClient) DetachInstancePoolInstance(ctx context.Context, request core.DetachInstancePoolInstanceRequest) (core.DetachInstancePoolInstanceResponse, error) {
+	// Like the OCI client, retry with the policy of the request.
+	policy := common.NoRetryPolicy()
+	if request.RetryPolicy() != nil {
+		policy = *request.RetryPolicy()
+	}
+	_, err := common.Retry(ctx, request, func(context.Context, common.OCIRequest, *common.OCIReadSeekCloser, map[string]string) (common.OCIResponse, error) {
+		m.detachInstancePoolInstanceCount++
+		if m.detachInstancePoolInstanceCount <= len(m.detachInstancePoolInstanceErrs) {
+			return core.DetachInstancePoolInstanceResponse{}, m.detachInstancePoolInstanceErrs[m.detachInstancePoolInstanceCount-1]
+		}
+		return m.detachInstancePoolInstanceResponse, m.err
+	}, policy)
+	if err != nil {
+		return core.DetachInstancePoolInstanceResponse{}, err
+	}
+	return m.detachInstancePoolInstanceResponse, nil
 }
 
 var computeClient = &mockComputeClient{
@@ -587,6 +630,252 @@ func TestGetInstancePoolTemplateNode(t *testing.T) {
 
 }
 
+func TestGetInstancePoolTemplateNodesMixedShapes(t *testing.T) {
+	instancePoolCache := newInstancePoolCache(computeManagementClient, computeClient, virtualNetworkClient, workRequestsClient)
+	instancePoolCache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &core.InstancePool{
+		Id:                      common.String("ocid1.instancepool.oc1.phx.aaaaaaaa1"),
+		CompartmentId:           common.String("ocid1.compartment.oc1..aaaaaaaa1"),
+		InstanceConfigurationId: common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa2"),
+		LifecycleState:          core.InstancePoolLifecycleStateRunning,
+		PlacementConfigurations: []core.InstancePoolPlacementConfiguration{{
+			AvailabilityDomain: common.String("hash:US-ASHBURN-1"),
+			PrimarySubnetId:    common.String("ocid1.subnet.oc1.phx.aaaaaaaa1"),
+		}},
+	}
+
+	// The instance configuration prefers a large flexible shape and falls back to a smaller fixed shape.
+	mixedShapeClient := &mockShapeClient{
+		listShapeResp: core.ListShapesResponse{
+			Items: []core.Shape{
+				{Shape: common.String("VM.Standard2.4"), Ocpus: common.Float32(4), MemoryInGBs: common.Float32(60)},
+			},
+		},
+		getInstanceConfigResp: core.GetInstanceConfigurationResponse{
+			InstanceConfiguration: core.InstanceConfiguration{
+				Id: common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa2"),
+				InstanceDetails: core.ComputeInstanceOptions{
+					Options: []core.ComputeInstanceDetails{
+						{LaunchDetails: &launchDetails},
+						{LaunchDetails: &core.InstanceConfigurationLaunchInstanceDetails{Shape: common.String("VM.Standard2.4")}},
+					},
+				},
+			},
+		},
+	}
+
+	manager := &InstancePoolManagerImpl{
+		ShapeGetter: ocicommon.CreateShapeGetter(mixedShapeClient),
+		staticInstancePools: map[string]*InstancePoolNodeGroup{
+			"ocid1.instancepool.oc1.phx.aaaaaaaa1": {id: "ocid1.instancepool.oc1.phx.aaaaaaaa1"},
+		},
+		instancePoolCache: instancePoolCache,
+	}
+	ip := InstancePoolNodeGroup{id: "ocid1.instancepool.oc1.phx.aaaaaaaa1"}
+
+	nodeTemplates, err := manager.GetInstancePoolTemplateNodes(ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if got := len(nodeTemplates); got != 2 {
+		t.Fatalf("expected 2 node templates, got %d", got)
+	}
+
+	expected := []struct {
+		shape  string
+		cpu    int64
+		memory int64
+	}{
+		{shape: "VM.Standard.E3.Flex", cpu: 8, memory: 128 * 1024 * 1024 * 1024},
+		{shape: "VM.Standard2.4", cpu: 4, memory: 60 * 1024 * 1024 * 1024},
+	}
+	for i, e := range expected {
+		node := nodeTemplates[i]
+		if got := node.Labels[apiv1.LabelInstanceTypeStable]; got != e.shape {
+			t.Errorf("template %d: expected shape label %s, got %s", i, e.shape, got)
+		}
+		if got := node.Status.Capacity.Cpu().Value(); got != e.cpu {
+			t.Errorf("template %d: expected %d cpus, got %d", i, e.cpu, got)
+		}
+		if got := node.Status.Capacity.Memory().Value(); got != e.memory {
+			t.Errorf("template %d: expected %d bytes of memory, got %d", i, e.memory, got)
+		}
+	}
+
+	// The single template used by the core is the preferred shape the pool launches.
+	nodeTemplate, err := manager.GetInstancePoolTemplateNode(ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if got := nodeTemplate.Labels[apiv1.LabelInstanceTypeStable]; got != "VM.Standard.E3.Flex" {
+		t.Errorf("expected template of shape VM.Standard.E3.Flex, got %s", got)
+	}
+}
+
+func TestGetInstancePoolTemplateNodeScaledToZero(t *testing.T) {
+	instancePoolCache := newInstancePoolCache(computeManagementClient, computeClient, virtualNetworkClient, workRequestsClient)
+	instancePoolCache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &core.InstancePool{
+		Id:                      common.String("ocid1.instancepool.oc1.phx.aaaaaaaa1"),
+		CompartmentId:           common.String("ocid1.compartment.oc1..aaaaaaaa1"),
+		InstanceConfigurationId: common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa1"),
+		LifecycleState:          core.InstancePoolLifecycleStateRunning,
+		Size:                    common.Int(0),
+		PlacementConfigurations: []core.InstancePoolPlacementConfiguration{{
+			AvailabilityDomain: common.String("hash:US-ASHBURN-1"),
+			PrimarySubnetId:    common.String("ocid1.subnet.oc1.phx.aaaaaaaa1"),
+		}},
+		FreeformTags: map[string]string{
+			consts.NodeTemplateLabelsTag: "workload=batch, tier=gpu,invalid",
+			consts.NodeTemplateTaintsTag: "dedicated=batch:NoSchedule,invalid",
+		},
+	}
+	// The pool has no instances, the template is built from the instance configuration alone.
+	instancePoolCache.instanceSummaryCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &[]core.InstanceSummary{}
+
+	imageLaunchDetails := launchDetails
+	imageLaunchDetails.SourceDetails = core.InstanceConfigurationInstanceSourceViaImageDetails{
+		ImageId:             common.String("ocid1.image.oc1.phx.aaaaaaaa1"),
+		BootVolumeSizeInGBs: common.Int64(100),
+	}
+	imageShapeClient := &mockShapeClient{
+		getInstanceConfigResp: core.GetInstanceConfigurationResponse{
+			InstanceConfiguration: core.InstanceConfiguration{
+				Id:              common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa1"),
+				InstanceDetails: core.ComputeInstanceDetails{LaunchDetails: &imageLaunchDetails},
+			},
+		},
+	}
+
+	manager := &InstancePoolManagerImpl{
+		ShapeGetter: ocicommon.CreateShapeGetter(imageShapeClient),
+		staticInstancePools: map[string]*InstancePoolNodeGroup{
+			"ocid1.instancepool.oc1.phx.aaaaaaaa1": {id: "ocid1.instancepool.oc1.phx.aaaaaaaa1", minSize: 0, maxSize: 5},
+		},
+		instancePoolCache: instancePoolCache,
+	}
+	ip := InstancePoolNodeGroup{id: "ocid1.instancepool.oc1.phx.aaaaaaaa1", minSize: 0, maxSize: 5}
+
+	nodeTemplate, err := manager.GetInstancePoolTemplateNode(ip)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	for key, value := range map[string]string{
+		"workload":                    "batch",
+		"tier":                        "gpu",
+		apiv1.LabelInstanceTypeStable: "VM.Standard.E3.Flex",
+		apiv1.LabelTopologyZone:       "US-ASHBURN-1",
+	} {
+		if got := nodeTemplate.Labels[key]; got != value {
+			t.Errorf("expected label %s=%s, got %q: %v", key, value, got, nodeTemplate.Labels)
+		}
+	}
+	if _, found := nodeTemplate.Labels["invalid"]; found {
+		t.Errorf("expected invalid label to be skipped: %v", nodeTemplate.Labels)
+	}
+
+	expectedTaints := []apiv1.Taint{{Key: "dedicated", Value: "batch", Effect: apiv1.TaintEffectNoSchedule}}
+	if !reflect.DeepEqual(nodeTemplate.Spec.Taints, expectedTaints) {
+		t.Errorf("expected taints %v, got %v", expectedTaints, nodeTemplate.Spec.Taints)
+	}
+
+	if got := nodeTemplate.Status.Capacity.StorageEphemeral().Value(); got != 100*1024*1024*1024 {
+		t.Errorf("expected %d bytes of ephemeral storage, got %d", 100*1024*1024*1024, got)
+	}
+	if got := nodeTemplate.Status.Capacity.Cpu().Value(); got != 8 {
+		t.Errorf("expected 8 cpus, got %d", got)
+	}
+}
+
+func TestGetInstancePoolTemplateNodeRegisteredLabelsAndTaints(t *testing.T) {
+	instancePoolCache := newInstancePoolCache(computeManagementClient, computeClient, virtualNetworkClient, workRequestsClient)
+	instancePoolCache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &core.InstancePool{
+		Id:                      common.String("ocid1.instancepool.oc1.phx.aaaaaaaa1"),
+		CompartmentId:           common.String("ocid1.compartment.oc1..aaaaaaaa1"),
+		InstanceConfigurationId: common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa1"),
+		LifecycleState:          core.InstancePoolLifecycleStateRunning,
+		PlacementConfigurations: []core.InstancePoolPlacementConfiguration{{
+			AvailabilityDomain: common.String("hash:US-ASHBURN-1"),
+			PrimarySubnetId:    common.String("ocid1.subnet.oc1.phx.aaaaaaaa1"),
+		}},
+		FreeformTags: map[string]string{
+			consts.NodeTemplateLabelsTag: "tier=gpu",
+			consts.NodeTemplateTaintsTag: "dedicated=batch:NoSchedule,gpu=true:NoExecute",
+		},
+	}
+
+	metadataLaunchDetails := launchDetails
+	metadataLaunchDetails.Metadata = map[string]string{
+		ocicommon.KubeletExtraArgsMetadataKey: "--node-labels=workload=batch,tier=cpu --register-with-taints=dedicated=batch:NoSchedule --address 0.0.0.0",
+	}
+	metadataShapeClient := &mockShapeClient{
+		getInstanceConfigResp: core.GetInstanceConfigurationResponse{
+			InstanceConfiguration: core.InstanceConfiguration{
+				Id:              common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa1"),
+				InstanceDetails: core.ComputeInstanceDetails{LaunchDetails: &metadataLaunchDetails},
+			},
+		},
+	}
+
+	manager := &InstancePoolManagerImpl{
+		ShapeGetter:       ocicommon.CreateShapeGetter(metadataShapeClient),
+		instancePoolCache: instancePoolCache,
+	}
+	nodeTemplate, err := manager.GetInstancePoolTemplateNode(InstancePoolNodeGroup{id: "ocid1.instancepool.oc1.phx.aaaaaaaa1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	// The labels of the pool's tags take precedence over the ones the kubelet registers with.
+	for key, value := range map[string]string{
+		"workload":                    "batch",
+		"tier":                        "gpu",
+		apiv1.LabelInstanceTypeStable: "VM.Standard.E3.Flex",
+	} {
+		if got := nodeTemplate.Labels[key]; got != value {
+			t.Errorf("expected label %s=%s, got %q: %v", key, value, got, nodeTemplate.Labels)
+		}
+	}
+
+	expectedTaints := []apiv1.Taint{
+		{Key: "dedicated", Value: "batch", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "gpu", Value: "true", Effect: apiv1.TaintEffectNoExecute},
+	}
+	if !reflect.DeepEqual(nodeTemplate.Spec.Taints, expectedTaints) {
+		t.Errorf("expected taints %v, got %v", expectedTaints, nodeTemplate.Spec.Taints)
+	}
+}
+
+// blockingComputeManagementClient never answers GetInstancePool calls, like a hung endpoint.
+type blockingComputeManagementClient struct {
+	*mockComputeManagementClient
+}
+
+func (m *blockingComputeManagementClient) GetInstancePool(ctx context.Context, _ core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	<-ctx.Done()
+	return core.GetInstancePoolResponse{}, ctx.Err()
+}
+
+func TestAPITimeout(t *testing.T) {
+	cache := newInstancePoolCache(&blockingComputeManagementClient{computeManagementClient}, computeClient, virtualNetworkClient, workRequestsClient)
+	cache.setAPITimeout(100 * time.Millisecond)
+	staticInstancePools := map[string]*InstancePoolNodeGroup{
+		"ocid1.instancepool.oc1.phx.aaaaaaaa1": {id: "ocid1.instancepool.oc1.phx.aaaaaaaa1"},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cache.rebuild(staticInstancePools, ocicommon.CloudConfig{})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the call to time out, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hung call to time out")
+	}
+}
+
 func TestDeleteInstances(t *testing.T) {
 
 	var computeManagementClient = &mockComputeManagementClient{
@@ -667,6 +956,62 @@ func TestDeleteInstances(t *testing.T) {
 	}
 }
 
+func TestRemoveInstanceRetriesOnThrottling(t *testing.T) {
+	defer func(p *common.RetryPolicy) { terminateInstanceRetryPolicy = p }(terminateInstanceRetryPolicy)
+	terminateInstanceRetryPolicy = ocicommon.NewRetryPolicyWithBackoff(wait.Backoff{Duration: time.Millisecond, Factor: 2, Jitter: 0.5, Steps: 6})
+
+	testCases := map[string]struct {
+		detachErrs            []error
+		apiTimeout            time.Duration
+		expectedDetached      bool
+		expectedDetachCalls   int
+		expectedSizeAfterCall int
+	}{
+		"429 twice then success": {
+			detachErrs:            []error{mockServiceError{statusCode: http.StatusTooManyRequests}, mockServiceError{statusCode: http.StatusTooManyRequests}},
+			expectedDetached:      true,
+			expectedDetachCalls:   3,
+			expectedSizeAfterCall: 1,
+		},
+		"429 twice then success with an API timeout shorter than the retries": {
+			detachErrs:            []error{mockServiceError{statusCode: http.StatusTooManyRequests}, mockServiceError{statusCode: http.StatusTooManyRequests}},
+			apiTimeout:            time.Nanosecond,
+			expectedDetached:      true,
+			expectedDetachCalls:   3,
+			expectedSizeAfterCall: 1,
+		},
+		"non retryable error": {
+			detachErrs:            []error{mockServiceError{statusCode: http.StatusNotFound}},
+			expectedDetached:      false,
+			expectedDetachCalls:   1,
+			expectedSizeAfterCall: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			computeManagementClient := &mockComputeManagementClient{detachInstancePoolInstanceErrs: tc.detachErrs}
+			cache := newInstancePoolCache(computeManagementClient, computeClient, virtualNetworkClient, workRequestsClient)
+			cache.setAPITimeout(tc.apiTimeout)
+			cache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &core.InstancePool{
+				Id:   common.String("ocid1.instancepool.oc1.phx.aaaaaaaa1"),
+				Size: common.Int(2),
+			}
+
+			detached := cache.removeInstance(InstancePoolNodeGroup{id: "ocid1.instancepool.oc1.phx.aaaaaaaa1"}, "ocid1.instance.oc1.phx.aaa2")
+			if detached != tc.expectedDetached {
+				t.Errorf("got detached %v ; wanted %v", detached, tc.expectedDetached)
+			}
+			if got := computeManagementClient.detachInstancePoolInstanceCount; got != tc.expectedDetachCalls {
+				t.Errorf("got %d detach calls ; wanted %d", got, tc.expectedDetachCalls)
+			}
+			if got := *cache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"].Size; got != tc.expectedSizeAfterCall {
+				t.Errorf("got size %d ; wanted %d", got, tc.expectedSizeAfterCall)
+			}
+		})
+	}
+}
+
 func TestBuildGenericLabels(t *testing.T) {
 
 	shapeName := "VM.Standard2.8"
@@ -732,3 +1077,117 @@ func TestBuildGenericLabels(t *testing.T) {
 	}
 
 }
+
+func TestInstancePoolCapacityReservation(t *testing.T) {
+	reservedLaunchDetails := launchDetails
+	reservedLaunchDetails.CapacityReservationId = common.String("ocid1.capacityreservation.oc1.phx.aaaaaaaa1")
+	reservedShapeClient := &mockShapeClient{
+		getInstanceConfigResp: core.GetInstanceConfigurationResponse{
+			InstanceConfiguration: core.InstanceConfiguration{
+				Id:              common.String("ocid1.instanceconfiguration.oc1.phx.aaaaaaaa1"),
+				InstanceDetails: core.ComputeInstanceDetails{LaunchDetails: &reservedLaunchDetails},
+			},
+		},
+	}
+	reservationComputeClient := &mockComputeClient{
+		getComputeCapacityReservationResponse: core.GetComputeCapacityReservationResponse{
+			ComputeCapacityReservation: core.ComputeCapacityReservation{
+				Id:                    common.String("ocid1.capacityreservation.oc1.phx.aaaaaaaa1"),
+				ReservedInstanceCount: common.Int64(15),
+				UsedInstanceCount:     common.Int64(6),
+				InstanceReservationConfigs: []core.InstanceReservationConfig{
+					{InstanceShape: common.String("VM.Standard.E3.Flex"), ReservedCount: common.Int64(5), UsedCount: common.Int64(4)},
+					// The capacity reserved for other shapes isn't available to the pool.
+					{InstanceShape: common.String("VM.Standard2.8"), ReservedCount: common.Int64(10), UsedCount: common.Int64(2)},
+				},
+			},
+		},
+		listComputeCapacityReservationInstancesResponse: core.ListComputeCapacityReservationInstancesResponse{
+			Items: []core.CapacityReservationInstanceSummary{
+				{Id: common.String("ocid1.instance.oc1.phx.aaa1")},
+				{Id: common.String("ocid1.instance.oc1.phx.aaa2")},
+				{Id: common.String("ocid1.instance.oc1.phx.other")},
+			},
+		},
+	}
+
+	instancePoolCache := newInstancePoolCache(computeManagementClient, reservationComputeClient, virtualNetworkClient, workRequestsClient)
+	instancePoolCache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &core.InstancePool{
+		Id:           common.String("ocid1.instancepool.oc1.phx.aaaaaaaa1"),
+		Size:         common.Int(3),
+		FreeformTags: map[string]string{consts.OnDemandCapacityTag: "2"},
+	}
+	// Two instances of the pool are launched into the reservation, the third one on demand.
+	instancePoolCache.instanceSummaryCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"] = &[]core.InstanceSummary{
+		{Id: common.String("ocid1.instance.oc1.phx.aaa1"), State: common.String(string(core.InstanceLifecycleStateRunning))},
+		{Id: common.String("ocid1.instance.oc1.phx.aaa2"), State: common.String(string(core.InstanceLifecycleStateRunning))},
+		{Id: common.String("ocid1.instance.oc1.phx.aaa3"), State: common.String(string(core.InstanceLifecycleStateRunning))},
+		{Id: common.String("ocid1.instance.oc1.phx.aaa4"), State: common.String(string(core.InstanceLifecycleStateTerminated))},
+	}
+	manager := &InstancePoolManagerImpl{
+		ShapeGetter:       ocicommon.CreateShapeGetter(reservedShapeClient),
+		instancePoolCache: instancePoolCache,
+	}
+	ip := &InstancePoolNodeGroup{id: "ocid1.instancepool.oc1.phx.aaaaaaaa1", maxSize: 10, manager: manager}
+
+	if got := ip.MaxSize(); got != 10 {
+		t.Errorf("expected the max size to be unbound before the reservation is read, got %d", got)
+	}
+	manager.refreshCapacityReservations(ip.Id())
+	capacity := manager.GetInstancePoolCapacityReservation(*ip)
+	expected := &CapacityReservation{
+		IDs:          []string{"ocid1.capacityreservation.oc1.phx.aaaaaaaa1"},
+		Reserved:     5,
+		Used:         4,
+		OnDemand:     2,
+		OnDemandUsed: 1,
+	}
+	if !reflect.DeepEqual(capacity, expected) {
+		t.Fatalf("got %+v\nwanted %+v", capacity, expected)
+	}
+	if got := capacity.Available(); got != 2 {
+		t.Errorf("expected 2 available instances, got %d", got)
+	}
+
+	// The pool is far from its max size, but the reservation can't fit more than two more instances.
+	if got := ip.MaxSize(); got != 5 {
+		t.Errorf("expected the max size to be bound by the reservation, got %d", got)
+	}
+	if err := ip.IncreaseSize(3); err == nil {
+		t.Error("expected the size increase beyond the capacity reservation to fail")
+	}
+
+	// A pool allowed to launch on demand falls back to that capacity if the reservation can't be read.
+	reservationComputeClient.err = errors.New("service unavailable")
+	capacity, err := manager.fetchCapacityReservation(ip.Id())
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	expected = &CapacityReservation{
+		IDs:          []string{"ocid1.capacityreservation.oc1.phx.aaaaaaaa1"},
+		OnDemand:     2,
+		OnDemandUsed: 3,
+	}
+	if !reflect.DeepEqual(capacity, expected) {
+		t.Fatalf("got %+v\nwanted %+v", capacity, expected)
+	}
+	// Otherwise the capacity read by the previous refresh is kept.
+	instancePoolCache.poolCache["ocid1.instancepool.oc1.phx.aaaaaaaa1"].FreeformTags = nil
+	if _, err := manager.fetchCapacityReservation(ip.Id()); err == nil {
+		t.Error("expected an error for a pool which can't launch on demand")
+	}
+	manager.refreshCapacityReservations(ip.Id())
+	if got := ip.MaxSize(); got != 5 {
+		t.Errorf("expected the max size of the previous refresh, got %d", got)
+	}
+
+	// The capacity of pools launching on demand isn't bound, and their reservations aren't read.
+	manager.ShapeGetter = ocicommon.CreateShapeGetter(shapeClient)
+	manager.refreshCapacityReservations(ip.Id())
+	if capacity := manager.GetInstancePoolCapacityReservation(*ip); capacity != nil {
+		t.Errorf("expected no capacity reservation, got %+v", capacity)
+	}
+	if got := ip.MaxSize(); got != 10 {
+		t.Errorf("expected the max size to be unbound, got %d", got)
+	}
+}
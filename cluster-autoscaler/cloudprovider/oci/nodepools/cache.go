@@ -12,11 +12,12 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/pkg/errors"
+	ocicommon "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/common"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/common"
 	oke "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/oci/vendor-internal/github.com/oracle/oci-go-sdk/v65/containerengine"
 )
 
-func newNodePoolCache(okeClient *oke.ContainerEngineClient) *nodePoolCache {
+func newNodePoolCache(okeClient okeClient) *nodePoolCache {
 	return &nodePoolCache{
 		cache:      map[string]*oke.NodePool{},
 		targetSize: map[string]int{},
@@ -30,6 +31,8 @@ type nodePoolCache struct {
 	targetSize map[string]int
 
 	okeClient okeClient
+	// refreshes the request signer when OKE rejects a call as unauthenticated, nil if the signer can't be refreshed.
+	signerRefresher ocicommon.SignerRefresher
 }
 
 func (c *nodePoolCache) nodePools() map[string]*oke.NodePool {
@@ -45,17 +48,26 @@ func (c *nodePoolCache) rebuild(staticNodePools map[string]NodePool, maxGetNodep
 	for id := range staticNodePools {
 		var resp oke.GetNodePoolResponse
 		for i := 1; i <= maxGetNodepoolRetries; i++ {
-			// prevent us from getting a node pool at the same time that we're performing delete actions on the node pool.
-			c.mu.Lock()
-			resp, err = c.okeClient.GetNodePool(context.Background(), oke.GetNodePoolRequest{
-				NodePoolId: common.String(id),
+			ctx := context.Background()
+			err = ocicommon.CallWithAuthRefresh(ctx, c.signerRefresher, func() error {
+				// prevent us from getting a node pool at the same time that we're performing delete actions on the node pool.
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				var getErr error
+				resp, getErr = c.okeClient.GetNodePool(ctx, oke.GetNodePoolRequest{
+					NodePoolId: common.String(id),
+				})
+				return getErr
 			})
-			c.mu.Unlock()
-			if err != nil {
-				klog.Errorf("Failed to fetch the nodepool : %v. Retries available : %v", id, maxGetNodepoolRetries-i)
-			} else {
+			if err == nil {
 				break
 			}
+			// the signer was already refreshed and the call retried, retrying again won't authenticate it.
+			if ocicommon.IsAuthError(err) {
+				klog.Errorf("Failed to fetch the nodepool : %v. Not retrying, the request could not be authenticated", id)
+				break
+			}
+			klog.Errorf("Failed to fetch the nodepool : %v. Retries available : %v", id, maxGetNodepoolRetries-i)
 		}
 		if err != nil {
 			// in order to let cluster autoscaler still do its work even with a wrong nodepoolid,
@@ -181,13 +193,16 @@ func (c *nodePoolCache) set(np *oke.NodePool) {
 
 func (c *nodePoolCache) setSize(id string, size int) error {
 
-	_, err := c.okeClient.UpdateNodePool(context.Background(), oke.UpdateNodePoolRequest{
-		NodePoolId: common.String(id),
-		UpdateNodePoolDetails: oke.UpdateNodePoolDetails{
-			NodeConfigDetails: &oke.UpdateNodePoolNodeConfigDetails{
-				Size: common.Int(size),
+	err := ocicommon.CallWithAuthRefresh(context.Background(), c.signerRefresher, func() error {
+		_, err := c.okeClient.UpdateNodePool(context.Background(), oke.UpdateNodePoolRequest{
+			NodePoolId: common.String(id),
+			UpdateNodePoolDetails: oke.UpdateNodePoolDetails{
+				NodeConfigDetails: &oke.UpdateNodePoolNodeConfigDetails{
+					Size: common.Int(size),
+				},
 			},
-		},
+		})
+		return err
 	})
 	if err != nil {
 		return err
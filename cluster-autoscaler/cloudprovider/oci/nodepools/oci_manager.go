@@ -86,11 +86,50 @@ type okeClient interface {
 	ListNodePools(ctx context.Context, request oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error)
 }
 
+// withOkeAPITimeout bounds every call of the client by the timeout. A timeout of 0 leaves the calls without deadline.
+func withOkeAPITimeout(client okeClient, timeout time.Duration) okeClient {
+	if timeout <= 0 {
+		return client
+	}
+	return &apiTimeoutOkeClient{okeClient: client, timeout: timeout}
+}
+
+// apiTimeoutOkeClient bounds every call of the wrapped okeClient by a timeout.
+type apiTimeoutOkeClient struct {
+	okeClient
+	timeout time.Duration
+}
+
+func (c *apiTimeoutOkeClient) GetNodePool(ctx context.Context, req oke.GetNodePoolRequest) (oke.GetNodePoolResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.okeClient.GetNodePool(ctx, req)
+}
+
+func (c *apiTimeoutOkeClient) UpdateNodePool(ctx context.Context, req oke.UpdateNodePoolRequest) (oke.UpdateNodePoolResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.okeClient.UpdateNodePool(ctx, req)
+}
+
+func (c *apiTimeoutOkeClient) DeleteNode(ctx context.Context, req oke.DeleteNodeRequest) (oke.DeleteNodeResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.okeClient.DeleteNode(ctx, req)
+}
+
+func (c *apiTimeoutOkeClient) ListNodePools(ctx context.Context, req oke.ListNodePoolsRequest) (oke.ListNodePoolsResponse, error) {
+	ctx, cancel := ocicommon.WithAPITimeout(ctx, c.timeout)
+	defer cancel()
+	return c.okeClient.ListNodePools(ctx, req)
+}
+
 // CreateNodePoolManager creates an NodePoolManager that can manage autoscaling node pools
 func CreateNodePoolManager(cloudConfigPath string, nodeGroupAutoDiscoveryList []string, discoveryOpts cloudprovider.NodeGroupDiscoveryOptions, kubeClient kubernetes.Interface) (NodePoolManager, error) {
 
 	var err error
 	var configProvider common.ConfigurationProvider
+	useInstancePrincipals := false
 
 	// enable SDK to look up the IMDS endpoint to figure out the right realmDomain
 	common.EnableInstanceMetadataServiceLookup()
@@ -107,6 +146,7 @@ func CreateNodePoolManager(cloudConfigPath string, nodeGroupAutoDiscoveryList []
 		if err != nil {
 			return nil, err
 		}
+		useInstancePrincipals = true
 	} else {
 		klog.Info("using default configuration provider")
 		configProvider = common.DefaultConfigProvider()
@@ -147,20 +187,27 @@ func CreateNodePoolManager(cloudConfigPath string, nodeGroupAutoDiscoveryList []
 	computeClient.SetCustomClientConfiguration(clientConfig)
 
 	//ociShapeGetter := ocicommon.CreateShapeGetter(computeClient)
-	ociShapeGetter := ocicommon.CreateShapeGetter(ocicommon.ShapeClientImpl{ComputeMgmtClient: computeMgmtClient, ComputeClient: computeClient})
+	ociShapeGetter := ocicommon.CreateShapeGetter(ocicommon.ShapeClientImpl{ComputeMgmtClient: computeMgmtClient, ComputeClient: computeClient,
+		APITimeout: cloudConfig.Global.APITimeout})
 	ociTagsGetter := ocicommon.CreateTagsGetter()
 
 	registeredTaintsGetter := CreateRegisteredTaintsGetter()
 
+	okeAPIClient := withOkeAPITimeout(&okeClient, cloudConfig.Global.APITimeout)
+
 	manager := &ociManagerImpl{
 		cfg:                    cloudConfig,
-		okeClient:              &okeClient,
+		okeClient:              okeAPIClient,
 		computeClient:          &computeClient,
 		staticNodePools:        map[string]NodePool{},
 		ociShapeGetter:         ociShapeGetter,
 		ociTagsGetter:          ociTagsGetter,
 		registeredTaintsGetter: registeredTaintsGetter,
-		nodePoolCache:          newNodePoolCache(&okeClient),
+		nodePoolCache:          newNodePoolCache(okeAPIClient),
+	}
+
+	if useInstancePrincipals {
+		manager.nodePoolCache.signerRefresher = ocicommon.NewInstancePrincipalSignerRefresher(&okeClient.BaseClient, &computeClient.BaseClient)
 	}
 
 	// auto discover nodepools from compartments with nodeGroupAutoDiscovery parameter
@@ -488,7 +535,9 @@ func (m *ociManagerImpl) GetExistingNodePoolSizeViaCompute(np NodePool) (int, er
 	klog.V(5).Infof("Filter used is prefix %q", displayNamePrefix)
 
 	listInstancesFunc := func(request core.ListInstancesRequest) (core.ListInstancesResponse, error) {
-		return m.computeClient.ListInstances(context.Background(), request)
+		ctx, cancel := ocicommon.WithAPITimeout(context.Background(), m.cfg.Global.APITimeout)
+		defer cancel()
+		return m.computeClient.ListInstances(ctx, request)
 	}
 
 	var instances []cloudprovider.Instance
@@ -503,34 +552,11 @@ func (m *ociManagerImpl) GetExistingNodePoolSizeViaCompute(np NodePool) (int, er
 			if !strings.HasPrefix(*item.DisplayName, displayNamePrefix) {
 				continue
 			}
-			switch item.LifecycleState {
-			case core.InstanceLifecycleStateStopped, core.InstanceLifecycleStateTerminated:
-				klog.V(4).Infof("skipping instance is in stopped/terminated state: %q", *item.Id)
-			case core.InstanceLifecycleStateCreatingImage, core.InstanceLifecycleStateStarting, core.InstanceLifecycleStateProvisioning, core.InstanceLifecycleStateMoving:
+			if status := ocicommon.InstanceStatusFromLifecycleState(*item.Id, string(item.LifecycleState)); status != nil {
 				instances = append(instances, cloudprovider.Instance{
-					Id: *item.Id,
-					Status: &cloudprovider.InstanceStatus{
-						State: cloudprovider.InstanceCreating,
-					},
-				})
-			// in case an instance is running, it could either be installing OKE software or become a Ready node.
-			// we do not know, but as we only need info if a node is stopped / terminated, we do not care
-			case core.InstanceLifecycleStateRunning:
-				instances = append(instances, cloudprovider.Instance{
-					Id: *item.Id,
-					Status: &cloudprovider.InstanceStatus{
-						State: cloudprovider.InstanceRunning,
-					},
-				})
-			case core.InstanceLifecycleStateStopping, core.InstanceLifecycleStateTerminating:
-				instances = append(instances, cloudprovider.Instance{
-					Id: *item.Id,
-					Status: &cloudprovider.InstanceStatus{
-						State: cloudprovider.InstanceDeleting,
-					},
+					Id:     *item.Id,
+					Status: status,
 				})
-			default:
-				klog.Warningf("instance found in unhandled state: (%q = %v)", *item.Id, item.LifecycleState)
 			}
 		}
 
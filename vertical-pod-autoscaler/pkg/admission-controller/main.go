@@ -78,6 +78,11 @@ var (
 	registerWebhook      = flag.Bool("register-webhook", true, "If set to true, admission webhook object will be created on start up to register with the API server.")
 	webhookLabels        = flag.String("webhook-labels", "", "Comma separated list of labels to add to the webhook object. Format: key1:value1,key2:value2")
 	registerByURL        = flag.Bool("register-by-url", false, "If set to true, admission webhook will be registered by URL (webhookAddress:webhookPort) instead of by service name")
+
+	recommendationCPUGranularity    = flag.String("recommendation-cpu-granularity", "", "If set, CPU recommendations are rounded to multiples of this quantity, e.g. 50m. The target is rounded up and still capped by the min and max allowed. Should match the flag of the updater.")
+	recommendationMemoryGranularity = flag.String("recommendation-memory-granularity", "", "If set, memory recommendations are rounded to multiples of this quantity, e.g. 32Mi. The target is rounded up and still capped by the min and max allowed. Should match the flag of the updater.")
+	recommendationMarginFraction    = flag.Float64("recommendation-margin-fraction", 0, "Fraction of the recommendation added as a safety margin, on top of the one of the recommender, to the resources of pods, e.g. 0.15 to set them to 1.15 times the recommendation. The margin is added before capping and rounding. Should match the flag of the updater.")
+	defaultResourcePolicyConfigMap  = flag.String("default-resource-policy-configmap", "", `ConfigMap given as namespace/name, or as name in the namespace of the VPA components, whose "minAllowed" and "maxAllowed" keys hold cluster-wide defaults, as JSON resource lists, e.g. {"cpu": "50m", "memory": "64Mi"}. They bound the resources of containers which the resource policy of their VPA doesn't bound. The ConfigMap is watched. Should match the flag of the updater. Leave empty to disable the defaults.`)
 )
 
 func main() {
@@ -95,7 +100,7 @@ func main() {
 
 	healthCheck := metrics.NewHealthCheck(time.Minute)
 	metrics_admission.Register()
-	server.Initialize(&commonFlags.EnableProfiling, healthCheck, address)
+	server.Initialize(&commonFlags.EnableProfiling, healthCheck, nil, address, false, nil)
 
 	config := common.CreateKubeConfigOrDie(commonFlags.KubeConfig, float32(commonFlags.KubeApiQps), int(commonFlags.KubeApiBurst))
 
@@ -113,7 +118,32 @@ func main() {
 		klog.ErrorS(err, "Failed to create limitRangeCalculator, falling back to not checking limits.")
 		limitRangeCalculator = limitrange.NewNoopLimitsCalculator()
 	}
-	recommendationProvider := recommendation.NewProvider(limitRangeCalculator, vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator))
+	var defaultResourcePolicy *vpa_api_util.DefaultResourcePolicy
+	if *defaultResourcePolicyConfigMap != "" {
+		defaultNamespace := status.AdmissionControllerStatusNamespace
+		if namespace != "" {
+			defaultNamespace = namespace
+		}
+		defaultResourcePolicy, err = vpa_api_util.WatchDefaultResourcePolicy(kubeClient, *defaultResourcePolicyConfigMap, defaultNamespace, make(chan struct{}))
+		if err != nil {
+			klog.ErrorS(err, "Failed to watch default resource policy")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+	// Overrides are honored by the admission controller too, so pods recreated by the updater get them.
+	recommendationProcessor := vpa_api_util.NewOverrideRecommendationProcessor(vpa_api_util.NewCappingRecommendationProcessorWithDefaults(limitRangeCalculator, defaultResourcePolicy))
+	granularity, err := vpa_api_util.ParseRecommendationGranularity(*recommendationCPUGranularity, *recommendationMemoryGranularity)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse recommendation granularity")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	if len(granularity) > 0 {
+		recommendationProcessor = vpa_api_util.NewRoundingRecommendationProcessor(recommendationProcessor, granularity)
+	}
+	if *recommendationMarginFraction > 0 {
+		recommendationProcessor = vpa_api_util.NewMarginRecommendationProcessor(recommendationProcessor, *recommendationMarginFraction)
+	}
+	recommendationProvider := recommendation.NewProvider(limitRangeCalculator, recommendationProcessor)
 	vpaMatcher := vpa.NewMatcher(vpaLister, targetSelectorFetcher, controllerFetcher)
 
 	stopCh := make(chan struct{})
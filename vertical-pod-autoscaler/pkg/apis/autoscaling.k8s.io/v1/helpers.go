@@ -24,5 +24,6 @@ func GetUpdateModes() map[UpdateMode]any {
 		UpdateModeRecreate:          nil,
 		UpdateModeAuto:              nil,
 		UpdateModeInPlaceOrRecreate: nil,
+		UpdateModeInPlaceOnly:       nil,
 	}
 }
@@ -152,7 +152,7 @@ type PodUpdatePolicy struct {
 }
 
 // UpdateMode controls when autoscaler applies changes to the pod resources.
-// +kubebuilder:validation:Enum=Off;Initial;Recreate;InPlaceOrRecreate;Auto
+// +kubebuilder:validation:Enum=Off;Initial;Recreate;InPlaceOrRecreate;InPlaceOnly;Auto
 type UpdateMode string
 
 const (
@@ -183,6 +183,13 @@ const (
 	// on the admission and updater pods.
 	// Requires cluster feature gate "InPlacePodVerticalScaling" to be enabled.
 	UpdateModeInPlaceOrRecreate UpdateMode = "InPlaceOrRecreate"
+	// UpdateModeInPlaceOnly means that autoscaler only assigns resources in-place
+	// and never recreates the pod. If an in-place update is not possible, it is
+	// reported with an event on the pod and the pod keeps its resources.
+	// Requires VPA level feature gate "InPlaceOrRecreate" to be enabled
+	// on the admission and updater pods.
+	// Requires cluster feature gate "InPlacePodVerticalScaling" to be enabled.
+	UpdateModeInPlaceOnly UpdateMode = "InPlaceOnly"
 )
 
 // PodResourcePolicy controls how autoscaler computes the recommended resources
@@ -279,6 +286,12 @@ type VerticalPodAutoscalerStatus struct {
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	Conditions []VerticalPodAutoscalerCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// RecentActions is the history of the most recent updates of the controlled pods
+	// performed by the updater, newest first. The number of entries is bounded by the updater.
+	// +optional
+	// +listType=atomic
+	RecentActions []VerticalPodAutoscalerAction `json:"recentActions,omitempty" protobuf:"bytes,3,rep,name=recentActions"`
 }
 
 // RecommendedPodResources is the recommendation of resources computed by
@@ -360,6 +373,46 @@ type VerticalPodAutoscalerCondition struct {
 	// the transition
 	// +optional
 	Message string `json:"message,omitempty" protobuf:"bytes,5,opt,name=message"`
+	// lastProbeTime is the last time the condition was confirmed, even if it
+	// didn't change
+	// +optional
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty" protobuf:"bytes,6,opt,name=lastProbeTime"`
+}
+
+// VerticalPodAutoscalerActionType is the way in which the updater applied a recommendation to a pod.
+type VerticalPodAutoscalerActionType string
+
+const (
+	// ActionEviction means that the pod was evicted, so that it is recreated with the recommended resources.
+	ActionEviction VerticalPodAutoscalerActionType = "Eviction"
+	// ActionInPlaceUpdate means that the pod was resized in place.
+	ActionInPlaceUpdate VerticalPodAutoscalerActionType = "InPlaceUpdate"
+)
+
+// VerticalPodAutoscalerAction describes an update of a pod performed by the updater.
+type VerticalPodAutoscalerAction struct {
+	// time is when the action was performed.
+	Time metav1.Time `json:"time" protobuf:"bytes,1,name=time"`
+	// type is the way in which the pod was updated.
+	Type VerticalPodAutoscalerActionType `json:"type" protobuf:"bytes,2,name=type"`
+	// podName is the name of the updated pod.
+	PodName string `json:"podName" protobuf:"bytes,3,name=podName"`
+	// containerDeltas describes the change of resource requests of each updated container.
+	// +optional
+	// +listType=atomic
+	ContainerDeltas []ContainerResourcesDelta `json:"containerDeltas,omitempty" protobuf:"bytes,4,rep,name=containerDeltas"`
+}
+
+// ContainerResourcesDelta describes the change of resource requests of a container.
+type ContainerResourcesDelta struct {
+	// containerName is the name of the container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// oldRequests are the resource requests of the container before the update.
+	// +optional
+	OldRequests v1.ResourceList `json:"oldRequests,omitempty" protobuf:"bytes,2,rep,name=oldRequests,casttype=ResourceList,castkey=ResourceName"`
+	// newRequests are the resource requests recommended for the container.
+	// +optional
+	NewRequests v1.ResourceList `json:"newRequests,omitempty" protobuf:"bytes,3,rep,name=newRequests,casttype=ResourceList,castkey=ResourceName"`
 }
 
 // +genclient
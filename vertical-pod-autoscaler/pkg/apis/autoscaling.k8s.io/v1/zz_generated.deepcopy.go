@@ -86,6 +86,36 @@ func (in *ContainerResourcePolicy) DeepCopy() *ContainerResourcePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerResourcesDelta) DeepCopyInto(out *ContainerResourcesDelta) {
+	*out = *in
+	if in.OldRequests != nil {
+		in, out := &in.OldRequests, &out.OldRequests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.NewRequests != nil {
+		in, out := &in.NewRequests, &out.NewRequests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourcesDelta.
+func (in *ContainerResourcesDelta) DeepCopy() *ContainerResourcesDelta {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerResourcesDelta)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EvictionRequirement) DeepCopyInto(out *EvictionRequirement) {
 	*out = *in
@@ -286,6 +316,30 @@ func (in *VerticalPodAutoscaler) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerticalPodAutoscalerAction) DeepCopyInto(out *VerticalPodAutoscalerAction) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.ContainerDeltas != nil {
+		in, out := &in.ContainerDeltas, &out.ContainerDeltas
+		*out = make([]ContainerResourcesDelta, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerticalPodAutoscalerAction.
+func (in *VerticalPodAutoscalerAction) DeepCopy() *VerticalPodAutoscalerAction {
+	if in == nil {
+		return nil
+	}
+	out := new(VerticalPodAutoscalerAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VerticalPodAutoscalerCheckpoint) DeepCopyInto(out *VerticalPodAutoscalerCheckpoint) {
 	*out = *in
@@ -388,6 +442,7 @@ func (in *VerticalPodAutoscalerCheckpointStatus) DeepCopy() *VerticalPodAutoscal
 func (in *VerticalPodAutoscalerCondition) DeepCopyInto(out *VerticalPodAutoscalerCondition) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
 	return
 }
 
@@ -507,6 +562,13 @@ func (in *VerticalPodAutoscalerStatus) DeepCopyInto(out *VerticalPodAutoscalerSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RecentActions != nil {
+		in, out := &in.RecentActions, &out.RecentActions
+		*out = make([]VerticalPodAutoscalerAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
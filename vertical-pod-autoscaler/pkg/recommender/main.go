@@ -172,7 +172,7 @@ func main() {
 	metrics_recommender.Register()
 	metrics_quality.Register()
 	metrics_resources.Register()
-	server.Initialize(&commonFlags.EnableProfiling, healthCheck, address)
+	server.Initialize(&commonFlags.EnableProfiling, healthCheck, nil, address, false, nil)
 
 	if !leaderElection.LeaderElect {
 		run(ctx, healthCheck, commonFlags)
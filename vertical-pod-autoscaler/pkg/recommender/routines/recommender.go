@@ -107,8 +107,10 @@ func processVPAUpdate(r *recommender, vpa *model.Vpa, observedVpa *v1.VerticalPo
 		}
 	}
 
+	status := vpa.AsStatus()
+	vpa_utils.SetRecommendationHeartbeat(status, &observedVpa.Status, time.Now())
 	_, err := vpa_utils.UpdateVpaStatusIfNeeded(
-		r.vpaClient.VerticalPodAutoscalers(vpa.ID.Namespace), vpa.ID.VpaName, vpa.AsStatus(), &observedVpa.Status)
+		r.vpaClient.VerticalPodAutoscalers(vpa.ID.Namespace), vpa.ID.VpaName, status, &observedVpa.Status)
 	if err != nil {
 		klog.ErrorS(err, "Cannot update VPA", "vpa", klog.KRef(vpa.ID.Namespace, vpa.ID.VpaName))
 	}
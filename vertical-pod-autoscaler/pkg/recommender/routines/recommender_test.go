@@ -17,6 +17,7 @@ limitations under the License.
 package routines
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/model"
 	metrics_recommender "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/recommender"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	vpa_utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 )
 
 type mockPodResourceRecommender struct{}
@@ -349,3 +351,70 @@ func (k mockAggregateStateKey) Labels() labels.Labels {
 	labels, _ := labels.ConvertSelectorToLabelsMap(k.labels)
 	return labels
 }
+
+func TestProcessVPAUpdateKeepsRecentActions(t *testing.T) {
+	vpaID := model.VpaID{Namespace: "default", VpaName: "vpa"}
+	selector, err := labels.Parse("app=test")
+	assert.NoError(t, err)
+	vpa := model.NewVpa(vpaID, selector, time.Now())
+	apiVpa := test.VerticalPodAutoscaler().WithName(vpaID.VpaName).WithNamespace(vpaID.Namespace).WithContainer("container").Get()
+	storedVpa := apiVpa.DeepCopy()
+	storedVpa.Status.RecentActions = []v1.VerticalPodAutoscalerAction{{PodName: "pod"}}
+	// The observed copy of the VPA doesn't have the action the updater recorded since.
+	vpaClientset := vpa_fake.NewSimpleClientset(storedVpa) //nolint:staticcheck // https://github.com/kubernetes/autoscaler/issues/8954
+	r := &recommender{
+		clusterState:           model.NewClusterState(time.Minute),
+		vpaClient:              vpaClientset.AutoscalingV1(),
+		podResourceRecommender: &mockPodResourceRecommender{},
+	}
+
+	processVPAUpdate(r, vpa, apiVpa)
+	updated, err := vpaClientset.AutoscalingV1().VerticalPodAutoscalers(vpaID.Namespace).Get(context.Background(), vpaID.VpaName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, storedVpa.Status.RecentActions, updated.Status.RecentActions, "the recent actions written by the updater are kept")
+	assert.NotEmpty(t, updated.Status.Conditions)
+}
+
+func TestProcessVPAUpdateRefreshesRecommendationHeartbeat(t *testing.T) {
+	vpaID := model.VpaID{Namespace: "default", VpaName: "vpa"}
+	selector, err := labels.Parse("app=test")
+	assert.NoError(t, err)
+	vpa := model.NewVpa(vpaID, selector, time.Now())
+	apiVpa := test.VerticalPodAutoscaler().WithName(vpaID.VpaName).WithNamespace(vpaID.Namespace).WithContainer("container").Get()
+	vpaClientset := vpa_fake.NewSimpleClientset(apiVpa) //nolint:staticcheck // https://github.com/kubernetes/autoscaler/issues/8954
+	r := &recommender{
+		clusterState:           model.NewClusterState(time.Minute),
+		vpaClient:              vpaClientset.AutoscalingV1(),
+		podResourceRecommender: &mockPodResourceRecommender{},
+	}
+	getVpa := func() *v1.VerticalPodAutoscaler {
+		updated, err := vpaClientset.AutoscalingV1().VerticalPodAutoscalers(vpaID.Namespace).Get(context.Background(), vpaID.VpaName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return updated
+	}
+	lastProbeTime := func(vpa *v1.VerticalPodAutoscaler) time.Time {
+		for _, condition := range vpa.Status.Conditions {
+			if condition.Type == v1.RecommendationProvided {
+				return condition.LastProbeTime.Time
+			}
+		}
+		return time.Time{}
+	}
+
+	processVPAUpdate(r, vpa, apiVpa)
+	written := getVpa()
+	assert.False(t, lastProbeTime(written).IsZero(), "the heartbeat is set")
+
+	recent := time.Now().Add(-time.Minute).Truncate(time.Second)
+	for i := range written.Status.Conditions {
+		written.Status.Conditions[i].LastProbeTime = metav1.NewTime(recent)
+	}
+	processVPAUpdate(r, vpa, written)
+	assert.True(t, recent.Equal(lastProbeTime(getVpa())), "a recent heartbeat is kept")
+
+	for i := range written.Status.Conditions {
+		written.Status.Conditions[i].LastProbeTime = metav1.NewTime(time.Now().Add(-vpa_utils.RecommendationHeartbeatInterval))
+	}
+	processVPAUpdate(r, vpa, written)
+	assert.True(t, lastProbeTime(getVpa()).After(recent), "an old heartbeat is refreshed even though the recommendation didn't change")
+}
@@ -304,6 +304,48 @@ func (f *controllerFetcher) getOwnerForScaleResource(ctx context.Context, groupK
 	return nil, lastError
 }
 
+// GetControllerMeta returns the metadata of the given controller, read from the informers of well-known
+// controllers or from the scale subresource of the others, whose UID and ResourceVersion are the ones
+// of the controller.
+func (f *controllerFetcher) GetControllerMeta(ctx context.Context, key *ControllerKeyWithAPIVersion) (*metav1.ObjectMeta, error) {
+	if informer, exists := f.informersMap[wellKnownController(key.Kind)]; exists {
+		obj, exists, err := informer.GetStore().GetByKey(key.Namespace + "/" + key.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s %s/%s does not exist", key.Kind, key.Namespace, key.Name)
+		}
+		accessor, err := apimeta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		return &metav1.ObjectMeta{
+			Namespace:       accessor.GetNamespace(),
+			Name:            accessor.GetName(),
+			UID:             accessor.GetUID(),
+			ResourceVersion: accessor.GetResourceVersion(),
+		}, nil
+	}
+	groupKind, err := key.groupKind()
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := f.mapper.RESTMappings(groupKind)
+	if err != nil {
+		return nil, err
+	}
+	var lastError error
+	for _, mapping := range mappings {
+		scale, err := f.getScaleForResource(ctx, key.Namespace, mapping.Resource.GroupResource(), key.Name)
+		if err == nil {
+			return &scale.ObjectMeta, nil
+		}
+		lastError = err
+	}
+	return nil, lastError
+}
+
 func (f *controllerFetcher) FindTopMostWellKnownOrScalable(ctx context.Context, key *ControllerKeyWithAPIVersion) (*ControllerKeyWithAPIVersion, error) {
 	if key == nil {
 		return nil, nil
@@ -421,3 +421,32 @@ func TestControllerFetcher(t *testing.T) {
 		})
 	}
 }
+
+func TestGetControllerMeta(t *testing.T) {
+	f := simpleControllerFetcher()
+	addController(t, f, &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: testDeployment, Namespace: testNamespace, UID: "deployment-uid", ResourceVersion: "42"},
+	})
+
+	meta, err := f.GetControllerMeta(context.Background(), &ControllerKeyWithAPIVersion{
+		ControllerKey: ControllerKey{Namespace: testNamespace, Kind: "Deployment", Name: testDeployment},
+		ApiVersion:    "apps/v1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "deployment-uid", string(meta.UID))
+	assert.Equal(t, "42", meta.ResourceVersion)
+
+	_, err = f.GetControllerMeta(context.Background(), &ControllerKeyWithAPIVersion{
+		ControllerKey: ControllerKey{Namespace: testNamespace, Kind: "Deployment", Name: "missing"},
+		ApiVersion:    "apps/v1",
+	})
+	assert.Error(t, err)
+
+	meta, err = f.GetControllerMeta(context.Background(), &ControllerKeyWithAPIVersion{
+		ControllerKey: ControllerKey{Namespace: testNamespace, Kind: "Scale", Name: testDeployment},
+		ApiVersion:    "Foo/Foo",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Scaler", meta.Name, "the metadata of other controllers is read from their scale subresource")
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package target
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/restmapper"
+	scalefake "k8s.io/client-go/scale/fake"
+	core "k8s.io/client-go/testing"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestFetch_ScaleSubresourceErrors(t *testing.T) {
+	mapper := restmapper.NewDiscoveryRESTMapper([]*restmapper.APIGroupResources{{
+		Group: metav1.APIGroup{
+			Name:     "foo",
+			Versions: []metav1.GroupVersionForDiscovery{{GroupVersion: "foo/v1", Version: "v1"}},
+		},
+		VersionedResources: map[string][]metav1.APIResource{
+			"v1": {{Kind: "Foo", Name: "foos", Namespaced: true}},
+		},
+	}})
+	scaleNamespacer := &scalefake.FakeScaleClient{}
+	failures := 1
+	scaleNamespacer.AddReactor("get", "foos", func(core.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, apierrors.NewServiceUnavailable("overloaded")
+		}
+		return true, &autoscalingv1.Scale{Status: autoscalingv1.ScaleStatus{Selector: "app=foo"}}, nil
+	})
+	fetcher := &vpaTargetSelectorFetcher{scaleNamespacer: scaleNamespacer, mapper: mapper}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("c").
+		WithTargetRef(&autoscalingv1.CrossVersionObjectReference{APIVersion: "foo/v1", Kind: "Foo", Name: "foo"}).Get()
+
+	_, err := fetcher.Fetch(context.Background(), vpa)
+	assert.True(t, apierrors.IsServiceUnavailable(err), "the error of the API server is kept, so that callers can tell it's transient: %v", err)
+
+	selector, err := fetcher.Fetch(context.Background(), vpa)
+	assert.NoError(t, err)
+	assert.Equal(t, "app=foo", selector.String())
+}
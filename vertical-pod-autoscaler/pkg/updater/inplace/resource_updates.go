@@ -46,6 +46,7 @@ func (*resourcesInplaceUpdatesPatchCalculator) PatchResourceTarget() patch.Patch
 }
 
 // CalculatePatches calculates a JSON patch from a VPA's recommendation to send to the pod "resize" subresource as an in-place resize.
+// Only regular containers are resized, recommendations of init containers are ignored as they already ran.
 func (c *resourcesInplaceUpdatesPatchCalculator) CalculatePatches(pod *core.Pod, vpa *vpa_types.VerticalPodAutoscaler) ([]resource_admission.PatchRecord, error) {
 	result := []resource_admission.PatchRecord{}
 
@@ -55,6 +56,11 @@ func (c *resourcesInplaceUpdatesPatchCalculator) CalculatePatches(pod *core.Pod,
 	}
 
 	for i, containerResources := range containersResources {
+		// Limits are left alone for containers whose VPA controls only requests.
+		controlledValues := vpa_api_util.GetContainerControlledValues(pod.Spec.Containers[i].Name, vpa.Spec.ResourcePolicy)
+		if controlledValues != vpa_types.ContainerControlledValuesRequestsOnly && isGuaranteedContainer(pod.Spec.Containers[i]) {
+			containerResources = withLimitsMatchingRequests(containerResources)
+		}
 		newPatches := getContainerPatch(pod, i, containerResources)
 		result = append(result, newPatches...)
 	}
@@ -62,6 +68,35 @@ func (c *resourcesInplaceUpdatesPatchCalculator) CalculatePatches(pod *core.Pod,
 	return result, nil
 }
 
+// isGuaranteedContainer returns true if the container has CPU and memory limits equal to its requests,
+// which is required for the pod to be in the Guaranteed QoS class.
+func isGuaranteedContainer(container core.Container) bool {
+	for _, resourceName := range []core.ResourceName{core.ResourceCPU, core.ResourceMemory} {
+		request, hasRequest := container.Resources.Requests[resourceName]
+		limit, hasLimit := container.Resources.Limits[resourceName]
+		if !hasRequest || !hasLimit || request.Cmp(limit) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// withLimitsMatchingRequests sets the limits of all recommended resources to the recommended requests,
+// so that resizing a Guaranteed container in place doesn't change the QoS class of its pod.
+func withLimitsMatchingRequests(containerResources vpa_api_util.ContainerResources) vpa_api_util.ContainerResources {
+	limits := make(core.ResourceList, len(containerResources.Limits)+len(containerResources.Requests))
+	for resourceName, limit := range containerResources.Limits {
+		limits[resourceName] = limit
+	}
+	for resourceName, request := range containerResources.Requests {
+		limits[resourceName] = request.DeepCopy()
+	}
+	return vpa_api_util.ContainerResources{
+		Requests: containerResources.Requests,
+		Limits:   limits,
+	}
+}
+
 func getContainerPatch(pod *core.Pod, i int, containerResources vpa_api_util.ContainerResources) []resource_admission.PatchRecord {
 	var patches []resource_admission.PatchRecord
 	// Add empty resources object if missing.
@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inplace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/recommendation"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+type fakeRecommendationProvider struct {
+	resources []vpa_api_util.ContainerResources
+}
+
+func (frp *fakeRecommendationProvider) GetContainersResourcesForPod(_ *core.Pod, _ *vpa_types.VerticalPodAutoscaler) ([]vpa_api_util.ContainerResources, vpa_api_util.ContainerToAnnotationsMap, error) {
+	return frp.resources, nil, nil
+}
+
+func addResourcePatch(fieldName string, resourceName core.ResourceName, amount string) resource_admission.PatchRecord {
+	return resource_admission.PatchRecord{
+		Op:    "add",
+		Path:  fmt.Sprintf("/spec/containers/0/resources/%s/%s", fieldName, resourceName),
+		Value: amount,
+	}
+}
+
+func TestCalculatePatches_GuaranteedContainer(t *testing.T) {
+	recommendation := []vpa_api_util.ContainerResources{{
+		Requests: core.ResourceList{
+			core.ResourceCPU:    resource.MustParse("2"),
+			core.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}}
+
+	requestsOnly := vpa_types.ContainerControlledValuesRequestsOnly
+	testCases := []struct {
+		name             string
+		container        core.Container
+		controlledValues *vpa_types.ContainerControlledValues
+		expectPatches    []resource_admission.PatchRecord
+	}{
+		{
+			name: "guaranteed container gets limits matching requests",
+			container: test.Container().WithName("container").
+				WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("1")).
+				WithMemRequest(resource.MustParse("1Gi")).WithMemLimit(resource.MustParse("1Gi")).Get(),
+			expectPatches: []resource_admission.PatchRecord{
+				addResourcePatch("requests", core.ResourceCPU, "2"),
+				addResourcePatch("requests", core.ResourceMemory, "2Gi"),
+				addResourcePatch("limits", core.ResourceCPU, "2"),
+				addResourcePatch("limits", core.ResourceMemory, "2Gi"),
+			},
+		},
+		{
+			name: "guaranteed container of a VPA controlling only requests keeps its limits",
+			container: test.Container().WithName("container").
+				WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("1")).
+				WithMemRequest(resource.MustParse("1Gi")).WithMemLimit(resource.MustParse("1Gi")).Get(),
+			controlledValues: &requestsOnly,
+			expectPatches: []resource_admission.PatchRecord{
+				addResourcePatch("requests", core.ResourceCPU, "2"),
+				addResourcePatch("requests", core.ResourceMemory, "2Gi"),
+			},
+		},
+		{
+			name: "burstable container keeps its limits",
+			container: test.Container().WithName("container").
+				WithCPURequest(resource.MustParse("1")).WithCPULimit(resource.MustParse("4")).
+				WithMemRequest(resource.MustParse("1Gi")).WithMemLimit(resource.MustParse("1Gi")).Get(),
+			expectPatches: []resource_admission.PatchRecord{
+				addResourcePatch("requests", core.ResourceCPU, "2"),
+				addResourcePatch("requests", core.ResourceMemory, "2Gi"),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := test.Pod().WithName("pod").AddContainer(tc.container).Get()
+			calculator := NewResourceInPlaceUpdatesCalculator(&fakeRecommendationProvider{resources: recommendation})
+
+			vpa := test.VerticalPodAutoscaler().WithContainer("container").Get()
+			if tc.controlledValues != nil {
+				vpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{ContainerPolicies: []vpa_types.ContainerResourcePolicy{{
+					ContainerName:    "container",
+					ControlledValues: tc.controlledValues,
+				}}}
+			}
+			patches, err := calculator.CalculatePatches(pod, vpa)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectPatches, patches)
+		})
+	}
+}
+
+func TestCalculatePatches_SkipsInitContainers(t *testing.T) {
+	pod := test.Pod().WithName("pod").
+		AddInitContainer(test.Container().WithName("init").WithCPURequest(resource.MustParse("100m")).Get()).
+		AddContainer(test.Container().WithName("container").WithCPURequest(resource.MustParse("100m")).Get()).
+		Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer("container").WithTarget("200m", "").
+		AppendRecommendation(test.Recommendation().WithContainer("init").WithTarget("200m", "").GetContainerResources()).Get()
+	calculator := NewResourceInPlaceUpdatesCalculator(recommendation.NewProvider(limitrange.NewNoopLimitsCalculator(), &test.FakeRecommendationProcessor{}))
+
+	patches, err := calculator.CalculatePatches(pod, vpa)
+	assert.NoError(t, err)
+	assert.Equal(t, []resource_admission.PatchRecord{addResourcePatch("requests", core.ResourceCPU, "200m")}, patches,
+		"init containers already ran, so their recommendation isn't applied in place")
+}
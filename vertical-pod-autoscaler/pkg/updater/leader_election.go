@@ -0,0 +1,159 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/retry"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/klog/v2"
+)
+
+// shardNamespacesAnnotation is the annotation of the lease of an updater shard listing the namespaces it watches.
+const shardNamespacesAnnotation = "vpa-updater-watched-namespaces.k8s.io"
+
+// parseNamespaces splits a comma-separated list of namespaces, ignoring the spaces around them and empty entries.
+func parseNamespaces(namespaces string) []string {
+	var parsed []string
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			parsed = append(parsed, namespace)
+		}
+	}
+	return parsed
+}
+
+// shardLeaseName returns the name of the leader election lease of an updater shard
+// watching the given namespaces. Shards watching the same set of namespaces, in any
+// order, share a lease, while shards watching different sets elect leaders independently.
+func shardLeaseName(baseName string, namespaces []string) string {
+	if len(namespaces) == 0 {
+		return baseName
+	}
+	hash := fnv.New32a()
+	// Writing to a hash never fails.
+	_, _ = hash.Write([]byte(strings.Join(sets.List(sets.New(namespaces...)), ",")))
+	return fmt.Sprintf("%s-%08x", baseName, hash.Sum32())
+}
+
+// newLeaderElector creates a leader elector for the updater, using a lease per set of
+// watched namespaces if shardLease is true. It fails if another shard watches some of the namespaces.
+func newLeaderElector(ctx context.Context, config componentbaseconfig.LeaderElectionConfiguration, kubeClient kube_client.Interface,
+	id string, watched []string, shardLease bool, callbacks leaderelection.LeaderCallbacks) (*leaderelection.LeaderElector, error) {
+	name := config.ResourceName
+	if shardLease {
+		name = shardLeaseName(name, watched)
+		klog.V(1).InfoS("Using leader election lease of the updater shard", "lease", klog.KRef(config.ResourceNamespace, name), "watchedNamespaces", watched)
+		if len(watched) > 0 {
+			if err := registerShard(ctx, kubeClient, config.ResourceNamespace, name, watched, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	lock, err := resourcelock.New(
+		config.ResourceLock,
+		config.ResourceNamespace,
+		name,
+		kubeClient.CoreV1(),
+		kubeClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create leader election lock: %v", err)
+	}
+
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   config.LeaseDuration.Duration,
+		RenewDeadline:   config.RenewDeadline.Duration,
+		RetryPeriod:     config.RetryPeriod.Duration,
+		ReleaseOnCancel: true,
+		Callbacks:       callbacks,
+	})
+}
+
+// registerShard records the namespaces watched by the updater shard on its lease. It returns an error if an active
+// shard, whose leader renewed its lease recently, watches some of the namespaces too, as both would act on their pods.
+func registerShard(ctx context.Context, kubeClient kube_client.Interface, namespace, name string, watched []string, now time.Time) error {
+	leases := kubeClient.CoordinationV1().Leases(namespace)
+	list, err := leases.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list leases of updater shards: %v", err)
+	}
+	namespaces := sets.New(watched...)
+	for _, lease := range list.Items {
+		value, found := lease.Annotations[shardNamespacesAnnotation]
+		if !found || lease.Name == name || !isLeaseHeld(&lease, now) {
+			continue
+		}
+		if overlap := namespaces.Intersection(sets.New(strings.Split(value, ",")...)); overlap.Len() > 0 {
+			return fmt.Errorf("watched namespaces %v are also watched by the updater shard holding lease %s/%s", sets.List(overlap), namespace, lease.Name)
+		}
+	}
+
+	value := strings.Join(sets.List(namespaces), ",")
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        name,
+				Annotations: map[string]string{shardNamespacesAnnotation: value},
+			}}
+			_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Another replica of the shard created the lease first, the annotation is set on the next attempt.
+				return apierrors.NewConflict(coordinationv1.Resource("leases"), name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("unable to get lease %s/%s: %v", namespace, name, err)
+		}
+		if lease.Annotations[shardNamespacesAnnotation] == value {
+			return nil
+		}
+		if lease.Annotations == nil {
+			lease.Annotations = make(map[string]string)
+		}
+		lease.Annotations[shardNamespacesAnnotation] = value
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// isLeaseHeld returns true if the holder of the lease renewed it within its duration.
+func isLeaseHeld(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	return now.Before(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
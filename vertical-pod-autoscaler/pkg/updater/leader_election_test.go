@@ -0,0 +1,166 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	assert.Nil(t, parseNamespaces(""))
+	assert.Equal(t, []string{"team-a", "team-b"}, parseNamespaces(" team-a, team-b ,,"))
+}
+
+func TestShardLeaseName(t *testing.T) {
+	assert.Equal(t, "vpa-updater", shardLeaseName("vpa-updater", nil))
+	assert.Equal(t, shardLeaseName("vpa-updater", []string{"a", "b"}), shardLeaseName("vpa-updater", []string{"b", "a", "b"}),
+		"the lease doesn't depend on the order of namespaces")
+	assert.NotEqual(t, shardLeaseName("vpa-updater", []string{"a", "b"}), shardLeaseName("vpa-updater", []string{"c"}))
+	assert.Regexp(t, "^vpa-updater-[0-9a-f]{8}$", shardLeaseName("vpa-updater", []string{"a"}))
+}
+
+func TestShardLeaderElection(t *testing.T) {
+	testCases := []struct {
+		name            string
+		watched         [][]string
+		shardLease      bool
+		expectedLeaders int
+	}{
+		{
+			name:            "shards with disjoint namespaces both lead",
+			watched:         [][]string{{"team-a", "team-b"}, {"team-c"}},
+			shardLease:      true,
+			expectedLeaders: 2,
+		},
+		{
+			name:            "shards with the same namespaces share a lease",
+			watched:         [][]string{{"team-a", "team-b"}, {"team-b", "team-a"}},
+			shardLease:      true,
+			expectedLeaders: 1,
+		},
+		{
+			name:            "without shard leases all updaters share a lease",
+			watched:         [][]string{{"team-a", "team-b"}, {"team-c"}},
+			expectedLeaders: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewClientset()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			started := make(chan string, len(tc.watched))
+			for i, watched := range tc.watched {
+				id := fmt.Sprintf("updater-%d", i)
+				elector, err := newLeaderElector(ctx, defaultLeaderElectionConfiguration(), kubeClient, id, watched, tc.shardLease, leaderelection.LeaderCallbacks{
+					OnStartedLeading: func(_ context.Context) { started <- id },
+					OnStoppedLeading: func() {},
+				})
+				assert.NoError(t, err)
+				go elector.Run(ctx)
+			}
+
+			leaders := 0
+			timeout := time.After(time.Second)
+		wait:
+			for leaders < len(tc.watched) {
+				select {
+				case <-started:
+					leaders++
+				case <-timeout:
+					break wait
+				}
+			}
+			assert.Equal(t, tc.expectedLeaders, leaders)
+		})
+	}
+}
+
+func TestRegisterShard(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	shardLease := func(name, namespaces string, renewed time.Time) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "kube-system",
+				Name:        name,
+				Annotations: map[string]string{shardNamespacesAnnotation: namespaces},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To("updater"),
+				LeaseDurationSeconds: ptr.To(int32(15)),
+				RenewTime:            &metav1.MicroTime{Time: renewed},
+			},
+		}
+	}
+	testCases := []struct {
+		name        string
+		leases      []*coordinationv1.Lease
+		expectError bool
+	}{
+		{
+			name:   "no other shard",
+			leases: nil,
+		},
+		{
+			name:   "disjoint shard",
+			leases: []*coordinationv1.Lease{shardLease("vpa-updater-other", "team-c", now)},
+		},
+		{
+			name:        "overlapping shard",
+			leases:      []*coordinationv1.Lease{shardLease("vpa-updater-other", "team-b,team-c", now)},
+			expectError: true,
+		},
+		{
+			name:   "overlapping shard which stopped",
+			leases: []*coordinationv1.Lease{shardLease("vpa-updater-other", "team-b,team-c", now.Add(-time.Minute))},
+		},
+		{
+			name:   "other replica of the shard",
+			leases: []*coordinationv1.Lease{shardLease("vpa-updater-self", "team-a,team-b", now)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewClientset()
+			for _, lease := range tc.leases {
+				_, err := kubeClient.CoordinationV1().Leases("kube-system").Create(context.Background(), lease, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+
+			err := registerShard(context.Background(), kubeClient, "kube-system", "vpa-updater-self", []string{"team-b", "team-a"}, now)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			lease, err := kubeClient.CoordinationV1().Leases("kube-system").Get(context.Background(), "vpa-updater-self", metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, "team-a,team-b", lease.Annotations[shardNamespacesAnnotation])
+		})
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/clock"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// vpaStatusPatcher applies a merge patch to the status of a VPA object.
+type vpaStatusPatcher func(ctx context.Context, namespace, name string, patch []byte) error
+
+// newVpaStatusPatcher returns a vpaStatusPatcher writing VPA objects of the autoscaling.k8s.io API group.
+func newVpaStatusPatcher(vpaClient vpa_clientset.Interface) vpaStatusPatcher {
+	return func(ctx context.Context, namespace, name string, patch []byte) error {
+		_, err := vpaClient.AutoscalingV1().VerticalPodAutoscalers(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+		return err
+	}
+}
+
+// newVpaResourceStatusPatcher returns a vpaStatusPatcher writing VPA objects of the given resource, for
+// updaters reading VPA objects from a resource other than the one of the autoscaling.k8s.io API group.
+func newVpaResourceStatusPatcher(dynamicClient dynamic.Interface, resource schema.GroupVersionResource) vpaStatusPatcher {
+	return func(ctx context.Context, namespace, name string, patch []byte) error {
+		_, err := dynamicClient.Resource(resource).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+		return err
+	}
+}
+
+// actionHistory keeps a bounded history of pod updates in the status of their VPA object.
+type actionHistory struct {
+	patchStatus vpaStatusPatcher
+	maxSize     int
+	clock       clock.PassiveClock
+	// flushInterval, if set, buffers the actions and writes them to the VPA objects at most once per interval.
+	flushInterval time.Duration
+	lastFlush     time.Time
+	// pending holds the actions not written yet, keyed by VPA object.
+	pending map[types.UID]*pendingActions
+	// written holds the history last written to each VPA object, until the informer's copy of the object catches up.
+	written map[types.UID][]vpa_types.VerticalPodAutoscalerAction
+}
+
+type pendingActions struct {
+	vpa     *vpa_types.VerticalPodAutoscaler
+	actions []vpa_types.VerticalPodAutoscalerAction
+}
+
+func newActionHistory(patchStatus vpaStatusPatcher, maxSize int, flushInterval time.Duration) *actionHistory {
+	return &actionHistory{
+		patchStatus:   patchStatus,
+		maxSize:       maxSize,
+		clock:         clock.RealClock{},
+		flushInterval: flushInterval,
+		pending:       make(map[types.UID]*pendingActions),
+		written:       make(map[types.UID][]vpa_types.VerticalPodAutoscalerAction),
+	}
+}
+
+// newAction describes an update of the pod to the given recommendation, which is the one applied to the pod.
+func (h *actionHistory) newAction(actionType vpa_types.VerticalPodAutoscalerActionType, pod *apiv1.Pod, podRecommendation *vpa_types.RecommendedPodResources) vpa_types.VerticalPodAutoscalerAction {
+	action := vpa_types.VerticalPodAutoscalerAction{
+		Time:    metav1.NewTime(h.clock.Now()),
+		Type:    actionType,
+		PodName: pod.Name,
+	}
+	for _, container := range pod.Spec.Containers {
+		recommendation := vpa_api_util.GetRecommendationForContainer(container.Name, podRecommendation)
+		if recommendation == nil {
+			continue
+		}
+		delta := vpa_types.ContainerResourcesDelta{
+			ContainerName: container.Name,
+			OldRequests:   apiv1.ResourceList{},
+			NewRequests:   recommendation.Target.DeepCopy(),
+		}
+		for resourceName := range recommendation.Target {
+			if request, found := container.Resources.Requests[resourceName]; found {
+				delta.OldRequests[resourceName] = request.DeepCopy()
+			}
+		}
+		action.ContainerDeltas = append(action.ContainerDeltas, delta)
+	}
+	return action
+}
+
+// record adds the given actions, ordered oldest first, to the history in the status of the VPA object.
+// With a flush interval the actions are only buffered, to be written by flush.
+func (h *actionHistory) record(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler, actions []vpa_types.VerticalPodAutoscalerAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	pending, found := h.pending[vpa.UID]
+	if !found {
+		pending = &pendingActions{}
+		h.pending[vpa.UID] = pending
+	}
+	pending.vpa = vpa
+	pending.actions = append(pending.actions, actions...)
+	if h.flushInterval > 0 {
+		return nil
+	}
+	return h.write(ctx, pending)
+}
+
+// flush writes the buffered actions, unless the previous flush happened less than the flush interval ago.
+func (h *actionHistory) flush(ctx context.Context) error {
+	if h.flushInterval <= 0 || h.clock.Since(h.lastFlush) < h.flushInterval {
+		return nil
+	}
+	return h.flushAll(ctx)
+}
+
+// flushAll writes all the buffered actions, e.g. before shutting down.
+func (h *actionHistory) flushAll(ctx context.Context) error {
+	h.lastFlush = h.clock.Now()
+	var errs []error
+	for _, pending := range h.pending {
+		errs = append(errs, h.write(ctx, pending))
+	}
+	return errors.Join(errs...)
+}
+
+// write adds the pending actions to the history of their VPA object. The write is skipped if the history
+// wouldn't change, and the actions are kept pending if it fails.
+func (h *actionHistory) write(ctx context.Context, pending *pendingActions) error {
+	vpa := pending.vpa
+	base := vpa.Status.RecentActions
+	if written := h.written[vpa.UID]; isNewerHistory(written, base) {
+		// The informer didn't see the previous write yet, building on its copy would drop actions.
+		base = written
+	} else {
+		delete(h.written, vpa.UID)
+	}
+	history := prependActions(base, pending.actions, h.maxSize)
+	if apiequality.Semantic.DeepEqual(history, base) {
+		delete(h.pending, vpa.UID)
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"recentActions": history,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = h.patchStatus(ctx, vpa.Namespace, vpa.Name, patch)
+	if apierrors.IsNotFound(err) {
+		delete(h.pending, vpa.UID)
+		delete(h.written, vpa.UID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record actions in status of VPA %s/%s: %v", vpa.Namespace, vpa.Name, err)
+	}
+	delete(h.pending, vpa.UID)
+	h.written[vpa.UID] = history
+	return nil
+}
+
+// isNewerHistory returns true if the history holds a more recent action than the other one.
+func isNewerHistory(history, other []vpa_types.VerticalPodAutoscalerAction) bool {
+	if len(history) == 0 {
+		return false
+	}
+	return len(other) == 0 || history[0].Time.After(other[0].Time.Time)
+}
+
+// prependActions returns the history, which is ordered newest first, with the given actions,
+// ordered oldest first, added in front of it. The result is truncated to maxSize entries.
+func prependActions(history, actions []vpa_types.VerticalPodAutoscalerAction, maxSize int) []vpa_types.VerticalPodAutoscalerAction {
+	result := make([]vpa_types.VerticalPodAutoscalerAction, 0, len(actions)+len(history))
+	for i := len(actions) - 1; i >= 0; i-- {
+		result = append(result, actions[i])
+	}
+	result = append(result, history...)
+	if len(result) > maxSize {
+		result = result[:maxSize]
+	}
+	return result
+}
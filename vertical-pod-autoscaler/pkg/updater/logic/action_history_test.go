@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamic_fake "k8s.io/client-go/dynamic/fake"
+	core "k8s.io/client-go/testing"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_fake "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+func podNames(actions []vpa_types.VerticalPodAutoscalerAction) []string {
+	names := make([]string, 0, len(actions))
+	for _, action := range actions {
+		names = append(names, action.PodName)
+	}
+	return names
+}
+
+func TestPrependActions(t *testing.T) {
+	history := []vpa_types.VerticalPodAutoscalerAction{{PodName: "old-2"}, {PodName: "old-1"}}
+	actions := []vpa_types.VerticalPodAutoscalerAction{{PodName: "new-1"}, {PodName: "new-2"}}
+
+	assert.Equal(t, []string{"new-2", "new-1", "old-2", "old-1"}, podNames(prependActions(history, actions, 10)))
+	assert.Equal(t, []string{"new-2", "new-1", "old-2"}, podNames(prependActions(history, actions, 3)))
+	assert.Equal(t, []string{"new-2"}, podNames(prependActions(history, actions, 1)))
+}
+
+func TestActionHistoryRecord(t *testing.T) {
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("c").WithTarget("2", "200M").Get()
+	vpa.Status.RecentActions = []vpa_types.VerticalPodAutoscalerAction{{PodName: "old-2"}, {PodName: "old-1"}}
+	client := vpa_fake.NewSimpleClientset(vpa)
+	history := newActionHistory(newVpaStatusPatcher(client), 3, 0)
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	history.clock = baseclocktest.NewFakeClock(now)
+
+	pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName("c").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).Get()
+	actions := []vpa_types.VerticalPodAutoscalerAction{
+		history.newAction(vpa_types.ActionInPlaceUpdate, pod, vpa.Status.Recommendation),
+		history.newAction(vpa_types.ActionEviction, pod, vpa.Status.Recommendation),
+	}
+	assert.NoError(t, history.record(context.Background(), vpa, actions))
+
+	updated, err := client.AutoscalingV1().VerticalPodAutoscalers("default").Get(context.Background(), "vpa", metav1.GetOptions{})
+	assert.NoError(t, err)
+	recentActions := updated.Status.RecentActions
+	if assert.Len(t, recentActions, 3) {
+		assert.Equal(t, vpa_types.ActionEviction, recentActions[0].Type)
+		assert.Equal(t, vpa_types.ActionInPlaceUpdate, recentActions[1].Type)
+		assert.Equal(t, "old-2", recentActions[2].PodName)
+		assert.True(t, now.Equal(recentActions[0].Time.Time))
+		if assert.Len(t, recentActions[0].ContainerDeltas, 1) {
+			delta := recentActions[0].ContainerDeltas[0]
+			assert.Equal(t, "c", delta.ContainerName)
+			assert.Equal(t, "1", delta.OldRequests.Cpu().String())
+			assert.Equal(t, "2", delta.NewRequests.Cpu().String())
+			assert.Equal(t, "200M", delta.NewRequests.Memory().String())
+		}
+	}
+}
+
+func TestActionHistoryFlush(t *testing.T) {
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("c").WithTarget("2", "200M").Get()
+	vpa.UID = "vpa-uid"
+	vpa.Status.RecentActions = []vpa_types.VerticalPodAutoscalerAction{{PodName: "old"}}
+	client := vpa_fake.NewSimpleClientset(vpa)
+	countWrites := func() int {
+		writes := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "patch" {
+				writes++
+			}
+		}
+		return writes
+	}
+	history := newActionHistory(newVpaStatusPatcher(client), 10, time.Minute)
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	history.clock = fakeClock
+	pod := test.Pod().WithName("pod-1").AddContainer(test.Container().WithName("c").Get()).Get()
+	otherPod := test.Pod().WithName("pod-2").AddContainer(test.Container().WithName("c").Get()).Get()
+
+	assert.NoError(t, history.record(context.Background(), vpa, []vpa_types.VerticalPodAutoscalerAction{history.newAction(vpa_types.ActionEviction, pod, vpa.Status.Recommendation)}))
+	assert.Equal(t, 0, countWrites(), "actions are buffered until the flush")
+	assert.NoError(t, history.flush(context.Background()))
+	assert.Equal(t, 1, countWrites())
+
+	fakeClock.Step(30 * time.Second)
+	assert.NoError(t, history.record(context.Background(), vpa, []vpa_types.VerticalPodAutoscalerAction{history.newAction(vpa_types.ActionEviction, otherPod, vpa.Status.Recommendation)}))
+	assert.NoError(t, history.flush(context.Background()))
+	assert.Equal(t, 1, countWrites(), "the actions are written at most once per flush interval")
+
+	fakeClock.Step(30 * time.Second)
+	// The informer's copy of the VPA object passed along doesn't hold the first write yet.
+	assert.NoError(t, history.flush(context.Background()))
+	assert.Equal(t, 2, countWrites())
+	updated, err := client.AutoscalingV1().VerticalPodAutoscalers("default").Get(context.Background(), "vpa", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pod-2", "pod-1", "old"}, podNames(updated.Status.RecentActions), "writes build on the last written history")
+
+	fakeClock.Step(time.Minute)
+	assert.NoError(t, history.record(context.Background(), updated, nil))
+	assert.NoError(t, history.flush(context.Background()))
+	assert.Equal(t, 2, countWrites(), "nothing is written if the history is unchanged")
+}
+
+func TestUpdaterNewAction(t *testing.T) {
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("c").WithTarget("2", "200M").Get()
+	pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName("c").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).Get()
+	u := &updater{
+		actionHistory:           newActionHistory(newVpaStatusPatcher(vpa_fake.NewSimpleClientset(vpa)), 3, 0),
+		recommendationProcessor: vpa_api_util.NewMarginRecommendationProcessor(&test.FakeRecommendationProcessor{}, 0.5),
+	}
+
+	action := u.newAction(vpa_types.ActionEviction, vpa, pod)
+	if assert.Len(t, action.ContainerDeltas, 1) {
+		assert.Equal(t, "3", action.ContainerDeltas[0].NewRequests.Cpu().String(), "the recommendation applied to the pod is recorded")
+		assert.Equal(t, "300M", action.ContainerDeltas[0].NewRequests.Memory().String())
+	}
+}
+
+func TestActionHistoryRecord_VpaResource(t *testing.T) {
+	vpaResource := schema.GroupVersionResource{Group: "autoscaling.example.com", Version: "v1", Resource: "verticalpodautoscalers"}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("c").WithTarget("2", "200M").Get()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vpa)
+	assert.NoError(t, err)
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion("autoscaling.example.com/v1")
+	obj.SetKind("VerticalPodAutoscaler")
+	dynamicClient := dynamic_fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{vpaResource: "VerticalPodAutoscalerList"}, obj)
+	history := newActionHistory(newVpaResourceStatusPatcher(dynamicClient, vpaResource), 3, 0)
+
+	pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName("c").WithCPURequest(resource.MustParse("1")).Get()).Get()
+	actions := []vpa_types.VerticalPodAutoscalerAction{history.newAction(vpa_types.ActionEviction, pod, vpa.Status.Recommendation)}
+	assert.NoError(t, history.record(context.Background(), vpa, actions))
+
+	if assert.Len(t, dynamicClient.Actions(), 1) {
+		patch, ok := dynamicClient.Actions()[0].(core.PatchAction)
+		if assert.True(t, ok) {
+			assert.Equal(t, vpaResource, patch.GetResource(), "the history is written to the resource the VPA objects are read from")
+			assert.Equal(t, "status", patch.GetSubresource())
+		}
+	}
+	updated, err := dynamicClient.Resource(vpaResource).Namespace("default").Get(context.Background(), "vpa", metav1.GetOptions{})
+	assert.NoError(t, err)
+	recentActions, found, err := unstructured.NestedSlice(updated.Object, "status", "recentActions")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, recentActions, 1)
+}
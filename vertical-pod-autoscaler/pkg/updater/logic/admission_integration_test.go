@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test/harness"
+)
+
+// newClusterUpdater returns an updater acting on the fake cluster.
+func newClusterUpdater(cluster *harness.Cluster) *updater {
+	return &updater{
+		vpaLister:               cluster.VpaLister(),
+		podLister:               cluster.PodLister(),
+		eventRecorder:           record.NewFakeRecorder(100),
+		restrictionFactory:      cluster.RestrictionFactory(),
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         cluster.SelectorFetcher(),
+		controllerFetcher:       cluster.ControllerFetcher(),
+		priorityProcessor:       priority.NewProcessor(),
+	}
+}
+
+func TestRunOnce_EvictedPodsAdmittedWithRecommendation(t *testing.T) {
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	cluster := harness.NewCluster()
+	vpaObj := test.VerticalPodAutoscaler().
+		WithName("vpa").
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	require.NoError(t, cluster.AddVpa(vpaObj, parseLabelSelector("app = testingApp")))
+
+	for i := 0; i < 3; i++ {
+		pod, err := cluster.CreatePod(test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get())
+		require.NoError(t, err)
+		assert.Equal(t, resource.MustParse("1"), pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU], "pods keep their requests without a recommendation")
+	}
+
+	updater := newClusterUpdater(cluster)
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Empty(t, cluster.Evicted(), "pods aren't evicted without a recommendation")
+
+	recommendation := test.Recommendation().WithContainer(containerName).WithTarget("2", "200M").Get()
+	require.NoError(t, cluster.SetRecommendation("default", "vpa", recommendation))
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.ElementsMatch(t, []string{"test_0", "test_1", "test_2"}, cluster.Evicted())
+	for i := 0; i < 3; i++ {
+		pod := cluster.Pod("default", "test_"+strconv.Itoa(i))
+		require.NotNil(t, pod, "evicted pods are recreated")
+		assert.Equal(t, resource.MustParse("2"), pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU])
+		assert.Equal(t, resource.MustParse("200M"), pod.Spec.Containers[0].Resources.Requests[apiv1.ResourceMemory])
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Len(t, cluster.Evicted(), 3, "recreated pods already have the recommended requests")
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+)
+
+// blockedReason is why the updater didn't act on a pod which needs an update.
+type blockedReason string
+
+const (
+	blockedByEvictionTolerance   blockedReason = "EvictionTolerance: evicting the pod would disrupt too many replicas of its controller"
+	blockedByPodDisruptionBudget blockedReason = "PodDisruptionBudget: the eviction was refused because of a PodDisruptionBudget"
+	blockedByRateLimit           blockedReason = "RateLimited: the rate limit of updates was reached"
+	blockedByBackoff             blockedReason = "BackingOff: the previous update of the pod failed"
+	blockedByEvictionsPaused     blockedReason = "EvictionsPaused: evictions are paused after too many failures"
+	blockedByCoordinator         blockedReason = "AwaitingAcknowledgement: the eviction wasn't acknowledged yet"
+	blockedByInPlaceDeferral     blockedReason = "InPlaceUpdateDeferred: the in-place update was deferred"
+	blockedByUnscheduled         blockedReason = "Unscheduled: the pod isn't scheduled to a node yet"
+	blockedByResourceQuota       blockedReason = "ResourceQuota: the recommended requests wouldn't fit in a ResourceQuota"
+	blockedByNotSafeToEvict      blockedReason = "NotSafeToEvict: the pod reports it isn't safe to evict now"
+	blockedByControllerLock      blockedReason = "ControllerLocked: another updater is acting on the pods of the controller"
+	blockedByDisruptionCap       blockedReason = "DisruptionCap: too many pods are disrupted to evict the pod now"
+	blockedByVpaDisruptionBudget blockedReason = "VpaDisruptionBudget: the disruption budget of the VPA allows no more evictions"
+)
+
+// blockedReasonAnnotator surfaces on pods why the updater didn't act on them, in the
+// VpaBlockedReasonAnnotation, and removes the annotation once they are no longer blocked.
+type blockedReasonAnnotator struct {
+	client kube_client.Interface
+	// blocked holds the reasons of the pods blocked since the last sync.
+	blocked map[types.UID]blockedReason
+}
+
+func newBlockedReasonAnnotator(client kube_client.Interface) *blockedReasonAnnotator {
+	return &blockedReasonAnnotator{
+		client:  client,
+		blocked: make(map[types.UID]blockedReason),
+	}
+}
+
+// block records why the pod wasn't acted on.
+func (a *blockedReasonAnnotator) block(pod *apiv1.Pod, reason blockedReason) {
+	if a == nil {
+		return
+	}
+	a.blocked[pod.UID] = reason
+}
+
+// sync annotates the given pods blocked since the last sync with their reason, and removes the
+// annotation from the other ones.
+func (a *blockedReasonAnnotator) sync(ctx context.Context, pods []*apiv1.Pod) error {
+	if a == nil {
+		return nil
+	}
+	var errs []error
+	for _, pod := range pods {
+		reason, blocked := a.blocked[pod.UID]
+		delete(a.blocked, pod.UID)
+		current, annotated := pod.Annotations[annotations.VpaBlockedReasonAnnotation]
+		switch {
+		case blocked && current != string(reason):
+			klog.V(4).InfoS("Annotating pod with the reason it wasn't updated", "pod", klog.KObj(pod), "reason", reason)
+			errs = append(errs, a.patch(ctx, pod, string(reason)))
+		case !blocked && annotated:
+			klog.V(4).InfoS("Removing the blocked reason of pod", "pod", klog.KObj(pod))
+			errs = append(errs, a.patch(ctx, pod, nil))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// patch sets the annotation to the value, or removes it if the value is nil.
+func (a *blockedReasonAnnotator) patch(ctx context.Context, pod *apiv1.Pod, value any) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{annotations.VpaBlockedReasonAnnotation: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	// Evicted pods may be gone already.
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to patch blocked reason of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
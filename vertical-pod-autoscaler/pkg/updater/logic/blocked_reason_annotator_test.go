@@ -0,0 +1,315 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestBlockedReasonAnnotator(t *testing.T) {
+	blocked := test.Pod().WithName("blocked").Get()
+	blocked.UID = "blocked"
+	unblocked := test.Pod().WithName("unblocked").Get()
+	unblocked.UID = "unblocked"
+	unblocked.Annotations = map[string]string{annotations.VpaBlockedReasonAnnotation: string(blockedByBackoff)}
+	untouched := test.Pod().WithName("untouched").Get()
+	untouched.UID = "untouched"
+	client := fake.NewClientset(blocked, unblocked, untouched)
+	annotator := newBlockedReasonAnnotator(client)
+
+	annotator.block(blocked, blockedByRateLimit)
+	assert.NoError(t, annotator.sync(context.Background(), []*apiv1.Pod{blocked, unblocked, untouched}))
+
+	got, err := client.CoreV1().Pods(blocked.Namespace).Get(context.Background(), "blocked", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(blockedByRateLimit), got.Annotations[annotations.VpaBlockedReasonAnnotation])
+	got, err = client.CoreV1().Pods(unblocked.Namespace).Get(context.Background(), "unblocked", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, got.Annotations, annotations.VpaBlockedReasonAnnotation, "pods which are no longer blocked are cleared")
+	assert.Equal(t, 2, countPatches(client), "pods which were never blocked are not patched")
+
+	blocked.Annotations = map[string]string{annotations.VpaBlockedReasonAnnotation: string(blockedByRateLimit)}
+	annotator.block(blocked, blockedByRateLimit)
+	assert.NoError(t, annotator.sync(context.Background(), []*apiv1.Pod{blocked}))
+	assert.Equal(t, 2, countPatches(client), "pods annotated with their reason already are not patched again")
+
+	var disabled *blockedReasonAnnotator
+	disabled.block(blocked, blockedByRateLimit)
+	assert.NoError(t, disabled.sync(context.Background(), []*apiv1.Pod{blocked}))
+}
+
+func TestRunOnce_BlockedByPodDisruptionBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("test_0").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	pod.UID = "test_0"
+	client := fake.NewClientset(pod)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(2)
+
+	newUpdater := func(pod *apiv1.Pod, evictErr error) *updater {
+		eviction := &test.PodsEvictionRestrictionMock{}
+		eviction.On("CanEvict", pod).Return(true)
+		eviction.On("Evict", pod, nil).Return(evictErr)
+		podLister := &test.PodListerMock{}
+		podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+		return &updater{
+			vpaLister:               vpaLister,
+			podLister:               podLister,
+			restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+			evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+			inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+			evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+			recommendationProcessor: &test.FakeRecommendationProcessor{},
+			selectorFetcher:         mockSelectorFetcher,
+			controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+			priorityProcessor:       priority.NewProcessor(),
+			blockedReasons:          newBlockedReasonAnnotator(client),
+		}
+	}
+
+	pdbErr := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	assert.NoError(t, newUpdater(pod, pdbErr).RunOnce(context.Background()))
+	annotated, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(blockedByPodDisruptionBudget), annotated.Annotations[annotations.VpaBlockedReasonAnnotation])
+
+	assert.NoError(t, newUpdater(annotated, nil).RunOnce(context.Background()))
+	cleared, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, cleared.Annotations, annotations.VpaBlockedReasonAnnotation, "the annotation is cleared once the pod is updated")
+}
+
+// exhaustedVpaDisruptionBudget is an eviction restriction whose VPA disruption budget allows no evictions.
+type exhaustedVpaDisruptionBudget struct {
+	*test.PodsEvictionRestrictionMock
+}
+
+func (exhaustedVpaDisruptionBudget) ExceedsVpaDisruptionBudget(*apiv1.Pod) bool {
+	return true
+}
+
+func TestRunOnce_BlockedByVpaDisruptionBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("test_0").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	pod.UID = "test_0"
+	client := fake.NewClientset(pod)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	eviction := &test.PodsEvictionRestrictionMock{}
+	eviction.On("CanEvict", pod).Return(false)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+	u := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: exhaustedVpaDisruptionBudget{eviction}, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		blockedReasons:          newBlockedReasonAnnotator(client),
+	}
+
+	assert.NoError(t, u.RunOnce(context.Background()))
+	eviction.AssertNotCalled(t, "Evict", pod, nil)
+	annotated, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(blockedByVpaDisruptionBudget), annotated.Annotations[annotations.VpaBlockedReasonAnnotation])
+}
+
+func TestRunOnce_BlockedByEvictionsPaused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("test_0").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	pod.UID = "test_0"
+	client := fake.NewClientset(pod)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	eviction := &test.PodsEvictionRestrictionMock{}
+	eviction.On("CanEvict", pod).Return(true)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+	breaker, _ := newTestEvictionCircuitBreaker()
+	for i := 0; i < minEvictionsToTrip; i++ {
+		breaker.record(true)
+	}
+	evictionRateLimiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	u := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     evictionRateLimiter,
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		blockedReasons:          newBlockedReasonAnnotator(client),
+		evictionCircuitBreaker:  breaker,
+	}
+
+	assert.NoError(t, u.RunOnce(context.Background()))
+	eviction.AssertNotCalled(t, "Evict", pod, nil)
+	assert.InDelta(t, 1, evictionRateLimiter.Tokens(), 0.01, "paused evictions don't consume rate limiter tokens")
+	annotated, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(blockedByEvictionsPaused), annotated.Annotations[annotations.VpaBlockedReasonAnnotation])
+}
+
+func TestRunOnce_BlockedUnscheduled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("test_0").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		WithPhase(apiv1.PodPending).
+		Get()
+	pod.UID = "test_0"
+	client := fake.NewClientset(pod)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	eviction := &test.PodsEvictionRestrictionMock{}
+	eviction.On("CanEvict", pod).Return(false)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+	recorder := record.NewFakeRecorder(10)
+	u := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		eventRecorder:           recorder,
+		blockedReasons:          newBlockedReasonAnnotator(client),
+	}
+
+	assert.NoError(t, u.RunOnce(context.Background()))
+	eviction.AssertNotCalled(t, "Evict", pod, nil)
+	annotated, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(blockedByUnscheduled), annotated.Annotations[annotations.VpaBlockedReasonAnnotation])
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "EvictionSkipped")
+}
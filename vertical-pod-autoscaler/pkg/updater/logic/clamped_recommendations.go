@@ -0,0 +1,95 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// clampedRecommendationEventInterval is how often a VPA whose recommendations keep being clamped gets an event.
+const clampedRecommendationEventInterval = time.Hour
+
+// clampedRecommendationReporter surfaces the recommendations applied to pods which are clamped by the
+// minAllowed or maxAllowed of their VPA, or by the cluster-wide defaults of these bounds, as those
+// bounds are easily overlooked when they are the binding constraint. Every clamped resource of an
+// updated pod is counted, and the VPA gets an event at most once per interval.
+type clampedRecommendationReporter struct {
+	recorder  record.EventRecorder
+	defaults  *vpa_api_util.DefaultResourcePolicy
+	interval  time.Duration
+	clock     clock.PassiveClock
+	lastEvent map[types.UID]time.Time
+}
+
+func newClampedRecommendationReporter(recorder record.EventRecorder, defaults *vpa_api_util.DefaultResourcePolicy) *clampedRecommendationReporter {
+	return &clampedRecommendationReporter{
+		recorder:  recorder,
+		defaults:  defaults,
+		interval:  clampedRecommendationEventInterval,
+		clock:     clock.RealClock{},
+		lastEvent: make(map[types.UID]time.Time),
+	}
+}
+
+// report records the resources of the recommendation applied to the pod of the VPA which are clamped by the
+// resource policy the recommendation processor applied to it, i.e. the one of the VPA with the defaults merged.
+func (r *clampedRecommendationReporter) report(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) {
+	if r == nil {
+		return
+	}
+	clamped := vpa_api_util.GetClampedResources(recommendation, r.defaults.Apply(vpa.Spec.ResourcePolicy, pod))
+	if len(clamped) == 0 {
+		return
+	}
+	descriptions := make([]string, 0, len(clamped))
+	for _, c := range clamped {
+		metrics_updater.RecordClampedRecommendation(string(c.Bound))
+		descriptions = append(descriptions, fmt.Sprintf("%s of container %s to %sAllowed", c.Resource, c.ContainerName, c.Bound))
+	}
+
+	now := r.clock.Now()
+	if last, found := r.lastEvent[vpa.UID]; found && now.Sub(last) < r.interval {
+		return
+	}
+	r.lastEvent[vpa.UID] = now
+	r.recorder.Event(vpa, apiv1.EventTypeNormal, "RecommendationClamped",
+		fmt.Sprintf("The recommendation applied to pods is clamped by the resource policy: %s. The policy rather than the usage of the containers decides their resources.", strings.Join(descriptions, ", ")))
+}
+
+// forgetExpired drops the VPAs whose interval passed, so that deleted VPAs are not kept around.
+func (r *clampedRecommendationReporter) forgetExpired() {
+	if r == nil {
+		return
+	}
+	now := r.clock.Now()
+	for uid, last := range r.lastEvent {
+		if now.Sub(last) >= r.interval {
+			delete(r.lastEvent, uid)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+func TestClampedRecommendationReporter(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	reporter := newClampedRecommendationReporter(fakeRecorder, nil)
+	reporter.clock = fakeClock
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("app").Get()
+	vpa.UID = types.UID("vpa")
+	vpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{{
+			ContainerName: "app",
+			MinAllowed:    apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("500Mi")},
+			MaxAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2")},
+		}},
+	}
+	pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName("app").Get()).Get()
+	clamped := test.Recommendation().WithContainer("app").WithTarget("2", "500Mi").Get()
+	unclamped := test.Recommendation().WithContainer("app").WithTarget("1", "1Gi").Get()
+
+	reporter.report(vpa, pod, unclamped)
+	assert.Empty(t, recordedEvents(fakeRecorder), "recommendations within the bounds are not reported")
+
+	reporter.report(vpa, pod, clamped)
+	assert.Equal(t, []string{"Normal RecommendationClamped The recommendation applied to pods is clamped by the resource policy: " +
+		"cpu of container app to maxAllowed, memory of container app to minAllowed. The policy rather than the usage of the containers decides their resources."},
+		recordedEvents(fakeRecorder))
+
+	fakeClock.Step(time.Minute)
+	reporter.report(vpa, pod, clamped)
+	assert.Empty(t, recordedEvents(fakeRecorder), "at most one event is emitted per interval")
+
+	fakeClock.Step(clampedRecommendationEventInterval)
+	reporter.forgetExpired()
+	assert.Empty(t, reporter.lastEvent)
+	reporter.report(vpa, pod, clamped)
+	assert.Len(t, recordedEvents(fakeRecorder), 1, "the event is emitted again once the interval passed")
+}
+
+func TestClampedRecommendationReporter_DefaultResourcePolicy(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	defaults := &vpa_api_util.DefaultResourcePolicy{}
+	defaults.Set(map[string]string{vpa_api_util.DefaultMinAllowedKey: `{"memory": "500Mi"}`})
+	reporter := newClampedRecommendationReporter(fakeRecorder, defaults)
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("app").Get()
+	vpa.UID = types.UID("vpa")
+	vpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{{
+			ContainerName: "app",
+			MaxAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2")},
+		}},
+	}
+	pod := test.Pod().WithName("pod").AddContainer(test.Container().WithName("app").Get()).Get()
+
+	reporter.report(vpa, pod, test.Recommendation().WithContainer("app").WithTarget("1", "500Mi").Get())
+	assert.Equal(t, []string{"Normal RecommendationClamped The recommendation applied to pods is clamped by the resource policy: " +
+		"memory of container app to minAllowed. The policy rather than the usage of the containers decides their resources."},
+		recordedEvents(fakeRecorder), "recommendations clamped by the cluster-wide defaults are reported")
+}
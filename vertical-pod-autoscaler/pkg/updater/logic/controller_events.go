@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// controllerMetaFetcher is implemented by ControllerFetchers which read the metadata of controllers.
+type controllerMetaFetcher interface {
+	GetControllerMeta(ctx context.Context, key *controllerfetcher.ControllerKeyWithAPIVersion) (*metav1.ObjectMeta, error)
+}
+
+// controllerEventRecorder emits events on the top-most controller of the pods the updater acts on,
+// e.g. their Deployment, where operators look rather than at the pods or the VPA objects.
+// At most one event is emitted on each controller per interval, the others are dropped.
+type controllerEventRecorder struct {
+	recorder          record.EventRecorder
+	controllerFetcher controllerfetcher.ControllerFetcher
+	interval          time.Duration
+	clock             clock.PassiveClock
+	mutex             sync.Mutex
+	lastEvent         map[controllerfetcher.ControllerKey]time.Time
+}
+
+func newControllerEventRecorder(recorder record.EventRecorder, controllerFetcher controllerfetcher.ControllerFetcher, interval time.Duration) *controllerEventRecorder {
+	return &controllerEventRecorder{
+		recorder:          recorder,
+		controllerFetcher: controllerFetcher,
+		interval:          interval,
+		clock:             clock.RealClock{},
+		lastEvent:         make(map[controllerfetcher.ControllerKey]time.Time),
+	}
+}
+
+// event emits an event on the controller of the pod, unless another one was emitted on it within the interval.
+func (c *controllerEventRecorder) event(ctx context.Context, pod *apiv1.Pod, eventType, reason, message string) {
+	if c == nil {
+		return
+	}
+	controller, err := vpa_api_util.FindParentControllerForPod(ctx, pod, c.controllerFetcher)
+	if err != nil {
+		klog.V(4).InfoS("Failed to find controller of pod, not emitting an event on it", "pod", klog.KObj(pod), "error", err)
+		return
+	}
+	if controller == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	now := c.clock.Now()
+	if last, found := c.lastEvent[controller.ControllerKey]; found && now.Sub(last) < c.interval {
+		c.mutex.Unlock()
+		return
+	}
+	c.lastEvent[controller.ControllerKey] = now
+	c.mutex.Unlock()
+
+	reference := &apiv1.ObjectReference{
+		APIVersion: controller.ApiVersion,
+		Kind:       controller.Kind,
+		Namespace:  controller.Namespace,
+		Name:       controller.Name,
+	}
+	// The UID keeps the event from being shown on a new controller of the same name.
+	if metaFetcher, ok := c.controllerFetcher.(controllerMetaFetcher); ok {
+		meta, err := metaFetcher.GetControllerMeta(ctx, controller)
+		if err != nil {
+			klog.V(4).InfoS("Failed to read controller of pod, emitting an event without its UID", "pod", klog.KObj(pod), "controller", klog.KRef(controller.Namespace, controller.Name), "error", err)
+		} else {
+			reference.UID = meta.UID
+			reference.ResourceVersion = meta.ResourceVersion
+		}
+	}
+	c.recorder.Event(reference, eventType, reason, message)
+}
+
+// forgetExpired drops the controllers whose interval passed, so that deleted controllers are not kept around.
+func (c *controllerEventRecorder) forgetExpired() {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.clock.Now()
+	for key, last := range c.lastEvent {
+		if now.Sub(last) >= c.interval {
+			delete(c.lastEvent, key)
+		}
+	}
+}
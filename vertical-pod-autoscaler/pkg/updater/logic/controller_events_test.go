@@ -0,0 +1,168 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+// replicaSetOwnerFetcher resolves ReplicaSets to the Deployment of the same name.
+type replicaSetOwnerFetcher struct{}
+
+func (replicaSetOwnerFetcher) FindTopMostWellKnownOrScalable(_ context.Context, controller *controllerfetcher.ControllerKeyWithAPIVersion) (*controllerfetcher.ControllerKeyWithAPIVersion, error) {
+	if controller.Kind != "ReplicaSet" {
+		return controller, nil
+	}
+	return &controllerfetcher.ControllerKeyWithAPIVersion{
+		ControllerKey: controllerfetcher.ControllerKey{Namespace: controller.Namespace, Kind: "Deployment", Name: controller.Name},
+		ApiVersion:    "apps/v1",
+	}, nil
+}
+
+func (replicaSetOwnerFetcher) GetControllerMeta(_ context.Context, controller *controllerfetcher.ControllerKeyWithAPIVersion) (*metav1.ObjectMeta, error) {
+	return &metav1.ObjectMeta{Namespace: controller.Namespace, Name: controller.Name, UID: types.UID(controller.Name + "-uid"), ResourceVersion: "7"}, nil
+}
+
+func TestControllerEventRecorder_ObjectReference(t *testing.T) {
+	recorder := test.MockEventRecorder()
+	recorder.On("Event", mock.Anything, apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-1").Return()
+	controllerEvents := newControllerEventRecorder(recorder, replicaSetOwnerFetcher{}, 5*time.Minute)
+
+	pod := test.Pod().WithName("pod-1").
+		WithCreator(&metav1.ObjectMeta{Name: "web", Namespace: "default"}, &metav1.TypeMeta{Kind: "ReplicaSet", APIVersion: "apps/v1"}).
+		Get()
+	controllerEvents.event(context.Background(), pod, apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-1")
+	recorder.AssertNumberOfCalls(t, "Event", 1)
+	assert.Equal(t, &apiv1.ObjectReference{
+		APIVersion:      "apps/v1",
+		Kind:            "Deployment",
+		Namespace:       "default",
+		Name:            "web",
+		UID:             "web-uid",
+		ResourceVersion: "7",
+	}, recorder.Calls[0].Arguments.Get(0), "the event is bound to the current controller of that name")
+}
+
+func TestControllerEventRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeRecorder.IncludeObject = true
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	controllerEvents := newControllerEventRecorder(fakeRecorder, replicaSetOwnerFetcher{}, 5*time.Minute)
+	controllerEvents.clock = fakeClock
+
+	newPod := func(name, replicaSet string) *apiv1.Pod {
+		return test.Pod().WithName(name).
+			WithCreator(&metav1.ObjectMeta{Name: replicaSet, Namespace: "default"}, &metav1.TypeMeta{Kind: "ReplicaSet", APIVersion: "apps/v1"}).
+			Get()
+	}
+	ctx := context.Background()
+	controllerEvents.event(ctx, newPod("pod-1", "web"), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-1")
+	controllerEvents.event(ctx, newPod("pod-2", "web"), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-2")
+	controllerEvents.event(ctx, newPod("pod-3", "api"), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-3")
+	controllerEvents.event(ctx, test.Pod().WithName("bare").Get(), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted bare")
+	assert.Equal(t, []string{
+		"Normal EvictedByVPA Evicted pod-1 involvedObject{kind=Deployment,apiVersion=apps/v1}",
+		"Normal EvictedByVPA Evicted pod-3 involvedObject{kind=Deployment,apiVersion=apps/v1}",
+	}, recordedEvents(fakeRecorder), "events on the same controller within the interval are dropped")
+
+	fakeClock.Step(5 * time.Minute)
+	controllerEvents.forgetExpired()
+	assert.Empty(t, controllerEvents.lastEvent)
+	controllerEvents.event(ctx, newPod("pod-2", "web"), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-2")
+	assert.Equal(t, []string{
+		"Normal EvictedByVPA Evicted pod-2 involvedObject{kind=Deployment,apiVersion=apps/v1}",
+	}, recordedEvents(fakeRecorder), "an event is emitted again once the interval passed")
+
+	var disabled *controllerEventRecorder
+	disabled.event(ctx, newPod("pod-1", "web"), apiv1.EventTypeNormal, "EvictedByVPA", "Evicted pod-1")
+	disabled.forgetExpired()
+}
+
+func TestRunOnce_ControllerEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	deployment := metav1.ObjectMeta{Name: "web", Namespace: "default"}
+	deploymentType := metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}
+	pod := test.Pod().WithName("web-1").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&deployment, &deploymentType).
+		WithLabels(map[string]string{"app": "web"}).
+		Get()
+	pod.OwnerReferences[0].APIVersion = deploymentType.APIVersion
+	eviction := &test.PodsEvictionRestrictionMock{}
+	eviction.On("CanEvict", pod).Return(true)
+	eviction.On("Evict", pod, nil).Return(nil)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithName("web").
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: deploymentType.Kind, Name: deployment.Name, APIVersion: deploymentType.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = web"), nil)
+
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeRecorder.IncludeObject = true
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		controllerEvents:        newControllerEventRecorder(fakeRecorder, controllerfetcher.FakeControllerFetcher{}, time.Minute),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	eviction.AssertNumberOfCalls(t, "Evict", 1)
+	assert.Equal(t, []string{
+		"Normal EvictedByVPA VPA Updater evicted pod web-1 to apply the recommendation of VPA web. involvedObject{kind=Deployment,apiVersion=apps/v1}",
+	}, recordedEvents(fakeRecorder))
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// controllerLockPrefix is the prefix of the names of the Leases locking controllers.
+const controllerLockPrefix = "vpa-updater-controller-"
+
+// ControllerLockConfig configures the Leases held by an updater shard while it acts on the pods of a controller,
+// so that shards watching overlapping namespaces don't disrupt the same controller at once.
+type ControllerLockConfig struct {
+	// LeaseDuration is how long a Lease held by a shard which stopped renewing it blocks the other shards.
+	// Zero disables the locks.
+	LeaseDuration time.Duration
+	// Identity identifies the shard holding a Lease. It must be unique among the shards.
+	Identity string
+}
+
+// controllerLocks acquires and releases the Leases locking the controllers targeted by VPA objects.
+type controllerLocks struct {
+	client    kube_client.Interface
+	namespace string
+	config    ControllerLockConfig
+	clock     clock.PassiveClock
+	// renewed holds when each Lease held by this shard was last acquired or renewed.
+	renewed map[string]time.Time
+}
+
+func newControllerLocks(client kube_client.Interface, namespace string, config ControllerLockConfig) *controllerLocks {
+	return &controllerLocks{
+		client:    client,
+		namespace: namespace,
+		config:    config,
+		clock:     clock.RealClock{},
+		renewed:   make(map[string]time.Time),
+	}
+}
+
+// controllerLockName returns the name of the Lease locking the controller targeted by the VPA, or an empty
+// string if the VPA has no target.
+func controllerLockName(vpa *vpa_types.VerticalPodAutoscaler) string {
+	if vpa.Spec.TargetRef == nil {
+		return ""
+	}
+	hash := fnv.New64a()
+	_, _ = fmt.Fprintf(hash, "%s/%s/%s", vpa.Namespace, vpa.Spec.TargetRef.Kind, vpa.Spec.TargetRef.Name)
+	return fmt.Sprintf("%s%016x", controllerLockPrefix, hash.Sum64())
+}
+
+// acquire takes or renews the Lease of the controller targeted by the VPA. It returns false if another shard
+// holds the Lease, or took it first.
+func (l *controllerLocks) acquire(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler) (bool, error) {
+	name := controllerLockName(vpa)
+	if name == "" {
+		return true, nil
+	}
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	now := metav1.NewMicroTime(l.clock.Now())
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: l.namespace, Name: name}}
+		l.hold(lease, now)
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to create lease %s/%s: %v", l.namespace, name, err)
+		}
+		l.renewed[name] = now.Time
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease %s/%s: %v", l.namespace, name, err)
+	}
+	if l.heldByOther(lease) {
+		klog.V(2).InfoS("Controller is locked by another updater", "vpa", klog.KObj(vpa), "lease", klog.KObj(lease), "holder", ptr.Deref(lease.Spec.HolderIdentity, ""))
+		return false, nil
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != l.config.Identity {
+		lease.Spec.AcquireTime = &now
+	}
+	l.hold(lease, now)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update lease %s/%s: %v", l.namespace, name, err)
+	}
+	l.renewed[name] = now.Time
+	return true, nil
+}
+
+// renew renews the Lease of the controller targeted by the VPA once a third of its duration passed since it
+// was acquired or last renewed, so that it doesn't expire while the shard acts on many pods. It returns false
+// if another shard took the Lease meanwhile.
+func (l *controllerLocks) renew(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler) (bool, error) {
+	name := controllerLockName(vpa)
+	if name == "" {
+		return true, nil
+	}
+	if renewed, found := l.renewed[name]; found && l.clock.Since(renewed) < l.config.LeaseDuration/3 {
+		return true, nil
+	}
+	return l.acquire(ctx, vpa)
+}
+
+// release gives up the Lease of the controller targeted by the VPA, if this shard holds it.
+func (l *controllerLocks) release(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler) {
+	if l == nil {
+		return
+	}
+	name := controllerLockName(vpa)
+	if name == "" {
+		return
+	}
+	delete(l.renewed, name)
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).InfoS("Failed to get controller lock to release it, it will expire", "vpa", klog.KObj(vpa), "lease", klog.KRef(l.namespace, name), "error", err)
+		return
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != l.config.Identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		klog.V(2).InfoS("Failed to release controller lock, it will expire", "vpa", klog.KObj(vpa), "lease", klog.KObj(lease), "error", err)
+	}
+}
+
+func (l *controllerLocks) hold(lease *coordinationv1.Lease, now metav1.MicroTime) {
+	if lease.Spec.AcquireTime == nil {
+		lease.Spec.AcquireTime = &now
+	}
+	lease.Spec.HolderIdentity = ptr.To(l.config.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(l.config.LeaseDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+}
+
+// heldByOther returns true if the Lease is held by another shard which renewed it recently enough.
+func (l *controllerLocks) heldByOther(lease *coordinationv1.Lease) bool {
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	if holder == "" || holder == l.config.Identity || lease.Spec.RenewTime == nil {
+		return false
+	}
+	duration := l.config.LeaseDuration
+	if lease.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return l.clock.Now().Before(lease.Spec.RenewTime.Add(duration))
+}
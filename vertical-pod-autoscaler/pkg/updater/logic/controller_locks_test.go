@@ -0,0 +1,257 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	baseclocktest "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestControllerLocks(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	newShard := func(identity string) *controllerLocks {
+		locks := newControllerLocks(client, "kube-system", ControllerLockConfig{LeaseDuration: time.Minute, Identity: identity})
+		locks.clock = fakeClock
+		return locks
+	}
+	shardA, shardB := newShard("shard-a"), newShard("shard-b")
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("app").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"}).Get()
+	other := test.VerticalPodAutoscaler().WithName("other").WithNamespace("default").WithContainer("app").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: "Deployment", Name: "other", APIVersion: "apps/v1"}).Get()
+
+	acquire := func(locks *controllerLocks, vpa *vpa_types.VerticalPodAutoscaler) bool {
+		acquired, err := locks.acquire(ctx, vpa)
+		assert.NoError(t, err)
+		return acquired
+	}
+	assert.True(t, acquire(shardA, vpa))
+	assert.False(t, acquire(shardB, vpa), "the controller is locked by the other shard")
+	assert.True(t, acquire(shardB, other), "other controllers are locked independently")
+	assert.True(t, acquire(shardA, vpa), "the holder renews the lock")
+
+	shardA.release(ctx, vpa)
+	assert.True(t, acquire(shardB, vpa), "a released lock is acquired by the other shard")
+	shardA.release(ctx, vpa)
+	assert.False(t, acquire(shardA, vpa), "only the holder releases the lock")
+
+	fakeClock.Step(time.Minute)
+	assert.True(t, acquire(shardA, vpa), "an expired lock is taken over")
+	lease, err := client.CoordinationV1().Leases("kube-system").Get(ctx, controllerLockName(vpa), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "shard-a", ptr.Deref(lease.Spec.HolderIdentity, ""))
+	assert.Equal(t, fakeClock.Now(), lease.Spec.AcquireTime.Time)
+
+	noTarget := test.VerticalPodAutoscaler().WithName("no-target").WithNamespace("default").WithContainer("app").Get()
+	noTarget.Spec.TargetRef = nil
+	assert.True(t, acquire(shardA, noTarget), "VPAs without a target are not locked")
+}
+
+func TestControllerLocksRenew(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	newShard := func(identity string) *controllerLocks {
+		locks := newControllerLocks(client, "kube-system", ControllerLockConfig{LeaseDuration: time.Minute, Identity: identity})
+		locks.clock = fakeClock
+		return locks
+	}
+	shardA, shardB := newShard("shard-a"), newShard("shard-b")
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("app").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"}).Get()
+	renewTime := func() time.Time {
+		lease, err := client.CoordinationV1().Leases("kube-system").Get(ctx, controllerLockName(vpa), metav1.GetOptions{})
+		assert.NoError(t, err)
+		return lease.Spec.RenewTime.Time
+	}
+	acquired, err := shardA.acquire(ctx, vpa)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquiredAt := fakeClock.Now()
+
+	fakeClock.Step(10 * time.Second)
+	renewed, err := shardA.renew(ctx, vpa)
+	assert.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, acquiredAt, renewTime(), "a recently renewed lock isn't renewed again")
+
+	fakeClock.Step(15 * time.Second)
+	renewed, err = shardA.renew(ctx, vpa)
+	assert.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, fakeClock.Now(), renewTime(), "the lock is renewed before it expires")
+
+	fakeClock.Step(2 * time.Minute)
+	acquired, err = shardB.acquire(ctx, vpa)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	renewed, err = shardA.renew(ctx, vpa)
+	assert.NoError(t, err)
+	assert.False(t, renewed, "a lock taken over by another shard isn't renewed")
+}
+
+func TestRunOnce_ControllerLockForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("pod").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	evictions := 0
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(*apiv1.Pod) bool { return true },
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evictions++
+			return nil
+		},
+	}
+	client := fake.NewClientset()
+	client.PrependReactor("get", "leases", func(core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, controllerLockName(vpaObj), nil)
+	})
+
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		controllerLocks:         newControllerLocks(client, "kube-system", ControllerLockConfig{LeaseDuration: time.Minute, Identity: "shard"}),
+	}
+
+	assert.Error(t, updater.RunOnce(context.Background()), "a lock which can't be acquired fails the loop")
+	assert.Equal(t, 0, evictions, "the pods of a controller which can't be locked are not evicted")
+}
+
+func TestRunOnce_ControllerLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("pod").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(2)
+
+	evictions := 0
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(*apiv1.Pod) bool { return true },
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evictions++
+			return nil
+		},
+	}
+	client := fake.NewClientset()
+	otherShard := newControllerLocks(client, "kube-system", ControllerLockConfig{LeaseDuration: time.Minute, Identity: "other-shard"})
+	acquired, err := otherShard.acquire(context.Background(), vpaObj)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		controllerLocks:         newControllerLocks(client, "kube-system", ControllerLockConfig{LeaseDuration: time.Minute, Identity: "shard"}),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 0, evictions, "the pods of a controller locked by another shard are not evicted")
+
+	otherShard.release(context.Background(), vpaObj)
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 1, evictions)
+	lease, err := client.CoordinationV1().Leases("kube-system").Get(context.Background(), controllerLockName(vpaObj), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, lease.Spec.HolderIdentity, "the lock is released after acting on the pods")
+}
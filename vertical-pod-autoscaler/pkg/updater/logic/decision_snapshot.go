@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// DecisionsPath is the path under which a DecisionSnapshot serves the decisions of a VPA, as <namespace>/<name>.
+const DecisionsPath = "/debug/vpa/"
+
+// Decisions of the updater about a pod.
+const (
+	// decisionNotEligible is the decision for pods which weren't accepted for an update, e.g. because
+	// their requests are close enough to the recommendation or they can't be updated in their update mode.
+	decisionNotEligible = "NotEligible"
+	// decisionNotAdmitted is the decision for pods accepted for an update, but refused by the eviction admission.
+	decisionNotAdmitted = "NotAdmitted"
+	// decisionEligible is the decision for pods accepted for an update which weren't acted on, e.g. because
+	// the updater started draining.
+	decisionEligible       = "Eligible"
+	decisionBlocked        = "Blocked"
+	decisionInPlaceUpdated = "InPlaceUpdated"
+	decisionEvicted        = "Evicted"
+	decisionFailed         = "Failed"
+)
+
+// inPlaceUpdateImpossible is the reason of pods in the InPlaceOnly mode which can't be updated in place.
+const inPlaceUpdateImpossible = "InPlaceUpdateImpossible: the pod can't be updated in place and its update mode is InPlaceOnly"
+
+// PodDecision is what the updater decided about a pod matched by a VPA.
+type PodDecision struct {
+	Pod string `json:"pod"`
+	// Priority is the update priority of the pod, unset if it wasn't accepted for an update.
+	Priority *priority.PodPriority `json:"priority,omitempty"`
+	Decision string                `json:"decision"`
+	Reason   string                `json:"reason,omitempty"`
+}
+
+// VpaDecisions are the decisions about the pods of a VPA in the most recent loop processing it.
+type VpaDecisions struct {
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Time      time.Time     `json:"time"`
+	Pods      []PodDecision `json:"pods"`
+}
+
+// DecisionSnapshot holds the decisions of the most recent loop for each VPA, to troubleshoot why pods
+// are or aren't updated. It serves them in JSON over HTTP, under DecisionsPath.
+type DecisionSnapshot struct {
+	mu   sync.RWMutex
+	vpas map[types.NamespacedName]*VpaDecisions
+}
+
+// NewDecisionSnapshot returns an empty DecisionSnapshot.
+func NewDecisionSnapshot() *DecisionSnapshot {
+	return &DecisionSnapshot{vpas: make(map[types.NamespacedName]*VpaDecisions)}
+}
+
+// ServeHTTP writes the decisions of the VPA whose namespace and name follow DecisionsPath in the request path.
+func (s *DecisionSnapshot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace, name, found := strings.Cut(strings.TrimPrefix(r.URL.Path, DecisionsPath), "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		http.Error(w, "expected "+DecisionsPath+"<namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+	s.mu.RLock()
+	decisions, found := s.vpas[types.NamespacedName{Namespace: namespace, Name: name}]
+	s.mu.RUnlock()
+	if !found {
+		http.Error(w, "no decisions recorded for VPA "+namespace+"/"+name, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(decisions); err != nil {
+		klog.ErrorS(err, "Failed to write the decisions of VPA", "vpa", klog.KRef(namespace, name))
+	}
+}
+
+// begin starts recording the decisions about the pods of the VPA, all of them not eligible at first.
+func (s *DecisionSnapshot) begin(vpa *vpa_types.VerticalPodAutoscaler, pods []*apiv1.Pod, now time.Time) *vpaDecisionRecorder {
+	if s == nil {
+		return nil
+	}
+	recorder := &vpaDecisionRecorder{
+		decisions: &VpaDecisions{Namespace: vpa.Namespace, Name: vpa.Name, Time: now, Pods: make([]PodDecision, len(pods))},
+		index:     make(map[*apiv1.Pod]int, len(pods)),
+	}
+	for i, pod := range pods {
+		recorder.decisions.Pods[i] = PodDecision{Pod: pod.Name, Decision: decisionNotEligible}
+		recorder.index[pod] = i
+	}
+	return recorder
+}
+
+// publish replaces the decisions of the VPA with the recorded ones.
+func (s *DecisionSnapshot) publish(recorder *vpaDecisionRecorder) {
+	if s == nil || recorder == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vpas[types.NamespacedName{Namespace: recorder.decisions.Namespace, Name: recorder.decisions.Name}] = recorder.decisions
+}
+
+// retain forgets the decisions of VPAs which aren't processed anymore.
+func (s *DecisionSnapshot) retain(vpas []*vpa_api_util.VpaWithSelector) {
+	if s == nil {
+		return
+	}
+	processed := make(map[types.NamespacedName]bool, len(vpas))
+	for _, vpa := range vpas {
+		processed[vpaKey(vpa.Vpa)] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.vpas {
+		if !processed[key] {
+			delete(s.vpas, key)
+		}
+	}
+}
+
+// vpaDecisionRecorder records the decisions about the pods of a VPA during a loop.
+type vpaDecisionRecorder struct {
+	decisions *VpaDecisions
+	index     map[*apiv1.Pod]int
+}
+
+// prioritize records that the pod was accepted for an update with the given priority.
+func (r *vpaDecisionRecorder) prioritize(pod *apiv1.Pod, podPriority priority.PodPriority) {
+	if r == nil {
+		return
+	}
+	if i, found := r.index[pod]; found {
+		r.decisions.Pods[i].Priority = &podPriority
+		r.decisions.Pods[i].Decision = decisionEligible
+	}
+}
+
+// decide records the decision about the pod, replacing any earlier one.
+func (r *vpaDecisionRecorder) decide(pod *apiv1.Pod, decision, reason string) {
+	if r == nil {
+		return
+	}
+	if i, found := r.index[pod]; found {
+		r.decisions.Pods[i].Decision = decision
+		r.decisions.Pods[i].Reason = reason
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestDecisionSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	newPod := func(name, cpu, memory string) *apiv1.Pod {
+		return test.Pod().WithName(name).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse(cpu)).WithMemRequest(resource.MustParse(memory)).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+	}
+	evicted := newPod("evicted", "1", "100M")
+	blocked := newPod("blocked", "1", "100M")
+	upToDate := newPod("up-to-date", "2", "200M")
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{evicted, blocked, upToDate}, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithName("vpa").
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(pod *apiv1.Pod) bool {
+			return pod != blocked
+		},
+	}
+	decisions := NewDecisionSnapshot()
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		decisions:               decisions,
+	}
+	assert.NoError(t, updater.RunOnce(context.Background()))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		decisions.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+		return response
+	}
+	response := get(DecisionsPath + "default/vpa")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	var vpaDecisions VpaDecisions
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &vpaDecisions))
+	assert.Equal(t, "default", vpaDecisions.Namespace)
+	assert.Equal(t, "vpa", vpaDecisions.Name)
+	assert.False(t, vpaDecisions.Time.IsZero())
+
+	podDecisions := make(map[string]PodDecision)
+	for _, decision := range vpaDecisions.Pods {
+		podDecisions[decision.Pod] = decision
+	}
+	require.Len(t, podDecisions, 3)
+	assert.Equal(t, decisionEvicted, podDecisions["evicted"].Decision)
+	if assert.NotNil(t, podDecisions["evicted"].Priority) {
+		assert.True(t, podDecisions["evicted"].Priority.ScaleUp)
+		assert.Positive(t, podDecisions["evicted"].Priority.ResourceDiff)
+	}
+	assert.Equal(t, decisionNotEligible, podDecisions["blocked"].Decision, "pods which can't be evicted aren't prioritized")
+	assert.Equal(t, decisionNotEligible, podDecisions["up-to-date"].Decision)
+	assert.Nil(t, podDecisions["up-to-date"].Priority)
+
+	assert.Equal(t, http.StatusNotFound, get(DecisionsPath+"default/other").Code)
+	assert.Equal(t, http.StatusBadRequest, get(DecisionsPath+"default").Code)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// ErroredVpaRequeueConfig configures retrying VPAs whose processing failed before the next loop.
+type ErroredVpaRequeueConfig struct {
+	// BaseDelay is the delay before the first retry of a VPA, doubled with every failed retry.
+	// Zero disables retries, errored VPAs are then only processed again in the next loop.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries of a VPA.
+	MaxDelay time.Duration
+	// Timeout bounds the time spent on a retry, so that it doesn't hold off the next loop.
+	// Zero means no bound.
+	Timeout time.Duration
+}
+
+func newErroredVpaQueue(config ErroredVpaRequeueConfig) workqueue.TypedRateLimitingInterface[types.NamespacedName] {
+	return workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[types.NamespacedName](config.BaseDelay, config.MaxDelay),
+		workqueue.TypedRateLimitingQueueConfig[types.NamespacedName]{Name: "vpa_updater_errored_vpas"},
+	)
+}
+
+func vpaKey(vpa *vpa_types.VerticalPodAutoscaler) types.NamespacedName {
+	return types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}
+}
+
+// requeueErroredVpa schedules another attempt at processing the VPA, with a delay growing
+// with the number of consecutive failures.
+func (u *updater) requeueErroredVpa(vpa *vpa_types.VerticalPodAutoscaler) {
+	if u.erroredVpas == nil {
+		return
+	}
+	key := vpaKey(vpa)
+	klog.V(3).InfoS("Requeueing VPA object after an error", "vpa", klog.KObj(vpa), "retries", u.erroredVpas.NumRequeues(key))
+	u.erroredVpas.AddRateLimited(key)
+}
+
+// forgetErroredVpa resets the retry delay of the VPA once it was processed successfully.
+func (u *updater) forgetErroredVpa(vpa *vpa_types.VerticalPodAutoscaler) {
+	if u.erroredVpas == nil {
+		return
+	}
+	u.erroredVpas.Forget(vpaKey(vpa))
+}
+
+// ProcessErroredVpas processes the VPAs requeued after an error one at a time, as soon as
+// their retry delay elapses, until the context is done. It returns right away if retries are disabled.
+func (u *updater) ProcessErroredVpas(ctx context.Context) {
+	if u.erroredVpas == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		u.erroredVpas.ShutDown()
+	}()
+	for {
+		key, shutdown := u.erroredVpas.Get()
+		if shutdown {
+			return
+		}
+		u.retryErroredVpa(ctx, key)
+		u.erroredVpas.Done(key)
+	}
+}
+
+func (u *updater) retryErroredVpa(ctx context.Context, key types.NamespacedName) {
+	if !u.runOnceLock.TryLock() {
+		// The running loop may have processed the VPA already, so it's retried again later.
+		klog.V(3).InfoS("Postponing retry of VPA object while a loop is running", "vpa", key)
+		u.erroredVpas.AddRateLimited(key)
+		return
+	}
+	defer u.runOnceLock.Unlock()
+	if u.erroredVpaRetryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.erroredVpaRetryTimeout)
+		defer cancel()
+	}
+	klog.V(3).InfoS("Retrying VPA object which errored", "vpa", key)
+	u.loopErrors = nil
+	u.runOnce(ctx, sets.New(key))
+}
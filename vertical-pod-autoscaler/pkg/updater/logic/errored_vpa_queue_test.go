@@ -0,0 +1,165 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestProcessErroredVpas_RetriesTransientFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		eviction.On("CanEvict", pods[i]).Return(true)
+		eviction.On("Evict", pods[i], nil).Return(nil)
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	gomock.InOrder(
+		mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(nil, errors.New("scale subresource unavailable")),
+		mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil),
+	)
+
+	admission := &loopInitCountingAdmission{PodEvictionAdmission: priority.NewDefaultPodEvictionAdmission()}
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       admission,
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		erroredVpas:             newErroredVpaQueue(ErroredVpaRequeueConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}),
+	}
+
+	updater.RunOnce(context.Background())
+	eviction.AssertNumberOfCalls(t, "Evict", 0)
+	loopInits := admission.loopInits
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		updater.ProcessErroredVpas(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return updater.erroredVpas.NumRequeues(vpaKey(vpaObj)) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the VPA is forgotten after a successful retry")
+	cancel()
+	<-done
+
+	eviction.AssertNumberOfCalls(t, "Evict", len(pods))
+	assert.Empty(t, updater.vpaSelectors, "retries don't track selectors")
+	assert.Equal(t, loopInits, admission.loopInits, "retries don't reinitialize the admissions with the pods of a single VPA")
+}
+
+type loopInitCountingAdmission struct {
+	priority.PodEvictionAdmission
+	loopInits int
+}
+
+func (a *loopInitCountingAdmission) LoopInit(allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	a.loopInits++
+	a.PodEvictionAdmission.LoopInit(allLivePods, vpaControlledPods)
+}
+
+func TestProcessErroredVpas_Disabled(t *testing.T) {
+	updater := &updater{}
+	// Returns right away without a queue.
+	updater.ProcessErroredVpas(context.Background())
+	updater.requeueErroredVpa(test.VerticalPodAutoscaler().WithContainer("container").Get())
+}
+
+func TestRetryErroredVpa_Timeout(t *testing.T) {
+	vpaObj := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("container").Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{}, nil)
+	selectorFetcher := &deadlineRecordingSelectorFetcher{}
+	updater := &updater{
+		vpaLister:              vpaLister,
+		podLister:              podLister,
+		selectorFetcher:        selectorFetcher,
+		controllerFetcher:      controllerfetcher.FakeControllerFetcher{},
+		evictionAdmission:      priority.NewDefaultPodEvictionAdmission(),
+		erroredVpas:            newErroredVpaQueue(ErroredVpaRequeueConfig{BaseDelay: time.Millisecond, MaxDelay: time.Second}),
+		erroredVpaRetryTimeout: time.Minute,
+	}
+
+	updater.retryErroredVpa(context.Background(), vpaKey(vpaObj))
+	assert.True(t, selectorFetcher.called)
+	assert.True(t, selectorFetcher.hasDeadline, "the retry is bounded")
+}
+
+type deadlineRecordingSelectorFetcher struct {
+	called      bool
+	hasDeadline bool
+}
+
+func (f *deadlineRecordingSelectorFetcher) Fetch(ctx context.Context, _ *vpa_types.VerticalPodAutoscaler) (labels.Selector, error) {
+	f.called = true
+	_, f.hasDeadline = ctx.Deadline()
+	return labels.Nothing(), nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// eventKey identifies events which are collapsed together.
+type eventKey struct {
+	objectType string
+	namespace  string
+	name       string
+	eventType  string
+	reason     string
+}
+
+// suppressedEvents tracks the events with the same key within a window.
+type suppressedEvents struct {
+	object      runtime.Object
+	annotations map[string]string
+	message     string
+	windowStart time.Time
+	// count is the number of events suppressed since the window started.
+	count int
+}
+
+// eventDeduplicator wraps an EventRecorder and collapses events with the same reason
+// on the same object within a window. The first event is recorded right away, the
+// following ones are recorded as a single aggregated event with a count once the window ends.
+type eventDeduplicator struct {
+	record.EventRecorder
+	window time.Duration
+	clock  clock.PassiveClock
+	mutex  sync.Mutex
+	events map[eventKey]*suppressedEvents
+}
+
+func newEventDeduplicator(recorder record.EventRecorder, window time.Duration) *eventDeduplicator {
+	return &eventDeduplicator{
+		EventRecorder: recorder,
+		window:        window,
+		clock:         clock.RealClock{},
+		events:        make(map[eventKey]*suppressedEvents),
+	}
+}
+
+// Event records the event unless the same event was recorded on the object within the window.
+func (d *eventDeduplicator) Event(object runtime.Object, eventtype, reason, message string) {
+	d.record(object, nil, eventtype, reason, message)
+}
+
+// Eventf formats the message and records it like Event.
+func (d *eventDeduplicator) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	d.record(object, nil, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf formats the message and records it with the annotations like Event.
+func (d *eventDeduplicator) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	d.record(object, annotations, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (d *eventDeduplicator) record(object runtime.Object, annotations map[string]string, eventtype, reason, message string) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		klog.V(4).InfoS("Not deduplicating event on object without metadata", "reason", reason, "error", err)
+		d.emit(object, annotations, eventtype, reason, message)
+		return
+	}
+	key := eventKey{
+		objectType: fmt.Sprintf("%T", object),
+		namespace:  accessor.GetNamespace(),
+		name:       accessor.GetName(),
+		eventType:  eventtype,
+		reason:     reason,
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	now := d.clock.Now()
+	if events, found := d.events[key]; found {
+		if now.Sub(events.windowStart) < d.window {
+			events.object = object
+			events.annotations = annotations
+			events.message = message
+			events.count++
+			return
+		}
+		d.recordAggregated(key, events)
+	}
+	d.events[key] = &suppressedEvents{object: object, annotations: annotations, message: message, windowStart: now}
+	d.emit(object, annotations, eventtype, reason, message)
+}
+
+// flush records aggregated events for the windows which ended and forgets about them.
+func (d *eventDeduplicator) flush() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	now := d.clock.Now()
+	for key, events := range d.events {
+		if now.Sub(events.windowStart) >= d.window {
+			d.recordAggregated(key, events)
+			delete(d.events, key)
+		}
+	}
+}
+
+// flushAll records aggregated events for all windows, e.g. before shutting down.
+func (d *eventDeduplicator) flushAll() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for key, events := range d.events {
+		d.recordAggregated(key, events)
+		delete(d.events, key)
+	}
+}
+
+func (d *eventDeduplicator) recordAggregated(key eventKey, events *suppressedEvents) {
+	if events.count == 0 {
+		return
+	}
+	d.emit(events.object, events.annotations, key.eventType, key.reason,
+		fmt.Sprintf("%s (repeated %d times in the last %v)", events.message, events.count, d.window))
+}
+
+// emit records the event with the wrapped recorder, keeping its annotations if it has any.
+func (d *eventDeduplicator) emit(object runtime.Object, annotations map[string]string, eventtype, reason, message string) {
+	if annotations == nil {
+		d.EventRecorder.Event(object, eventtype, reason, message)
+		return
+	}
+	d.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+}
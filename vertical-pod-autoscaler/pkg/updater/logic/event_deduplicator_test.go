@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventDeduplicator(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	deduplicator := newEventDeduplicator(fakeRecorder, 5*time.Minute)
+	deduplicator.clock = fakeClock
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("container").Get()
+	otherVpa := test.VerticalPodAutoscaler().WithName("other").WithNamespace("default").WithContainer("container").Get()
+
+	for range 4 {
+		deduplicator.Event(vpa, apiv1.EventTypeWarning, "EvictionsPaused", "Evictions paused")
+		fakeClock.Step(time.Minute)
+	}
+	deduplicator.Event(otherVpa, apiv1.EventTypeWarning, "EvictionsPaused", "Evictions paused")
+	deduplicator.Eventf(vpa, apiv1.EventTypeNormal, "Other", "Other %s", "reason")
+	assert.Equal(t, []string{
+		"Warning EvictionsPaused Evictions paused",
+		"Warning EvictionsPaused Evictions paused",
+		"Normal Other Other reason",
+	}, recordedEvents(fakeRecorder), "repeated events within the window are suppressed")
+
+	deduplicator.flush()
+	assert.Empty(t, recordedEvents(fakeRecorder), "the window didn't end yet")
+
+	fakeClock.Step(time.Minute)
+	deduplicator.flush()
+	assert.Equal(t, []string{
+		"Warning EvictionsPaused Evictions paused (repeated 3 times in the last 5m0s)",
+	}, recordedEvents(fakeRecorder), "suppressed events are aggregated once the window ends")
+
+	deduplicator.Event(vpa, apiv1.EventTypeWarning, "EvictionsPaused", "Evictions paused")
+	assert.Equal(t, []string{"Warning EvictionsPaused Evictions paused"}, recordedEvents(fakeRecorder), "a new window starts")
+}
+
+func TestEventDeduplicator_AnnotatedEvents(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	deduplicator := newEventDeduplicator(fakeRecorder, 5*time.Minute)
+	deduplicator.clock = fakeClock
+
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer("container").Get()
+	annotations := map[string]string{"key": "value"}
+
+	for range 3 {
+		deduplicator.AnnotatedEventf(vpa, annotations, apiv1.EventTypeWarning, "EvictionsPaused", "Evictions paused for %s", "vpa")
+		fakeClock.Step(time.Minute)
+	}
+	assert.Equal(t, []string{
+		"Warning EvictionsPaused Evictions paused for vpa map[key:value]",
+	}, recordedEvents(fakeRecorder), "repeated annotated events within the window are suppressed")
+
+	fakeClock.Step(3 * time.Minute)
+	deduplicator.flush()
+	assert.Equal(t, []string{
+		"Warning EvictionsPaused Evictions paused for vpa (repeated 2 times in the last 5m0s) map[key:value]",
+	}, recordedEvents(fakeRecorder), "the aggregated event keeps the annotations")
+}
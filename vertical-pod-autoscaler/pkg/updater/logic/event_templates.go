@@ -0,0 +1,163 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"text/template"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// eventReasonRegexp matches the reasons events can have, short CamelCase words.
+var eventReasonRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// EventTemplateData is the context the templates of events are rendered with.
+type EventTemplateData struct {
+	// Reason and Message are the ones the event would have by default.
+	Reason  string
+	Message string
+	// Type is the type of the event, Normal or Warning.
+	Type string
+	// Kind, Namespace and Name identify the object the event is recorded on.
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// EventTemplates override the reasons and messages of the events recorded by the updater.
+// They are keyed by the default reason of the events they apply to, events with other
+// reasons are recorded unchanged.
+type EventTemplates map[string]eventTemplate
+
+type eventTemplate struct {
+	reason  *template.Template
+	message *template.Template
+}
+
+// LoadEventTemplates reads event templates from a JSON file mapping default event reasons to
+// objects with a reason and a message, both Go templates rendered with EventTemplateData, e.g.
+//
+//	{"EvictedByVPA": {"reason": "VpaEviction", "message": "Evicted {{.Namespace}}/{{.Name}}: {{.Message}}"}}
+//
+// A missing reason or message keeps the default one. The templates are checked by rendering them
+// with sample data, so that mistakes are reported at startup rather than when recording events.
+func LoadEventTemplates(path string) (EventTemplates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event templates: %v", err)
+	}
+	var raw map[string]struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse event templates: %v", err)
+	}
+	templates := make(EventTemplates, len(raw))
+	for defaultReason, t := range raw {
+		var parsed eventTemplate
+		if t.Reason != "" {
+			if parsed.reason, err = template.New(defaultReason + ".reason").Option("missingkey=error").Parse(t.Reason); err != nil {
+				return nil, fmt.Errorf("invalid reason template for event %s: %v", defaultReason, err)
+			}
+		}
+		if t.Message != "" {
+			if parsed.message, err = template.New(defaultReason + ".message").Option("missingkey=error").Parse(t.Message); err != nil {
+				return nil, fmt.Errorf("invalid message template for event %s: %v", defaultReason, err)
+			}
+		}
+		sample := EventTemplateData{Reason: defaultReason, Message: "message", Type: apiv1.EventTypeNormal, Kind: "Pod", Namespace: "default", Name: "pod"}
+		if _, _, err := parsed.render(sample); err != nil {
+			return nil, fmt.Errorf("invalid template for event %s: %v", defaultReason, err)
+		}
+		templates[defaultReason] = parsed
+	}
+	return templates, nil
+}
+
+// render returns the reason and message of an event with the given default data.
+func (t eventTemplate) render(data EventTemplateData) (string, string, error) {
+	reason, message := data.Reason, data.Message
+	if t.reason != nil {
+		var buf bytes.Buffer
+		if err := t.reason.Execute(&buf, data); err != nil {
+			return "", "", err
+		}
+		reason = buf.String()
+		if !eventReasonRegexp.MatchString(reason) {
+			return "", "", fmt.Errorf("rendered reason %q is not a CamelCase word", reason)
+		}
+	}
+	if t.message != nil {
+		var buf bytes.Buffer
+		if err := t.message.Execute(&buf, data); err != nil {
+			return "", "", err
+		}
+		message = buf.String()
+	}
+	return reason, message, nil
+}
+
+// eventTemplater wraps an EventRecorder and rewrites the reasons and messages of the events
+// which have a template.
+type eventTemplater struct {
+	record.EventRecorder
+	templates EventTemplates
+}
+
+func newEventTemplater(recorder record.EventRecorder, templates EventTemplates) *eventTemplater {
+	return &eventTemplater{EventRecorder: recorder, templates: templates}
+}
+
+// Event records the event with the reason and message rendered from its template, if any.
+// Events whose template fails to render are recorded unchanged.
+func (e *eventTemplater) Event(object runtime.Object, eventtype, reason, message string) {
+	t, found := e.templates[reason]
+	if !found {
+		e.EventRecorder.Event(object, eventtype, reason, message)
+		return
+	}
+	data := EventTemplateData{Reason: reason, Message: message, Type: eventtype}
+	if ref, ok := object.(*apiv1.ObjectReference); ok {
+		data.Kind, data.Namespace, data.Name = ref.Kind, ref.Namespace, ref.Name
+	} else if accessor, err := meta.Accessor(object); err == nil {
+		data.Kind = reflect.Indirect(reflect.ValueOf(object)).Type().Name()
+		data.Namespace, data.Name = accessor.GetNamespace(), accessor.GetName()
+	}
+	renderedReason, renderedMessage, err := t.render(data)
+	if err != nil {
+		klog.V(2).InfoS("Failed to render event template, recording the default event", "reason", reason, "error", err)
+		e.EventRecorder.Event(object, eventtype, reason, message)
+		return
+	}
+	e.EventRecorder.Event(object, eventtype, renderedReason, renderedMessage)
+}
+
+// Eventf formats the message and records it like Event.
+func (e *eventTemplater) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	e.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
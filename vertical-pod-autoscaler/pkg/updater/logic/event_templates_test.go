@@ -0,0 +1,102 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func writeEventTemplates(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadEventTemplates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		content   string
+		expectErr bool
+	}{
+		{
+			name:    "valid",
+			content: `{"EvictedByVPA": {"reason": "VpaEviction", "message": "{{.Kind}} {{.Namespace}}/{{.Name}}: {{.Message}}"}}`,
+		},
+		{
+			name:    "message only",
+			content: `{"EvictedByVPA": {"message": "evicted"}}`,
+		},
+		{
+			name:      "invalid JSON",
+			content:   `{"EvictedByVPA": `,
+			expectErr: true,
+		},
+		{
+			name:      "invalid template syntax",
+			content:   `{"EvictedByVPA": {"message": "{{.Name"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "unknown field",
+			content:   `{"EvictedByVPA": {"message": "{{.Pod}}"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "reason which isn't a CamelCase word",
+			content:   `{"EvictedByVPA": {"reason": "evicted by {{.Type}}"}}`,
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadEventTemplates(writeEventTemplates(t, tc.content))
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := LoadEventTemplates(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestEventTemplater(t *testing.T) {
+	templates, err := LoadEventTemplates(writeEventTemplates(t,
+		`{"EvictedByVPA": {"reason": "VpaEviction", "message": "{{.Type}}: {{.Kind}} {{.Namespace}}/{{.Name}} was evicted. {{.Message}}"}}`))
+	assert.NoError(t, err)
+	fakeRecorder := record.NewFakeRecorder(10)
+	templater := newEventTemplater(fakeRecorder, templates)
+	pod := test.Pod().WithName("pod").Get()
+	pod.Namespace = "default"
+
+	templater.Event(pod, apiv1.EventTypeNormal, "EvictedByVPA", "Pod was evicted by VPA Updater to apply resource recommendation.")
+	templater.Eventf(pod, apiv1.EventTypeNormal, "InPlaceResizedByVPA", "Pod was resized in place by VPA Updater %s.", "to apply resource recommendation")
+	assert.Equal(t, []string{
+		"Normal VpaEviction Normal: Pod default/pod was evicted. Pod was evicted by VPA Updater to apply resource recommendation.",
+		"Normal InPlaceResizedByVPA Pod was resized in place by VPA Updater to apply resource recommendation.",
+	}, recordedEvents(fakeRecorder), "events without a template are recorded unchanged")
+}
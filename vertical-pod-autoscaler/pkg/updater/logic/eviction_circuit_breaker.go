@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+)
+
+// minEvictionsToTrip is the number of evictions within the window below which the circuit breaker never trips,
+// so that a single failure doesn't pause all evictions.
+const minEvictionsToTrip = 5
+
+// EvictionCircuitBreakerConfig configures pausing all evictions after a spike of eviction errors.
+type EvictionCircuitBreakerConfig struct {
+	// ErrorThreshold is the fraction of failed evictions within Window above which evictions are paused.
+	// Zero disables the circuit breaker.
+	ErrorThreshold float64
+	// Window is the sliding window over which the eviction error rate is computed.
+	Window time.Duration
+	// Cooldown is how long evictions are paused before a single probe eviction is attempted.
+	Cooldown time.Duration
+}
+
+type evictionOutcome struct {
+	time   time.Time
+	failed bool
+}
+
+// evictionCircuitBreaker pauses evictions cluster-wide when too many of them fail, e.g. during
+// a webhook outage. Once the cooldown passes, a single probe eviction is allowed: evictions resume
+// if it succeeds, and are paused for another cooldown otherwise.
+type evictionCircuitBreaker struct {
+	config   EvictionCircuitBreakerConfig
+	clock    clock.PassiveClock
+	outcomes []evictionOutcome
+	// openedAt is the time the breaker last tripped, zero while it's closed.
+	openedAt time.Time
+	// probing is set while the probe eviction after the cooldown is in flight.
+	probing bool
+}
+
+func newEvictionCircuitBreaker(config EvictionCircuitBreakerConfig) *evictionCircuitBreaker {
+	return &evictionCircuitBreaker{
+		config: config,
+		clock:  clock.RealClock{},
+	}
+}
+
+// allow returns true if an eviction may be attempted now.
+func (b *evictionCircuitBreaker) allow() bool {
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || b.clock.Since(b.openedAt) < b.config.Cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// cancel gives back the probe eviction allowed by allow when no eviction was attempted after all,
+// e.g. because the rate limiter wait failed, so that another one is allowed. It's a no-op on a nil breaker.
+func (b *evictionCircuitBreaker) cancel() {
+	if b == nil {
+		return
+	}
+	b.probing = false
+}
+
+// record registers the outcome of an eviction allowed by the breaker. It returns true
+// if the outcome tripped the breaker.
+func (b *evictionCircuitBreaker) record(failed bool) bool {
+	now := b.clock.Now()
+	if b.probing {
+		b.probing = false
+		if failed {
+			klog.V(0).InfoS("Probe eviction failed, evictions stay paused", "cooldown", b.config.Cooldown)
+			b.openedAt = now
+			return false
+		}
+		klog.V(0).InfoS("Probe eviction succeeded, resuming evictions")
+		b.openedAt = time.Time{}
+		b.outcomes = nil
+		metrics_updater.RecordEvictionCircuitBreakerClosed()
+		return false
+	}
+
+	b.outcomes = append(b.outcomes, evictionOutcome{time: now, failed: failed})
+	start := 0
+	for start < len(b.outcomes) && now.Sub(b.outcomes[start].time) > b.config.Window {
+		start++
+	}
+	b.outcomes = b.outcomes[start:]
+
+	failures := 0
+	for _, outcome := range b.outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	if len(b.outcomes) < minEvictionsToTrip || float64(failures)/float64(len(b.outcomes)) <= b.config.ErrorThreshold {
+		return false
+	}
+	klog.V(0).InfoS("Too many evictions failed, pausing evictions", "failed", failures, "attempted", len(b.outcomes), "window", b.config.Window, "cooldown", b.config.Cooldown)
+	b.openedAt = now
+	metrics_updater.RecordEvictionCircuitBreakerTripped()
+	return true
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	baseclocktest "k8s.io/utils/clock/testing"
+)
+
+func newTestEvictionCircuitBreaker() (*evictionCircuitBreaker, *baseclocktest.FakeClock) {
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	breaker := newEvictionCircuitBreaker(EvictionCircuitBreakerConfig{
+		ErrorThreshold: 0.5,
+		Window:         time.Minute,
+		Cooldown:       5 * time.Minute,
+	})
+	breaker.clock = fakeClock
+	return breaker, fakeClock
+}
+
+func TestEvictionCircuitBreakerTripsAndRecovers(t *testing.T) {
+	breaker, fakeClock := newTestEvictionCircuitBreaker()
+
+	// An error spike trips the breaker once enough evictions were attempted.
+	for _, failed := range []bool{false, true, true, false} {
+		assert.True(t, breaker.allow())
+		assert.False(t, breaker.record(failed))
+	}
+	assert.True(t, breaker.allow())
+	assert.True(t, breaker.record(true), "3 out of 5 evictions failed")
+	assert.False(t, breaker.allow())
+
+	fakeClock.Step(4 * time.Minute)
+	assert.False(t, breaker.allow(), "evictions are paused during the cooldown")
+
+	// A failed probe pauses evictions for another cooldown.
+	fakeClock.Step(time.Minute)
+	assert.True(t, breaker.allow(), "a probe is allowed after the cooldown")
+	assert.False(t, breaker.allow(), "only a single probe is allowed")
+	breaker.record(true)
+	assert.False(t, breaker.allow())
+
+	// A successful probe resets the breaker.
+	fakeClock.Step(5 * time.Minute)
+	assert.True(t, breaker.allow())
+	breaker.record(false)
+	assert.True(t, breaker.allow(), "evictions resume after a successful probe")
+	assert.True(t, breaker.allow())
+}
+
+func TestEvictionCircuitBreakerSlidingWindow(t *testing.T) {
+	breaker, fakeClock := newTestEvictionCircuitBreaker()
+
+	for range 4 {
+		breaker.record(true)
+	}
+	fakeClock.Step(2 * time.Minute)
+	for range 4 {
+		assert.False(t, breaker.record(false), "failures outside of the window are not counted")
+	}
+	assert.True(t, breaker.allow())
+}
+
+func TestEvictionCircuitBreakerCancelledProbe(t *testing.T) {
+	breaker, fakeClock := newTestEvictionCircuitBreaker()
+	for i := 0; i < minEvictionsToTrip; i++ {
+		breaker.record(true)
+	}
+	assert.False(t, breaker.allow())
+
+	fakeClock.Step(5 * time.Minute)
+	assert.True(t, breaker.allow())
+	breaker.cancel()
+	assert.True(t, breaker.allow(), "a probe is allowed again once the previous one was cancelled")
+}
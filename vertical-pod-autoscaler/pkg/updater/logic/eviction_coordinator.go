@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+)
+
+// evictionRequestedValue is the value of the coordination annotation set by the updater.
+const evictionRequestedValue = "requested"
+
+// evictionCoordinator splits evictions into two phases for an external disruption coordinator:
+// the updater first annotates the pod, and evicts it in a later loop once the coordinator
+// acknowledged the request by removing the annotation. The request is also recorded in the
+// VpaEvictionRequestedAnnotation of the pod, so that it survives restarts and leader changes.
+type evictionCoordinator struct {
+	client     kube_client.Interface
+	annotation string
+	// requested holds the pods annotated by the updater, which are waiting for acknowledgement.
+	// It covers the requests not yet seen in the annotations of the pods listed from the cache.
+	requested map[types.UID]bool
+}
+
+func newEvictionCoordinator(client kube_client.Interface, annotation string) *evictionCoordinator {
+	return &evictionCoordinator{
+		client:     client,
+		annotation: annotation,
+		requested:  make(map[types.UID]bool),
+	}
+}
+
+// canEvict returns true if the eviction of the pod was acknowledged by the coordinator.
+// Otherwise it requests the eviction by annotating the pod, unless that was already done.
+func (c *evictionCoordinator) canEvict(ctx context.Context, pod *apiv1.Pod) (bool, error) {
+	_, annotated := pod.Annotations[c.annotation]
+	_, recorded := pod.Annotations[annotations.VpaEvictionRequestedAnnotation]
+	if c.requested[pod.UID] || recorded {
+		if annotated {
+			klog.V(2).InfoS("Waiting for eviction to be acknowledged", "pod", klog.KObj(pod), "annotation", c.annotation)
+			return false, nil
+		}
+		return true, nil
+	}
+	if !annotated {
+		if err := c.requestEviction(ctx, pod); err != nil {
+			return false, err
+		}
+		klog.V(2).InfoS("Requested eviction acknowledgement", "pod", klog.KObj(pod), "annotation", c.annotation)
+	}
+	c.requested[pod.UID] = true
+	return false, nil
+}
+
+func (c *evictionCoordinator) requestEviction(ctx context.Context, pod *apiv1.Pod) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{
+				c.annotation: evictionRequestedValue,
+				annotations.VpaEvictionRequestedAnnotation: evictionRequestedValue,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to annotate pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
+
+// retain forgets the eviction requests of pods which no longer exist.
+func (c *evictionCoordinator) retain(pods []*apiv1.Pod) {
+	live := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		live[pod.UID] = true
+	}
+	for uid := range c.requested {
+		if !live[uid] {
+			delete(c.requested, uid)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+const testCoordinationAnnotation = "example.com/eviction-requested"
+
+func countPatches(client *fake.Clientset) int {
+	patches := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+	return patches
+}
+
+func TestEvictionCoordinatorRequestPhase(t *testing.T) {
+	pod := test.Pod().WithName("pod").Get()
+	pod.UID = "pod-uid"
+	client := fake.NewSimpleClientset(pod)
+	coordinator := newEvictionCoordinator(client, testCoordinationAnnotation)
+
+	acknowledged, err := coordinator.canEvict(context.Background(), pod)
+	assert.NoError(t, err)
+	assert.False(t, acknowledged)
+
+	annotated, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, evictionRequestedValue, annotated.Annotations[testCoordinationAnnotation])
+	assert.Equal(t, evictionRequestedValue, annotated.Annotations[annotations.VpaEvictionRequestedAnnotation])
+
+	// The coordinator didn't acknowledge the request yet.
+	acknowledged, err = coordinator.canEvict(context.Background(), annotated)
+	assert.NoError(t, err)
+	assert.False(t, acknowledged)
+	assert.Equal(t, 1, countPatches(client), "the pod should be annotated only once")
+}
+
+func TestEvictionCoordinatorEvictionPhase(t *testing.T) {
+	pod := test.Pod().WithName("pod").Get()
+	pod.UID = "pod-uid"
+	pod.Annotations = map[string]string{testCoordinationAnnotation: evictionRequestedValue}
+	client := fake.NewSimpleClientset(pod)
+	coordinator := newEvictionCoordinator(client, testCoordinationAnnotation)
+
+	// A pod annotated before the updater restarted is waited for, not annotated again.
+	acknowledged, err := coordinator.canEvict(context.Background(), pod)
+	assert.NoError(t, err)
+	assert.False(t, acknowledged)
+	assert.Equal(t, 0, countPatches(client))
+
+	acknowledgedPod := pod.DeepCopy()
+	acknowledgedPod.Annotations = nil
+	acknowledged, err = coordinator.canEvict(context.Background(), acknowledgedPod)
+	assert.NoError(t, err)
+	assert.True(t, acknowledged)
+
+	// Once the pod is gone, a new pod with the same name needs a new acknowledgement.
+	coordinator.retain([]*apiv1.Pod{})
+	acknowledged, err = coordinator.canEvict(context.Background(), acknowledgedPod)
+	assert.NoError(t, err)
+	assert.False(t, acknowledged)
+}
+
+func TestEvictionCoordinatorAcknowledgedBeforeRestart(t *testing.T) {
+	pod := test.Pod().WithName("pod").Get()
+	pod.UID = "pod-uid"
+	// The request was made by a previous updater, and acknowledged while none was running.
+	pod.Annotations = map[string]string{annotations.VpaEvictionRequestedAnnotation: evictionRequestedValue}
+	client := fake.NewSimpleClientset(pod)
+	coordinator := newEvictionCoordinator(client, testCoordinationAnnotation)
+
+	acknowledged, err := coordinator.canEvict(context.Background(), pod)
+	assert.NoError(t, err)
+	assert.True(t, acknowledged)
+	assert.Equal(t, 0, countPatches(client), "an acknowledged request isn't made again")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/configmap"
+)
+
+// killSwitchDisabledKey is the key of the kill switch ConfigMap which stops all actions when "true".
+const killSwitchDisabledKey = "disabled"
+
+// killSwitch stops all actions of the updater while the kill switch ConfigMap says so, so that
+// SREs can stop VPA cluster-wide in an emergency without editing VPA objects or restarting the updater.
+type killSwitch struct {
+	engaged atomic.Bool
+}
+
+// isEngaged returns true if the updater must not act on any pods.
+func (k *killSwitch) isEngaged() bool {
+	return k != nil && k.engaged.Load()
+}
+
+// set engages the kill switch if the data of the ConfigMap has disabled set to true.
+func (k *killSwitch) set(data map[string]string) {
+	engaged := false
+	if value, found := data[killSwitchDisabledKey]; found {
+		var err error
+		engaged, err = strconv.ParseBool(value)
+		if err != nil {
+			klog.ErrorS(err, "Ignoring invalid value of the kill switch", "key", killSwitchDisabledKey, "value", value)
+		}
+	}
+	if k.engaged.Swap(engaged) != engaged {
+		klog.V(0).InfoS("Kill switch of the updater changed", "engaged", engaged)
+	}
+}
+
+// watchKillSwitch keeps the kill switch in sync with the ConfigMap. If the ConfigMap doesn't exist,
+// the kill switch isn't engaged.
+func watchKillSwitch(kubeClient kube_client.Interface, namespace, name string, stopCh <-chan struct{}, k *killSwitch) error {
+	if err := configmap.Watch(kubeClient, namespace, name, configmap.DefaultSyncTimeout, stopCh, k.set); err != nil {
+		return fmt.Errorf("failed to watch kill switch: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestWatchKillSwitch(t *testing.T) {
+	client := fake.NewClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	k := &killSwitch{}
+	configMap := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpa-killswitch", Namespace: "vpa-system"},
+		Data:       map[string]string{"disabled": "true"},
+	}
+	_, err := client.CoreV1().ConfigMaps("vpa-system").Create(context.TODO(), configMap, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, watchKillSwitch(client, "vpa-system", "vpa-killswitch", stopCh, k))
+	assert.True(t, k.isEngaged(), "the kill switch is read before the first loop")
+
+	configMap.Data = map[string]string{"disabled": "false"}
+	_, err = client.CoreV1().ConfigMaps("vpa-system").Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return !k.isEngaged() }, 5*time.Second, 10*time.Millisecond, "the kill switch is released")
+
+	configMap.Data = map[string]string{"disabled": "true"}
+	_, err = client.CoreV1().ConfigMaps("vpa-system").Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, k.isEngaged, 5*time.Second, 10*time.Millisecond, "the kill switch is engaged again")
+
+	err = client.CoreV1().ConfigMaps("vpa-system").Delete(context.TODO(), "vpa-killswitch", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return !k.isEngaged() }, 5*time.Second, 10*time.Millisecond,
+		"the kill switch is released once the ConfigMap is deleted")
+
+	k.set(map[string]string{"disabled": "maybe"})
+	assert.False(t, k.isEngaged(), "invalid values don't engage the kill switch")
+
+	var disabled *killSwitch
+	assert.False(t, disabled.isEngaged())
+}
+
+func TestWatchKillSwitch_Unreadable(t *testing.T) {
+	client := fake.NewClientset()
+	client.PrependReactor("list", "configmaps", func(core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "vpa-killswitch", nil)
+	})
+	stopCh := make(chan struct{})
+	close(stopCh)
+
+	assert.Error(t, watchKillSwitch(client, "vpa-system", "vpa-killswitch", stopCh, &killSwitch{}),
+		"the updater doesn't start without reading the kill switch")
+}
+
+func TestRunOnce_KillSwitch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 2)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	evicted := 0
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evicted++
+			return nil
+		},
+	}
+	k := &killSwitch{}
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		killSwitch:              k,
+	}
+
+	k.set(map[string]string{"disabled": "true"})
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 0, evicted, "no pods are evicted while the kill switch is engaged")
+	vpaLister.AssertNotCalled(t, "List")
+
+	k.set(map[string]string{"disabled": "false"})
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 2, evicted, "pods are evicted once the kill switch is released")
+}
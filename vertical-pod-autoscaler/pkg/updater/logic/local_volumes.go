@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// LocalVolumePolicy decides how the updater acts on pods using local PersistentVolumes. Such pods
+// can only run on the node of their volumes, so evicting them may leave them pending.
+type LocalVolumePolicy string
+
+const (
+	// LocalVolumePolicyEvict updates pods with local volumes like any other pod.
+	LocalVolumePolicyEvict LocalVolumePolicy = "evict"
+	// LocalVolumePolicyPreferInPlace updates pods with local volumes in-place if possible, and evicts them otherwise.
+	LocalVolumePolicyPreferInPlace LocalVolumePolicy = "prefer-in-place"
+	// LocalVolumePolicyNeverEvict only updates pods with local volumes in-place.
+	LocalVolumePolicyNeverEvict LocalVolumePolicy = "never-evict"
+)
+
+// ParseLocalVolumePolicy returns the LocalVolumePolicy with the given name.
+func ParseLocalVolumePolicy(name string) (LocalVolumePolicy, error) {
+	switch policy := LocalVolumePolicy(name); policy {
+	case LocalVolumePolicyEvict, LocalVolumePolicyPreferInPlace, LocalVolumePolicyNeverEvict:
+		return policy, nil
+	case "":
+		return LocalVolumePolicyEvict, nil
+	}
+	return "", fmt.Errorf("unknown local volume policy %q, expected %s, %s or %s", name,
+		LocalVolumePolicyEvict, LocalVolumePolicyPreferInPlace, LocalVolumePolicyNeverEvict)
+}
+
+// LocalVolumeConfig configures how the updater acts on pods using local PersistentVolumes.
+type LocalVolumeConfig struct {
+	Policy LocalVolumePolicy
+	// PvcLister and PvLister are used to find the volumes of pods. They are only needed if
+	// Policy isn't LocalVolumePolicyEvict.
+	PvcLister v1lister.PersistentVolumeClaimLister
+	PvLister  v1lister.PersistentVolumeLister
+}
+
+// localVolumes finds the pods using local PersistentVolumes and decides how to update them.
+type localVolumes struct {
+	policy    LocalVolumePolicy
+	pvcLister v1lister.PersistentVolumeClaimLister
+	pvLister  v1lister.PersistentVolumeLister
+}
+
+func newLocalVolumes(config LocalVolumeConfig) *localVolumes {
+	return &localVolumes{
+		policy:    config.Policy,
+		pvcLister: config.PvcLister,
+		pvLister:  config.PvLister,
+	}
+}
+
+// updateMode returns the update mode applied to the pod, which avoids evicting it if it uses
+// local volumes. UpdateModeOff means the pod mustn't be updated at all.
+func (l *localVolumes) updateMode(pod *apiv1.Pod, vpaMode vpa_types.UpdateMode) vpa_types.UpdateMode {
+	if l == nil || !l.hasLocalVolume(pod) {
+		return vpaMode
+	}
+	switch vpaMode {
+	case vpa_types.UpdateModeAuto, vpa_types.UpdateModeRecreate, vpa_types.UpdateModeInPlaceOrRecreate:
+		if l.policy == LocalVolumePolicyNeverEvict {
+			return vpa_types.UpdateModeInPlaceOnly
+		}
+		return vpa_types.UpdateModeInPlaceOrRecreate
+	case vpa_types.UpdateModeInitial:
+		// Pods of VPAs in Initial mode are only evicted to correct their drift, never updated in-place.
+		if l.policy == LocalVolumePolicyNeverEvict {
+			return vpa_types.UpdateModeOff
+		}
+	}
+	return vpaMode
+}
+
+// hasLocalVolume returns true if the pod mounts a PersistentVolumeClaim bound to a local PersistentVolume.
+func (l *localVolumes) hasLocalVolume(pod *apiv1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := l.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			klog.V(4).InfoS("Failed to get PersistentVolumeClaim of pod", "pod", klog.KObj(pod), "claim", volume.PersistentVolumeClaim.ClaimName, "error", err)
+			continue
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := l.pvLister.Get(pvc.Spec.VolumeName)
+		if err != nil {
+			klog.V(4).InfoS("Failed to get PersistentVolume of pod", "pod", klog.KObj(pod), "volume", pvc.Spec.VolumeName, "error", err)
+			continue
+		}
+		if pv.Spec.Local != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/features"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+// newLocalVolumeListers returns listers holding a claim bound to a local volume and one bound to a network volume.
+func newLocalVolumeListers(t *testing.T) (v1lister.PersistentVolumeClaimLister, v1lister.PersistentVolumeLister) {
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pv := range []*apiv1.PersistentVolume{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+			Spec: apiv1.PersistentVolumeSpec{
+				PersistentVolumeSource: apiv1.PersistentVolumeSource{Local: &apiv1.LocalVolumeSource{Path: "/mnt/disks/ssd1"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "network-pv"},
+			Spec: apiv1.PersistentVolumeSpec{
+				PersistentVolumeSource: apiv1.PersistentVolumeSource{NFS: &apiv1.NFSVolumeSource{Server: "nfs", Path: "/"}},
+			},
+		},
+	} {
+		assert.NoError(t, pvIndexer.Add(pv))
+	}
+	for claim, volume := range map[string]string{"local-claim": "local-pv", "network-claim": "network-pv", "unbound-claim": ""} {
+		assert.NoError(t, pvcIndexer.Add(&apiv1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claim, Namespace: "default"},
+			Spec:       apiv1.PersistentVolumeClaimSpec{VolumeName: volume},
+		}))
+	}
+	return v1lister.NewPersistentVolumeClaimLister(pvcIndexer), v1lister.NewPersistentVolumeLister(pvIndexer)
+}
+
+func withClaim(pod *apiv1.Pod, claim string) *apiv1.Pod {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+		Name:         claim,
+		VolumeSource: apiv1.VolumeSource{PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+	})
+	return pod
+}
+
+func TestParseLocalVolumePolicy(t *testing.T) {
+	for _, name := range []string{"evict", "prefer-in-place", "never-evict"} {
+		policy, err := ParseLocalVolumePolicy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, LocalVolumePolicy(name), policy)
+	}
+	policy, err := ParseLocalVolumePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, LocalVolumePolicyEvict, policy)
+	_, err = ParseLocalVolumePolicy("skip")
+	assert.Error(t, err)
+}
+
+func TestLocalVolumesUpdateMode(t *testing.T) {
+	pvcLister, pvLister := newLocalVolumeListers(t)
+	newPod := func(claims ...string) *apiv1.Pod {
+		pod := test.Pod().WithName("pod").Get()
+		for _, claim := range claims {
+			withClaim(pod, claim)
+		}
+		return pod
+	}
+	testCases := []struct {
+		name     string
+		policy   LocalVolumePolicy
+		pod      *apiv1.Pod
+		vpaMode  vpa_types.UpdateMode
+		expected vpa_types.UpdateMode
+	}{
+		{
+			name:     "local volume preferring in-place",
+			policy:   LocalVolumePolicyPreferInPlace,
+			pod:      newPod("network-claim", "local-claim"),
+			vpaMode:  vpa_types.UpdateModeRecreate,
+			expected: vpa_types.UpdateModeInPlaceOrRecreate,
+		},
+		{
+			name:     "local volume never evicted",
+			policy:   LocalVolumePolicyNeverEvict,
+			pod:      newPod("local-claim"),
+			vpaMode:  vpa_types.UpdateModeInPlaceOrRecreate,
+			expected: vpa_types.UpdateModeInPlaceOnly,
+		},
+		{
+			name:     "local volume never evicted in Initial mode",
+			policy:   LocalVolumePolicyNeverEvict,
+			pod:      newPod("local-claim"),
+			vpaMode:  vpa_types.UpdateModeInitial,
+			expected: vpa_types.UpdateModeOff,
+		},
+		{
+			name:     "local volume preferring in-place in Initial mode",
+			policy:   LocalVolumePolicyPreferInPlace,
+			pod:      newPod("local-claim"),
+			vpaMode:  vpa_types.UpdateModeInitial,
+			expected: vpa_types.UpdateModeInitial,
+		},
+		{
+			name:     "network volume",
+			policy:   LocalVolumePolicyNeverEvict,
+			pod:      newPod("network-claim"),
+			vpaMode:  vpa_types.UpdateModeRecreate,
+			expected: vpa_types.UpdateModeRecreate,
+		},
+		{
+			name:     "unbound and missing claims",
+			policy:   LocalVolumePolicyNeverEvict,
+			pod:      newPod("unbound-claim", "missing-claim"),
+			vpaMode:  vpa_types.UpdateModeRecreate,
+			expected: vpa_types.UpdateModeRecreate,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			volumes := newLocalVolumes(LocalVolumeConfig{Policy: tc.policy, PvcLister: pvcLister, PvLister: pvLister})
+			assert.Equal(t, tc.expected, volumes.updateMode(tc.pod, tc.vpaMode))
+		})
+	}
+}
+
+func TestRunOnce_LocalVolumes(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+	pvcLister, pvLister := newLocalVolumeListers(t)
+
+	testCases := []struct {
+		name              string
+		policy            LocalVolumePolicy
+		updateMode        vpa_types.UpdateMode
+		vpaAnnotations    map[string]string
+		inPlaceImpossible bool
+		expectedInPlace   []string
+		expectedEvicted   []string
+	}{
+		{
+			name:            "evict",
+			policy:          LocalVolumePolicyEvict,
+			expectedEvicted: []string{"test_0", "test_1"},
+		},
+		{
+			name:            "prefer in-place",
+			policy:          LocalVolumePolicyPreferInPlace,
+			expectedInPlace: []string{"test_0"},
+			expectedEvicted: []string{"test_1"},
+		},
+		{
+			name:              "prefer in-place when in-place is impossible",
+			policy:            LocalVolumePolicyPreferInPlace,
+			inPlaceImpossible: true,
+			expectedEvicted:   []string{"test_0", "test_1"},
+		},
+		{
+			name:              "never evict when in-place is impossible",
+			policy:            LocalVolumePolicyNeverEvict,
+			inPlaceImpossible: true,
+			expectedEvicted:   []string{"test_1"},
+		},
+		{
+			name:            "prefer in-place with eviction preferred",
+			policy:          LocalVolumePolicyPreferInPlace,
+			vpaAnnotations:  map[string]string{annotations.VpaUpdatePreferenceAnnotation: "evict"},
+			expectedInPlace: []string{"test_0"},
+			expectedEvicted: []string{"test_1"},
+		},
+		{
+			name:            "prefer in-place with eviction preferred in InPlaceOrRecreate mode",
+			policy:          LocalVolumePolicyPreferInPlace,
+			updateMode:      vpa_types.UpdateModeInPlaceOrRecreate,
+			vpaAnnotations:  map[string]string{annotations.VpaUpdatePreferenceAnnotation: "evict"},
+			expectedInPlace: []string{"test_0"},
+			expectedEvicted: []string{"test_1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			pods := make([]*apiv1.Pod, 2)
+			for i := range pods {
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+				pods[i].UID = types.UID(pods[i].Name)
+			}
+			withClaim(pods[0], "local-claim")
+			withClaim(pods[1], "network-claim")
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			updateMode := tc.updateMode
+			if updateMode == "" {
+				updateMode = vpa_types.UpdateModeRecreate
+			}
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithUpdateMode(updateMode).
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				WithAnnotations(tc.vpaAnnotations).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+			var inPlaceUpdated, evicted []string
+			eviction := &restriction.FuncPodsEvictionRestriction{
+				EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					evicted = append(evicted, pod.Name)
+					return nil
+				},
+			}
+			inPlace := &restriction.FuncPodsInPlaceRestriction{
+				CanInPlaceUpdateFunc: func(*apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason) {
+					if tc.inPlaceImpossible {
+						return utils.InPlaceEvict, utils.InPlaceReasonUnknownCreator
+					}
+					return utils.InPlaceApproved, utils.InPlaceReasonWithinTolerance
+				},
+				InPlaceUpdateFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					inPlaceUpdated = append(inPlaceUpdated, pod.Name)
+					return nil
+				},
+			}
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inPlace},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				eventRecorder:           record.NewFakeRecorder(10),
+			}
+			if tc.policy != LocalVolumePolicyEvict {
+				updater.localVolumes = newLocalVolumes(LocalVolumeConfig{Policy: tc.policy, PvcLister: pvcLister, PvLister: pvLister})
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()))
+			assert.ElementsMatch(t, tc.expectedInPlace, inPlaceUpdated)
+			assert.ElementsMatch(t, tc.expectedEvicted, evicted)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// loopSummary counts what the updater did in a loop, to log it in a single line at the end.
+type loopSummary struct {
+	start          time.Time
+	vpas           int
+	matchedPods    int
+	evicted        int
+	inPlaceUpdated int
+	failed         int
+	// skipped is the number of pods needing an update which weren't updated, by reason.
+	skipped map[string]int
+}
+
+func newLoopSummary(start time.Time) *loopSummary {
+	return &loopSummary{
+		start:   start,
+		skipped: make(map[string]int),
+	}
+}
+
+// skip counts a pod which wasn't updated for the given reason.
+func (s *loopSummary) skip(reason blockedReason) {
+	if s == nil {
+		return
+	}
+	s.skipped[reason.name()]++
+}
+
+// log emits the summary, taking the end of the loop as now.
+func (s *loopSummary) log(partial bool, now time.Time) {
+	if s == nil {
+		return
+	}
+	msg := "Updater loop finished"
+	if partial {
+		msg = "Retry of errored VPAs finished"
+	}
+	klog.V(1).InfoS(msg, "vpas", s.vpas, "matchedPods", s.matchedPods, "evictedPods", s.evicted,
+		"inPlaceUpdatedPods", s.inPlaceUpdated, "failedPods", s.failed, "skippedPods", s.skipped, "duration", now.Sub(s.start))
+}
+
+// name returns the short name of the reason, e.g. RateLimited.
+func (r blockedReason) name() string {
+	name, _, _ := strings.Cut(string(r), ":")
+	return name
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/configmap"
+)
+
+// namespaceRateLimit is the rate limit of pod updates in a namespace.
+type namespaceRateLimit struct {
+	// QPS is the number of updates per second, 0 or less leaves the namespace subject to
+	// the global rate limit only.
+	QPS   float64
+	Burst int
+}
+
+// parseNamespaceRateLimits parses the data of the rate limits ConfigMap, mapping namespaces
+// to qps:burst pairs, e.g. "team-a: 0.5:2". Invalid entries are ignored.
+func parseNamespaceRateLimits(data map[string]string) map[string]namespaceRateLimit {
+	limits := make(map[string]namespaceRateLimit, len(data))
+	for namespace, value := range data {
+		limit, err := parseNamespaceRateLimit(value)
+		if err != nil {
+			klog.ErrorS(err, "Ignoring invalid namespace rate limit", "namespace", namespace, "value", value)
+			continue
+		}
+		limits[namespace] = limit
+	}
+	return limits
+}
+
+func parseNamespaceRateLimit(value string) (namespaceRateLimit, error) {
+	qpsValue, burstValue, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return namespaceRateLimit{}, fmt.Errorf("%q is not in the qps:burst format", value)
+	}
+	qps, err := strconv.ParseFloat(qpsValue, 64)
+	if err != nil {
+		return namespaceRateLimit{}, fmt.Errorf("invalid qps: %v", err)
+	}
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil {
+		return namespaceRateLimit{}, fmt.Errorf("invalid burst: %v", err)
+	}
+	if qps > 0 && burst < 1 {
+		return namespaceRateLimit{}, fmt.Errorf("burst has to be positive")
+	}
+	return namespaceRateLimit{QPS: qps, Burst: burst}, nil
+}
+
+// namespaceRateLimiters holds the rate limiters of the namespaces with their own rate limit.
+// Pods in these namespaces are paced by both their own and the global rate limiter, pods in
+// other namespaces by the global rate limiter only.
+type namespaceRateLimiters struct {
+	mutex    sync.RWMutex
+	limiters map[string]*rate.Limiter
+}
+
+func newNamespaceRateLimiters() *namespaceRateLimiters {
+	return &namespaceRateLimiters{limiters: make(map[string]*rate.Limiter)}
+}
+
+// get returns the rate limiter pacing pods of the namespace: the given global one, combined
+// with the one of the namespace if it has a rate limit of its own.
+func (n *namespaceRateLimiters) get(namespace string, global rateLimiter) rateLimiter {
+	if n == nil {
+		return global
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	if limiter, found := n.limiters[namespace]; found {
+		return bothRateLimiters{namespace: limiter, global: global}
+	}
+	return global
+}
+
+// setLimits replaces the rate limits of the namespaces. The rate limiters of namespaces which
+// keep a rate limit are adjusted rather than recreated, so that reloads don't reset their bursts.
+func (n *namespaceRateLimiters) setLimits(limits map[string]namespaceRateLimit) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for namespace := range n.limiters {
+		if limit, found := limits[namespace]; !found || limit.QPS <= 0 {
+			delete(n.limiters, namespace)
+		}
+	}
+	for namespace, limit := range limits {
+		if limit.QPS <= 0 {
+			// Like for the global rate limit, 0 means the default: no rate limit of the namespace's own.
+			continue
+		}
+		if limiter, found := n.limiters[namespace]; found {
+			limiter.SetLimit(rate.Limit(limit.QPS))
+			limiter.SetBurst(limit.Burst)
+			continue
+		}
+		n.limiters[namespace] = rate.NewLimiter(rate.Limit(limit.QPS), limit.Burst)
+	}
+}
+
+// bothRateLimiters paces pods of a namespace with a rate limit of its own, so that the namespace
+// can't exceed either its own or the global rate limit.
+type bothRateLimiters struct {
+	namespace *rate.Limiter
+	global    rateLimiter
+}
+
+// Wait waits for a token of the namespace rate limiter, then of the global one.
+func (b bothRateLimiters) Wait(ctx context.Context) error {
+	if err := b.namespace.Wait(ctx); err != nil {
+		return err
+	}
+	return b.global.Wait(ctx)
+}
+
+// Limit returns the stricter of both limits.
+func (b bothRateLimiters) Limit() rate.Limit {
+	return min(b.namespace.Limit(), b.global.Limit())
+}
+
+// Tokens returns the tokens of the rate limiter closer to running out of them. The tokens of a
+// disabled global rate limiter are meaningless, so they don't count.
+func (b bothRateLimiters) Tokens() float64 {
+	if b.global.Limit() == rate.Inf {
+		return b.namespace.Tokens()
+	}
+	return min(b.namespace.Tokens(), b.global.Tokens())
+}
+
+// watchNamespaceRateLimits keeps the rate limits of the given limiters in sync with the ConfigMap.
+// If the ConfigMap doesn't exist, the global rate limits apply to all namespaces.
+func watchNamespaceRateLimits(kubeClient kube_client.Interface, namespace, name string, stopCh <-chan struct{}, limiters ...*namespaceRateLimiters) error {
+	err := configmap.Watch(kubeClient, namespace, name, configmap.DefaultSyncTimeout, stopCh, func(data map[string]string) {
+		limits := parseNamespaceRateLimits(data)
+		klog.V(2).InfoS("Reloading namespace rate limits", "configMap", klog.KRef(namespace, name), "namespaces", len(limits))
+		for _, l := range limiters {
+			l.setLimits(limits)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch namespace rate limits: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseNamespaceRateLimits(t *testing.T) {
+	limits := parseNamespaceRateLimits(map[string]string{
+		"team-a":    "0.5:2",
+		"team-b":    " 0:0 ",
+		"no-burst":  "1:0",
+		"no-colon":  "1",
+		"bad-qps":   "fast:1",
+		"bad-burst": "1:many",
+	})
+	assert.Equal(t, map[string]namespaceRateLimit{
+		"team-a": {QPS: 0.5, Burst: 2},
+		"team-b": {QPS: 0, Burst: 0},
+	}, limits)
+}
+
+func TestNamespaceRateLimiters(t *testing.T) {
+	global := rate.NewLimiter(10, 10)
+	limiters := newNamespaceRateLimiters()
+	assert.Same(t, global, limiters.get("team-a", global), "namespaces fall back to the global rate limiter")
+
+	limiters.setLimits(map[string]namespaceRateLimit{"team-a": {QPS: 0.5, Burst: 2}})
+	teamA := limiters.get("team-a", global).(bothRateLimiters)
+	assert.Same(t, global, teamA.global, "the global rate limiter applies to namespaces with their own rate limit too")
+	assert.Equal(t, rate.Limit(0.5), teamA.namespace.Limit())
+	assert.Equal(t, 2, teamA.namespace.Burst())
+	assert.Same(t, global, limiters.get("team-b", global))
+
+	limiters.setLimits(map[string]namespaceRateLimit{"team-a": {QPS: 2, Burst: 4}, "team-b": {QPS: 0, Burst: 0}})
+	assert.Same(t, teamA.namespace, limiters.get("team-a", global).(bothRateLimiters).namespace, "reloads adjust existing rate limiters")
+	assert.Equal(t, rate.Limit(2), teamA.namespace.Limit())
+	assert.Same(t, global, limiters.get("team-b", global), "a qps of 0 leaves the namespace to the global rate limiter")
+
+	limiters.setLimits(map[string]namespaceRateLimit{"team-a": {QPS: 0, Burst: 0}})
+	assert.Same(t, global, limiters.get("team-a", global), "a qps of 0 removes the rate limit of the namespace")
+
+	limiters.setLimits(map[string]namespaceRateLimit{"team-a": {QPS: 1, Burst: 1}})
+	limiters.setLimits(nil)
+	assert.Same(t, global, limiters.get("team-a", global), "namespaces removed from the limits fall back to the global rate limiter")
+
+	var disabled *namespaceRateLimiters
+	assert.Same(t, global, disabled.get("team-a", global))
+}
+
+func TestBothRateLimiters(t *testing.T) {
+	global := rate.NewLimiter(10, 1)
+	limiter := bothRateLimiters{namespace: rate.NewLimiter(1, 2), global: global}
+	assert.Equal(t, rate.Limit(1), limiter.Limit())
+	assert.False(t, isOutOfTokens(limiter))
+
+	assert.NoError(t, limiter.Wait(context.TODO()))
+	assert.True(t, isOutOfTokens(limiter), "the global rate limiter runs out of tokens although the namespace has one left")
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond)
+	defer cancel()
+	assert.Error(t, limiter.Wait(ctx), "both rate limiters have to hand out a token")
+
+	unlimited := bothRateLimiters{namespace: rate.NewLimiter(1, 1), global: rate.NewLimiter(rate.Inf, 0)}
+	assert.False(t, isOutOfTokens(unlimited))
+	assert.NoError(t, unlimited.Wait(context.TODO()))
+	assert.True(t, isOutOfTokens(unlimited), "the namespace rate limit applies without a global one")
+}
+
+func TestWatchNamespaceRateLimits(t *testing.T) {
+	client := fake.NewClientset()
+	global := rate.NewLimiter(10, 10)
+	limiters := newNamespaceRateLimiters()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	limitOf := func(namespace string) rate.Limit {
+		return limiters.get(namespace, global).Limit()
+	}
+	configMap := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpa-rate-limits", Namespace: "kube-system"},
+		Data:       map[string]string{"team-a": "0.5:2"},
+	}
+	_, err := client.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), configMap, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, watchNamespaceRateLimits(client, "kube-system", "vpa-rate-limits", stopCh, limiters))
+	assert.Equal(t, rate.Limit(0.5), limitOf("team-a"), "the rate limits are read before the first loop")
+
+	configMap.Data = map[string]string{"team-a": "2:4"}
+	_, err = client.CoreV1().ConfigMaps("kube-system").Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return limitOf("team-a") == 2 }, 5*time.Second, 10*time.Millisecond, "changes are reloaded")
+
+	err = client.CoreV1().ConfigMaps("kube-system").Delete(context.TODO(), "vpa-rate-limits", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return limitOf("team-a") == 10 }, 5*time.Second, 10*time.Millisecond,
+		"the global rate limiter applies once the ConfigMap is deleted")
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"time"
+
+	"k8s.io/utils/clock"
+
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+)
+
+// Phases of the updater loop, whose durations are recorded by the phaseTimer.
+const (
+	// phaseList is spent listing VPA objects and pods.
+	phaseList = "list"
+	// phaseFetch is spent fetching the selectors and controllers matching VPA objects to pods.
+	phaseFetch = "fetch"
+	// phasePrioritize is spent deciding which pods of VPA objects to update, and in which order.
+	phasePrioritize = "prioritize"
+	// phaseAct is spent updating pods.
+	phaseAct = "act"
+)
+
+// phaseTimer sums the time spent in each phase of a loop, which the loop goes through once per VPA
+// object for some phases.
+type phaseTimer struct {
+	clock     clock.PassiveClock
+	current   string
+	since     time.Time
+	durations map[string]time.Duration
+}
+
+func newPhaseTimer(clock clock.PassiveClock) *phaseTimer {
+	return &phaseTimer{clock: clock, durations: make(map[string]time.Duration)}
+}
+
+// enter ends the current phase, if any, and starts the given one.
+func (p *phaseTimer) enter(phase string) {
+	now := p.clock.Now()
+	if p.current != "" {
+		p.durations[p.current] += now.Sub(p.since)
+	}
+	p.current, p.since = phase, now
+}
+
+// observe ends the current phase and records the durations of the phases the loop went through.
+func (p *phaseTimer) observe() {
+	p.enter("")
+	for phase, duration := range p.durations {
+		metrics_updater.RecordPhaseDuration(phase, duration)
+	}
+}
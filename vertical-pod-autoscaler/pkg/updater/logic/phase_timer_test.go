@@ -0,0 +1,143 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+// gatherPhaseDurations returns the number of observations and the sum of each phase of the phase duration histogram.
+// The histogram is shared by the tests, so they compare the values gathered before and after running.
+func gatherPhaseDurations(t *testing.T, registry *prometheus.Registry) (map[string]uint64, map[string]float64) {
+	metricFamilies, err := registry.Gather()
+	assert.NoError(t, err)
+	counts, sums := make(map[string]uint64), make(map[string]float64)
+	for _, mf := range metricFamilies {
+		if mf.GetName() != "vpa_updater_phase_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "phase" {
+					counts[label.GetValue()] += m.GetHistogram().GetSampleCount()
+					sums[label.GetValue()] += m.GetHistogram().GetSampleSum()
+				}
+			}
+		}
+	}
+	return counts, sums
+}
+
+func TestPhaseTimer(t *testing.T) {
+	registry := registerTestMetrics(t)
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	phases := newPhaseTimer(fakeClock)
+	countsBefore, sumsBefore := gatherPhaseDurations(t, registry)
+
+	phases.enter(phaseList)
+	fakeClock.Step(time.Second)
+	phases.enter(phasePrioritize)
+	fakeClock.Step(2 * time.Second)
+	phases.enter(phaseAct)
+	fakeClock.Step(3 * time.Second)
+	phases.enter(phasePrioritize)
+	fakeClock.Step(4 * time.Second)
+	phases.observe()
+
+	counts, sums := gatherPhaseDurations(t, registry)
+	for phase, expected := range map[string]float64{phaseList: 1, phaseFetch: 0, phasePrioritize: 6, phaseAct: 3} {
+		if expected == 0 {
+			assert.Equal(t, countsBefore[phase], counts[phase], "phases the loop didn't go through are not observed")
+			continue
+		}
+		assert.Equal(t, countsBefore[phase]+1, counts[phase], "phase %s is observed once per loop", phase)
+		assert.InDelta(t, expected, sums[phase]-sumsBefore[phase], 0.001, "durations of phase %s entered several times are summed", phase)
+	}
+}
+
+func TestRunOnce_PhaseDurations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	registry := registerTestMetrics(t)
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 2)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: &restriction.FuncPodsEvictionRestriction{}, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+
+	countsBefore, _ := gatherPhaseDurations(t, registry)
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	counts, _ := gatherPhaseDurations(t, registry)
+	for _, phase := range []string{phaseList, phaseFetch, phasePrioritize, phaseAct} {
+		assert.Equal(t, countsBefore[phase]+1, counts[phase], "phase %s is observed once per loop", phase)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+)
+
+// BackoffStrategy decides how long the updater waits before acting on a Pod again
+// after its eviction or in-place update failed.
+type BackoffStrategy interface {
+	// Backoff returns the delay after the given number of consecutive failures, which is at least 1.
+	Backoff(failures int) time.Duration
+}
+
+// NewBackoffStrategy returns the BackoffStrategy with the given name, "linear" or "exponential",
+// or nil for "none", which disables the backoff.
+func NewBackoffStrategy(name string, delay, maxDelay time.Duration) (BackoffStrategy, error) {
+	switch name {
+	case "none", "":
+		return nil, nil
+	case "linear":
+		return NewLinearBackoff(delay, maxDelay), nil
+	case "exponential":
+		return NewExponentialBackoff(delay, maxDelay), nil
+	}
+	return nil, fmt.Errorf("unknown backoff strategy %q, expected none, linear or exponential", name)
+}
+
+// NewLinearBackoff returns a BackoffStrategy whose delay grows by step with every failure,
+// up to maxDelay. A maxDelay of 0 leaves the delay uncapped.
+func NewLinearBackoff(step, maxDelay time.Duration) BackoffStrategy {
+	return &linearBackoff{step: step, maxDelay: maxDelay}
+}
+
+type linearBackoff struct {
+	step     time.Duration
+	maxDelay time.Duration
+}
+
+func (l *linearBackoff) Backoff(failures int) time.Duration {
+	if l.step <= 0 {
+		return 0
+	}
+	if l.maxDelay > 0 && time.Duration(failures) > l.maxDelay/l.step {
+		return l.maxDelay
+	}
+	return time.Duration(failures) * l.step
+}
+
+// NewExponentialBackoff returns a BackoffStrategy whose delay starts at base and doubles with every
+// further failure, up to maxDelay. A maxDelay of 0 leaves the delay uncapped.
+func NewExponentialBackoff(base, maxDelay time.Duration) BackoffStrategy {
+	return &exponentialBackoff{base: base, maxDelay: maxDelay}
+}
+
+type exponentialBackoff struct {
+	base     time.Duration
+	maxDelay time.Duration
+}
+
+func (e *exponentialBackoff) Backoff(failures int) time.Duration {
+	delay := e.base
+	// Stop doubling before the delay overflows.
+	for i := 1; i < failures && delay > 0 && delay < time.Duration(math.MaxInt64/2); i++ {
+		if e.maxDelay > 0 && delay >= e.maxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if e.maxDelay > 0 && delay > e.maxDelay {
+		return e.maxDelay
+	}
+	return delay
+}
+
+type podFailures struct {
+	count      int
+	retryAfter time.Time
+}
+
+// podBackoff keeps Pods whose eviction or in-place update failed from being acted on again
+// until the delay given by the strategy passes.
+type podBackoff struct {
+	strategy BackoffStrategy
+	clock    clock.PassiveClock
+	failures map[types.UID]podFailures
+}
+
+func newPodBackoff(strategy BackoffStrategy) *podBackoff {
+	return &podBackoff{
+		strategy: strategy,
+		clock:    clock.RealClock{},
+		failures: make(map[types.UID]podFailures),
+	}
+}
+
+// inBackoff returns true if the Pod failed recently and must not be acted on yet.
+func (b *podBackoff) inBackoff(pod *apiv1.Pod) bool {
+	if b == nil {
+		return false
+	}
+	failures, found := b.failures[pod.UID]
+	return found && b.clock.Now().Before(failures.retryAfter)
+}
+
+// recordFailure extends the backoff of the Pod.
+func (b *podBackoff) recordFailure(pod *apiv1.Pod) {
+	if b == nil {
+		return
+	}
+	failures := b.failures[pod.UID]
+	failures.count++
+	failures.retryAfter = b.clock.Now().Add(b.strategy.Backoff(failures.count))
+	b.failures[pod.UID] = failures
+}
+
+// recordSuccess resets the backoff of the Pod.
+func (b *podBackoff) recordSuccess(pod *apiv1.Pod) {
+	if b == nil {
+		return
+	}
+	delete(b.failures, pod.UID)
+}
+
+// retain forgets the failures of Pods which are gone.
+func (b *podBackoff) retain(livePods []*apiv1.Pod) {
+	if b == nil {
+		return
+	}
+	live := make(map[types.UID]bool, len(livePods))
+	for _, pod := range livePods {
+		live[pod.UID] = true
+	}
+	for uid := range b.failures {
+		if !live[uid] {
+			delete(b.failures, uid)
+		}
+	}
+}
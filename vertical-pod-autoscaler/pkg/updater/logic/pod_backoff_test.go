@@ -0,0 +1,194 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy BackoffStrategy
+		expected []time.Duration
+	}{
+		{
+			name:     "linear",
+			strategy: NewLinearBackoff(2*time.Minute, 5*time.Minute),
+			expected: []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		{
+			name:     "linear without cap",
+			strategy: NewLinearBackoff(time.Minute, 0),
+			expected: []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute},
+		},
+		{
+			name:     "exponential",
+			strategy: NewExponentialBackoff(time.Minute, 5*time.Minute),
+			expected: []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		{
+			name:     "exponential without cap",
+			strategy: NewExponentialBackoff(time.Second, 0),
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected, tc.strategy.Backoff(i+1), "delay after %d failures", i+1)
+			}
+		})
+	}
+	assert.Positive(t, NewExponentialBackoff(time.Second, 0).Backoff(1000), "the delay doesn't overflow")
+}
+
+func TestNewBackoffStrategy(t *testing.T) {
+	strategy, err := NewBackoffStrategy("none", time.Minute, time.Hour)
+	assert.NoError(t, err)
+	assert.Nil(t, strategy)
+
+	strategy, err = NewBackoffStrategy("linear", time.Minute, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Minute, strategy.Backoff(3))
+
+	strategy, err = NewBackoffStrategy("exponential", time.Minute, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Minute, strategy.Backoff(3))
+
+	_, err = NewBackoffStrategy("random", time.Minute, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestPodBackoff(t *testing.T) {
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	backoff := newPodBackoff(NewExponentialBackoff(time.Minute, time.Hour))
+	backoff.clock = fakeClock
+	pod := test.Pod().WithName("pod").Get()
+	pod.UID = types.UID("pod")
+	other := test.Pod().WithName("other").Get()
+	other.UID = types.UID("other")
+
+	backoff.recordFailure(pod)
+	assert.True(t, backoff.inBackoff(pod))
+	assert.False(t, backoff.inBackoff(other), "only the failed pod backs off")
+	fakeClock.Step(time.Minute)
+	assert.False(t, backoff.inBackoff(pod))
+
+	backoff.recordFailure(pod)
+	fakeClock.Step(time.Minute)
+	assert.True(t, backoff.inBackoff(pod), "the delay grows with consecutive failures")
+	fakeClock.Step(time.Minute)
+	assert.False(t, backoff.inBackoff(pod))
+
+	backoff.recordFailure(pod)
+	backoff.recordSuccess(pod)
+	assert.False(t, backoff.inBackoff(pod), "a success resets the backoff")
+
+	backoff.recordFailure(pod)
+	backoff.retain([]*apiv1.Pod{other})
+	assert.False(t, backoff.inBackoff(pod), "failures of pods which are gone are forgotten")
+
+	var disabled *podBackoff
+	disabled.recordFailure(pod)
+	assert.False(t, disabled.inBackoff(pod))
+}
+
+func TestRunOnce_PodBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 2)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		pods[i].UID = types.UID(pods[i].Name)
+		eviction.On("CanEvict", pods[i]).Return(true)
+	}
+	eviction.On("Evict", pods[0], nil).Return(errors.New("webhook unavailable"))
+	eviction.On("Evict", pods[1], nil).Return(nil)
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(3)
+
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	backoff := newPodBackoff(NewLinearBackoff(time.Minute, 0))
+	backoff.clock = fakeClock
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		podBackoff:              backoff,
+	}
+
+	assert.Error(t, updater.RunOnce(context.Background()))
+	eviction.AssertNumberOfCalls(t, "Evict", 2)
+
+	assert.NoError(t, updater.RunOnce(context.Background()), "the pod whose eviction failed is skipped")
+	eviction.AssertNumberOfCalls(t, "Evict", 3)
+
+	fakeClock.Step(time.Minute)
+	assert.Error(t, updater.RunOnce(context.Background()), "the eviction is retried once the backoff passed")
+	eviction.AssertNumberOfCalls(t, "Evict", 5)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+)
+
+// PodPredicate decides whether the updater may act on a Pod which needs an update. If it returns
+// false, the updater leaves the Pod alone in this loop and reports the returned reason, which should
+// be a short CamelCase word like "CIRunning" as it is used as a metric label.
+type PodPredicate func(pod *apiv1.Pod) (bool, string)
+
+// admittedByPredicates returns true if all predicates let the updater act on the pod. Otherwise it
+// records the reason of the first predicate refusing the pod and returns false. The refusal is
+// reported with a single event on the pod until its reason changes.
+func (u *updater) admittedByPredicates(pod *apiv1.Pod) bool {
+	for _, predicate := range u.podPredicates {
+		ok, reason := predicate(pod)
+		if ok {
+			continue
+		}
+		klog.V(4).InfoS("Not updating pod, refused by a custom predicate", "pod", klog.KObj(pod), "reason", reason)
+		metrics_updater.RecordBlockedByPredicate(reason)
+		u.blockPod(pod, blockedReason(fmt.Sprintf("%s: the pod was refused by a custom predicate", reason)))
+		if reported, found := u.predicateRefusals[pod.UID]; found && reported == reason {
+			return false
+		}
+		if u.predicateRefusals == nil {
+			u.predicateRefusals = make(map[types.UID]string)
+		}
+		u.predicateRefusals[pod.UID] = reason
+		if u.eventRecorder != nil {
+			u.eventRecorder.Event(pod, apiv1.EventTypeNormal, "UpdateRefusedByPredicate",
+				fmt.Sprintf("VPA Updater didn't update the pod because a custom predicate refused it: %s", reason))
+		}
+		return false
+	}
+	delete(u.predicateRefusals, pod.UID)
+	return true
+}
+
+// retainPredicateRefusals forgets the refusals reported on pods which are gone.
+func (u *updater) retainPredicateRefusals(livePods []*apiv1.Pod) {
+	if len(u.predicateRefusals) == 0 {
+		return
+	}
+	live := make(map[types.UID]bool, len(livePods))
+	for _, pod := range livePods {
+		live[pod.UID] = true
+	}
+	for uid := range u.predicateRefusals {
+		if !live[uid] {
+			delete(u.predicateRefusals, uid)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRunOnce_PodPredicates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		pods[i].UID = types.UID(pods[i].Name)
+	}
+	pods[0].Annotations = map[string]string{"ci.example.com/running": "true"}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(2)
+
+	var evicted []*apiv1.Pod
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+			evicted = append(evicted, pod)
+			return nil
+		},
+	}
+	var checked []*apiv1.Pod
+	fakeRecorder := record.NewFakeRecorder(10)
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		eventRecorder:           fakeRecorder,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		podPredicates: []PodPredicate{
+			func(pod *apiv1.Pod) (bool, string) {
+				return pod.Annotations["ci.example.com/running"] != "true", "CIRunning"
+			},
+			func(pod *apiv1.Pod) (bool, string) {
+				checked = append(checked, pod)
+				return pod != pods[1], "Pinned"
+			},
+		},
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, []*apiv1.Pod{pods[2]}, evicted, "only the pod admitted by all predicates is evicted")
+	assert.NotContains(t, checked, pods[0], "the first refusing predicate short-circuits the others")
+	assert.Equal(t, map[string]int{"CIRunning": 1, "Pinned": 1}, updater.loopSummary.skipped)
+	assert.ElementsMatch(t, []string{
+		"Normal UpdateRefusedByPredicate VPA Updater didn't update the pod because a custom predicate refused it: CIRunning",
+		"Normal UpdateRefusedByPredicate VPA Updater didn't update the pod because a custom predicate refused it: Pinned",
+	}, recordedEvents(fakeRecorder))
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, map[string]int{"CIRunning": 1, "Pinned": 1}, updater.loopSummary.skipped, "the pods are refused again")
+	assert.Empty(t, recordedEvents(fakeRecorder), "refusals already reported aren't reported again")
+}
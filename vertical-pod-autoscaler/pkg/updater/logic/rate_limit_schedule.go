@@ -0,0 +1,190 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// rateLimiter paces pod updates.
+type rateLimiter interface {
+	Wait(ctx context.Context) error
+	Limit() rate.Limit
+	Tokens() float64
+}
+
+// isOutOfTokens returns true if the rate limiter has no token left, so that the next update has to wait.
+func isOutOfTokens(limiter rateLimiter) bool {
+	tokens := limiter.Tokens()
+	return limiter.Limit() != rate.Inf && tokens < 1
+}
+
+// RateLimitSchedule changes the rate limit over a window which starts every day at the same
+// wall-clock time.
+type RateLimitSchedule struct {
+	// Hour and Minute give the time of day, in Location, at which the window starts.
+	Hour, Minute int
+	Location     *time.Location
+	// Steps are sorted by their Offset, which is shorter than a day.
+	Steps []RateLimitScheduleStep
+}
+
+// RateLimitScheduleStep sets the rate limit applied from Offset after the start of the window
+// until the Offset of the next step, or until the window starts again.
+type RateLimitScheduleStep struct {
+	Offset time.Duration
+	// QPS is the number of updates per second, 0 or less disables the rate limit.
+	QPS   float64
+	Burst int
+}
+
+// ParseRateLimitSchedule parses a comma-separated list of offset:qps:burst steps,
+// e.g. "0s:10:20,10m:1:5", applied every day from start, given as HH:MM in timeZone.
+// It returns nil if schedule is empty.
+func ParseRateLimitSchedule(schedule, start, timeZone string) (*RateLimitSchedule, error) {
+	if schedule == "" {
+		return nil, nil
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, fmt.Errorf("start of rate limit schedule %q is not in the HH:MM format", start)
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone of rate limit schedule %q: %v", timeZone, err)
+	}
+	var steps []RateLimitScheduleStep
+	for _, s := range strings.Split(schedule, ",") {
+		parts := strings.Split(strings.TrimSpace(s), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("rate limit schedule step %q is not in the offset:qps:burst format", s)
+		}
+		offset, err := time.ParseDuration(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset in rate limit schedule step %q: %v", s, err)
+		}
+		if offset < 0 || offset >= 24*time.Hour {
+			return nil, fmt.Errorf("offset in rate limit schedule step %q has to be at least 0 and shorter than a day", s)
+		}
+		qps, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid qps in rate limit schedule step %q: %v", s, err)
+		}
+		burst, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst in rate limit schedule step %q: %v", s, err)
+		}
+		if qps > 0 && burst < 1 {
+			return nil, fmt.Errorf("burst in rate limit schedule step %q has to be positive", s)
+		}
+		steps = append(steps, RateLimitScheduleStep{Offset: offset, QPS: qps, Burst: burst})
+	}
+	slices.SortFunc(steps, func(a, b RateLimitScheduleStep) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	return &RateLimitSchedule{
+		Hour:     startTime.Hour(),
+		Minute:   startTime.Minute(),
+		Location: location,
+		Steps:    steps,
+	}, nil
+}
+
+// windowStart returns the latest start of the window at or before now.
+func (s *RateLimitSchedule) windowStart(now time.Time) time.Time {
+	now = now.In(s.Location)
+	start := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, s.Minute, 0, 0, s.Location)
+	if start.After(now) {
+		start = time.Date(now.Year(), now.Month(), now.Day()-1, s.Hour, s.Minute, 0, 0, s.Location)
+	}
+	return start
+}
+
+// scheduledRateLimiter adjusts the limit and burst of a rate limiter as the wall-clock time
+// passes the offsets of the schedule. Before the first offset of each window, the limit the
+// rate limiter was created with applies.
+type scheduledRateLimiter struct {
+	*rate.Limiter
+	schedule *RateLimitSchedule
+	clock    clock.PassiveClock
+	// limit and burst are the ones the rate limiter was created with.
+	limit rate.Limit
+	burst int
+	// applied is the index of the step in effect, -1 before the first one.
+	applied int
+}
+
+func newScheduledRateLimiter(limiter *rate.Limiter, schedule *RateLimitSchedule, clock clock.PassiveClock) *scheduledRateLimiter {
+	s := &scheduledRateLimiter{
+		Limiter:  limiter,
+		schedule: schedule,
+		clock:    clock,
+		limit:    limiter.Limit(),
+		burst:    limiter.Burst(),
+		applied:  -1,
+	}
+	s.adjust()
+	return s
+}
+
+// Wait applies the step of the schedule in effect and waits for the rate limiter.
+func (s *scheduledRateLimiter) Wait(ctx context.Context) error {
+	s.adjust()
+	return s.Limiter.Wait(ctx)
+}
+
+// Tokens applies the step of the schedule in effect and returns the number of tokens available now.
+func (s *scheduledRateLimiter) Tokens() float64 {
+	s.adjust()
+	return s.Limiter.Tokens()
+}
+
+func (s *scheduledRateLimiter) adjust() {
+	now := s.clock.Now()
+	elapsed := now.Sub(s.schedule.windowStart(now))
+	step := -1
+	for step+1 < len(s.schedule.Steps) && s.schedule.Steps[step+1].Offset <= elapsed {
+		step++
+	}
+	if step == s.applied {
+		return
+	}
+	s.applied = step
+	if step < 0 {
+		klog.V(2).InfoS("Restoring rate limit before the first step of the schedule", "limit", s.limit, "burst", s.burst)
+		s.SetLimit(s.limit)
+		s.SetBurst(s.burst)
+		return
+	}
+	limit := rate.Limit(s.schedule.Steps[step].QPS)
+	if s.schedule.Steps[step].QPS <= 0 {
+		limit = rate.Inf
+	}
+	klog.V(2).InfoS("Adjusting rate limit according to the schedule", "offset", s.schedule.Steps[step].Offset, "qps", s.schedule.Steps[step].QPS, "burst", s.schedule.Steps[step].Burst)
+	s.SetLimit(limit)
+	s.SetBurst(s.schedule.Steps[step].Burst)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	baseclocktest "k8s.io/utils/clock/testing"
+)
+
+func TestParseRateLimitSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	testCases := []struct {
+		name             string
+		schedule         string
+		start            string
+		timeZone         string
+		expectedSchedule *RateLimitSchedule
+		expectError      bool
+	}{
+		{
+			name:     "empty schedule",
+			schedule: "",
+			start:    "00:00",
+			timeZone: "UTC",
+		},
+		{
+			name:     "steps are sorted by offset",
+			schedule: "10m:1:5, 0s:10:20,30m:0:0",
+			start:    "22:30",
+			timeZone: "Europe/Berlin",
+			expectedSchedule: &RateLimitSchedule{
+				Hour:     22,
+				Minute:   30,
+				Location: berlin,
+				Steps: []RateLimitScheduleStep{
+					{Offset: 0, QPS: 10, Burst: 20},
+					{Offset: 10 * time.Minute, QPS: 1, Burst: 5},
+					{Offset: 30 * time.Minute, QPS: 0, Burst: 0},
+				},
+			},
+		},
+		{
+			name:        "missing burst",
+			schedule:    "0s:10",
+			start:       "00:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "invalid offset",
+			schedule:    "10:1:5",
+			start:       "00:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "negative offset",
+			schedule:    "-1m:1:5",
+			start:       "00:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "offset of a day",
+			schedule:    "24h:1:5",
+			start:       "00:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "zero burst with a rate limit",
+			schedule:    "0s:1:0",
+			start:       "00:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "invalid start",
+			schedule:    "0s:1:1",
+			start:       "25:00",
+			timeZone:    "UTC",
+			expectError: true,
+		},
+		{
+			name:        "unknown time zone",
+			schedule:    "0s:1:1",
+			start:       "00:00",
+			timeZone:    "Mars/Olympus_Mons",
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseRateLimitSchedule(tc.schedule, tc.start, tc.timeZone)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSchedule, schedule)
+		})
+	}
+}
+
+func TestScheduledRateLimiter(t *testing.T) {
+	schedule, err := ParseRateLimitSchedule("1m:10:20,10m:2:5,30m:0:0", "12:00", "Europe/Berlin")
+	assert.NoError(t, err)
+	// 12:00 in Berlin.
+	windowStart := time.Date(2025, time.March, 1, 11, 0, 0, 0, time.UTC)
+	fakeClock := baseclocktest.NewFakeClock(windowStart)
+	limiter := newScheduledRateLimiter(getRateLimiter(1, 1), schedule, fakeClock)
+
+	assertLimit := func(limiter *scheduledRateLimiter, expectedLimit rate.Limit, expectedBurst int, msg string) {
+		assert.NoError(t, limiter.Wait(context.Background()))
+		assert.Equal(t, expectedLimit, limiter.Limit(), msg)
+		assert.Equal(t, expectedBurst, limiter.Burst(), msg)
+	}
+
+	assertLimit(limiter, 1, 1, "the initial limit applies before the first offset")
+	fakeClock.Step(time.Minute)
+	assertLimit(limiter, 10, 20, "the first step applies at its offset")
+	fakeClock.Step(8 * time.Minute)
+	assertLimit(limiter, 10, 20, "the first step applies until the next offset")
+	fakeClock.Step(time.Minute)
+	assertLimit(limiter, 2, 5, "the second step applies at its offset")
+
+	restarted := newScheduledRateLimiter(getRateLimiter(1, 1), schedule, fakeClock)
+	assertLimit(restarted, 2, 5, "offsets are measured from the start of the window, not from the start of the updater")
+
+	fakeClock.Step(time.Hour)
+	assertLimit(limiter, rate.Inf, 0, "a step without qps disables the rate limit")
+	fakeClock.SetTime(windowStart.Add(24 * time.Hour))
+	assertLimit(limiter, 1, 1, "the initial limit applies again once the window starts the next day")
+}
+
+func TestIsOutOfTokens(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	assert.False(t, isOutOfTokens(limiter))
+	assert.True(t, limiter.Allow())
+	assert.True(t, isOutOfTokens(limiter), "the burst is used up")
+	assert.False(t, isOutOfTokens(rate.NewLimiter(rate.Inf, 0)), "a disabled rate limiter never runs out of tokens")
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// RecommendationSnapshot holds the recommendations of VPA objects at a point in time.
+type RecommendationSnapshot map[types.NamespacedName]*vpa_types.RecommendedPodResources
+
+// NewRecommendationSnapshot takes a snapshot of the current recommendations of the given VPA objects.
+func NewRecommendationSnapshot(vpas []*vpa_types.VerticalPodAutoscaler) RecommendationSnapshot {
+	snapshot := make(RecommendationSnapshot, len(vpas))
+	for _, vpa := range vpas {
+		snapshot[types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}] = vpa.Status.Recommendation
+	}
+	return snapshot
+}
+
+// LoadRecommendationSnapshot reads a snapshot from a file holding a list of VPA objects
+// in JSON, e.g. the output of `kubectl get vpa --all-namespaces -o json`.
+func LoadRecommendationSnapshot(path string) (RecommendationSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recommendation snapshot: %v", err)
+	}
+	var vpaList vpa_types.VerticalPodAutoscalerList
+	if err := json.Unmarshal(data, &vpaList); err != nil {
+		return nil, fmt.Errorf("failed to parse recommendation snapshot: %v", err)
+	}
+	vpas := make([]*vpa_types.VerticalPodAutoscaler, 0, len(vpaList.Items))
+	for i := range vpaList.Items {
+		vpas = append(vpas, &vpaList.Items[i])
+	}
+	return NewRecommendationSnapshot(vpas), nil
+}
+
+// RecommendationChange describes how the recommendation of a single VPA object changed between two snapshots.
+type RecommendationChange struct {
+	VPA types.NamespacedName
+	// Previous is nil if the VPA object had no recommendation in the previous snapshot.
+	Previous *vpa_types.RecommendedPodResources
+	// Current is nil if the VPA object has no recommendation in the current snapshot.
+	Current *vpa_types.RecommendedPodResources
+	// WouldUpdate is true if pods running with the previous recommendation would be updated
+	// to the current one.
+	WouldUpdate bool
+}
+
+// DiffRecommendationSnapshots returns the changes between two snapshots, sorted by VPA object.
+// Whether a change would trigger an update is decided by the same priority calculation the
+// updater uses for live pods, for a long-running pod requesting the previous recommendation.
+// The defaults of the priority package are used if updateConfig is nil.
+func DiffRecommendationSnapshots(previous, current RecommendationSnapshot,
+	recommendationProcessor vpa_api_util.RecommendationProcessor,
+	priorityProcessor priority.PriorityProcessor,
+	updateConfig *priority.UpdateConfig) []RecommendationChange {
+	keys := make([]types.NamespacedName, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range previous {
+		if _, found := current[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	changes := []RecommendationChange{}
+	for _, key := range keys {
+		if apiequality.Semantic.DeepEqual(previous[key], current[key]) {
+			continue
+		}
+		change := RecommendationChange{VPA: key, Previous: previous[key], Current: current[key]}
+		if change.Current != nil {
+			change.WouldUpdate = wouldUpdate(key, change.Previous, change.Current, recommendationProcessor, priorityProcessor, updateConfig)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func wouldUpdate(key types.NamespacedName, previous, current *vpa_types.RecommendedPodResources,
+	recommendationProcessor vpa_api_util.RecommendationProcessor,
+	priorityProcessor priority.PriorityProcessor,
+	updateConfig *priority.UpdateConfig) bool {
+	vpa := &vpa_types.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Status:     vpa_types.VerticalPodAutoscalerStatus{Recommendation: current},
+	}
+	calculator := priority.NewUpdatePriorityCalculator(vpa, updateConfig, recommendationProcessor, priorityProcessor)
+	calculator.AddPod(podRequestingRecommendation(key, previous, current), time.Now())
+	return len(calculator.GetSortedPods(priority.NewDefaultPodEvictionAdmission())) > 0
+}
+
+// podRequestingRecommendation returns a pod whose containers request the targets of the
+// previous recommendation, with a container for every container of the current recommendation.
+func podRequestingRecommendation(key types.NamespacedName, previous, current *vpa_types.RecommendedPodResources) *apiv1.Pod {
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Status:     apiv1.PodStatus{StartTime: &metav1.Time{}},
+	}
+	for _, containerRecommendation := range current.ContainerRecommendations {
+		container := apiv1.Container{Name: containerRecommendation.ContainerName}
+		if previousRecommendation := vpa_api_util.GetRecommendationForContainer(container.Name, previous); previousRecommendation != nil {
+			container.Resources.Requests = previousRecommendation.Target.DeepCopy()
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, container)
+	}
+	return pod
+}
+
+func (u *updater) reportRecommendationChanges(vpas []*vpa_api_util.VpaWithSelector) {
+	current := make([]*vpa_types.VerticalPodAutoscaler, 0, len(vpas))
+	for _, vpa := range vpas {
+		current = append(current, vpa.Vpa)
+	}
+	changes := DiffRecommendationSnapshots(u.recommendationSnapshot, NewRecommendationSnapshot(current), u.recommendationProcessor, u.priorityProcessor, u.updateConfig)
+	for _, change := range changes {
+		klog.V(0).InfoS("Recommendation changed since snapshot", "vpa", change.VPA, "wouldUpdate", change.WouldUpdate,
+			"previous", change.Previous, "current", change.Current)
+	}
+	klog.V(0).InfoS("Dry-run comparison against recommendation snapshot finished, no pods were updated", "changes", len(changes))
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestDiffRecommendationSnapshots(t *testing.T) {
+	grown := types.NamespacedName{Namespace: "default", Name: "grown"}
+	slightlyGrown := types.NamespacedName{Namespace: "default", Name: "slightly-grown"}
+	unchanged := types.NamespacedName{Namespace: "default", Name: "unchanged"}
+	added := types.NamespacedName{Namespace: "default", Name: "added"}
+	removed := types.NamespacedName{Namespace: "default", Name: "removed"}
+
+	previous := RecommendationSnapshot{
+		grown:         test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+		slightlyGrown: test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+		unchanged:     test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+		removed:       test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+	}
+	current := RecommendationSnapshot{
+		grown:         test.Recommendation().WithContainer("c").WithTarget("2", "100M").Get(),
+		slightlyGrown: test.Recommendation().WithContainer("c").WithTarget("1050m", "100M").Get(),
+		unchanged:     test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+		added:         test.Recommendation().WithContainer("c").WithTarget("1", "100M").Get(),
+	}
+
+	changes := DiffRecommendationSnapshots(previous, current, &test.FakeRecommendationProcessor{}, priority.NewProcessor(), nil)
+
+	assert.Equal(t, []RecommendationChange{
+		{VPA: added, Current: current[added], WouldUpdate: true},
+		{VPA: grown, Previous: previous[grown], Current: current[grown], WouldUpdate: true},
+		{VPA: removed, Previous: previous[removed]},
+		{VPA: slightlyGrown, Previous: previous[slightlyGrown], Current: current[slightlyGrown], WouldUpdate: false},
+	}, changes)
+}
+
+func TestLoadRecommendationSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vpas.json")
+	snapshotJSON := `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [{
+    "apiVersion": "autoscaling.k8s.io/v1",
+    "kind": "VerticalPodAutoscaler",
+    "metadata": {"name": "vpa", "namespace": "default"},
+    "status": {"recommendation": {"containerRecommendations": [{"containerName": "c", "target": {"cpu": "1", "memory": "100M"}}]}}
+  }]
+}`
+	assert.NoError(t, os.WriteFile(path, []byte(snapshotJSON), 0o600))
+
+	snapshot, err := LoadRecommendationSnapshot(path)
+	assert.NoError(t, err)
+	assert.Len(t, snapshot, 1)
+	recommendation := snapshot[types.NamespacedName{Namespace: "default", Name: "vpa"}]
+	if assert.NotNil(t, recommendation) && assert.Len(t, recommendation.ContainerRecommendations, 1) {
+		assert.Equal(t, "c", recommendation.ContainerRecommendations[0].ContainerName)
+		assert.Equal(t, "1", recommendation.ContainerRecommendations[0].Target.Cpu().String())
+		assert.Equal(t, "100M", recommendation.ContainerRecommendations[0].Target.Memory().String())
+	}
+
+	_, err = LoadRecommendationSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
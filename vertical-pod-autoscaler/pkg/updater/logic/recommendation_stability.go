@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"math"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// RecommendationStabilityConfig configures how long the recommendation for a pod has to be stable
+// before the pod is updated.
+type RecommendationStabilityConfig struct {
+	// Loops is the number of consecutive loops the recommendation has to stay within Tolerance.
+	// A value of 1 or less disables the stability gate.
+	Loops int
+	// Tolerance is the fraction by which the target of a container resource may differ from the one
+	// seen when the recommendation became stable.
+	Tolerance float64
+}
+
+// stableTargets are the recommendation targets of the containers of a pod since they became stable.
+type stableTargets struct {
+	targets map[string]apiv1.ResourceList
+	// loops is the number of consecutive loops the targets stayed within the tolerance, including the first one.
+	loops int
+}
+
+// recommendationStability keeps pods from being updated until the recommendation for their containers
+// stayed within a tolerance for a number of consecutive loops, so that the updater doesn't act on a
+// recommendation which is still oscillating.
+type recommendationStability struct {
+	config RecommendationStabilityConfig
+	pods   map[types.UID]*stableTargets
+}
+
+func newRecommendationStability(config RecommendationStabilityConfig) *recommendationStability {
+	return &recommendationStability{
+		config: config,
+		pods:   make(map[types.UID]*stableTargets),
+	}
+}
+
+// observe records the recommendation for the pod seen in this loop.
+func (s *recommendationStability) observe(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) {
+	if s == nil {
+		return
+	}
+	targets := containerTargets(recommendation)
+	stable, found := s.pods[pod.UID]
+	if found && s.withinTolerance(stable.targets, targets) {
+		stable.loops++
+		return
+	}
+	s.pods[pod.UID] = &stableTargets{targets: targets, loops: 1}
+}
+
+// isStable returns true if the recommendation for the pod stayed within the tolerance for enough loops.
+func (s *recommendationStability) isStable(pod *apiv1.Pod) bool {
+	if s == nil {
+		return true
+	}
+	stable, found := s.pods[pod.UID]
+	return found && stable.loops >= s.config.Loops
+}
+
+// retain forgets the recommendations for pods which are gone.
+func (s *recommendationStability) retain(livePods []*apiv1.Pod) {
+	if s == nil {
+		return
+	}
+	live := make(map[types.UID]bool, len(livePods))
+	for _, pod := range livePods {
+		live[pod.UID] = true
+	}
+	for uid := range s.pods {
+		if !live[uid] {
+			delete(s.pods, uid)
+		}
+	}
+}
+
+// withinTolerance returns true if the targets have the same containers and resources as the reference
+// and differ from them by at most the tolerance.
+func (s *recommendationStability) withinTolerance(reference, targets map[string]apiv1.ResourceList) bool {
+	if len(reference) != len(targets) {
+		return false
+	}
+	for container, referenceResources := range reference {
+		resources, found := targets[container]
+		if !found || len(resources) != len(referenceResources) {
+			return false
+		}
+		for resourceName, referenceValue := range referenceResources {
+			value, found := resources[resourceName]
+			if !found {
+				return false
+			}
+			base := math.Max(float64(referenceValue.MilliValue()), 1)
+			if math.Abs(float64(value.MilliValue()-referenceValue.MilliValue()))/base > s.config.Tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containerTargets(recommendation *vpa_types.RecommendedPodResources) map[string]apiv1.ResourceList {
+	targets := make(map[string]apiv1.ResourceList)
+	if recommendation == nil {
+		return targets
+	}
+	for _, containerRecommendation := range recommendation.ContainerRecommendations {
+		targets[containerRecommendation.ContainerName] = containerRecommendation.Target.DeepCopy()
+	}
+	return targets
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRecommendationStability(t *testing.T) {
+	stability := newRecommendationStability(RecommendationStabilityConfig{Loops: 3, Tolerance: 0.1})
+	pod := test.Pod().WithName("pod").Get()
+	pod.UID = types.UID("pod")
+	other := test.Pod().WithName("other").Get()
+	other.UID = types.UID("other")
+	recommendation := func(cpu string) *vpa_types.RecommendedPodResources {
+		return test.Recommendation().WithContainer("container").WithTarget(cpu, "100M").Get()
+	}
+
+	assert.False(t, stability.isStable(pod), "a pod never observed isn't stable")
+	stability.observe(pod, recommendation("1"))
+	assert.False(t, stability.isStable(pod))
+	stability.observe(pod, recommendation("1050m"))
+	assert.False(t, stability.isStable(pod))
+	stability.observe(pod, recommendation("950m"))
+	assert.True(t, stability.isStable(pod), "changes within the tolerance count as stable")
+
+	stability.observe(pod, recommendation("2"))
+	assert.False(t, stability.isStable(pod), "a change beyond the tolerance starts over")
+	stability.observe(pod, recommendation("2"))
+	withSidecar := recommendation("2")
+	withSidecar.ContainerRecommendations = append(withSidecar.ContainerRecommendations,
+		test.Recommendation().WithContainer("sidecar").WithTarget("1", "100M").GetContainerResources())
+	stability.observe(pod, withSidecar)
+	assert.False(t, stability.isStable(pod), "a new container starts over")
+
+	stability.retain([]*apiv1.Pod{other})
+	stability.observe(pod, recommendation("1"))
+	stability.observe(pod, recommendation("1"))
+	assert.False(t, stability.isStable(pod), "recommendations of pods which are gone are forgotten")
+
+	var disabled *recommendationStability
+	disabled.observe(pod, recommendation("1"))
+	assert.True(t, disabled.isStable(pod))
+}
+
+func TestRunOnce_RecommendationStability(t *testing.T) {
+	testCases := []struct {
+		name            string
+		targets         []string
+		expectedEvicted []int
+	}{
+		{
+			name:            "fluctuating recommendation",
+			targets:         []string{"2", "4", "2", "4", "2", "4"},
+			expectedEvicted: []int{0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:            "stable recommendation",
+			targets:         []string{"2", "2", "2", "2"},
+			expectedEvicted: []int{0, 0, 2, 4},
+		},
+		{
+			name:            "recommendation which settles",
+			targets:         []string{"4", "2", "2", "2"},
+			expectedEvicted: []int{0, 0, 0, 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			pods := make([]*apiv1.Pod, 2)
+			for i := range pods {
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+				pods[i].UID = types.UID(pods[i].Name)
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).AnyTimes()
+
+			evicted := 0
+			eviction := &restriction.FuncPodsEvictionRestriction{
+				EvictFunc: func(_ *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					evicted++
+					return nil
+				},
+			}
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				recommendationStability: newRecommendationStability(RecommendationStabilityConfig{Loops: 3, Tolerance: 0.1}),
+			}
+
+			for i, target := range tc.targets {
+				vpaObj.Status.Recommendation = test.Recommendation().WithContainer(containerName).WithTarget(target, "200M").Get()
+				assert.NoError(t, updater.RunOnce(context.Background()))
+				assert.Equal(t, tc.expectedEvicted[i], evicted, "evictions after loop %d", i+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"slices"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+)
+
+// maxRescheduleWait is how long an eviction waits for a ready replacement pod before it's
+// forgotten, e.g. because the controller was scaled down in the meantime.
+const maxRescheduleWait = time.Hour
+
+// pendingReschedules holds the evictions of pods of one controller which weren't matched
+// with a ready replacement pod yet.
+type pendingReschedules struct {
+	// evictedAt holds the eviction times, oldest first.
+	evictedAt []time.Time
+	// replacements holds the pods already matched with an eviction.
+	replacements sets.Set[types.UID]
+}
+
+// rescheduleTracker measures the time from the eviction of a pod until a replacement pod
+// of the same controller becomes ready. A ready pod created after an eviction is matched
+// with the oldest eviction of its controller not matched yet.
+type rescheduleTracker struct {
+	clock   clock.PassiveClock
+	pending map[metav1.OwnerReference]*pendingReschedules
+	// recordLatency records the latency of a matched eviction.
+	recordLatency func(latency time.Duration)
+}
+
+func newRescheduleTracker() *rescheduleTracker {
+	return &rescheduleTracker{
+		clock:         clock.RealClock{},
+		pending:       make(map[metav1.OwnerReference]*pendingReschedules),
+		recordLatency: metrics_updater.RecordRescheduleLatency,
+	}
+}
+
+// ownerKey identifies the controller of the pod, ignoring the fields of the reference
+// which don't identify it.
+func ownerKey(pod *apiv1.Pod) (metav1.OwnerReference, bool) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return metav1.OwnerReference{}, false
+	}
+	return metav1.OwnerReference{APIVersion: owner.APIVersion, Kind: owner.Kind, Name: owner.Name, UID: owner.UID}, true
+}
+
+// recordEviction remembers that the pod was evicted now.
+func (t *rescheduleTracker) recordEviction(pod *apiv1.Pod) {
+	key, found := ownerKey(pod)
+	if !found {
+		return
+	}
+	pending, found := t.pending[key]
+	if !found {
+		pending = &pendingReschedules{replacements: sets.New[types.UID]()}
+		t.pending[key] = pending
+	}
+	pending.evictedAt = append(pending.evictedAt, t.clock.Now())
+	// The evicted pod may still be ready for a while, it mustn't be taken for its replacement.
+	pending.replacements.Insert(pod.UID)
+}
+
+// observe matches the ready pods with pending evictions of their controllers and records
+// the reschedule latency of the matched evictions.
+func (t *rescheduleTracker) observe(pods []*apiv1.Pod) {
+	if len(t.pending) == 0 {
+		return
+	}
+	now := t.clock.Now()
+	candidates := make([]*apiv1.Pod, 0)
+	for _, pod := range pods {
+		if key, found := ownerKey(pod); found && t.pending[key] != nil && !t.pending[key].replacements.Has(pod.UID) {
+			candidates = append(candidates, pod)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b *apiv1.Pod) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+
+	for _, pod := range candidates {
+		readySince, ready := podReadySince(pod)
+		if !ready {
+			continue
+		}
+		key, _ := ownerKey(pod)
+		pending := t.pending[key]
+		if len(pending.evictedAt) == 0 {
+			continue
+		}
+		evictedAt := pending.evictedAt[0]
+		// Creation timestamps are truncated to seconds.
+		if pod.CreationTimestamp.Time.Before(evictedAt.Truncate(time.Second)) {
+			continue
+		}
+		pending.evictedAt = pending.evictedAt[1:]
+		pending.replacements.Insert(pod.UID)
+		if readySince.Before(evictedAt) {
+			readySince = now
+		}
+		t.recordLatency(readySince.Sub(evictedAt))
+	}
+
+	for key, pending := range t.pending {
+		pending.evictedAt = slices.DeleteFunc(pending.evictedAt, func(evictedAt time.Time) bool {
+			return now.Sub(evictedAt) > maxRescheduleWait
+		})
+		if len(pending.evictedAt) == 0 {
+			delete(t.pending, key)
+		}
+	}
+}
+
+// podReadySince returns the time the pod became ready, if it is ready.
+func podReadySince(pod *apiv1.Pod) (time.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRescheduleTracker(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := baseclocktest.NewFakeClock(start)
+	tracker := newRescheduleTracker()
+	tracker.clock = fakeClock
+	var latencies []time.Duration
+	tracker.recordLatency = func(latency time.Duration) {
+		latencies = append(latencies, latency)
+	}
+
+	rs := metav1.ObjectMeta{Name: "rs", Namespace: "default", UID: "rs-uid"}
+	otherRs := metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other-uid"}
+	typeMeta := metav1.TypeMeta{Kind: "ReplicaSet"}
+	pod := func(name string, creator metav1.ObjectMeta, created time.Time, readySince *time.Time) *apiv1.Pod {
+		p := test.Pod().WithName(name).WithCreator(&creator, &typeMeta).Get()
+		p.UID = types.UID(name)
+		p.CreationTimestamp = metav1.NewTime(created)
+		if readySince != nil {
+			p.Status.Conditions = []apiv1.PodCondition{{
+				Type:               apiv1.PodReady,
+				Status:             apiv1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(*readySince),
+			}}
+		}
+		return p
+	}
+
+	readyBefore := start.Add(-time.Hour)
+	evicted := pod("evicted", rs, readyBefore, &readyBefore)
+	sibling := pod("sibling", rs, readyBefore, &readyBefore)
+	tracker.recordEviction(evicted)
+
+	// The evicted pod is still terminating and the replacement isn't ready yet.
+	fakeClock.Step(10 * time.Second)
+	replacement := pod("replacement", rs, fakeClock.Now(), nil)
+	tracker.observe([]*apiv1.Pod{evicted, sibling, replacement})
+	assert.Empty(t, latencies, "pods which aren't ready or were created before the eviction aren't replacements")
+
+	fakeClock.Step(30 * time.Second)
+	readySince := start.Add(35 * time.Second)
+	replacement = pod("replacement", rs, start.Add(10*time.Second), &readySince)
+	unrelated := pod("unrelated", otherRs, start.Add(10*time.Second), &readySince)
+	tracker.observe([]*apiv1.Pod{sibling, replacement, unrelated})
+	assert.Equal(t, []time.Duration{35 * time.Second}, latencies)
+
+	fakeClock.Step(30 * time.Second)
+	tracker.observe([]*apiv1.Pod{sibling, replacement, unrelated})
+	assert.Equal(t, []time.Duration{35 * time.Second}, latencies, "the replacement is only counted once")
+	assert.Empty(t, tracker.pending)
+}
+
+func TestRescheduleTracker_ForgetsOldEvictions(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := baseclocktest.NewFakeClock(start)
+	tracker := newRescheduleTracker()
+	tracker.clock = fakeClock
+
+	rs := metav1.ObjectMeta{Name: "rs", Namespace: "default", UID: "rs-uid"}
+	evicted := test.Pod().WithName("evicted").WithCreator(&rs, &metav1.TypeMeta{Kind: "ReplicaSet"}).Get()
+	tracker.recordEviction(evicted)
+	tracker.observe(nil)
+	assert.Len(t, tracker.pending, 1)
+
+	fakeClock.Step(maxRescheduleWait + time.Second)
+	tracker.observe(nil)
+	assert.Empty(t, tracker.pending, "evictions without a replacement are forgotten eventually")
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	resourcehelpers "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/resources"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// resourceLimitsPrefix is the prefix of the resources of quotas limiting the limits of pods, e.g. limits.cpu.
+const resourceLimitsPrefix = "limits."
+
+// resourceQuotas keeps the updater from evicting pods whose recreated replacement wouldn't fit in
+// the ResourceQuotas of their namespace, which would keep their controller from recreating them.
+// Only quotas without scopes are checked, both on requests and limits. The growth of the usage of
+// evicted pods is reserved for the rest of the loop, as the usage of the quotas is only updated later.
+type resourceQuotas struct {
+	lister   v1lister.ResourceQuotaLister
+	reserved map[types.NamespacedName]apiv1.ResourceList
+	// admitted holds the growth of the usage of the quotas of admitted pods, reserved once they are evicted.
+	admitted map[types.UID]map[types.NamespacedName]apiv1.ResourceList
+	// reported holds the quota already reported on each pod it keeps from being evicted.
+	reported map[types.UID]string
+}
+
+func newResourceQuotas(lister v1lister.ResourceQuotaLister) *resourceQuotas {
+	return &resourceQuotas{
+		lister:   lister,
+		reserved: make(map[types.NamespacedName]apiv1.ResourceList),
+		admitted: make(map[types.UID]map[types.NamespacedName]apiv1.ResourceList),
+		reported: make(map[types.UID]string),
+	}
+}
+
+// reset forgets the reservations of the previous loop.
+func (r *resourceQuotas) reset() {
+	if r == nil {
+		return
+	}
+	r.reserved = make(map[types.NamespacedName]apiv1.ResourceList)
+	r.admitted = make(map[types.UID]map[types.NamespacedName]apiv1.ResourceList)
+}
+
+// admit returns the name of a ResourceQuota of the namespace of the pod which can't accommodate
+// the growth of its usage under the recommendation, or an empty string if all of them can. The
+// growth of the usage of an admitted pod is only reserved once reserve is called after its eviction.
+func (r *resourceQuotas) admit(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	delete(r.admitted, pod.UID)
+	growth := quotaUsageGrowth(vpa, pod, recommendation)
+	if len(growth) == 0 {
+		return "", nil
+	}
+	quotas, err := r.lister.ResourceQuotas(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	admitted := make(map[types.NamespacedName]apiv1.ResourceList)
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		key := types.NamespacedName{Namespace: quota.Namespace, Name: quota.Name}
+		reserved := r.reserved[key]
+		for name, hard := range quota.Status.Hard {
+			delta, found := growth[name]
+			if !found {
+				continue
+			}
+			used := quota.Status.Used[name].DeepCopy()
+			used.Add(reserved[name])
+			used.Add(delta)
+			if used.Cmp(hard) > 0 {
+				return quota.Name, nil
+			}
+			if admitted[key] == nil {
+				admitted[key] = make(apiv1.ResourceList)
+			}
+			admitted[key][name] = delta
+		}
+	}
+	r.admitted[pod.UID] = admitted
+	return "", nil
+}
+
+// reserve reserves the growth of the usage of the quotas of an admitted pod once it's evicted.
+func (r *resourceQuotas) reserve(pod *apiv1.Pod) {
+	if r == nil {
+		return
+	}
+	for key, growth := range r.admitted[pod.UID] {
+		reserved := r.reserved[key]
+		if reserved == nil {
+			reserved = make(apiv1.ResourceList)
+			r.reserved[key] = reserved
+		}
+		for name, delta := range growth {
+			total := reserved[name]
+			total.Add(delta)
+			reserved[name] = total
+		}
+	}
+	delete(r.admitted, pod.UID)
+}
+
+// report returns true if the quota keeping the pod from being evicted wasn't reported on it yet.
+func (r *resourceQuotas) report(pod *apiv1.Pod, quota string) bool {
+	if quota == "" {
+		delete(r.reported, pod.UID)
+		return false
+	}
+	if r.reported[pod.UID] == quota {
+		return false
+	}
+	r.reported[pod.UID] = quota
+	return true
+}
+
+// quotaUsageGrowth returns by how much the pod grows the usage of each resource of a quota, e.g. requests.cpu
+// or limits.memory, when it's recreated with the recommendation. Limits only grow for containers whose VPA
+// controls them, proportionally to their requests.
+func quotaUsageGrowth(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) apiv1.ResourceList {
+	growth := make(apiv1.ResourceList)
+	add := func(name apiv1.ResourceName, quantity, current resource.Quantity) {
+		delta := quantity.DeepCopy()
+		delta.Sub(current)
+		total := growth[name]
+		total.Add(delta)
+		growth[name] = total
+	}
+	for _, container := range pod.Spec.Containers {
+		containerRecommendation := vpa_api_util.GetRecommendationForContainer(container.Name, recommendation)
+		if containerRecommendation == nil {
+			continue
+		}
+		requests, limits := resourcehelpers.ContainerRequestsAndLimits(container.Name, pod)
+		for name, target := range containerRecommendation.Target {
+			add(apiv1.ResourceName(apiv1.DefaultResourceRequestsPrefix+string(name)), target, requests[name])
+			if name == apiv1.ResourceCPU || name == apiv1.ResourceMemory || name == apiv1.ResourceEphemeralStorage {
+				// Quotas limit the requests of standard resources by their bare name too.
+				add(name, target, requests[name])
+			}
+		}
+		if vpa_api_util.GetContainerControlledValues(container.Name, vpa.Spec.ResourcePolicy) != vpa_types.ContainerControlledValuesRequestsAndLimits {
+			continue
+		}
+		proportionalLimits, _ := vpa_api_util.GetProportionalLimit(limits, requests, containerRecommendation.Target, nil)
+		for name, limit := range proportionalLimits {
+			add(apiv1.ResourceName(resourceLimitsPrefix+string(name)), limit, limits[name])
+		}
+	}
+	for name, delta := range growth {
+		if delta.Sign() <= 0 {
+			delete(growth, name)
+		}
+	}
+	return growth
+}
+
+// exceededResourceQuota returns the name of the ResourceQuota keeping the pod from being evicted,
+// or an empty string if it may be evicted.
+func (u *updater) exceededResourceQuota(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod) string {
+	if u.resourceQuotas == nil {
+		return ""
+	}
+	recommendation, _, err := u.recommendationProcessor.Apply(vpa, pod)
+	if err != nil {
+		klog.V(4).InfoS("Cannot process recommendation for pod, not checking its ResourceQuotas", "pod", klog.KObj(pod), "error", err)
+		return ""
+	}
+	quota, err := u.resourceQuotas.admit(vpa, pod, recommendation)
+	if err != nil {
+		klog.V(2).InfoS("Failed to list ResourceQuotas, not checking them", "namespace", pod.Namespace, "error", err)
+		return ""
+	}
+	if u.resourceQuotas.report(pod, quota) {
+		u.eventRecorder.Event(pod, apiv1.EventTypeWarning, "EvictionDeferredByResourceQuota",
+			fmt.Sprintf("VPA Updater didn't evict the pod because the recommended resources wouldn't fit in ResourceQuota %s.", quota))
+	}
+	if quota != "" {
+		klog.V(2).InfoS("Not evicting pod, its new resources would exceed a ResourceQuota", "pod", klog.KObj(pod), "resourceQuota", quota)
+		u.blockPod(pod, blockedByResourceQuota)
+	}
+	return quota
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func newResourceQuotaLister(t *testing.T, quotas ...*apiv1.ResourceQuota) v1lister.ResourceQuotaLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, quota := range quotas {
+		assert.NoError(t, indexer.Add(quota))
+	}
+	return v1lister.NewResourceQuotaLister(indexer)
+}
+
+func newResourceQuota(name string, hard, used apiv1.ResourceList) *apiv1.ResourceQuota {
+	return &apiv1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     apiv1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestResourceQuotasAdmit(t *testing.T) {
+	newPod := func(name string) *apiv1.Pod {
+		pod := test.Pod().WithName(name).AddContainer(test.Container().WithName("container1").
+			WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).
+			WithCPULimit(resource.MustParse("2")).Get()).Get()
+		pod.Namespace = "default"
+		pod.UID = types.UID(name)
+		return pod
+	}
+	recommendation := test.Recommendation().WithContainer("container1").WithTarget("2", "100M").Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer("container1").Get()
+	requestsOnly := vpa_types.ContainerControlledValuesRequestsOnly
+	requestsOnlyVpa := test.VerticalPodAutoscaler().WithContainer("container1").Get()
+	requestsOnlyVpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+		{ContainerName: "container1", ControlledValues: &requestsOnly},
+	}}
+	scoped := newResourceQuota("scoped",
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("1")},
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("1")})
+	scoped.Spec.Scopes = []apiv1.ResourceQuotaScope{apiv1.ResourceQuotaScopeBestEffort}
+
+	testCases := []struct {
+		name           string
+		quotas         []*apiv1.ResourceQuota
+		vpa            *vpa_types.VerticalPodAutoscaler
+		recommendation *vpa_types.RecommendedPodResources
+		expected       []string
+	}{
+		{
+			name:           "without quotas",
+			recommendation: recommendation,
+			expected:       []string{"", ""},
+		},
+		{
+			name: "with room for both pods",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("compute",
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("4")},
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")})},
+			recommendation: recommendation,
+			expected:       []string{"", ""},
+		},
+		{
+			name: "with room for one pod",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("compute",
+				apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("3")},
+				apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2")})},
+			recommendation: recommendation,
+			expected:       []string{"", "compute"},
+		},
+		{
+			name: "without room",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("compute",
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")},
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")})},
+			recommendation: recommendation,
+			expected:       []string{"compute", "compute"},
+		},
+		{
+			name: "shrinking recommendation",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("compute",
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")},
+				apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")})},
+			recommendation: test.Recommendation().WithContainer("container1").WithTarget("500m", "100M").Get(),
+			expected:       []string{"", ""},
+		},
+		{
+			name: "without room for a resource which doesn't grow",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("memory",
+				apiv1.ResourceList{apiv1.ResourceRequestsMemory: resource.MustParse("200M")},
+				apiv1.ResourceList{apiv1.ResourceRequestsMemory: resource.MustParse("200M")})},
+			recommendation: recommendation,
+			expected:       []string{"", ""},
+		},
+		{
+			name: "with room for one pod in a limits quota",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("limits",
+				apiv1.ResourceList{apiv1.ResourceLimitsCPU: resource.MustParse("6")},
+				apiv1.ResourceList{apiv1.ResourceLimitsCPU: resource.MustParse("4")})},
+			recommendation: recommendation,
+			expected:       []string{"", "limits"},
+		},
+		{
+			name: "without room in a limits quota for limits which aren't controlled",
+			quotas: []*apiv1.ResourceQuota{newResourceQuota("limits",
+				apiv1.ResourceList{apiv1.ResourceLimitsCPU: resource.MustParse("4")},
+				apiv1.ResourceList{apiv1.ResourceLimitsCPU: resource.MustParse("4")})},
+			vpa:            requestsOnlyVpa,
+			recommendation: recommendation,
+			expected:       []string{"", ""},
+		},
+		{
+			name:           "with a scoped quota",
+			quotas:         []*apiv1.ResourceQuota{scoped},
+			recommendation: recommendation,
+			expected:       []string{"", ""},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quotas := newResourceQuotas(newResourceQuotaLister(t, tc.quotas...))
+			if tc.vpa == nil {
+				tc.vpa = vpa
+			}
+			for i, expected := range tc.expected {
+				pod := newPod("pod" + strconv.Itoa(i))
+				quota, err := quotas.admit(tc.vpa, pod, tc.recommendation)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, quota, "pod %d", i)
+				quotas.reserve(pod)
+			}
+		})
+	}
+
+	quotas := newResourceQuotas(newResourceQuotaLister(t, newResourceQuota("compute",
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("3")},
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")})))
+	quota, _ := quotas.admit(vpa, newPod("pod0"), recommendation)
+	assert.Empty(t, quota)
+	quota, _ = quotas.admit(vpa, newPod("pod1"), recommendation)
+	assert.Empty(t, quota, "the growth of pods which weren't evicted isn't reserved")
+	quotas.reserve(newPod("pod1"))
+	quotas.reset()
+	quota, _ = quotas.admit(vpa, newPod("pod0"), recommendation)
+	assert.Empty(t, quota, "reservations of the previous loop are forgotten")
+}
+
+func TestRunOnce_ResourceQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 2)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		pods[i].Namespace = "default"
+		pods[i].UID = types.UID(pods[i].Name)
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(2)
+
+	evicted := 0
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evicted++
+			return nil
+		},
+	}
+	quotaLister := newResourceQuotaLister(t, newResourceQuota("compute",
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")},
+		apiv1.ResourceList{apiv1.ResourceRequestsCPU: resource.MustParse("2")}))
+	fakeRecorder := record.NewFakeRecorder(10)
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		eventRecorder:           fakeRecorder,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		resourceQuotas:          newResourceQuotas(quotaLister),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 0, evicted, "pods are not evicted while the quota has no room for their new requests")
+	assert.Equal(t, map[string]int{"ResourceQuota": 2}, updater.loopSummary.skipped)
+	assert.Equal(t, []string{
+		"Warning EvictionDeferredByResourceQuota VPA Updater didn't evict the pod because the recommended resources wouldn't fit in ResourceQuota compute.",
+		"Warning EvictionDeferredByResourceQuota VPA Updater didn't evict the pod because the recommended resources wouldn't fit in ResourceQuota compute.",
+	}, recordedEvents(fakeRecorder))
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 0, evicted)
+	assert.Empty(t, recordedEvents(fakeRecorder), "pods kept from being evicted by the same quota are reported once")
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// notSafeToEvict returns true, and blocks the Pod, if the Pod reports the safe-to-evict condition
+// but not as True. Pods without the condition are not held back.
+func (u *updater) notSafeToEvict(pod *apiv1.Pod) bool {
+	if u.safeToEvictCondition == "" {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != u.safeToEvictCondition {
+			continue
+		}
+		if condition.Status == apiv1.ConditionTrue {
+			return false
+		}
+		klog.V(4).InfoS("Not evicting pod, it reports it isn't safe to evict", "pod", klog.KObj(pod), "condition", condition.Type, "status", condition.Status)
+		u.blockPod(pod, blockedByNotSafeToEvict)
+		return true
+	}
+	return false
+}
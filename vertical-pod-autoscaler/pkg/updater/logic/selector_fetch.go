@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// newSelectorFetchBackoff returns the backoff between attempts to fetch the selector of a VPA
+// object within a loop, making at most retries more attempts after the first one fails.
+func newSelectorFetchBackoff(retries int) wait.Backoff {
+	return wait.Backoff{
+		Duration: 100 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    retries + 1,
+	}
+}
+
+// fetchSelector fetches the selector of the VPA object, retrying transient errors with a backoff so
+// that a single failure doesn't keep the VPA object from being processed in this loop.
+// Selectors of well-known controllers are read from informer caches, so only the fetches of the
+// scale subresource of other targets fail with transient errors of the API server.
+func (u *updater) fetchSelector(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler) (labels.Selector, error) {
+	if u.selectorFetchBackoff.Steps <= 1 {
+		return u.selectorFetcher.Fetch(ctx, vpa)
+	}
+	var selector labels.Selector
+	var lastErr error
+	attempt := 0
+	err := wait.ExponentialBackoffWithContext(ctx, u.selectorFetchBackoff, func(ctx context.Context) (bool, error) {
+		attempt++
+		selector, lastErr = u.selectorFetcher.Fetch(ctx, vpa)
+		if lastErr == nil {
+			return true, nil
+		}
+		if !isTransientError(lastErr) {
+			return false, lastErr
+		}
+		klog.V(4).InfoS("Failed to fetch selector of VPA object, retrying", "vpa", klog.KObj(vpa), "attempt", attempt, "error", lastErr)
+		return false, nil
+	})
+	if err != nil && lastErr != nil {
+		// Report the error of the fetcher rather than the one of the backoff running out of attempts.
+		return nil, lastErr
+	}
+	return selector, err
+}
+
+// isTransientError returns true if the error may be gone when retrying the request which failed.
+func isTransientError(err error) bool {
+	if apierrors.IsInternalError(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) ||
+		utilnet.IsProbableEOF(err) || utilnet.IsConnectionReset(err) {
+		return true
+	}
+	if _, shouldRetry := apierrors.SuggestsClientDelay(err); shouldRetry {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
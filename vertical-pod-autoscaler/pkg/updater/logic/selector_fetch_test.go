@@ -0,0 +1,170 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, isTransientError(apierrors.NewServiceUnavailable("unavailable")))
+	assert.True(t, isTransientError(apierrors.NewTooManyRequests("throttled", 1)))
+	assert.True(t, isTransientError(apierrors.NewTimeoutError("timeout", 1)))
+	assert.True(t, isTransientError(context.DeadlineExceeded))
+	assert.True(t, isTransientError(fmt.Errorf("unhandled targetRef, last error %w", apierrors.NewServiceUnavailable("unavailable"))),
+		"errors of the API server wrapped by the selector fetcher")
+	assert.False(t, isTransientError(apierrors.NewNotFound(v1.Resource("deployments"), "web")))
+	assert.False(t, isTransientError(errors.New("unsupported target")))
+}
+
+func TestRunOnce_SelectorFetchRetry(t *testing.T) {
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+
+	testCases := []struct {
+		name            string
+		fetchErr        error
+		retries         int
+		expectedFetches int
+		expectedEvicted int
+	}{
+		{
+			name:            "transient error is retried",
+			fetchErr:        apierrors.NewServiceUnavailable("scale subresource unavailable"),
+			retries:         2,
+			expectedFetches: 2,
+			expectedEvicted: 2,
+		},
+		{
+			name:            "permanent error is not retried",
+			fetchErr:        errors.New("unsupported target"),
+			retries:         2,
+			expectedFetches: 1,
+			expectedEvicted: 0,
+		},
+		{
+			name:            "retries disabled",
+			fetchErr:        apierrors.NewServiceUnavailable("scale subresource unavailable"),
+			retries:         0,
+			expectedFetches: 1,
+			expectedEvicted: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			pods := make([]*apiv1.Pod, 2)
+			for i := range pods {
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			gomock.InOrder(
+				mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(nil, tc.fetchErr),
+				mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(tc.expectedFetches-1),
+			)
+
+			evicted := 0
+			backoff := newSelectorFetchBackoff(tc.retries)
+			backoff.Duration = time.Millisecond
+			updater := &updater{
+				vpaLister: vpaLister,
+				podLister: podLister,
+				restrictionFactory: &restriction.FakePodsRestrictionFactory{
+					Eviction: &restriction.FuncPodsEvictionRestriction{EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+						evicted++
+						return nil
+					}},
+					InPlace: &restriction.FuncPodsInPlaceRestriction{},
+				},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				selectorFetchBackoff:    backoff,
+			}
+
+			_ = updater.RunOnce(context.Background())
+			assert.Equal(t, tc.expectedEvicted, evicted)
+		})
+	}
+}
+
+func TestFetchSelectorHonorsContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vpaObj := test.VerticalPodAutoscaler().WithContainer("container1").Get()
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(nil, apierrors.NewServiceUnavailable("unavailable")).MinTimes(1)
+	updater := &updater{
+		selectorFetcher:      mockSelectorFetcher,
+		selectorFetchBackoff: wait.Backoff{Duration: time.Hour, Factor: 1, Steps: 3},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := updater.fetchSelector(ctx, vpaObj)
+	assert.True(t, apierrors.IsServiceUnavailable(err), "the error of the fetcher is reported")
+	assert.Less(t, time.Since(start), time.Minute, "the retries stop once the context is done")
+}
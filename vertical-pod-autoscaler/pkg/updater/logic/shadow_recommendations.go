@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// shadowTargetRatio is the ratio of the target recommended by a shadow VPA for a resource of a container
+// to the target recommended by the active VPA.
+type shadowTargetRatio struct {
+	container string
+	resource  apiv1.ResourceName
+	ratio     float64
+}
+
+// shadowTargetRatios compares the targets of the containers recommended by both the active and the shadow
+// recommendation. Resources without an active target, or with a zero one, are skipped.
+func shadowTargetRatios(active, shadow *vpa_types.RecommendedPodResources) []shadowTargetRatio {
+	if active == nil || shadow == nil {
+		return nil
+	}
+	var ratios []shadowTargetRatio
+	for _, activeRecommendation := range active.ContainerRecommendations {
+		shadowRecommendation := vpa_api_util.GetRecommendationForContainer(activeRecommendation.ContainerName, shadow)
+		if shadowRecommendation == nil {
+			continue
+		}
+		for _, resource := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+			activeTarget, found := activeRecommendation.Target[resource]
+			if !found || activeTarget.IsZero() {
+				continue
+			}
+			shadowTarget, found := shadowRecommendation.Target[resource]
+			if !found {
+				continue
+			}
+			ratios = append(ratios, shadowTargetRatio{
+				container: activeRecommendation.ContainerName,
+				resource:  resource,
+				ratio:     shadowTarget.AsApproximateFloat64() / activeTarget.AsApproximateFloat64(),
+			})
+		}
+	}
+	return ratios
+}
+
+// compareShadowRecommendations records how the recommendations of the shadow VPAs named by the
+// VpaShadowRecommendationAnnotation of the given VPAs differ from theirs. The shadow VPAs are looked up
+// in vpaList, since they are usually skipped by the updater for being in the Off mode.
+func compareShadowRecommendations(vpaList []*vpa_types.VerticalPodAutoscaler, vpas []*vpa_api_util.VpaWithSelector) {
+	var allVpas map[types.NamespacedName]*vpa_types.VerticalPodAutoscaler
+	for _, vpa := range vpas {
+		shadowName, found := vpa.Vpa.Annotations[annotations.VpaShadowRecommendationAnnotation]
+		if !found {
+			continue
+		}
+		if allVpas == nil {
+			allVpas = make(map[types.NamespacedName]*vpa_types.VerticalPodAutoscaler, len(vpaList))
+			for _, v := range vpaList {
+				allVpas[vpaKey(v)] = v
+			}
+		}
+		shadow, found := allVpas[types.NamespacedName{Namespace: vpa.Vpa.Namespace, Name: shadowName}]
+		if !found {
+			klog.V(2).InfoS("Shadow VPA object not found", "vpa", klog.KObj(vpa.Vpa), "shadow", shadowName)
+			continue
+		}
+		for _, ratio := range shadowTargetRatios(vpa.Vpa.Status.Recommendation, shadow.Status.Recommendation) {
+			klog.V(4).InfoS("Compared shadow recommendation", "vpa", klog.KObj(vpa.Vpa), "shadow", shadowName,
+				"container", ratio.container, "resource", ratio.resource, "ratio", ratio.ratio)
+			metrics_updater.RecordShadowTargetRatio(string(ratio.resource), ratio.ratio)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestShadowTargetRatios(t *testing.T) {
+	active := test.Recommendation().WithContainer("app").WithTarget("1", "200Mi").GetContainerResources()
+	sidecar := test.Recommendation().WithContainer("sidecar").WithTarget("100m", "50Mi").GetContainerResources()
+	shadowApp := test.Recommendation().WithContainer("app").WithTarget("1500m", "100Mi").GetContainerResources()
+	onlyInShadow := test.Recommendation().WithContainer("other").WithTarget("2", "1Gi").GetContainerResources()
+
+	ratios := shadowTargetRatios(
+		&vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{active, sidecar}},
+		&vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{shadowApp, onlyInShadow}},
+	)
+	assert.Equal(t, []shadowTargetRatio{
+		{container: "app", resource: apiv1.ResourceCPU, ratio: 1.5},
+		{container: "app", resource: apiv1.ResourceMemory, ratio: 0.5},
+	}, ratios, "only containers recommended by both are compared")
+
+	assert.Empty(t, shadowTargetRatios(nil, &vpa_types.RecommendedPodResources{}))
+	assert.Empty(t, shadowTargetRatios(&vpa_types.RecommendedPodResources{}, nil))
+
+	zeroActive := test.Recommendation().WithContainer("app").WithTarget("0", "200Mi").GetContainerResources()
+	ratios = shadowTargetRatios(
+		&vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{zeroActive}},
+		&vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{shadowApp}},
+	)
+	assert.Equal(t, []shadowTargetRatio{{container: "app", resource: apiv1.ResourceMemory, ratio: 0.5}}, ratios, "zero targets are skipped")
+}
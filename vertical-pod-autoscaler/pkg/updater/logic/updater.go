@@ -17,15 +17,29 @@ limitations under the License.
 package logic
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/time/rate"
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	corescheme "k8s.io/client-go/kubernetes/scheme"
@@ -33,7 +47,9 @@ import (
 	v1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
@@ -46,11 +62,15 @@ import (
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
 	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
 	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/status"
 	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 )
 
+// tracerName identifies the spans emitted by the updater.
+const tracerName = "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater"
+
 // logDeprecationWarnings logs deprecation warnings for VPAs using deprecated modes
 func logDeprecationWarnings(vpa *vpa_types.VerticalPodAutoscaler) {
 	if vpa.Spec.UpdatePolicy != nil &&
@@ -63,31 +83,201 @@ func logDeprecationWarnings(vpa *vpa_types.VerticalPodAutoscaler) {
 
 // Updater performs updates on pods if recommended by Vertical Pod Autoscaler
 type Updater interface {
-	// RunOnce represents single iteration in the main-loop of Updater
-	RunOnce(context.Context)
+	// RunOnce represents single iteration in the main-loop of Updater, it returns the errors the iteration ran into
+	RunOnce(context.Context) error
+	// ProcessErroredVpas retries the VPAs whose processing failed in a loop until the context is done
+	ProcessErroredVpas(context.Context)
+	// Shutdown records the pending events and stops recording new ones
+	Shutdown()
+	// Drain makes the running loop, and any later one, stop acting on further pods.
+	// The updates already started complete.
+	Drain()
 }
 
 type updater struct {
-	vpaLister                    vpa_lister.VerticalPodAutoscalerLister
-	podLister                    v1lister.PodLister
-	eventRecorder                record.EventRecorder
-	restrictionFactory           restriction.PodsRestrictionFactory
-	recommendationProcessor      vpa_api_util.RecommendationProcessor
-	evictionAdmission            priority.PodEvictionAdmission
-	priorityProcessor            priority.PriorityProcessor
-	evictionRateLimiter          *rate.Limiter
-	inPlaceRateLimiter           *rate.Limiter
-	selectorFetcher              target.VpaTargetSelectorFetcher
-	useAdmissionControllerStatus bool
-	statusValidator              status.Validator
-	controllerFetcher            controllerfetcher.ControllerFetcher
-	ignoredNamespaces            []string
+	vpaLister               vpa_lister.VerticalPodAutoscalerLister
+	podLister               v1lister.PodLister
+	eventRecorder           record.EventRecorder
+	restrictionFactory      restriction.PodsRestrictionFactory
+	recommendationProcessor vpa_api_util.RecommendationProcessor
+	evictionAdmission       priority.PodEvictionAdmission
+	priorityProcessor       priority.PriorityProcessor
+	evictionRateLimiter     rateLimiter
+	inPlaceRateLimiter      rateLimiter
+	// namespaceEvictionRateLimiters and namespaceInPlaceRateLimiters, if set, hold the rate limiters
+	// of namespaces with their own rate limits, which replace the global ones above.
+	namespaceEvictionRateLimiters *namespaceRateLimiters
+	namespaceInPlaceRateLimiters  *namespaceRateLimiters
+	selectorFetcher               target.VpaTargetSelectorFetcher
+	useAdmissionControllerStatus  bool
+	statusValidator               status.Validator
+	controllerFetcher             controllerfetcher.ControllerFetcher
+	ignoredNamespaces             []string
+	watchedNamespaces             []string
+	prioritizeSelectorChanges     bool
+	// preferLowPriorityPods makes VPAs be processed in the order of the lowest scheduling priority
+	// of their pods, so that pods are acted on in the order of their scheduling priority across VPAs.
+	preferLowPriorityPods  bool
+	appliedRecommendations *priority.AppliedRecommendationCache
+	// updateConfig configures the update priority calculators, the defaults are used if it's nil.
+	updateConfig         *priority.UpdateConfig
+	maxRecommendationAge time.Duration
+	// initialModeDriftThreshold, if positive, makes the updater evict the pods of VPAs in Initial mode
+	// whose requests drifted from the recommendation by at least this resource diff.
+	initialModeDriftThreshold float64
+	// selectorFetchBackoff is the backoff of the retries of selector fetches failing with transient errors.
+	// Fetches are not retried if its Steps is at most 1.
+	selectorFetchBackoff wait.Backoff
+	// recommendationSnapshot, if set, makes RunOnce only report how recommendations changed since it was taken.
+	recommendationSnapshot RecommendationSnapshot
+	// evictionCoordinator, if set, makes pods wait for an external coordinator before being evicted.
+	evictionCoordinator *evictionCoordinator
+	// controllerLocks, if set, makes the updater hold a Lease per controller while acting on its pods.
+	controllerLocks *controllerLocks
+	// actionHistory, if set, records the updates of pods in the status of their VPA object.
+	actionHistory *actionHistory
+	// evictionCircuitBreaker, if set, pauses all evictions after a spike of eviction errors.
+	evictionCircuitBreaker *evictionCircuitBreaker
+	// rescheduleTracker, if set, measures how long evicted pods take to be replaced by ready ones.
+	rescheduleTracker *rescheduleTracker
+	// podBackoff, if set, keeps pods whose update failed from being acted on again for a while.
+	podBackoff *podBackoff
+	// clampedRecommendations, if set, reports the recommendations of updated pods clamped by the policy of their VPA.
+	clampedRecommendations *clampedRecommendationReporter
+	// killSwitch, if set, stops all actions while it is engaged.
+	killSwitch *killSwitch
+	// localVolumes, if set, avoids evicting pods which use local volumes.
+	localVolumes *localVolumes
+	// invalidUpdatePreferences holds the invalid update preference already reported for each VPA.
+	invalidUpdatePreferences map[types.UID]string
+	// invalidDisruptionBudgets holds the invalid disruption budget already reported for each VPA.
+	invalidDisruptionBudgets map[types.UID]string
+	// recommendationStability, if set, keeps pods from being updated until their recommendation is stable.
+	recommendationStability *recommendationStability
+	// resourceQuotas, if set, keeps pods whose new requests wouldn't fit in a ResourceQuota from being evicted.
+	resourceQuotas *resourceQuotas
+	// podPredicates must all admit a pod for the updater to act on it.
+	podPredicates []PodPredicate
+	// predicateRefusals holds the reason already reported on each pod refused by a predicate.
+	predicateRefusals map[types.UID]string
+	// safeToEvictCondition, if set, is a pod condition which must be True for pods reporting it to be evicted.
+	safeToEvictCondition apiv1.PodConditionType
+	// blockedReasons, if set, annotates pods with the reason they need an update but weren't updated.
+	blockedReasons *blockedReasonAnnotator
+	// decisions, if set, holds the decisions about the pods of each VPA in the most recent loop processing it.
+	decisions *DecisionSnapshot
+	// vpaDecisions records the decisions about the pods of the VPA being processed.
+	vpaDecisions *vpaDecisionRecorder
+	// erroredVpas, if set, holds the VPAs whose processing failed, to be retried before the next loop.
+	erroredVpas workqueue.TypedRateLimitingInterface[types.NamespacedName]
+	// erroredVpaRetryTimeout, if positive, bounds the time spent on a retry of an errored VPA.
+	erroredVpaRetryTimeout time.Duration
+	// eventDeduplicator, if set, wraps eventRecorder and needs flushing to record aggregated events.
+	eventDeduplicator *eventDeduplicator
+	// controllerEvents, if set, emits events on the top-most controllers of the updated pods.
+	controllerEvents *controllerEventRecorder
+	// eventBroadcaster delivers the events of eventRecorder, if set.
+	eventBroadcaster record.EventBroadcaster
+	// loopErrors collects the errors the running loop ran into.
+	loopErrors []error
+	// loopSummary counts what the running loop did.
+	loopSummary *loopSummary
+	// runOnceLock guards against a slow loop overlapping with the next one.
+	runOnceLock sync.Mutex
+	// draining is set once the updater is shutting down and must not start further updates.
+	draining atomic.Bool
+	// drained is closed once the updater drains, to stop the waits for the rate limiters. It's guarded by drainMutex.
+	drained    chan struct{}
+	drainMutex sync.Mutex
+	// vpaSelectors holds the selectors seen in the previous loop, keyed by VPA namespace/name.
+	vpaSelectors map[string]string
+	// tracer emits spans for the phases of each loop. The global tracer provider is used if unset.
+	tracer trace.Tracer
+}
+
+// Options holds the optional configuration of the Updater. The zero value of each field disables
+// the feature it configures, or keeps the default behavior.
+type Options struct {
+	// EvictionRateSchedule, if set, changes the eviction rate limit and burst over a daily window.
+	EvictionRateSchedule *RateLimitSchedule
+	// EvictionPolicy configures how pods are evicted.
+	EvictionPolicy restriction.EvictionPolicy
+	// InPlacePolicy configures how pods are updated in place.
+	InPlacePolicy restriction.InPlacePolicy
+	// GroupingPolicy configures which pods are grouped by their controller to be updated.
+	GroupingPolicy restriction.GroupingPolicy
+	// UpdateConfig, if set, configures which pods are updated and in which order. The defaults of
+	// the priority package are used otherwise.
+	UpdateConfig *priority.UpdateConfig
+	// WatchedNamespaces, if set, are the only namespaces whose VPA objects are processed.
+	WatchedNamespaces []string
+	// PrioritizeSelectorChanges makes VPAs whose selector changed since the previous loop be processed first.
+	PrioritizeSelectorChanges bool
+	// MaxRecommendationAge, if positive, skips VPAs whose recommendation wasn't updated for longer than it.
+	MaxRecommendationAge time.Duration
+	// RecommendationSnapshot, if set, makes the Updater only report how recommendations changed since it was taken.
+	RecommendationSnapshot RecommendationSnapshot
+	// EvictionCoordinationAnnotation, if set, makes pods wait for an external coordinator before being evicted.
+	EvictionCoordinationAnnotation string
+	// ActionHistorySize is the number of the most recent pod updates kept in the status of each VPA.
+	ActionHistorySize int
+	// ActionHistoryFlushInterval, if positive, buffers the action history and writes it at most once per interval.
+	ActionHistoryFlushInterval time.Duration
+	// EventSourceComponent is the component the events are reported from.
+	EventSourceComponent string
+	// EventDeduplicationWindow, if positive, aggregates identical events recorded within the window.
+	EventDeduplicationWindow time.Duration
+	// EvictionCircuitBreaker configures pausing all evictions after a spike of eviction errors.
+	EvictionCircuitBreaker EvictionCircuitBreakerConfig
+	// ErroredVpaRequeue configures retrying the VPAs whose processing failed before the next loop.
+	ErroredVpaRequeue ErroredVpaRequeueConfig
+	// NamespaceRateLimitsConfigMap, if set, names the ConfigMap holding the rate limits of namespaces.
+	NamespaceRateLimitsConfigMap string
+	// PodBackoffStrategy, if set, keeps pods whose update failed from being acted on again for a while.
+	PodBackoffStrategy BackoffStrategy
+	// AnnotateBlockedPods annotates pods with the reason they need an update but weren't updated.
+	AnnotateBlockedPods bool
+	// ControllerEventsInterval, if positive, emits events on the top-most controllers of the updated pods.
+	ControllerEventsInterval time.Duration
+	// InitialModeDriftThreshold, if positive, evicts the pods of VPAs in Initial mode drifting from the recommendation.
+	InitialModeDriftThreshold float64
+	// RecommendationStability configures waiting for the recommendation of pods to be stable.
+	RecommendationStability RecommendationStabilityConfig
+	// LocalVolumes configures how pods using local volumes are updated.
+	LocalVolumes LocalVolumeConfig
+	// KillSwitchConfigMap, if set, names the ConfigMap stopping all actions while it is engaged.
+	KillSwitchConfigMap string
+	// PodPredicates must all admit a pod for the Updater to act on it.
+	PodPredicates []PodPredicate
+	// EventTemplates, if set, override the messages of the events.
+	EventTemplates EventTemplates
+	// ResourceQuotaLister, if set, keeps pods whose new requests wouldn't fit in a ResourceQuota from being evicted.
+	ResourceQuotaLister v1lister.ResourceQuotaLister
+	// DefaultResourcePolicy, if set, holds the cluster-wide defaults the recommendation processor applies,
+	// so that recommendations clamped by them are reported too.
+	DefaultResourcePolicy *vpa_api_util.DefaultResourcePolicy
+	// SelectorFetchRetries is the number of attempts of selector fetches failing with transient errors.
+	SelectorFetchRetries int
+	// SafeToEvictCondition, if set, is a pod condition which must be True for pods reporting it to be evicted.
+	SafeToEvictCondition string
+	// DecisionSnapshot, if set, holds the decisions about the pods of each VPA in the most recent loop.
+	DecisionSnapshot *DecisionSnapshot
+	// ControllerLock configures holding a Lease per controller while acting on its pods.
+	ControllerLock ControllerLockConfig
+	// VpaResource, if set, is the resource the VPA objects are read from instead of the one of the
+	// autoscaling.k8s.io API group. Their status is written to the same resource with VpaResourceClient.
+	VpaResource *schema.GroupVersionResource
+	// VpaResourceClient is the client of VpaResource.
+	VpaResourceClient dynamic.Interface
+	// StopCh stops the watches of the ConfigMaps configuring the Updater when closed.
+	StopCh <-chan struct{}
 }
 
 // NewUpdater creates Updater with given configuration
 func NewUpdater(
 	kubeClient kube_client.Interface,
 	vpaClient *vpa_clientset.Clientset,
+	vpaLister vpa_lister.VerticalPodAutoscalerLister,
 	minReplicasForEviction int,
 	evictionRateLimit float64,
 	evictionRateBurst int,
@@ -103,89 +293,447 @@ func NewUpdater(
 	namespace string,
 	ignoredNamespaces []string,
 	patchCalculators []patch.Calculator,
+	options Options,
 ) (Updater, error) {
-	evictionRateLimiter := getRateLimiter(evictionRateLimit, evictionRateBurst)
-	// TODO: Create in-place rate limits for the in-place rate limiter
-	inPlaceRateLimiter := getRateLimiter(evictionRateLimit, evictionRateBurst)
+	var evictionRateLimiter, inPlaceRateLimiter rateLimiter
+	if options.EvictionRateSchedule != nil {
+		evictionRateLimiter = newScheduledRateLimiter(getRateLimiter(evictionRateLimit, evictionRateBurst), options.EvictionRateSchedule, clock.RealClock{})
+		// TODO: Create in-place rate limits for the in-place rate limiter
+		inPlaceRateLimiter = newScheduledRateLimiter(getRateLimiter(evictionRateLimit, evictionRateBurst), options.EvictionRateSchedule, clock.RealClock{})
+	} else {
+		evictionRateLimiter = getRateLimiter(evictionRateLimit, evictionRateBurst)
+		// TODO: Create in-place rate limits for the in-place rate limiter
+		inPlaceRateLimiter = getRateLimiter(evictionRateLimit, evictionRateBurst)
+	}
 	factory, err := restriction.NewPodsRestrictionFactory(
 		kubeClient,
 		minReplicasForEviction,
 		evictionToleranceFraction,
 		patchCalculators,
 		inPlaceSkipDisruptionBudget,
+		options.EvictionPolicy,
+		options.InPlacePolicy,
+		options.GroupingPolicy,
+		recommendationProcessor,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create restriction factory: %v", err)
 	}
 
+	var coordinator *evictionCoordinator
+	if options.EvictionCoordinationAnnotation != "" {
+		coordinator = newEvictionCoordinator(kubeClient, options.EvictionCoordinationAnnotation)
+	}
+
+	var locks *controllerLocks
+	if options.ControllerLock.LeaseDuration > 0 {
+		// The Leases live next to the admission controller status object, in the namespace of the VPA components.
+		locks = newControllerLocks(kubeClient, statusNamespace, options.ControllerLock)
+	}
+
+	var history *actionHistory
+	if options.ActionHistorySize > 0 {
+		patchStatus := newVpaStatusPatcher(vpaClient)
+		if options.VpaResource != nil {
+			patchStatus = newVpaResourceStatusPatcher(options.VpaResourceClient, *options.VpaResource)
+		}
+		history = newActionHistory(patchStatus, options.ActionHistorySize, options.ActionHistoryFlushInterval)
+	}
+
+	var circuitBreaker *evictionCircuitBreaker
+	if options.EvictionCircuitBreaker.ErrorThreshold > 0 {
+		circuitBreaker = newEvictionCircuitBreaker(options.EvictionCircuitBreaker)
+	}
+
+	var namespaceEvictionRateLimiters, namespaceInPlaceRateLimiters *namespaceRateLimiters
+	if options.NamespaceRateLimitsConfigMap != "" {
+		namespaceEvictionRateLimiters = newNamespaceRateLimiters()
+		namespaceInPlaceRateLimiters = newNamespaceRateLimiters()
+		// The ConfigMap lives next to the admission controller status object, in the namespace of the VPA components.
+		err := watchNamespaceRateLimits(kubeClient, statusNamespace, options.NamespaceRateLimitsConfigMap, options.StopCh,
+			namespaceEvictionRateLimiters, namespaceInPlaceRateLimiters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var killSwitchConfig *killSwitch
+	if options.KillSwitchConfigMap != "" {
+		killSwitchNamespace, killSwitchName, err := cache.SplitMetaNamespaceKey(options.KillSwitchConfigMap)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kill switch ConfigMap %q: %v", options.KillSwitchConfigMap, err)
+		}
+		if killSwitchNamespace == "" {
+			killSwitchNamespace = statusNamespace
+		}
+		killSwitchConfig = &killSwitch{}
+		// The Updater doesn't start if the kill switch can't be read, as it might be engaged.
+		if err := watchKillSwitch(kubeClient, killSwitchNamespace, killSwitchName, options.StopCh, killSwitchConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	var backoff *podBackoff
+	if options.PodBackoffStrategy != nil {
+		backoff = newPodBackoff(options.PodBackoffStrategy)
+	}
+
+	var stability *recommendationStability
+	if options.RecommendationStability.Loops > 1 {
+		stability = newRecommendationStability(options.RecommendationStability)
+	}
+
+	var volumes *localVolumes
+	if options.LocalVolumes.Policy != "" && options.LocalVolumes.Policy != LocalVolumePolicyEvict {
+		volumes = newLocalVolumes(options.LocalVolumes)
+	}
+
+	var quotas *resourceQuotas
+	if options.ResourceQuotaLister != nil {
+		quotas = newResourceQuotas(options.ResourceQuotaLister)
+	}
+	var blockedReasons *blockedReasonAnnotator
+	if options.AnnotateBlockedPods {
+		blockedReasons = newBlockedReasonAnnotator(kubeClient)
+	}
+
+	var erroredVpas workqueue.TypedRateLimitingInterface[types.NamespacedName]
+	if options.ErroredVpaRequeue.BaseDelay > 0 {
+		if options.RecommendationSnapshot != nil {
+			// Retries would only report partial recommendation changes.
+			klog.V(0).InfoS("Retries of errored VPA objects are disabled when comparing recommendations to a snapshot")
+		} else {
+			erroredVpas = newErroredVpaQueue(options.ErroredVpaRequeue)
+		}
+	}
+
+	eventRecorder, eventBroadcaster := newEventRecorder(kubeClient, options.EventSourceComponent)
+	if len(options.EventTemplates) > 0 {
+		eventRecorder = newEventTemplater(eventRecorder, options.EventTemplates)
+	}
+	var deduplicator *eventDeduplicator
+	if options.EventDeduplicationWindow > 0 {
+		deduplicator = newEventDeduplicator(eventRecorder, options.EventDeduplicationWindow)
+		eventRecorder = deduplicator
+	}
+	var controllerEvents *controllerEventRecorder
+	if options.ControllerEventsInterval > 0 {
+		controllerEvents = newControllerEventRecorder(eventRecorder, controllerFetcher, options.ControllerEventsInterval)
+	}
+
 	return &updater{
-		vpaLister:                    vpa_api_util.NewVpasLister(vpaClient, make(chan struct{}), namespace),
-		podLister:                    newPodLister(kubeClient, namespace),
-		eventRecorder:                newEventRecorder(kubeClient),
-		restrictionFactory:           factory,
-		recommendationProcessor:      recommendationProcessor,
-		evictionRateLimiter:          evictionRateLimiter,
-		inPlaceRateLimiter:           inPlaceRateLimiter,
-		evictionAdmission:            evictionAdmission,
-		priorityProcessor:            priorityProcessor,
-		selectorFetcher:              selectorFetcher,
-		controllerFetcher:            controllerFetcher,
-		useAdmissionControllerStatus: useAdmissionControllerStatus,
+		vpaLister:                     vpaLister,
+		podLister:                     newPodLister(kubeClient, namespace),
+		eventRecorder:                 eventRecorder,
+		eventDeduplicator:             deduplicator,
+		controllerEvents:              controllerEvents,
+		eventBroadcaster:              eventBroadcaster,
+		restrictionFactory:            factory,
+		recommendationProcessor:       recommendationProcessor,
+		evictionRateLimiter:           evictionRateLimiter,
+		inPlaceRateLimiter:            inPlaceRateLimiter,
+		namespaceEvictionRateLimiters: namespaceEvictionRateLimiters,
+		namespaceInPlaceRateLimiters:  namespaceInPlaceRateLimiters,
+		evictionAdmission:             evictionAdmission,
+		priorityProcessor:             priorityProcessor,
+		selectorFetcher:               selectorFetcher,
+		selectorFetchBackoff:          newSelectorFetchBackoff(options.SelectorFetchRetries),
+		controllerFetcher:             controllerFetcher,
+		useAdmissionControllerStatus:  useAdmissionControllerStatus,
 		statusValidator: status.NewValidator(
 			kubeClient,
 			status.AdmissionControllerStatusName,
 			statusNamespace,
 		),
-		ignoredNamespaces: ignoredNamespaces,
+		ignoredNamespaces:         ignoredNamespaces,
+		watchedNamespaces:         options.WatchedNamespaces,
+		prioritizeSelectorChanges: options.PrioritizeSelectorChanges,
+		preferLowPriorityPods:     options.UpdateConfig != nil && options.UpdateConfig.PreferLowPriorityPods,
+		updateConfig:              options.UpdateConfig,
+		appliedRecommendations:    priority.NewAppliedRecommendationCache(),
+		maxRecommendationAge:      options.MaxRecommendationAge,
+		initialModeDriftThreshold: options.InitialModeDriftThreshold,
+		recommendationSnapshot:    options.RecommendationSnapshot,
+		evictionCoordinator:       coordinator,
+		controllerLocks:           locks,
+		actionHistory:             history,
+		evictionCircuitBreaker:    circuitBreaker,
+		rescheduleTracker:         newRescheduleTracker(),
+		podBackoff:                backoff,
+		recommendationStability:   stability,
+		localVolumes:              volumes,
+		killSwitch:                killSwitchConfig,
+		clampedRecommendations:    newClampedRecommendationReporter(eventRecorder, options.DefaultResourcePolicy),
+		resourceQuotas:            quotas,
+		podPredicates:             options.PodPredicates,
+		safeToEvictCondition:      apiv1.PodConditionType(options.SafeToEvictCondition),
+		blockedReasons:            blockedReasons,
+		decisions:                 options.DecisionSnapshot,
+		erroredVpas:               erroredVpas,
+		erroredVpaRetryTimeout:    options.ErroredVpaRequeue.Timeout,
+		tracer:                    otel.Tracer(tracerName),
 	}, nil
 }
 
 // RunOnce represents single iteration in the main-loop of Updater
-func (u *updater) RunOnce(ctx context.Context) {
+func (u *updater) RunOnce(ctx context.Context) error {
+	if !u.runOnceLock.TryLock() {
+		klog.V(2).InfoS("Previous updater loop is still running, skipping this one")
+		metrics_updater.RecordSkippedOverlappingLoop()
+		return nil
+	}
+	defer u.runOnceLock.Unlock()
+	u.loopErrors = nil
+	u.runOnce(ctx, nil)
+	return errors.Join(u.loopErrors...)
+}
+
+// Shutdown records the events aggregated by the deduplicator and waits for the queued
+// events to be handed over for recording.
+func (u *updater) Shutdown() {
+	if u.eventDeduplicator != nil {
+		u.eventDeduplicator.flushAll()
+	}
+	if u.eventBroadcaster != nil {
+		u.eventBroadcaster.Shutdown()
+	}
+}
+
+// Drain makes the running loop, and any later one, stop acting on further pods.
+func (u *updater) Drain() {
+	if !u.draining.Swap(true) {
+		close(u.drainedChannel())
+	}
+}
+
+// drainedChannel returns the channel closed once the updater drains.
+func (u *updater) drainedChannel() chan struct{} {
+	u.drainMutex.Lock()
+	defer u.drainMutex.Unlock()
+	if u.drained == nil {
+		u.drained = make(chan struct{})
+	}
+	return u.drained
+}
+
+// waitForToken waits for a token of the rate limiter. The wait is given up once the updater drains, as no
+// further pods are acted on then.
+func (u *updater) waitForToken(ctx context.Context, limiter rateLimiter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	drained := u.drainedChannel()
+	go func() {
+		select {
+		case <-drained:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return limiter.Wait(ctx)
+}
+
+// recordLoopError remembers an error of the running loop, so that RunOnce returns it.
+func (u *updater) recordLoopError(err error) {
+	u.loopErrors = append(u.loopErrors, err)
+}
+
+// blockPod records why the updater didn't act on a pod which needs an update.
+func (u *updater) blockPod(pod *apiv1.Pod, reason blockedReason) {
+	u.blockedReasons.block(pod, reason)
+	u.vpaDecisions.decide(pod, decisionBlocked, string(reason))
+	u.loopSummary.skip(reason)
+}
+
+// reportClampedRecommendation reports the resources of the recommendation applied to an updated pod
+// which are clamped by the resource policy of its VPA.
+func (u *updater) reportClampedRecommendation(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod) {
+	if u.clampedRecommendations == nil {
+		return
+	}
+	recommendation, _, err := u.recommendationProcessor.Apply(vpa, pod)
+	if err != nil {
+		klog.V(4).InfoS("Cannot process recommendation for pod, not checking whether it is clamped", "pod", klog.KObj(pod), "error", err)
+		return
+	}
+	u.clampedRecommendations.report(vpa, pod, recommendation)
+}
+
+// newAction describes the update of the pod for the action history. The new requests are the recommendation
+// applied to the pod, i.e. the one of the VPA after capping, rounding and the margin.
+func (u *updater) newAction(actionType vpa_types.VerticalPodAutoscalerActionType, vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod) vpa_types.VerticalPodAutoscalerAction {
+	recommendation, _, err := u.recommendationProcessor.Apply(vpa, pod)
+	if err != nil {
+		klog.V(4).InfoS("Cannot process recommendation for pod, recording the recommendation of its VPA", "pod", klog.KObj(pod), "error", err)
+		recommendation = vpa.Status.Recommendation
+	}
+	return u.actionHistory.newAction(actionType, pod, recommendation)
+}
+
+// lockController acquires the lock of the controller targeted by the VPA, if there are pods to act on.
+// If another updater holds it, or it can't be acquired, the pods are blocked and false is returned.
+func (u *updater) lockController(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler, podsForInPlace, podsForEviction []*apiv1.Pod) bool {
+	if u.controllerLocks == nil || len(podsForInPlace)+len(podsForEviction) == 0 {
+		return true
+	}
+	acquired, err := u.controllerLocks.acquire(ctx, vpa)
+	if err != nil {
+		klog.V(2).InfoS("Failed to lock controller, not acting on its pods", "vpa", klog.KObj(vpa), "error", err)
+		u.recordLoopError(fmt.Errorf("failed to lock controller of VPA %s: %w", klog.KObj(vpa), err))
+	}
+	if acquired {
+		return true
+	}
+	for _, pod := range append(slices.Clone(podsForInPlace), podsForEviction...) {
+		u.blockPod(pod, blockedByControllerLock)
+	}
+	return false
+}
+
+// renewControllerLock renews the lock of the controller targeted by the VPA before acting on the pod, if it's
+// due. If the lock was lost, or can't be renewed, the pod is blocked and false is returned.
+func (u *updater) renewControllerLock(ctx context.Context, vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod) bool {
+	if u.controllerLocks == nil {
+		return true
+	}
+	renewed, err := u.controllerLocks.renew(ctx, vpa)
+	if err != nil {
+		klog.V(2).InfoS("Failed to renew controller lock, not acting on its pods", "vpa", klog.KObj(vpa), "error", err)
+		u.recordLoopError(fmt.Errorf("failed to renew lock of controller of VPA %s: %w", klog.KObj(vpa), err))
+	}
+	if !renewed {
+		u.blockPod(pod, blockedByControllerLock)
+	}
+	return renewed
+}
+
+// syncBlockedReasons updates the blocked reason annotations of the pods of a VPA after acting on them.
+func (u *updater) syncBlockedReasons(ctx context.Context, pods []*apiv1.Pod) {
+	if err := u.blockedReasons.sync(ctx, pods); err != nil {
+		klog.ErrorS(err, "Failed to update the blocked reasons of pods")
+		u.recordLoopError(err)
+	}
+}
+
+// runOnce processes the VPAs in the given set, or all VPAs if it's nil. Partial runs retrying
+// errored VPAs leave the per-loop state, like the metrics and the tracked selectors, untouched.
+func (u *updater) runOnce(ctx context.Context, only sets.Set[types.NamespacedName]) {
+	partial := only != nil
 	timer := metrics_updater.NewExecutionTimer()
-	defer timer.ObserveTotal()
+	observeStep := func(step string) {
+		if !partial {
+			timer.ObserveStep(step)
+		}
+	}
+	phases := newPhaseTimer(clock.RealClock{})
+	if !partial {
+		defer timer.ObserveTotal()
+		defer phases.observe()
+	}
+	u.loopSummary = newLoopSummary(time.Now())
+	defer func() { u.loopSummary.log(partial, time.Now()) }()
+
+	tracer := u.tracer
+	if tracer == nil {
+		tracer = otel.Tracer(tracerName)
+	}
+	spanName := "RunOnce"
+	if partial {
+		spanName = "RetryErroredVpas"
+	}
+	ctx, loopSpan := tracer.Start(ctx, spanName)
+	defer loopSpan.End()
+	// Deferred, so that the actions of a loop which stops early, e.g. because the updater drains, are written.
+	defer u.flushActionHistory(ctx)
+
+	if u.eventDeduplicator != nil {
+		u.eventDeduplicator.flush()
+	}
+	u.controllerEvents.forgetExpired()
+	u.clampedRecommendations.forgetExpired()
+	u.resourceQuotas.reset()
+	if !partial {
+		// Reset before anything can end the loop early, so that no VPA keeps the count of an earlier loop.
+		metrics_updater.ResetMatchedPods()
+	}
+
+	if u.killSwitch != nil {
+		engaged := u.killSwitch.isEngaged()
+		metrics_updater.RecordKillSwitchEngaged(engaged)
+		if engaged {
+			klog.V(2).InfoS("Kill switch is engaged, skipping updater loop")
+			return
+		}
+	}
 
 	if u.useAdmissionControllerStatus {
 		isValid, err := u.statusValidator.IsStatusValid(ctx, status.AdmissionControllerStatusTimeout)
 		if err != nil {
 			klog.ErrorS(err, "Error getting Admission Controller status. Skipping eviction loop")
+			u.recordLoopError(fmt.Errorf("failed to get admission controller status: %w", err))
 			return
 		}
+		metrics_updater.RecordSuccessfulAPIServerContact()
 		if !isValid {
 			klog.V(0).InfoS("Admission Controller status is not valid. Skipping eviction loop", "timeout", status.AdmissionControllerStatusTimeout)
 			return
 		}
 	}
 
+	phases.enter(phaseList)
+	_, listSpan := tracer.Start(ctx, "ListVPAs")
 	vpaList, err := u.vpaLister.List(labels.Everything())
 	if err != nil {
 		klog.ErrorS(err, "Failed to get VPA list")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
-	timer.ObserveStep("ListVPAs")
+	listSpan.SetAttributes(attribute.Int("vpa.count", len(vpaList)))
+	listSpan.End()
+	observeStep("ListVPAs")
 
 	vpas := make([]*vpa_api_util.VpaWithSelector, 0)
 
 	inPlaceFeatureEnable := features.Enabled(features.InPlaceOrRecreate)
+	if !partial {
+		metrics_updater.ResetRecommendationAges()
+		metrics_updater.ResetRateLimitedActions()
+	}
+	now := time.Now()
 
+	phases.enter(phaseFetch)
+	selectorsCtx, selectorsSpan := tracer.Start(ctx, "FetchSelectors")
 	for _, vpa := range vpaList {
+		if partial && !only.Has(vpaKey(vpa)) {
+			continue
+		}
 		if slices.Contains(u.ignoredNamespaces, vpa.Namespace) {
 			klog.V(3).InfoS("Skipping VPA object in ignored namespace", "vpa", klog.KObj(vpa), "namespace", vpa.Namespace)
 			continue
 		}
+		if len(u.watchedNamespaces) > 0 && !slices.Contains(u.watchedNamespaces, vpa.Namespace) {
+			klog.V(3).InfoS("Skipping VPA object outside of watched namespaces", "vpa", klog.KObj(vpa), "namespace", vpa.Namespace)
+			continue
+		}
 
 		// Log deprecation warnings for VPAs using deprecated modes
 		logDeprecationWarnings(vpa)
+		age, aged := recordRecommendationAge(vpa, now)
 
 		if vpa_api_util.GetUpdateMode(vpa) != vpa_types.UpdateModeRecreate &&
 			vpa_api_util.GetUpdateMode(vpa) != vpa_types.UpdateModeAuto && //nolint:staticcheck
-			vpa_api_util.GetUpdateMode(vpa) != vpa_types.UpdateModeInPlaceOrRecreate {
-			klog.V(3).InfoS("Skipping VPA object because its mode is not  \"InPlaceOrRecreate\", \"Recreate\" or \"Auto\"", "vpa", klog.KObj(vpa))
+			vpa_api_util.GetUpdateMode(vpa) != vpa_types.UpdateModeInPlaceOrRecreate &&
+			vpa_api_util.GetUpdateMode(vpa) != vpa_types.UpdateModeInPlaceOnly &&
+			!u.correctsDriftOf(vpa) {
+			klog.V(3).InfoS("Skipping VPA object because its mode is not \"InPlaceOrRecreate\", \"InPlaceOnly\", \"Recreate\" or \"Auto\"", "vpa", klog.KObj(vpa))
 			continue
 		}
-		selector, err := u.selectorFetcher.Fetch(ctx, vpa)
+		if aged && !u.isRecommendationFresh(vpa, age) {
+			continue
+		}
+		selector, err := u.fetchSelector(selectorsCtx, vpa)
 		if err != nil {
-			klog.V(3).InfoS("Skipping VPA object because we cannot fetch selector", "vpa", klog.KObj(vpa))
+			klog.V(3).InfoS("Skipping VPA object because we cannot fetch selector", "vpa", klog.KObj(vpa), "error", err)
+			u.recordLoopError(fmt.Errorf("failed to fetch selector of VPA %s: %w", klog.KObj(vpa), err))
+			u.requeueErroredVpa(vpa)
 			continue
 		}
 
@@ -194,8 +742,27 @@ func (u *updater) RunOnce(ctx context.Context) {
 			Selector: selector,
 		})
 	}
+	selectorsSpan.SetAttributes(attribute.Int("vpa.count", len(vpas)))
+	selectorsSpan.End()
+	if !partial {
+		compareShadowRecommendations(vpaList, vpas)
+	}
+
+	if u.recommendationSnapshot != nil {
+		u.reportRecommendationChanges(vpas)
+		return
+	}
+
+	vpasWithChangedSelector := make(map[*vpa_types.VerticalPodAutoscaler]bool)
+	if !partial {
+		vpasWithChangedSelector = u.trackSelectorChanges(vpas)
+		u.decisions.retain(vpas)
+	}
 
 	if len(vpas) == 0 {
+		if partial {
+			return
+		}
 		klog.V(0).InfoS("No VPA objects to process")
 		if u.evictionAdmission != nil {
 			u.evictionAdmission.CleanUp()
@@ -203,14 +770,29 @@ func (u *updater) RunOnce(ctx context.Context) {
 		return
 	}
 
+	phases.enter(phaseList)
 	podsList, err := u.podLister.List(labels.Everything())
 	if err != nil {
 		klog.ErrorS(err, "Failed to get pods list")
+		u.recordLoopError(fmt.Errorf("failed to list pods: %w", err))
 		return
 	}
-	timer.ObserveStep("ListPods")
+	observeStep("ListPods")
 	allLivePods := filterDeletedPods(podsList)
+	if u.appliedRecommendations != nil {
+		u.appliedRecommendations.Retain(allLivePods)
+	}
+	if u.evictionCoordinator != nil {
+		u.evictionCoordinator.retain(allLivePods)
+	}
+	u.podBackoff.retain(allLivePods)
+	u.retainPredicateRefusals(allLivePods)
+	u.recommendationStability.retain(allLivePods)
+	if u.rescheduleTracker != nil {
+		u.rescheduleTracker.observe(allLivePods)
+	}
 
+	phases.enter(phaseFetch)
 	controlledPods := make(map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod)
 	for _, pod := range allLivePods {
 		controllingVPA := vpa_api_util.GetControllingVPAForPod(ctx, pod, vpas, u.controllerFetcher)
@@ -218,22 +800,44 @@ func (u *updater) RunOnce(ctx context.Context) {
 			controlledPods[controllingVPA.Vpa] = append(controlledPods[controllingVPA.Vpa], pod)
 		}
 	}
-	timer.ObserveStep("FilterPods")
+	for _, vpa := range vpas {
+		metrics_updater.RecordMatchedPods(vpa.Vpa.Name, vpa.Vpa.Namespace, len(controlledPods[vpa.Vpa]))
+	}
+	observeStep("FilterPods")
 
-	if u.evictionAdmission != nil {
-		u.evictionAdmission.LoopInit(allLivePods, controlledPods)
+	phases.enter(phasePrioritize)
+	// Partial runs only see the pods of the retried VPAs, they keep the state of the admissions
+	// initialized with all pods in the last loop.
+	if u.evictionAdmission != nil && !partial {
+		if contextAdmission, ok := u.evictionAdmission.(priority.ContextPodEvictionAdmission); ok {
+			contextAdmission.LoopInitWithContext(ctx, allLivePods, controlledPods)
+		} else {
+			u.evictionAdmission.LoopInit(allLivePods, controlledPods)
+		}
 	}
-	timer.ObserveStep("AdmissionInit")
+	observeStep("AdmissionInit")
 
 	// wrappers for metrics which are computed every loop run
-	controlledPodsCounter := metrics_updater.NewControlledPodsCounter()
-	evictablePodsCounter := metrics_updater.NewEvictablePodsCounter()
-	inPlaceUpdatablePodsCounter := metrics_updater.NewInPlaceUpdatablePodsCounter()
-	vpasWithEvictablePodsCounter := metrics_updater.NewVpasWithEvictablePodsCounter()
-	vpasWithEvictedPodsCounter := metrics_updater.NewVpasWithEvictedPodsCounter()
+	newModeAndSizeBasedGauge := func(newCounter func() *metrics_updater.UpdateModeAndSizeBasedGauge) *metrics_updater.UpdateModeAndSizeBasedGauge {
+		if partial {
+			return metrics_updater.NewUnobservedModeAndSizeBasedGauge()
+		}
+		return newCounter()
+	}
+	newSizeBasedGauge := func(newCounter func() *metrics_updater.SizeBasedGauge) *metrics_updater.SizeBasedGauge {
+		if partial {
+			return metrics_updater.NewUnobservedSizeBasedGauge()
+		}
+		return newCounter()
+	}
+	controlledPodsCounter := newModeAndSizeBasedGauge(metrics_updater.NewControlledPodsCounter)
+	evictablePodsCounter := newModeAndSizeBasedGauge(metrics_updater.NewEvictablePodsCounter)
+	inPlaceUpdatablePodsCounter := newSizeBasedGauge(metrics_updater.NewInPlaceUpdatablePodsCounter)
+	vpasWithEvictablePodsCounter := newModeAndSizeBasedGauge(metrics_updater.NewVpasWithEvictablePodsCounter)
+	vpasWithEvictedPodsCounter := newModeAndSizeBasedGauge(metrics_updater.NewVpasWithEvictedPodsCounter)
 
-	vpasWithInPlaceUpdatablePodsCounter := metrics_updater.NewVpasWithInPlaceUpdatablePodsCounter()
-	vpasWithInPlaceUpdatedPodsCounter := metrics_updater.NewVpasWithInPlaceUpdatedPodsCounter()
+	vpasWithInPlaceUpdatablePodsCounter := newSizeBasedGauge(metrics_updater.NewVpasWithInPlaceUpdatablePodsCounter)
+	vpasWithInPlaceUpdatedPodsCounter := newSizeBasedGauge(metrics_updater.NewVpasWithInPlaceUpdatedPodsCounter)
 
 	// using defer to protect against 'return' after evictionRateLimiter.Wait
 	defer controlledPodsCounter.Observe()
@@ -246,90 +850,373 @@ func (u *updater) RunOnce(ctx context.Context) {
 	defer vpasWithInPlaceUpdatedPodsCounter.Observe()
 
 	// NOTE: this loop assumes that controlledPods are filtered
-	// to contain only Pods controlled by a VPA in auto, recreate, or inPlaceOrRecreate mode
-	for vpa, livePods := range controlledPods {
+	// to contain only Pods controlled by a VPA in auto, recreate, or inPlaceOrRecreate mode,
+	// or in initial mode with drift correction enabled
+	for _, vpa := range u.getVpasProcessingOrder(controlledPods, vpasWithChangedSelector) {
+		if u.draining.Load() || u.killSwitch.isEngaged() {
+			klog.V(2).InfoS("Updater is draining or stopped by the kill switch, not acting on further VPAs")
+			break
+		}
+		livePods := controlledPods[vpa]
 		vpaSize := len(livePods)
+		u.loopSummary.vpas++
+		u.loopSummary.matchedPods += vpaSize
+		u.vpaDecisions = u.decisions.begin(vpa, livePods, time.Now())
 		updateMode := vpa_api_util.GetUpdateMode(vpa)
 		controlledPodsCounter.Add(vpaSize, updateMode, vpaSize)
+		vpaAttributes := []attribute.KeyValue{
+			attribute.String("vpa.namespace", vpa.Namespace),
+			attribute.String("vpa.name", vpa.Name),
+		}
+		phases.enter(phasePrioritize)
+		_, prioritiesSpan := tracer.Start(ctx, "ComputePriorities", trace.WithAttributes(vpaAttributes...))
+		prioritiesSpan.SetAttributes(attribute.Int("pod.count", vpaSize))
 		creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := u.restrictionFactory.GetCreatorMaps(livePods, vpa)
 		if err != nil {
-			klog.ErrorS(err, "Failed to get creator maps")
+			klog.ErrorS(err, "Failed to get creator maps", "vpa", klog.KObj(vpa))
+			prioritiesSpan.End()
+			u.recordLoopError(fmt.Errorf("failed to get creator maps of VPA %s: %w", klog.KObj(vpa), err))
+			u.requeueErroredVpa(vpa)
 			continue
 		}
+		u.forgetErroredVpa(vpa)
+		u.reportInvalidDisruptionBudget(vpa)
+
+		// Partial loops would count the same recommendation twice.
+		stablePods := u.filterUnstablePods(livePods, vpa, !partial)
 
 		evictionLimiter := u.restrictionFactory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 		inPlaceLimiter := u.restrictionFactory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
 		podsForInPlace := make([]*apiv1.Pod, 0)
 		podsForEviction := make([]*apiv1.Pod, 0)
+		// pods whose in-place update failed and are evicted instead
+		podsFallingBackToEviction := make(map[*apiv1.Pod]bool)
 
-		if updateMode == vpa_types.UpdateModeInPlaceOrRecreate && inPlaceFeatureEnable {
-			podsForInPlace = u.getPodsUpdateOrder(filterNonInPlaceUpdatablePods(livePods, inPlaceLimiter), vpa)
-			inPlaceUpdatablePodsCounter.Add(vpaSize, len(podsForInPlace))
-		} else {
-			// If the feature gate is not enabled but update mode is InPlaceOrRecreate, updater will always fallback to eviction.
-			if updateMode == vpa_types.UpdateModeInPlaceOrRecreate {
-				klog.InfoS("Warning: feature gate is not enabled for this updateMode", "featuregate", features.InPlaceOrRecreate, "updateMode", vpa_types.UpdateModeInPlaceOrRecreate)
+		// pods which are never evicted, because of the update mode of the VPA or their local volumes
+		inPlaceOnlyPods := make(map[*apiv1.Pod]bool)
+		preferEviction := u.prefersEviction(vpa)
+		modes, podsByMode := u.groupByUpdateMode(stablePods, updateMode)
+		for _, mode := range modes {
+			pods := podsByMode[mode]
+			inPlaceOnly := mode == vpa_types.UpdateModeInPlaceOnly
+			if (mode == vpa_types.UpdateModeInPlaceOrRecreate || inPlaceOnly) && inPlaceFeatureEnable {
+				if !inPlaceOnly {
+					// An in-place update doesn't renew pods which exceeded their maximum lifetime, so they are evicted.
+					// The eviction preference flips the order of the mode for the other pods too: they are evicted
+					// when possible and only those which can't be evicted are updated in place. It doesn't apply
+					// to pods with local volumes, which prefer in-place updates regardless of the mode of their VPA.
+					expired := filterPods(pods, func(pod *apiv1.Pod) bool { return u.updateConfig.ExceedsMaxPodLifetime(pod, now) })
+					evictFirst := expired
+					if preferEviction && mode == updateMode {
+						evictFirst = filterPods(pods, func(pod *apiv1.Pod) bool {
+							return u.localVolumes == nil || !u.localVolumes.hasLocalVolume(pod) || slices.Contains(expired, pod)
+						})
+					}
+					evictable := u.getPodsUpdateOrder(filterNonEvictablePods(evictFirst, evictionLimiter), vpa)
+					evictablePodsCounter.Add(vpaSize, updateMode, len(evictable))
+					podsForEviction = append(podsForEviction, evictable...)
+					pods = filterPods(pods, func(pod *apiv1.Pod) bool {
+						return !slices.Contains(expired, pod) && !slices.Contains(evictable, pod)
+					})
+				}
+				inPlaceUpdatable := u.getPodsInPlaceUpdateOrder(filterNonInPlaceUpdatablePods(pods, inPlaceLimiter), vpa)
+				inPlaceUpdatablePodsCounter.Add(vpaSize, len(inPlaceUpdatable))
+				for _, pod := range inPlaceUpdatable {
+					inPlaceOnlyPods[pod] = inPlaceOnly
+				}
+				podsForInPlace = append(podsForInPlace, inPlaceUpdatable...)
+			} else if inPlaceOnly {
+				// Pods are never evicted in this mode, so there is nothing to do without in-place updates.
+				klog.InfoS("Warning: feature gate is not enabled for this updateMode, not updating pods", "featuregate", features.InPlaceOrRecreate, "updateMode", vpa_types.UpdateModeInPlaceOnly, "vpa", klog.KObj(vpa))
+			} else if mode != vpa_types.UpdateModeOff {
+				// If the feature gate is not enabled but update mode is InPlaceOrRecreate, updater will always fallback to eviction.
+				if mode == vpa_types.UpdateModeInPlaceOrRecreate {
+					klog.InfoS("Warning: feature gate is not enabled for this updateMode", "featuregate", features.InPlaceOrRecreate, "updateMode", vpa_types.UpdateModeInPlaceOrRecreate)
+				}
+				// Pods refused because they aren't scheduled or because of the disruption budget of their VPA are
+				// kept, so that the ones needing an update are blocked with that reason when they are processed.
+				evictablePods := filterPods(pods, func(pod *apiv1.Pod) bool {
+					return evictionLimiter.CanEvict(pod) || restriction.IsUnscheduled(pod) || exceedsVpaDisruptionBudget(evictionLimiter, pod)
+				})
+				if mode == vpa_types.UpdateModeInitial {
+					evictablePods = u.filterNonDriftedPods(evictablePods, vpa)
+				}
+				evictable := u.getPodsUpdateOrder(evictablePods, vpa)
+				evictablePodsCounter.Add(vpaSize, updateMode, len(filterNonEvictablePods(evictable, evictionLimiter)))
+				podsForEviction = append(podsForEviction, evictable...)
 			}
-			podsForEviction = u.getPodsUpdateOrder(filterNonEvictablePods(livePods, evictionLimiter), vpa)
-			evictablePodsCounter.Add(vpaSize, updateMode, len(podsForEviction))
 		}
+		prioritiesSpan.SetAttributes(
+			attribute.Int("pod.in_place_candidates", len(podsForInPlace)),
+			attribute.Int("pod.eviction_candidates", len(podsForEviction)))
+		prioritiesSpan.End()
+
+		phases.enter(phaseAct)
+		actCtx, actSpan := tracer.Start(ctx, "Act", trace.WithAttributes(vpaAttributes...))
+		if !u.lockController(actCtx, vpa, podsForInPlace, podsForEviction) {
+			u.syncBlockedReasons(actCtx, livePods)
+			u.decisions.publish(u.vpaDecisions)
+			actSpan.End()
+			continue
+		}
+		inPlaceUpdated, evicted, rateLimited := 0, 0, 0
+		metrics_updater.RecordRateLimitedActions(vpa.Name, vpa.Namespace, rateLimited)
 
 		withInPlaceUpdatable := false
 		withInPlaceUpdated := false
 		withEvictable := false
 		withEvicted := false
+		// set when the lock of the controller was lost, so that no more pods are acted on
+		lockLost := false
+		// set when waiting for a rate limiter failed, so that no more pods or VPAs are acted on
+		stopped := false
+		var actions []vpa_types.VerticalPodAutoscalerAction
 
+		// Pods are routed on their own in-place decision, so the same VPA may get some of its pods
+		// resized in-place and the others evicted in this loop, each drawing from its own rate limiter.
 		for _, pod := range podsForInPlace {
+			if u.draining.Load() || u.killSwitch.isEngaged() {
+				break
+			}
+			inPlaceOnly := inPlaceOnlyPods[pod]
+			if !u.admittedByPredicates(pod) {
+				continue
+			}
 			withInPlaceUpdatable = true
-			decision := inPlaceLimiter.CanInPlaceUpdate(pod)
+			decision, reason := inPlaceLimiter.CanInPlaceUpdate(pod)
+			metrics_updater.RecordInPlaceDecision(string(decision), string(reason))
 
 			if decision == utils.InPlaceDeferred {
-				klog.V(0).InfoS("In-place update deferred", "pod", klog.KObj(pod))
+				klog.V(0).InfoS("In-place update deferred", "pod", klog.KObj(pod), "reason", reason)
+				u.blockPod(pod, blockedByInPlaceDeferral)
+				if inPlaceOnly {
+					u.reportInPlaceOnlyNotUpdated(pod, vpa, vpaSize, apiv1.EventTypeNormal, "InPlaceUpdateDeferred",
+						"VPA Updater deferred the in-place update of the pod, it will be retried.")
+				}
 				continue
 			} else if decision == utils.InPlaceEvict {
+				if inPlaceOnly {
+					u.reportInPlaceOnlyNotUpdated(pod, vpa, vpaSize, apiv1.EventTypeWarning, "InPlaceUpdateImpossible",
+						"VPA Updater can't update the pod in-place and doesn't evict it because the update mode is InPlaceOnly.")
+					u.vpaDecisions.decide(pod, decisionBlocked, inPlaceUpdateImpossible)
+					continue
+				}
+				klog.V(2).InfoS("Can't update pod in-place, falling back to eviction", "pod", klog.KObj(pod), "reason", reason)
 				podsForEviction = append(podsForEviction, pod)
 				continue
 			}
-			err = u.inPlaceRateLimiter.Wait(ctx)
-			if err != nil {
+			if u.podBackoff.inBackoff(pod) {
+				klog.V(4).InfoS("Not updating pod in-place, backing off after a failed update", "pod", klog.KObj(pod))
+				u.blockPod(pod, blockedByBackoff)
+				continue
+			}
+			limiter := u.namespaceInPlaceRateLimiters.get(pod.Namespace, u.inPlaceRateLimiter)
+			if isOutOfTokens(limiter) {
+				rateLimited++
+				metrics_updater.RecordRateLimitedActions(vpa.Name, vpa.Namespace, rateLimited)
+			}
+			err = u.waitForToken(actCtx, limiter)
+			if err != nil && u.draining.Load() {
+				klog.V(2).InfoS("Updater is draining, not acting on further pods")
+			} else if err != nil {
 				klog.V(0).InfoS("In-place rate limiter wait failed for in-place resize", "error", err)
 				metrics_updater.RecordFailedInPlaceUpdate(vpaSize, vpa.Name, vpa.Namespace, "InPlaceUpdateRateLimiterWaitFailed")
-				return
+				u.recordLoopError(fmt.Errorf("in-place rate limiter wait failed: %w", err))
+				u.blockPod(pod, blockedByRateLimit)
+			}
+			if err != nil {
+				stopped = true
+				break
+			}
+			if !u.renewControllerLock(actCtx, vpa, pod) {
+				lockLost = true
+				break
 			}
 			err := inPlaceLimiter.InPlaceUpdate(pod, vpa, u.eventRecorder)
+			if errors.Is(err, restriction.ErrInPlaceUnsupportedResource) || errors.Is(err, restriction.ErrInPlaceGrowthDisallowed) {
+				if inPlaceOnly {
+					u.reportInPlaceOnlyNotUpdated(pod, vpa, vpaSize, apiv1.EventTypeWarning, "InPlaceUpdateImpossible",
+						fmt.Sprintf("VPA Updater can't update the pod in-place and doesn't evict it because the update mode is InPlaceOnly: %v", err))
+					u.vpaDecisions.decide(pod, decisionBlocked, inPlaceUpdateImpossible)
+					continue
+				}
+				klog.V(2).InfoS("Can't update pod in-place, evicting it", "pod", klog.KObj(pod), "reason", err)
+				podsForEviction = append(podsForEviction, pod)
+				continue
+			}
+			if err != nil {
+				u.recordLoopError(fmt.Errorf("failed to update pod %s in-place: %w", klog.KObj(pod), err))
+			}
+			if err != nil && inPlaceOnly {
+				klog.V(0).InfoS("In-place resize failed", "error", err, "pod", klog.KObj(pod))
+				// Pods falling back to eviction back off only if the eviction fails too.
+				u.podBackoff.recordFailure(pod)
+				u.loopSummary.failed++
+				u.reportInPlaceOnlyNotUpdated(pod, vpa, vpaSize, apiv1.EventTypeWarning, "InPlaceUpdateError",
+					fmt.Sprintf("VPA Updater failed to update the pod in-place: %v", err))
+				u.vpaDecisions.decide(pod, decisionFailed, err.Error())
+				continue
+			}
 			if err != nil {
 				klog.V(0).InfoS("In-place resize failed, falling back to eviction", "error", err, "pod", klog.KObj(pod))
 				metrics_updater.RecordFailedInPlaceUpdate(vpaSize, vpa.Name, vpa.Namespace, "InPlaceUpdateError")
 				podsForEviction = append(podsForEviction, pod)
+				podsFallingBackToEviction[pod] = true
 				continue
 			}
+			u.podBackoff.recordSuccess(pod)
+			u.vpaDecisions.decide(pod, decisionInPlaceUpdated, "")
 			withInPlaceUpdated = true
-			metrics_updater.AddInPlaceUpdatedPod(vpaSize, vpa.Name, vpa.Namespace)
+			inPlaceUpdated++
+			u.loopSummary.inPlaceUpdated++
+			metrics_updater.AddInPlaceUpdatedPod(actCtx, vpaSize, vpa.Name, vpa.Namespace)
+			metrics_updater.RecordSuccessfulAPIServerContact()
+			u.reportClampedRecommendation(vpa, pod)
+			u.controllerEvents.event(actCtx, pod, apiv1.EventTypeNormal, "InPlaceResizedByVPA",
+				fmt.Sprintf("VPA Updater updated pod %s in-place to apply the recommendation of VPA %s.", pod.Name, vpa.Name))
+			if u.actionHistory != nil {
+				actions = append(actions, u.newAction(vpa_types.ActionInPlaceUpdate, vpa, pod))
+			}
 		}
 
 		for _, pod := range podsForEviction {
+			if lockLost || stopped || u.draining.Load() || u.killSwitch.isEngaged() {
+				break
+			}
+			if !u.admittedByPredicates(pod) {
+				continue
+			}
 			withEvictable = true
 			if !evictionLimiter.CanEvict(pod) {
+				if restriction.IsUnscheduled(pod) {
+					u.blockPod(pod, blockedByUnscheduled)
+					u.eventRecorder.Event(pod, apiv1.EventTypeNormal, "EvictionSkipped",
+						"Not evicting the pod, it isn't scheduled yet.")
+				} else if exceedsVpaDisruptionBudget(evictionLimiter, pod) {
+					u.blockPod(pod, blockedByVpaDisruptionBudget)
+				} else {
+					u.blockPod(pod, blockedByEvictionTolerance)
+				}
 				continue
 			}
-			err = u.evictionRateLimiter.Wait(ctx)
-			if err != nil {
+			if u.notSafeToEvict(pod) {
+				continue
+			}
+			if u.podBackoff.inBackoff(pod) {
+				klog.V(4).InfoS("Not evicting pod, backing off after a failed update", "pod", klog.KObj(pod))
+				u.blockPod(pod, blockedByBackoff)
+				continue
+			}
+			if u.exceededResourceQuota(vpa, pod) != "" {
+				continue
+			}
+			if u.evictionCoordinator != nil {
+				acknowledged, err := u.evictionCoordinator.canEvict(actCtx, pod)
+				if err != nil {
+					klog.V(0).InfoS("Requesting eviction acknowledgement failed", "error", err, "pod", klog.KObj(pod))
+					u.recordLoopError(fmt.Errorf("failed to request eviction acknowledgement of pod %s: %w", klog.KObj(pod), err))
+					continue
+				}
+				if !acknowledged {
+					u.blockPod(pod, blockedByCoordinator)
+					continue
+				}
+			}
+			budget, withBudget := u.evictionAdmission.(priority.PodEvictionBudget)
+			if withBudget && !budget.AllowsEviction(pod) {
+				u.blockPod(pod, blockedByDisruptionCap)
+				continue
+			}
+			// Checked before the rate limiter, so that paused evictions don't consume its tokens.
+			if u.evictionCircuitBreaker != nil && !u.evictionCircuitBreaker.allow() {
+				klog.V(2).InfoS("Not evicting pod, evictions are paused after too many failures", "pod", klog.KObj(pod))
+				u.blockPod(pod, blockedByEvictionsPaused)
+				continue
+			}
+			limiter := u.namespaceEvictionRateLimiters.get(pod.Namespace, u.evictionRateLimiter)
+			if isOutOfTokens(limiter) {
+				rateLimited++
+				metrics_updater.RecordRateLimitedActions(vpa.Name, vpa.Namespace, rateLimited)
+			}
+			err = u.waitForToken(actCtx, limiter)
+			if err != nil && u.draining.Load() {
+				klog.V(2).InfoS("Updater is draining, not acting on further pods")
+			} else if err != nil {
 				klog.V(0).InfoS("Eviction rate limiter wait failed", "error", err)
-				metrics_updater.RecordFailedEviction(vpaSize, vpa.Name, vpa.Namespace, updateMode, "EvictionRateLimiterWaitFailed")
-				return
+				metrics_updater.RecordFailedEviction(actCtx, vpaSize, vpa.Name, vpa.Namespace, updateMode, "EvictionRateLimiterWaitFailed")
+				u.recordLoopError(fmt.Errorf("eviction rate limiter wait failed: %w", err))
+				u.blockPod(pod, blockedByRateLimit)
+			}
+			if err != nil {
+				u.evictionCircuitBreaker.cancel()
+				stopped = true
+				break
+			}
+			if !u.renewControllerLock(actCtx, vpa, pod) {
+				u.evictionCircuitBreaker.cancel()
+				break
 			}
 			klog.V(2).InfoS("Evicting pod", "pod", klog.KObj(pod))
 			evictErr := evictionLimiter.Evict(pod, vpa, u.eventRecorder)
+			// Evictions blocked by a PodDisruptionBudget are expected and don't indicate an outage.
+			if u.evictionCircuitBreaker != nil && u.evictionCircuitBreaker.record(evictErr != nil && !apierrors.IsTooManyRequests(evictErr)) {
+				u.eventRecorder.Event(vpa, apiv1.EventTypeWarning, "EvictionsPaused",
+					"VPA Updater paused all evictions because too many of them failed recently.")
+			}
 			if evictErr != nil {
 				klog.V(0).InfoS("Eviction failed", "error", evictErr, "pod", klog.KObj(pod))
-				metrics_updater.RecordFailedEviction(vpaSize, vpa.Name, vpa.Namespace, updateMode, "EvictionError")
+				u.podBackoff.recordFailure(pod)
+				metrics_updater.RecordFailedEviction(actCtx, vpaSize, vpa.Name, vpa.Namespace, updateMode, "EvictionError")
+				if apierrors.IsTooManyRequests(evictErr) {
+					u.blockPod(pod, blockedByPodDisruptionBudget)
+				} else {
+					u.loopSummary.failed++
+					u.recordLoopError(fmt.Errorf("failed to evict pod %s: %w", klog.KObj(pod), evictErr))
+					u.vpaDecisions.decide(pod, decisionFailed, evictErr.Error())
+				}
+				if podsFallingBackToEviction[pod] {
+					klog.ErrorS(evictErr, "Fallback eviction failed after in-place update failure, pod was not updated", "pod", klog.KObj(pod))
+					metrics_updater.RecordFailedFallbackEviction(vpaSize, vpa.Name, vpa.Namespace)
+				}
 			} else {
+				u.podBackoff.recordSuccess(pod)
+				u.resourceQuotas.reserve(pod)
+				if withBudget {
+					budget.RecordEviction(pod)
+				}
+				u.vpaDecisions.decide(pod, decisionEvicted, "")
 				withEvicted = true
-				metrics_updater.AddEvictedPod(vpaSize, vpa.Name, vpa.Namespace, updateMode)
+				evicted++
+				u.loopSummary.evicted++
+				metrics_updater.AddEvictedPod(actCtx, vpaSize, vpa.Name, vpa.Namespace, updateMode)
+				metrics_updater.RecordSuccessfulAPIServerContact()
+				u.reportClampedRecommendation(vpa, pod)
+				u.controllerEvents.event(actCtx, pod, apiv1.EventTypeNormal, "EvictedByVPA",
+					fmt.Sprintf("VPA Updater evicted pod %s to apply the recommendation of VPA %s.", pod.Name, vpa.Name))
+				if u.rescheduleTracker != nil {
+					u.rescheduleTracker.recordEviction(pod)
+				}
+				if u.actionHistory != nil {
+					actions = append(actions, u.newAction(vpa_types.ActionEviction, vpa, pod))
+				}
 			}
 		}
 
+		u.controllerLocks.release(actCtx, vpa)
+		u.syncBlockedReasons(actCtx, livePods)
+		u.decisions.publish(u.vpaDecisions)
+
+		if u.actionHistory != nil {
+			// The actions were taken, so they are recorded even if the loop ran out of time.
+			if err := u.actionHistory.record(context.WithoutCancel(actCtx), vpa, actions); err != nil {
+				klog.ErrorS(err, "Failed to record action history", "vpa", klog.KObj(vpa))
+				u.recordLoopError(fmt.Errorf("failed to record action history of VPA %s: %w", klog.KObj(vpa), err))
+			}
+		}
+		actSpan.SetAttributes(attribute.Int("pod.in_place_updated", inPlaceUpdated), attribute.Int("pod.evicted", evicted))
+		actSpan.End()
+
 		if withInPlaceUpdatable {
 			vpasWithInPlaceUpdatablePodsCounter.Add(vpaSize, 1)
 		}
@@ -342,8 +1229,112 @@ func (u *updater) RunOnce(ctx context.Context) {
 		if withEvicted {
 			vpasWithEvictedPodsCounter.Add(vpaSize, updateMode, 1)
 		}
+		if stopped {
+			break
+		}
+	}
+	observeStep("EvictPods")
+}
+
+// flushActionHistory writes the buffered action history, all of it if the updater is draining, as
+// it's lost otherwise.
+func (u *updater) flushActionHistory(ctx context.Context) {
+	if u.actionHistory == nil {
+		return
+	}
+	// The loop may have run out of time, the buffered actions are written anyway.
+	ctx = context.WithoutCancel(ctx)
+	flush := u.actionHistory.flush
+	if u.draining.Load() {
+		flush = u.actionHistory.flushAll
+	}
+	if err := flush(ctx); err != nil {
+		klog.ErrorS(err, "Failed to flush action history")
+		u.recordLoopError(fmt.Errorf("failed to flush action history: %w", err))
+	}
+}
+
+// recordRecommendationAge records the age of the VPA recommendation and returns it, or false if it isn't
+// known. It's recorded for all VPAs in every loop, so that the metric keeps advancing for VPAs which aren't acted on.
+func recordRecommendationAge(vpa *vpa_types.VerticalPodAutoscaler, now time.Time) (time.Duration, bool) {
+	lastUpdateTime, found := vpa_api_util.RecommendationLastUpdateTime(vpa)
+	if !found {
+		return 0, false
+	}
+	age := now.Sub(lastUpdateTime)
+	metrics_updater.RecordRecommendationAge(vpa.Name, vpa.Namespace, age)
+	return age, true
+}
+
+// isRecommendationFresh returns false if the VPA recommendation of the given age is older than
+// maxRecommendationAge, e.g. because the recommender is down.
+func (u *updater) isRecommendationFresh(vpa *vpa_types.VerticalPodAutoscaler, age time.Duration) bool {
+	if u.maxRecommendationAge > 0 && age > u.maxRecommendationAge {
+		klog.V(2).InfoS("Skipping VPA object because its recommendation is too old", "vpa", klog.KObj(vpa), "age", age, "maxRecommendationAge", u.maxRecommendationAge)
+		return false
+	}
+	return true
+}
+
+// trackSelectorChanges records the selectors of the given VPAs and returns the VPAs
+// whose selector changed since the previous loop.
+func (u *updater) trackSelectorChanges(vpas []*vpa_api_util.VpaWithSelector) map[*vpa_types.VerticalPodAutoscaler]bool {
+	changed := make(map[*vpa_types.VerticalPodAutoscaler]bool)
+	selectors := make(map[string]string, len(vpas))
+	for _, vpa := range vpas {
+		key := vpa.Vpa.Namespace + "/" + vpa.Vpa.Name
+		selector := vpa.Selector.String()
+		if previous, found := u.vpaSelectors[key]; found && previous != selector {
+			klog.V(2).InfoS("VPA selector changed since previous loop", "vpa", klog.KObj(vpa.Vpa), "previousSelector", previous, "selector", selector)
+			changed[vpa.Vpa] = true
+		}
+		selectors[key] = selector
+	}
+	u.vpaSelectors = selectors
+	return changed
+}
+
+// getVpasProcessingOrder returns the VPAs to process in this loop. If enabled, VPAs are ordered by the
+// lowest scheduling priority of their pods, so that combined with the order of the pods of each VPA,
+// low-priority pods are acted on first across VPAs. If enabled, VPAs whose selector changed are processed
+// first among VPAs of the same scheduling priority, so their newly matched pods are not starved by rate limiting.
+func (u *updater) getVpasProcessingOrder(controlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod, vpasWithChangedSelector map[*vpa_types.VerticalPodAutoscaler]bool) []*vpa_types.VerticalPodAutoscaler {
+	vpas := make([]*vpa_types.VerticalPodAutoscaler, 0, len(controlledPods))
+	lowestSchedulingPriority := make(map[*vpa_types.VerticalPodAutoscaler]int32, len(controlledPods))
+	for vpa, pods := range controlledPods {
+		vpas = append(vpas, vpa)
+		if !u.preferLowPriorityPods {
+			continue
+		}
+		lowest := int32(math.MaxInt32)
+		for _, pod := range pods {
+			lowest = min(lowest, schedulingPriority(pod))
+		}
+		lowestSchedulingPriority[vpa] = lowest
 	}
-	timer.ObserveStep("EvictPods")
+	slices.SortStableFunc(vpas, func(a, b *vpa_types.VerticalPodAutoscaler) int {
+		if c := cmp.Compare(lowestSchedulingPriority[a], lowestSchedulingPriority[b]); c != 0 {
+			return c
+		}
+		if u.prioritizeSelectorChanges {
+			switch {
+			case vpasWithChangedSelector[a] && !vpasWithChangedSelector[b]:
+				return -1
+			case !vpasWithChangedSelector[a] && vpasWithChangedSelector[b]:
+				return 1
+			}
+		}
+		return 0
+	})
+	return vpas
+}
+
+// schedulingPriority returns the scheduling priority of the pod, as set by its PriorityClass.
+func schedulingPriority(pod *apiv1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
 }
 
 func getRateLimiter(rateLimit float64, rateLimitBurst int) *rate.Limiter {
@@ -361,17 +1352,151 @@ func getRateLimiter(rateLimit float64, rateLimitBurst int) *rate.Limiter {
 
 // getPodsUpdateOrder returns list of pods that should be updated ordered by update priority
 func (u *updater) getPodsUpdateOrder(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) []*apiv1.Pod {
+	return u.getPodsUpdateOrderFor(pods, vpa, false)
+}
+
+// getPodsInPlaceUpdateOrder is getPodsUpdateOrder for pods updated in place, which are not
+// accepted only because they exceeded their maximum lifetime, as resizing them doesn't renew them.
+func (u *updater) getPodsInPlaceUpdateOrder(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) []*apiv1.Pod {
+	return u.getPodsUpdateOrderFor(pods, vpa, true)
+}
+
+func (u *updater) getPodsUpdateOrderFor(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, inPlace bool) []*apiv1.Pod {
 	priorityCalculator := priority.NewUpdatePriorityCalculator(
 		vpa,
-		nil,
+		u.updateConfig,
 		u.recommendationProcessor,
 		u.priorityProcessor)
+	if u.appliedRecommendations != nil {
+		priorityCalculator.SetAppliedRecommendationCache(u.appliedRecommendations)
+	}
+	if inPlace {
+		priorityCalculator.IgnoreMaxPodLifetime()
+	}
 
 	for _, pod := range pods {
 		priorityCalculator.AddPod(pod, time.Now())
 	}
 
-	return priorityCalculator.GetSortedPods(u.evictionAdmission)
+	sorted := priorityCalculator.GetSortedPods(u.evictionAdmission)
+	if u.vpaDecisions != nil {
+		for _, pod := range pods {
+			podPriority, accepted := priorityCalculator.GetPodPriority(pod)
+			if !accepted {
+				continue
+			}
+			u.vpaDecisions.prioritize(pod, podPriority)
+			if !slices.Contains(sorted, pod) {
+				u.vpaDecisions.decide(pod, decisionNotAdmitted, "the pod was removed from the update queue by the eviction admission")
+			}
+		}
+	}
+	return sorted
+}
+
+// correctsDriftOf returns true if the VPA is in Initial mode and pods drifting far from its recommendation are evicted.
+func (u *updater) correctsDriftOf(vpa *vpa_types.VerticalPodAutoscaler) bool {
+	return u.initialModeDriftThreshold > 0 && vpa_api_util.GetUpdateMode(vpa) == vpa_types.UpdateModeInitial
+}
+
+// filterNonDriftedPods keeps the pods whose resource diff to the recommendation is at least initialModeDriftThreshold.
+func (u *updater) filterNonDriftedPods(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) []*apiv1.Pod {
+	return filterPods(pods, func(pod *apiv1.Pod) bool {
+		recommendation, _, err := u.recommendationProcessor.Apply(vpa, pod)
+		if err != nil {
+			klog.V(2).ErrorS(err, "Cannot process recommendation for pod", "pod", klog.KObj(pod))
+			return false
+		}
+		drift := u.priorityProcessor.GetUpdatePriority(pod, vpa, recommendation).ResourceDiff
+		if drift < u.initialModeDriftThreshold {
+			klog.V(4).InfoS("Not updating pod of VPA in Initial mode, it didn't drift far enough from the recommendation", "pod", klog.KObj(pod), "drift", drift, "threshold", u.initialModeDriftThreshold)
+			return false
+		}
+		return true
+	})
+}
+
+// groupByUpdateMode groups the pods by the update mode applied to them, which differs from the mode of
+// their VPA for pods with local volumes. The modes are returned in the order their first pod was seen.
+func (u *updater) groupByUpdateMode(pods []*apiv1.Pod, vpaMode vpa_types.UpdateMode) ([]vpa_types.UpdateMode, map[vpa_types.UpdateMode][]*apiv1.Pod) {
+	if u.localVolumes == nil {
+		return []vpa_types.UpdateMode{vpaMode}, map[vpa_types.UpdateMode][]*apiv1.Pod{vpaMode: pods}
+	}
+	var modes []vpa_types.UpdateMode
+	podsByMode := make(map[vpa_types.UpdateMode][]*apiv1.Pod)
+	for _, pod := range pods {
+		mode := u.localVolumes.updateMode(pod, vpaMode)
+		if mode != vpaMode {
+			klog.V(4).InfoS("Pod uses local volumes, changing its update mode", "pod", klog.KObj(pod), "vpaUpdateMode", vpaMode, "updateMode", mode)
+		}
+		if _, found := podsByMode[mode]; !found {
+			modes = append(modes, mode)
+		}
+		podsByMode[mode] = append(podsByMode[mode], pod)
+	}
+	return modes, podsByMode
+}
+
+// prefersEviction returns true if the pods of the VPA are to be evicted before trying to update them in place.
+// An invalid preference is reported with a single event on the VPA until it changes.
+func (u *updater) prefersEviction(vpa *vpa_types.VerticalPodAutoscaler) bool {
+	preference, err := annotations.GetVpaUpdatePreference(vpa.Annotations)
+	if err != nil {
+		klog.V(4).InfoS("Ignoring the update preference of VPA", "vpa", klog.KObj(vpa), "error", err)
+		value := vpa.Annotations[annotations.VpaUpdatePreferenceAnnotation]
+		if reported, found := u.invalidUpdatePreferences[vpa.UID]; !found || reported != value {
+			if u.invalidUpdatePreferences == nil {
+				u.invalidUpdatePreferences = make(map[types.UID]string)
+			}
+			u.invalidUpdatePreferences[vpa.UID] = value
+			u.eventRecorder.Event(vpa, apiv1.EventTypeWarning, "InvalidUpdatePreference", fmt.Sprintf("Ignoring the update preference: %v", err))
+		}
+	} else {
+		delete(u.invalidUpdatePreferences, vpa.UID)
+	}
+	return preference == annotations.UpdatePreferenceEvict
+}
+
+// reportInvalidDisruptionBudget reports an invalid disruption budget of the VPA, which blocks the
+// evictions of its pods, with a single event on the VPA until it changes.
+func (u *updater) reportInvalidDisruptionBudget(vpa *vpa_types.VerticalPodAutoscaler) {
+	_, _, err := annotations.GetVpaDisruptionBudget(vpa.Annotations)
+	if err == nil {
+		delete(u.invalidDisruptionBudgets, vpa.UID)
+		return
+	}
+	value := vpa.Annotations[annotations.VpaMaxUnavailableAnnotation] + "/" + vpa.Annotations[annotations.VpaMinAvailableAnnotation]
+	if reported, found := u.invalidDisruptionBudgets[vpa.UID]; found && reported == value {
+		return
+	}
+	if u.invalidDisruptionBudgets == nil {
+		u.invalidDisruptionBudgets = make(map[types.UID]string)
+	}
+	u.invalidDisruptionBudgets[vpa.UID] = value
+	u.eventRecorder.Event(vpa, apiv1.EventTypeWarning, "InvalidDisruptionBudget", fmt.Sprintf("Not evicting pods until the disruption budget is fixed: %v", err))
+}
+
+// filterUnstablePods keeps the pods whose recommendation was stable for enough loops, recording the
+// recommendation seen in this loop first if observe is set. Pods with the evict-now annotation are always kept.
+func (u *updater) filterUnstablePods(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, observe bool) []*apiv1.Pod {
+	if u.recommendationStability == nil {
+		return pods
+	}
+	return filterPods(pods, func(pod *apiv1.Pod) bool {
+		if observe {
+			recommendation, _, err := u.recommendationProcessor.Apply(vpa, pod)
+			if err != nil {
+				klog.V(2).ErrorS(err, "Cannot process recommendation for pod", "pod", klog.KObj(pod))
+				return false
+			}
+			u.recommendationStability.observe(pod, recommendation)
+		}
+		if annotations.IsVpaEvictNowRequested(pod.Annotations) || u.recommendationStability.isStable(pod) {
+			return true
+		}
+		klog.V(4).InfoS("Not updating pod, its recommendation isn't stable yet", "pod", klog.KObj(pod))
+		return false
+	})
 }
 
 func filterPods(pods []*apiv1.Pod, predicate func(*apiv1.Pod) bool) []*apiv1.Pod {
@@ -386,7 +1511,13 @@ func filterPods(pods []*apiv1.Pod, predicate func(*apiv1.Pod) bool) []*apiv1.Pod
 
 func filterNonInPlaceUpdatablePods(pods []*apiv1.Pod, inplaceRestriction restriction.PodsInPlaceRestriction) []*apiv1.Pod {
 	return filterPods(pods, func(pod *apiv1.Pod) bool {
-		return inplaceRestriction.CanInPlaceUpdate(pod) != utils.InPlaceDeferred
+		decision, reason := inplaceRestriction.CanInPlaceUpdate(pod)
+		if decision == utils.InPlaceDeferred {
+			// Pods which aren't filtered out record the decision made when they are processed.
+			metrics_updater.RecordInPlaceDecision(string(decision), string(reason))
+			return false
+		}
+		return true
 	})
 }
 
@@ -394,6 +1525,12 @@ func filterNonEvictablePods(pods []*apiv1.Pod, evictionRestriction restriction.P
 	return filterPods(pods, evictionRestriction.CanEvict)
 }
 
+// exceedsVpaDisruptionBudget returns true if the disruption budget of the VPA of the pod allows no more evictions.
+func exceedsVpaDisruptionBudget(evictionRestriction restriction.PodsEvictionRestriction, pod *apiv1.Pod) bool {
+	budget, ok := evictionRestriction.(restriction.VpaDisruptionBudgetRestriction)
+	return ok && budget.ExceedsVpaDisruptionBudget(pod)
+}
+
 func filterDeletedPods(pods []*apiv1.Pod) []*apiv1.Pod {
 	return filterPods(pods, func(pod *apiv1.Pod) bool {
 		return pod.DeletionTimestamp == nil
@@ -413,7 +1550,14 @@ func newPodLister(kubeClient kube_client.Interface, namespace string) v1lister.P
 	return podLister
 }
 
-func newEventRecorder(kubeClient kube_client.Interface) record.EventRecorder {
+// reportInPlaceOnlyNotUpdated reports a pod of a VPA in InPlaceOnly mode which wasn't updated in-place.
+// Such pods are never evicted instead.
+func (u *updater) reportInPlaceOnlyNotUpdated(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, vpaSize int, eventType, reason, message string) {
+	metrics_updater.RecordFailedInPlaceUpdate(vpaSize, vpa.Name, vpa.Namespace, reason)
+	u.eventRecorder.Event(pod, eventType, reason, message)
+}
+
+func newEventRecorder(kubeClient kube_client.Interface, component string) (record.EventRecorder, record.EventBroadcaster) {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(4)
 	if _, isFake := kubeClient.(*fake.Clientset); !isFake {
@@ -428,5 +1572,5 @@ func newEventRecorder(kubeClient kube_client.Interface) record.EventRecorder {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
-	return eventBroadcaster.NewRecorder(vpascheme, apiv1.EventSource{Component: "vpa-updater"})
+	return eventBroadcaster.NewRecorder(vpascheme, apiv1.EventSource{Component: component}), eventBroadcaster
 }
@@ -19,11 +19,19 @@ package logic
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/mock/gomock"
 	"golang.org/x/time/rate"
 	v1 "k8s.io/api/autoscaling/v1"
@@ -33,17 +41,22 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_fake "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/fake"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/features"
 	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	target_mock "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/mock"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
 	restriction "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
 	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/status"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 )
 
 func parseLabelSelector(selector string) labels.Selector {
@@ -62,142 +75,1338 @@ func TestRunOnce_Mode(t *testing.T) {
 		expectedInPlacedCount int
 		canEvict              bool
 		canInPlaceUpdate      utils.InPlaceDecision
+		inPlaceReason         utils.InPlaceDecisionReason
+		// expectedInPlaceDecisions is the number of in-place decisions recorded with canInPlaceUpdate and inPlaceReason.
+		expectedInPlaceDecisions int
 	}{
 		{
-			name:                  "with Auto mode",
-			updateMode:            vpa_types.UpdateModeAuto, //nolint:staticcheck
-			shouldInPlaceFail:     false,
+			name:                     "with Auto mode",
+			updateMode:               vpa_types.UpdateModeAuto, //nolint:staticcheck
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    5,
+			expectedInPlacedCount:    0,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 0,
+		},
+		{
+			name:                     "with Initial mode",
+			updateMode:               vpa_types.UpdateModeInitial,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         false,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    0,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 0,
+		},
+		{
+			name:                     "with Off mode",
+			updateMode:               vpa_types.UpdateModeOff,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         false,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    0,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 0,
+		},
+		{
+			name:                     "with InPlaceOrRecreate mode expecting in-place updates",
+			updateMode:               vpa_types.UpdateModeInPlaceOrRecreate,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    5,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOrRecreate mode expecting fallback to evictions",
+			updateMode:               vpa_types.UpdateModeInPlaceOrRecreate,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    5,
+			expectedInPlacedCount:    0,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceEvict,
+			inPlaceReason:            utils.InPlaceReasonResizeFailed,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOrRecreate mode expecting no evictions or in-place",
+			updateMode:               vpa_types.UpdateModeInPlaceOrRecreate,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    0,
+			canEvict:                 false,
+			canInPlaceUpdate:         utils.InPlaceDeferred,
+			inPlaceReason:            utils.InPlaceReasonToleranceExceeded,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOrRecreate mode and failed in-place update",
+			updateMode:               vpa_types.UpdateModeInPlaceOrRecreate,
+			shouldInPlaceFail:        true,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    5, // All pods should be evicted after in-place update fails
+			expectedInPlacedCount:    5, // All pods attempt in-place update first
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOnly mode expecting in-place updates",
+			updateMode:               vpa_types.UpdateModeInPlaceOnly,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    5,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOnly mode expecting no fallback to evictions",
+			updateMode:               vpa_types.UpdateModeInPlaceOnly,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    0,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceEvict,
+			inPlaceReason:            utils.InPlaceReasonResizeFailed,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOnly mode expecting no evictions or in-place",
+			updateMode:               vpa_types.UpdateModeInPlaceOnly,
+			shouldInPlaceFail:        false,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0,
+			expectedInPlacedCount:    0,
+			canEvict:                 false,
+			canInPlaceUpdate:         utils.InPlaceDeferred,
+			inPlaceReason:            utils.InPlaceReasonToleranceExceeded,
+			expectedInPlaceDecisions: 5,
+		},
+		{
+			name:                     "with InPlaceOnly mode and failed in-place update",
+			updateMode:               vpa_types.UpdateModeInPlaceOnly,
+			shouldInPlaceFail:        true,
+			expectFetchCalls:         true,
+			expectedEvictionCount:    0, // Pods are not evicted after in-place update fails
+			expectedInPlacedCount:    5,
+			canEvict:                 true,
+			canInPlaceUpdate:         utils.InPlaceApproved,
+			inPlaceReason:            utils.InPlaceReasonWithinTolerance,
+			expectedInPlaceDecisions: 5,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := registerTestMetrics(t)
+			decisionLabels := map[string]string{"decision": string(tc.canInPlaceUpdate), "reason": string(tc.inPlaceReason)}
+			before := gatherMetricValueWithLabels(t, registry, "vpa_updater_in_place_decisions_total", decisionLabels)
+
+			testRunOnceBase(
+				t,
+				tc.updateMode,
+				tc.shouldInPlaceFail,
+				false,
+				newFakeValidator(true),
+				tc.expectFetchCalls,
+				tc.expectedEvictionCount,
+				tc.expectedInPlacedCount,
+				tc.canInPlaceUpdate,
+				tc.inPlaceReason,
+			)
+
+			assert.Equal(t, float64(tc.expectedInPlaceDecisions),
+				gatherMetricValueWithLabels(t, registry, "vpa_updater_in_place_decisions_total", decisionLabels)-before)
+		})
+	}
+}
+
+func TestRunOnce_Status(t *testing.T) {
+	tests := []struct {
+		name                  string
+		statusValidator       status.Validator
+		expectFetchCalls      bool
+		expectedEvictionCount int
+		expectedInPlacedCount int
+	}{
+		{
+			name:                  "with valid status",
+			statusValidator:       newFakeValidator(true),
 			expectFetchCalls:      true,
 			expectedEvictionCount: 5,
 			expectedInPlacedCount: 0,
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceApproved,
 		},
 		{
-			name:                  "with Initial mode",
-			updateMode:            vpa_types.UpdateModeInitial,
-			shouldInPlaceFail:     false,
+			name:                  "with invalid status",
+			statusValidator:       newFakeValidator(false),
 			expectFetchCalls:      false,
 			expectedEvictionCount: 0,
 			expectedInPlacedCount: 0,
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceApproved,
 		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testRunOnceBase(
+				t,
+				vpa_types.UpdateModeRecreate,
+				false,
+				false,
+				tc.statusValidator,
+				tc.expectFetchCalls,
+				tc.expectedEvictionCount,
+				tc.expectedInPlacedCount,
+				utils.InPlaceApproved,
+				utils.InPlaceReasonWithinTolerance,
+			)
+		})
+	}
+}
+
+func TestRunOnce_FallbackEvictionFailure(t *testing.T) {
+	registry := registerTestMetrics(t)
+	// Counters outlive a single test run, so compare values before and after the loop.
+	before := gatherMetricValue(t, registry, "vpa_updater_fallback_eviction_failures_total")
+
+	testRunOnceBase(
+		t,
+		vpa_types.UpdateModeInPlaceOrRecreate,
+		true,
+		true,
+		newFakeValidator(true),
+		true,
+		5, // All pods fall back to eviction after in-place update fails
+		5,
+		utils.InPlaceApproved,
+		utils.InPlaceReasonWithinTolerance,
+	)
+
+	assert.Equal(t, float64(5), gatherMetricValue(t, registry, "vpa_updater_fallback_eviction_failures_total")-before)
+}
+
+func TestRunOnce_InPlaceUnsupportedResource(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	for _, tc := range []struct {
+		updateMode            vpa_types.UpdateMode
+		expectedEvictionCount int
+	}{
+		{updateMode: vpa_types.UpdateModeInPlaceOrRecreate, expectedEvictionCount: 3},
+		{updateMode: vpa_types.UpdateModeInPlaceOnly, expectedEvictionCount: 0},
+	} {
+		t.Run(string(tc.updateMode), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			eviction := &test.PodsEvictionRestrictionMock{}
+			inplace := &test.PodsInPlaceRestrictionMock{}
+			eventRecorder := test.FakeEventRecorder()
+			pods := make([]*apiv1.Pod, 3)
+			for i := range pods {
+				container := test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).WithMemRequest(resource.MustParse("200M")).Get()
+				container.Resources.Requests[apiv1.ResourceEphemeralStorage] = resource.MustParse("1Gi")
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(container).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+				inplace.On("CanInPlaceUpdate", pods[i]).Return(utils.InPlaceApproved, utils.InPlaceReasonWithinTolerance)
+				inplace.On("InPlaceUpdate", pods[i], eventRecorder).Return(fmt.Errorf("%w: ephemeral-storage", restriction.ErrInPlaceUnsupportedResource))
+				eviction.On("CanEvict", pods[i]).Return(true)
+				eviction.On("Evict", pods[i], eventRecorder).Return(nil)
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			vpaObj := test.VerticalPodAutoscaler().
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithTargetResource(apiv1.ResourceEphemeralStorage, "4Gi").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				WithUpdateMode(tc.updateMode).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				eventRecorder:           eventRecorder,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inplace},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()), "pods which can't be resized in place are expected to be evicted")
+			inplace.AssertNumberOfCalls(t, "InPlaceUpdate", len(pods))
+			eviction.AssertNumberOfCalls(t, "Evict", tc.expectedEvictionCount)
+		})
+	}
+}
+
+func TestRunOnce_InitContainerRecommendation(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	for _, tc := range []struct {
+		updateMode vpa_types.UpdateMode
+	}{
+		{updateMode: vpa_types.UpdateModeInPlaceOrRecreate},
+		{updateMode: vpa_types.UpdateModeInPlaceOnly},
+		{updateMode: vpa_types.UpdateModeRecreate},
+	} {
+		t.Run(string(tc.updateMode), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			initContainerName := "init"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			eviction := &test.PodsEvictionRestrictionMock{}
+			inplace := &test.PodsInPlaceRestrictionMock{}
+			eventRecorder := test.FakeEventRecorder()
+			pods := make([]*apiv1.Pod, 3)
+			for i := range pods {
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).WithMemRequest(resource.MustParse("200M")).Get()).
+					AddInitContainer(test.Container().WithName(initContainerName).WithCPURequest(resource.MustParse("100m")).WithMemRequest(resource.MustParse("50M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+				inplace.On("CanInPlaceUpdate", pods[i]).Return(utils.InPlaceApproved, utils.InPlaceReasonWithinTolerance)
+				eviction.On("CanEvict", pods[i]).Return(true)
+				eviction.On("Evict", pods[i], eventRecorder).Return(nil)
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			// Only the recommendation of the init container differs from the requests of the pods.
+			vpaObj := test.VerticalPodAutoscaler().
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				WithUpdateMode(tc.updateMode).
+				Get()
+			vpaObj.Status.Recommendation.ContainerRecommendations = append(vpaObj.Status.Recommendation.ContainerRecommendations,
+				test.Recommendation().WithContainer(initContainerName).WithTarget("1", "100M").GetContainerResources())
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				eventRecorder:           eventRecorder,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inplace},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()))
+			inplace.AssertNotCalled(t, "InPlaceUpdate", mock.Anything, mock.Anything)
+			// The admission controller doesn't set the requests of init containers, so recreating
+			// the pods wouldn't apply the recommendation either.
+			eviction.AssertNotCalled(t, "Evict", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRunOnce_LastSuccessfulAPIServerContact(t *testing.T) {
+	registry := registerTestMetrics(t)
+	before := float64(time.Now().Unix())
+
+	testRunOnceBase(
+		t,
+		vpa_types.UpdateModeRecreate,
+		false,
+		false,
+		newFakeValidator(true),
+		true,
+		5,
+		0,
+		utils.InPlaceApproved,
+		utils.InPlaceReasonWithinTolerance,
+	)
+
+	assert.GreaterOrEqual(t, gatherMetricValue(t, registry, "vpa_updater_last_successful_apiserver_contact_seconds"), before)
+}
+
+func TestRunOnce_MatchedPods(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	registry := registerTestMetrics(t)
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		eviction.On("CanEvict", pods[i]).Return(false)
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	newVpa := func(name string) *vpa_types.VerticalPodAutoscaler {
+		return test.VerticalPodAutoscaler().
+			WithName(name).
+			WithNamespace("default").
+			WithContainer(containerName).
+			WithTarget("2", "200M").
+			WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+			Get()
+	}
+	matchingVpa := newVpa("matching")
+	mismatchedVpa := newVpa("mismatched")
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{matchingVpa, mismatchedVpa}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(matchingVpa)).Return(parseLabelSelector("app = testingApp"), nil)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(mismatchedVpa)).Return(parseLabelSelector("app = otherApp"), nil)
+
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		killSwitch:              &killSwitch{},
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, float64(len(pods)), gatherMetricValueWithLabels(t, registry, "vpa_updater_matched_pods",
+		map[string]string{"vpa_name": "matching", "vpa_namespace": "default"}))
+	assert.Equal(t, 0.0, gatherMetricValueWithLabels(t, registry, "vpa_updater_matched_pods",
+		map[string]string{"vpa_name": "mismatched", "vpa_namespace": "default"}))
+	assert.Equal(t, 2, testutil.CollectAndCount(registry, "vpa_updater_matched_pods"), "VPAs matching no pods are reported too")
+
+	updater.killSwitch.set(map[string]string{"disabled": "true"})
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.Equal(t, 0, testutil.CollectAndCount(registry, "vpa_updater_matched_pods"), "loops ending early don't keep the counts of earlier loops")
+}
+
+func TestRunOnce_RateLimitedActions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	registry := registerTestMetrics(t)
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		eviction.On("CanEvict", pods[i]).Return(true)
+		eviction.On("Evict", pods[i], nil).Return(nil)
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithName("throttled").
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	updater := &updater{
+		vpaLister:          vpaLister,
+		podLister:          podLister,
+		restrictionFactory: &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		// A single token, refilled every 50ms, so that all evictions but the first one have to wait.
+		evictionRateLimiter:     rate.NewLimiter(rate.Every(50*time.Millisecond), 1),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	eviction.AssertNumberOfCalls(t, "Evict", len(pods))
+	assert.Equal(t, float64(len(pods)-1), gatherMetricValueWithLabels(t, registry, "vpa_updater_rate_limited_actions",
+		map[string]string{"vpa_name": "throttled", "vpa_namespace": "default"}))
+}
+
+func TestRunOnce_Drain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+		eviction.On("CanEvict", pods[i]).Return(true)
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).Times(2)
+
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+	// The updater is asked to drain while it evicts the first pod.
+	eviction.On("Evict", mock.Anything, nil).Return(nil).Run(func(mock.Arguments) { updater.Drain() })
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	eviction.AssertNumberOfCalls(t, "Evict", 1)
+
+	// Later loops don't act on pods either.
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	eviction.AssertNumberOfCalls(t, "Evict", 1)
+}
+
+func TestRunOnce_DrainStopsRateLimiterWait(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pod := test.Pod().WithName("pod").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+		WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+		WithLabels(map[string]string{"app": "testingApp"}).
+		Get()
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return([]*apiv1.Pod{pod}, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	var evictions atomic.Int32
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(*apiv1.Pod) bool { return true },
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evictions.Add(1)
+			return nil
+		},
+	}
+	// The only token is taken, so the eviction waits for an hour.
+	evictionRateLimiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	evictionRateLimiter.Allow()
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     evictionRateLimiter,
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+
+	done := make(chan error)
+	go func() { done <- updater.RunOnce(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+	updater.Drain()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "a wait stopped by the drain isn't an error")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the wait for the rate limiter wasn't stopped by the drain")
+	}
+	assert.Equal(t, int32(0), evictions.Load())
+}
+
+func TestRunOnce_DrainFlushesActionHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 2)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithName("vpa").
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	var evictions atomic.Int32
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(*apiv1.Pod) bool { return true },
+		EvictFunc: func(*apiv1.Pod, *vpa_types.VerticalPodAutoscaler, record.EventRecorder) error {
+			evictions.Add(1)
+			return nil
+		},
+	}
+	vpaClient := vpa_fake.NewSimpleClientset(vpaObj)
+	// The history was flushed just now, so the loop would only buffer its actions.
+	history := newActionHistory(newVpaStatusPatcher(vpaClient), 10, time.Hour)
+	history.lastFlush = time.Now()
+	updater := &updater{
+		vpaLister:          vpaLister,
+		podLister:          podLister,
+		restrictionFactory: &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		// The first pod takes the only token, so the eviction of the second one waits for an hour.
+		evictionRateLimiter:     rate.NewLimiter(rate.Every(time.Hour), 1),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		eventRecorder:           record.NewFakeRecorder(10),
+		actionHistory:           history,
+	}
+
+	done := make(chan error)
+	go func() { done <- updater.RunOnce(context.Background()) }()
+	time.Sleep(100 * time.Millisecond)
+	updater.Drain()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the wait for the rate limiter wasn't stopped by the drain")
+	}
+	assert.Equal(t, int32(1), evictions.Load())
+
+	updated, err := vpaClient.AutoscalingV1().VerticalPodAutoscalers("default").Get(context.Background(), "vpa", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Status.RecentActions, 1, "the action taken before the drain is written")
+}
+
+func TestRunOnce_InitialModeDriftCorrection(t *testing.T) {
+	testCases := []struct {
+		name            string
+		driftThreshold  float64
+		expectedEvicted []string
+	}{
 		{
-			name:                  "with Off mode",
-			updateMode:            vpa_types.UpdateModeOff,
-			shouldInPlaceFail:     false,
-			expectFetchCalls:      false,
-			expectedEvictionCount: 0,
-			expectedInPlacedCount: 0,
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceApproved,
+			name:            "drift correction disabled",
+			driftThreshold:  0,
+			expectedEvicted: nil,
+		},
+		{
+			name:            "only pods drifting over the threshold are evicted",
+			driftThreshold:  1,
+			expectedEvicted: []string{"drifted"},
+		},
+		{
+			name:            "threshold above every drift",
+			driftThreshold:  3,
+			expectedEvicted: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			newPod := func(name, cpu, memory string) *apiv1.Pod {
+				return test.Pod().WithName(name).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse(cpu)).WithMemRequest(resource.MustParse(memory)).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+			}
+			// The drift of a pod is the sum of the relative differences of its requests to the target.
+			pods := []*apiv1.Pod{newPod("within", "1.8", "180M"), newPod("drifted", "1", "100M")}
+			eviction := &test.PodsEvictionRestrictionMock{}
+			for _, pod := range pods {
+				eviction.On("CanEvict", pod).Return(true)
+				eviction.On("Evict", pod, nil).Return(nil)
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithUpdateMode(vpa_types.UpdateModeInitial).
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil).AnyTimes()
+
+			updater := &updater{
+				vpaLister:                 vpaLister,
+				podLister:                 podLister,
+				restrictionFactory:        &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &test.PodsInPlaceRestrictionMock{}},
+				evictionRateLimiter:       rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:        rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:         priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor:   &test.FakeRecommendationProcessor{},
+				selectorFetcher:           mockSelectorFetcher,
+				controllerFetcher:         controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:         priority.NewProcessor(),
+				initialModeDriftThreshold: tc.driftThreshold,
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()))
+			var evicted []string
+			for _, call := range eviction.Calls {
+				if call.Method == "Evict" {
+					evicted = append(evicted, call.Arguments.Get(0).(*apiv1.Pod).Name)
+				}
+			}
+			assert.Equal(t, tc.expectedEvicted, evicted)
+		})
+	}
+}
+
+func TestRunOnce_FuncRestrictionFakes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	pods := make([]*apiv1.Pod, 3)
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get()
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	var evicted []string
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		CanEvictFunc: func(pod *apiv1.Pod) bool { return pod.Name != "test_0" },
+		EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+			evicted = append(evicted, pod.Name)
+			return nil
 		},
+	}
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: &restriction.FuncPodsInPlaceRestriction{}},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.ElementsMatch(t, []string{"test_1", "test_2"}, evicted)
+}
+
+func TestRunOnce_MixedInPlaceDecisions(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	decisions := map[string]utils.InPlaceDecision{
+		"approved_0": utils.InPlaceApproved,
+		"approved_1": utils.InPlaceApproved,
+		"evict_0":    utils.InPlaceEvict,
+		"deferred_0": utils.InPlaceDeferred,
+	}
+	var pods []*apiv1.Pod
+	for name := range decisions {
+		pods = append(pods, test.Pod().WithName(name).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(map[string]string{"app": "testingApp"}).
+			Get())
+	}
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		WithUpdateMode(vpa_types.UpdateModeInPlaceOrRecreate).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	var inPlaceUpdated, evicted []string
+	inPlace := &restriction.FuncPodsInPlaceRestriction{
+		CanInPlaceUpdateFunc: func(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason) {
+			return decisions[pod.Name], utils.InPlaceReasonWithinTolerance
+		},
+		InPlaceUpdateFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+			inPlaceUpdated = append(inPlaceUpdated, pod.Name)
+			return nil
+		},
+	}
+	eviction := &restriction.FuncPodsEvictionRestriction{
+		EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+			evicted = append(evicted, pod.Name)
+			return nil
+		},
+	}
+	// The limiters don't refill within the test, so the tokens left show which actions drew from them.
+	inPlaceRateLimiter := rate.NewLimiter(rate.Every(time.Hour), 5)
+	evictionRateLimiter := rate.NewLimiter(rate.Every(time.Hour), 5)
+	updater := &updater{
+		vpaLister:               vpaLister,
+		podLister:               podLister,
+		restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inPlace},
+		evictionRateLimiter:     evictionRateLimiter,
+		inPlaceRateLimiter:      inPlaceRateLimiter,
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.ElementsMatch(t, []string{"approved_0", "approved_1"}, inPlaceUpdated)
+	assert.ElementsMatch(t, []string{"evict_0"}, evicted)
+	assert.InDelta(t, 3, inPlaceRateLimiter.Tokens(), 0.01, "only in-place updates draw from the in-place rate limiter")
+	assert.InDelta(t, 4, evictionRateLimiter.Tokens(), 0.01, "only evictions draw from the eviction rate limiter")
+}
+
+func TestRunOnce_MaxPodLifetimeInPlace(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+	updateConfig := priority.NewDefaultUpdateConfig()
+	updateConfig.MaxPodLifetime = time.Hour
+
+	testCases := []struct {
+		updateMode             vpa_types.UpdateMode
+		expectedEvicted        []string
+		expectedInPlaceUpdated []string
+	}{
+		{updateMode: vpa_types.UpdateModeInPlaceOrRecreate, expectedEvicted: []string{"expired"}},
+		{updateMode: vpa_types.UpdateModeInPlaceOnly},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.updateMode), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			var pods []*apiv1.Pod
+			for name, age := range map[string]time.Duration{"expired": 2 * time.Hour, "young": time.Minute} {
+				pod := test.Pod().WithName(name).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get()
+				pod.Status.StartTime = &metav1.Time{Time: time.Now().Add(-age)}
+				pods = append(pods, pod)
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			// The requests match the recommendation, so only the lifetime makes pods eligible.
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("1", "100M").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				WithUpdateMode(tc.updateMode).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+			var inPlaceUpdated, evicted []string
+			inPlace := &restriction.FuncPodsInPlaceRestriction{
+				InPlaceUpdateFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					inPlaceUpdated = append(inPlaceUpdated, pod.Name)
+					return nil
+				},
+			}
+			eviction := &restriction.FuncPodsEvictionRestriction{
+				EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					evicted = append(evicted, pod.Name)
+					return nil
+				},
+			}
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inPlace},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				updateConfig:            updateConfig,
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()))
+			assert.ElementsMatch(t, tc.expectedEvicted, evicted)
+			assert.ElementsMatch(t, tc.expectedInPlaceUpdated, inPlaceUpdated, "resizing doesn't renew pods which exceeded their lifetime")
+		})
+	}
+}
+
+func TestRunOnce_UpdatePreference(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+	testCases := []struct {
+		name                   string
+		annotations            map[string]string
+		expectedInPlaceUpdated []string
+		expectedEvicted        []string
+	}{
 		{
-			name:                  "with InPlaceOrRecreate mode expecting in-place updates",
-			updateMode:            vpa_types.UpdateModeInPlaceOrRecreate,
-			shouldInPlaceFail:     false,
-			expectFetchCalls:      true,
-			expectedEvictionCount: 0,
-			expectedInPlacedCount: 5,
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceApproved,
+			name:                   "no preference",
+			expectedInPlaceUpdated: []string{"pod_0", "pod_1", "pinned"},
 		},
 		{
-			name:                  "with InPlaceOrRecreate mode expecting fallback to evictions",
-			updateMode:            vpa_types.UpdateModeInPlaceOrRecreate,
-			shouldInPlaceFail:     false,
-			expectFetchCalls:      true,
-			expectedEvictionCount: 5,
-			expectedInPlacedCount: 0,
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceEvict,
+			name:                   "in-place preferred",
+			annotations:            map[string]string{annotations.VpaUpdatePreferenceAnnotation: "inplace"},
+			expectedInPlaceUpdated: []string{"pod_0", "pod_1", "pinned"},
 		},
 		{
-			name:                  "with InPlaceOrRecreate mode expecting no evictions or in-place",
-			updateMode:            vpa_types.UpdateModeInPlaceOrRecreate,
-			shouldInPlaceFail:     false,
-			expectFetchCalls:      true,
-			expectedEvictionCount: 0,
-			expectedInPlacedCount: 0,
-			canEvict:              false,
-			canInPlaceUpdate:      utils.InPlaceDeferred,
+			name:                   "eviction preferred",
+			annotations:            map[string]string{annotations.VpaUpdatePreferenceAnnotation: "evict"},
+			expectedInPlaceUpdated: []string{"pinned"},
+			expectedEvicted:        []string{"pod_0", "pod_1"},
 		},
 		{
-			name:                  "with InPlaceOrRecreate mode and failed in-place update",
-			updateMode:            vpa_types.UpdateModeInPlaceOrRecreate,
-			shouldInPlaceFail:     true,
-			expectFetchCalls:      true,
-			expectedEvictionCount: 5, // All pods should be evicted after in-place update fails
-			expectedInPlacedCount: 5, // All pods attempt in-place update first
-			canEvict:              true,
-			canInPlaceUpdate:      utils.InPlaceApproved,
+			name:                   "invalid preference",
+			annotations:            map[string]string{annotations.VpaUpdatePreferenceAnnotation: "restart"},
+			expectedInPlaceUpdated: []string{"pod_0", "pod_1", "pinned"},
 		},
 	}
-	for _, tc := range tests {
+	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			testRunOnceBase(
-				t,
-				tc.updateMode,
-				tc.shouldInPlaceFail,
-				newFakeValidator(true),
-				tc.expectFetchCalls,
-				tc.expectedEvictionCount,
-				tc.expectedInPlacedCount,
-				tc.canInPlaceUpdate,
-			)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "apps/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+			}
+			var pods []*apiv1.Pod
+			for _, name := range []string{"pod_0", "pod_1", "pinned"} {
+				pods = append(pods, test.Pod().WithName(name).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					WithLabels(map[string]string{"app": "testingApp"}).
+					Get())
+			}
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				WithUpdateMode(vpa_types.UpdateModeInPlaceOrRecreate).
+				WithAnnotations(tc.annotations).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+			var inPlaceUpdated, evicted []string
+			inPlace := &restriction.FuncPodsInPlaceRestriction{
+				InPlaceUpdateFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					inPlaceUpdated = append(inPlaceUpdated, pod.Name)
+					return nil
+				},
+			}
+			eviction := &restriction.FuncPodsEvictionRestriction{
+				// The pinned pod can't be evicted, so it is updated in place whatever the preference.
+				CanEvictFunc: func(pod *apiv1.Pod) bool {
+					return pod.Name != "pinned"
+				},
+				EvictFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+					evicted = append(evicted, pod.Name)
+					return nil
+				},
+			}
+			updater := &updater{
+				vpaLister:               vpaLister,
+				podLister:               podLister,
+				restrictionFactory:      &restriction.FakePodsRestrictionFactory{Eviction: eviction, InPlace: inPlace},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				eventRecorder:           record.NewFakeRecorder(10),
+			}
+
+			assert.NoError(t, updater.RunOnce(context.Background()))
+			assert.ElementsMatch(t, tc.expectedInPlaceUpdated, inPlaceUpdated)
+			assert.ElementsMatch(t, tc.expectedEvicted, evicted)
 		})
 	}
 }
 
-func TestRunOnce_Status(t *testing.T) {
-	tests := []struct {
-		name                  string
-		statusValidator       status.Validator
-		expectFetchCalls      bool
-		expectedEvictionCount int
-		expectedInPlacedCount int
+func TestPrefersEviction_InvalidPreference(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	u := &updater{eventRecorder: recorder}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("container1").
+		WithAnnotations(map[string]string{annotations.VpaUpdatePreferenceAnnotation: "restart"}).Get()
+	vpa.UID = "vpa"
+
+	assert.False(t, u.prefersEviction(vpa))
+	assert.False(t, u.prefersEviction(vpa))
+	assert.Len(t, recorder.Events, 1, "an invalid preference is reported once")
+	assert.Contains(t, <-recorder.Events, "InvalidUpdatePreference")
+
+	vpa.Annotations[annotations.VpaUpdatePreferenceAnnotation] = "evict"
+	assert.True(t, u.prefersEviction(vpa))
+	vpa.Annotations[annotations.VpaUpdatePreferenceAnnotation] = "restart"
+	assert.False(t, u.prefersEviction(vpa))
+	assert.Len(t, recorder.Events, 1, "an invalid preference is reported again after it was fixed")
+}
+
+func TestReportInvalidDisruptionBudget(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	u := &updater{eventRecorder: recorder}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("container1").
+		WithAnnotations(map[string]string{annotations.VpaMaxUnavailableAnnotation: "one"}).Get()
+	vpa.UID = "vpa"
+
+	u.reportInvalidDisruptionBudget(vpa)
+	u.reportInvalidDisruptionBudget(vpa)
+	assert.Len(t, recorder.Events, 1, "an invalid budget is reported once")
+	assert.Contains(t, <-recorder.Events, "InvalidDisruptionBudget")
+
+	vpa.Annotations[annotations.VpaMaxUnavailableAnnotation] = "1"
+	u.reportInvalidDisruptionBudget(vpa)
+	assert.Empty(t, recorder.Events, "a valid budget isn't reported")
+	vpa.Annotations[annotations.VpaMaxUnavailableAnnotation] = "one"
+	u.reportInvalidDisruptionBudget(vpa)
+	assert.Len(t, recorder.Events, 1, "an invalid budget is reported again after it was fixed")
+}
+
+func TestRunOnce_RecommendationAgeOfVpaNotActedOn(t *testing.T) {
+	registry := registerTestMetrics(t)
+
+	vpa := test.VerticalPodAutoscaler().WithName("off").WithNamespace("default").WithContainer("container1").
+		WithUpdateMode(vpa_types.UpdateModeOff).
+		AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", time.Now().Add(-time.Hour)).Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpa}, nil)
+	updater := &updater{
+		vpaLister:         vpaLister,
+		evictionAdmission: priority.NewDefaultPodEvictionAdmission(),
+	}
+
+	assert.NoError(t, updater.RunOnce(context.Background()))
+	assert.GreaterOrEqual(t, gatherMetricValueWithLabels(t, registry, "vpa_updater_recommendation_age_seconds",
+		map[string]string{"vpa_name": "off", "vpa_namespace": "default"}), 3600.0, "the age is recorded for VPAs which aren't acted on too")
+}
+
+func TestIsRecommendationFresh(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name                 string
+		vpa                  *vpa_types.VerticalPodAutoscaler
+		maxRecommendationAge time.Duration
+		expectedFresh        bool
+		expectedAge          float64
 	}{
 		{
-			name:                  "with valid status",
-			statusValidator:       newFakeValidator(true),
-			expectFetchCalls:      true,
-			expectedEvictionCount: 5,
-			expectedInPlacedCount: 0,
+			name: "fresh recommendation",
+			vpa: test.VerticalPodAutoscaler().WithName("fresh").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-time.Minute)).Get(),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        true,
+			expectedAge:          60,
 		},
 		{
-			name:                  "with invalid status",
-			statusValidator:       newFakeValidator(false),
-			expectFetchCalls:      false,
-			expectedEvictionCount: 0,
-			expectedInPlacedCount: 0,
+			name: "stale recommendation",
+			vpa: test.VerticalPodAutoscaler().WithName("stale").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-2*time.Hour)).Get(),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        false,
+			expectedAge:          7200,
+		},
+		{
+			name: "stale recommendation with guard disabled",
+			vpa: test.VerticalPodAutoscaler().WithName("stale").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-2*time.Hour)).Get(),
+			expectedFresh: true,
+			expectedAge:   7200,
+		},
+		{
+			name: "recommendation written by the recommender",
+			vpa: withStatusManagedFields(test.VerticalPodAutoscaler().WithName("written").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-2*time.Hour)).Get(),
+				vpa_api_util.RecommenderFieldManager, now.Add(-time.Minute)),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        true,
+			expectedAge:          60,
+		},
+		{
+			name: "status written by another manager",
+			vpa: withStatusManagedFields(test.VerticalPodAutoscaler().WithName("stale").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-2*time.Hour)).Get(),
+				"vpa-updater", now.Add(-time.Minute)),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        false,
+			expectedAge:          7200,
+		},
+		{
+			name: "unchanged recommendation confirmed by the recommender",
+			vpa: withRecommendationHeartbeat(test.VerticalPodAutoscaler().WithName("unchanged").WithContainer("container1").
+				AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", now.Add(-2*time.Hour)).Get(),
+				now.Add(-time.Minute)),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        true,
+			expectedAge:          60,
+		},
+		{
+			name:                 "recommendation without timestamp",
+			vpa:                  test.VerticalPodAutoscaler().WithName("unknown").WithContainer("container1").Get(),
+			maxRecommendationAge: time.Hour,
+			expectedFresh:        true,
 		},
 	}
-	for _, tc := range tests {
+	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			testRunOnceBase(
-				t,
-				vpa_types.UpdateModeRecreate,
-				false,
-				tc.statusValidator,
-				tc.expectFetchCalls,
-				tc.expectedEvictionCount,
-				tc.expectedInPlacedCount,
-				utils.InPlaceApproved,
-			)
+			registry := registerTestMetrics(t)
+			metrics_updater.ResetRecommendationAges()
+			u := &updater{maxRecommendationAge: tc.maxRecommendationAge}
+			age, aged := recordRecommendationAge(tc.vpa, now)
+			assert.Equal(t, tc.expectedFresh, !aged || u.isRecommendationFresh(tc.vpa, age))
+			assert.Equal(t, tc.expectedAge, gatherMetricValue(t, registry, "vpa_updater_recommendation_age_seconds"))
 		})
 	}
 }
 
+func withRecommendationHeartbeat(vpa *vpa_types.VerticalPodAutoscaler, lastProbeTime time.Time) *vpa_types.VerticalPodAutoscaler {
+	for i := range vpa.Status.Conditions {
+		if vpa.Status.Conditions[i].Type == vpa_types.RecommendationProvided {
+			vpa.Status.Conditions[i].LastProbeTime = metav1.NewTime(lastProbeTime)
+		}
+	}
+	return vpa
+}
+
+func withStatusManagedFields(vpa *vpa_types.VerticalPodAutoscaler, manager string, writeTime time.Time) *vpa_types.VerticalPodAutoscaler {
+	vpa.ManagedFields = append(vpa.ManagedFields, metav1.ManagedFieldsEntry{
+		Manager:     manager,
+		Subresource: "status",
+		Time:        &metav1.Time{Time: writeTime},
+	})
+	return vpa
+}
+
+// registerTestMetrics registers the updater metrics in a fresh registry, used as the default one for the duration of the test.
+func registerTestMetrics(t *testing.T) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	defaultRegisterer, defaultGatherer := prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	prometheus.DefaultRegisterer, prometheus.DefaultGatherer = registry, registry
+	t.Cleanup(func() {
+		prometheus.DefaultRegisterer, prometheus.DefaultGatherer = defaultRegisterer, defaultGatherer
+	})
+	metrics_updater.Register()
+	return registry
+}
+
+// gatherMetricValueWithLabels returns the sum of the values of the series of the given counter or gauge with the given labels.
+func gatherMetricValueWithLabels(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	metricFamilies, err := registry.Gather()
+	assert.NoError(t, err)
+	value := 0.0
+	for _, mf := range metricFamilies {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matching := 0
+			for _, label := range m.GetLabel() {
+				if expected, found := labels[label.GetName()]; found && expected == label.GetValue() {
+					matching++
+				}
+			}
+			if matching == len(labels) {
+				value += m.GetCounter().GetValue() + m.GetGauge().GetValue()
+			}
+		}
+	}
+	return value
+}
+
+// gatherMetricValue returns the sum of the values of all series of the given counter or gauge.
+func gatherMetricValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	metricFamilies, err := registry.Gather()
+	assert.NoError(t, err)
+	value := 0.0
+	for _, mf := range metricFamilies {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			value += m.GetCounter().GetValue() + m.GetGauge().GetValue()
+		}
+	}
+	return value
+}
+
 func testRunOnceBase(
 	t *testing.T,
 	updateMode vpa_types.UpdateMode,
 	shouldInPlaceFail bool,
+	shouldEvictionFail bool,
 	statusValidator status.Validator,
 	expectFetchCalls bool,
 	expectedEvictionCount int,
 	expectedInPlacedCount int,
 	canInPlaceUpdate utils.InPlaceDecision,
+	canInPlaceUpdateReason utils.InPlaceDecisionReason,
 ) {
 	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
 	ctrl := gomock.NewController(t)
@@ -224,6 +1433,7 @@ func testRunOnceBase(
 	pods := make([]*apiv1.Pod, livePods)
 	eviction := &test.PodsEvictionRestrictionMock{}
 	inplace := &test.PodsInPlaceRestrictionMock{}
+	eventRecorder := test.FakeEventRecorder()
 
 	for i := range pods {
 		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
@@ -233,15 +1443,19 @@ func testRunOnceBase(
 
 		pods[i].Labels = labels
 
-		inplace.On("CanInPlaceUpdate", pods[i]).Return(canInPlaceUpdate)
+		inplace.On("CanInPlaceUpdate", pods[i]).Return(canInPlaceUpdate, canInPlaceUpdateReason)
 		if shouldInPlaceFail {
-			inplace.On("InPlaceUpdate", pods[i], nil).Return(errors.New("in-place update failed"))
+			inplace.On("InPlaceUpdate", pods[i], eventRecorder).Return(errors.New("in-place update failed"))
 		} else {
-			inplace.On("InPlaceUpdate", pods[i], nil).Return(nil)
+			inplace.On("InPlaceUpdate", pods[i], eventRecorder).Return(nil)
 		}
 
 		eviction.On("CanEvict", pods[i]).Return(true)
-		eviction.On("Evict", pods[i], nil).Return(nil)
+		if shouldEvictionFail {
+			eviction.On("Evict", pods[i], eventRecorder).Return(errors.New("eviction failed"))
+		} else {
+			eviction.On("Evict", pods[i], eventRecorder).Return(nil)
+		}
 	}
 
 	factory := &restriction.FakePodsRestrictionFactory{
@@ -274,6 +1488,7 @@ func testRunOnceBase(
 	updater := &updater{
 		vpaLister:                    vpaLister,
 		podLister:                    podLister,
+		eventRecorder:                eventRecorder,
 		restrictionFactory:           factory,
 		evictionRateLimiter:          rate.NewLimiter(rate.Inf, 0),
 		inPlaceRateLimiter:           rate.NewLimiter(rate.Inf, 0),
@@ -319,6 +1534,110 @@ func TestRunOnceNotingToProcess(t *testing.T) {
 	updater.RunOnce(context.Background())
 }
 
+func TestSelectorChangePrioritizesVpa(t *testing.T) {
+	unchangedVpa := test.VerticalPodAutoscaler().WithName("unchanged").WithContainer("container1").Get()
+	changedVpa := test.VerticalPodAutoscaler().WithName("changed").WithContainer("container1").Get()
+	controlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{
+		unchangedVpa: {test.Pod().WithName("unchanged-pod").Get()},
+		changedVpa:   {test.Pod().WithName("changed-pod").Get()},
+	}
+	u := &updater{prioritizeSelectorChanges: true}
+
+	changed := u.trackSelectorChanges([]*vpa_api_util.VpaWithSelector{
+		{Vpa: unchangedVpa, Selector: parseLabelSelector("app = unchanged")},
+		{Vpa: changedVpa, Selector: parseLabelSelector("app = old")},
+	})
+	assert.Empty(t, changed, "no selector changes expected on the first loop")
+
+	changed = u.trackSelectorChanges([]*vpa_api_util.VpaWithSelector{
+		{Vpa: unchangedVpa, Selector: parseLabelSelector("app = unchanged")},
+		{Vpa: changedVpa, Selector: parseLabelSelector("app = new")},
+	})
+	assert.Equal(t, map[*vpa_types.VerticalPodAutoscaler]bool{changedVpa: true}, changed)
+
+	// Map iteration order is random, so check the order over several runs.
+	for i := 0; i < 10; i++ {
+		order := u.getVpasProcessingOrder(controlledPods, changed)
+		assert.Equal(t, []*vpa_types.VerticalPodAutoscaler{changedVpa, unchangedVpa}, order)
+	}
+
+	changed = u.trackSelectorChanges([]*vpa_api_util.VpaWithSelector{
+		{Vpa: unchangedVpa, Selector: parseLabelSelector("app = unchanged")},
+		{Vpa: changedVpa, Selector: parseLabelSelector("app = new")},
+	})
+	assert.Empty(t, changed, "selector change should only be prioritized once")
+}
+
+func TestLowPriorityPodsPrioritizeVpa(t *testing.T) {
+	lowPriority, highPriority := int32(100), int32(1000)
+	highVpa := test.VerticalPodAutoscaler().WithName("high").WithContainer("container1").Get()
+	lowVpa := test.VerticalPodAutoscaler().WithName("low").WithContainer("container1").Get()
+	defaultVpa := test.VerticalPodAutoscaler().WithName("default").WithContainer("container1").Get()
+	highPod := test.Pod().WithName("high-pod").Get()
+	highPod.Spec.Priority = &highPriority
+	lowPod := test.Pod().WithName("low-pod").Get()
+	lowPod.Spec.Priority = &lowPriority
+	controlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{
+		highVpa:    {highPod},
+		lowVpa:     {highPod.DeepCopy(), lowPod},
+		defaultVpa: {test.Pod().WithName("default-pod").Get()},
+	}
+	changed := map[*vpa_types.VerticalPodAutoscaler]bool{highVpa: true, defaultVpa: true}
+
+	u := &updater{prioritizeSelectorChanges: true, preferLowPriorityPods: true}
+	// Map iteration order is random, so check the order over several runs.
+	for i := 0; i < 10; i++ {
+		order := u.getVpasProcessingOrder(controlledPods, changed)
+		assert.Equal(t, []*vpa_types.VerticalPodAutoscaler{defaultVpa, lowVpa, highVpa}, order,
+			"VPAs are processed in the order of the lowest scheduling priority of their pods")
+	}
+
+	u.preferLowPriorityPods = false
+	for i := 0; i < 10; i++ {
+		order := u.getVpasProcessingOrder(controlledPods, changed)
+		assert.Equal(t, lowVpa, order[2], "VPAs whose selector changed come first without prefer-low-priority-pods")
+	}
+}
+
+// blockingValidator blocks status validation until released, to simulate a slow loop.
+type blockingValidator struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingValidator) IsStatusValid(ctx context.Context, statusTimeout time.Duration) (bool, error) {
+	close(b.started)
+	<-b.release
+	return true, nil
+}
+
+func TestRunOnceSkipsOverlappingLoop(t *testing.T) {
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return(nil, nil).Once()
+	validator := &blockingValidator{started: make(chan struct{}), release: make(chan struct{})}
+
+	updater := &updater{
+		vpaLister:                    vpaLister,
+		podLister:                    &test.PodListerMock{},
+		useAdmissionControllerStatus: true,
+		statusValidator:              validator,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		updater.RunOnce(context.Background())
+		close(done)
+	}()
+	<-validator.started
+
+	// The first loop is still running, so this one must return immediately without listing VPAs.
+	updater.RunOnce(context.Background())
+
+	close(validator.release)
+	<-done
+	vpaLister.AssertNumberOfCalls(t, "List", 1)
+}
+
 func TestGetRateLimiter(t *testing.T) {
 	cases := []struct {
 		rateLimit       float64
@@ -433,6 +1752,29 @@ func TestRunOnceIgnoreNamespaceMatchingPods(t *testing.T) {
 	eviction.AssertNumberOfCalls(t, "Evict", 5)
 }
 
+func TestRunOnceReturnsLoopErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer("container").
+		WithTarget("2", "200M").Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil)
+	fetchErr := errors.New("scale subresource unavailable")
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(nil, fetchErr)
+
+	updater := &updater{
+		vpaLister:         vpaLister,
+		selectorFetcher:   mockSelectorFetcher,
+		evictionAdmission: priority.NewDefaultPodEvictionAdmission(),
+	}
+
+	assert.ErrorIs(t, updater.RunOnce(context.Background()), fetchErr)
+}
+
 func TestRunOnceIgnoreNamespaceMatching(t *testing.T) {
 	eviction := &test.PodsEvictionRestrictionMock{}
 	vpaLister := &test.VerticalPodAutoscalerListerMock{}
@@ -452,9 +1794,193 @@ func TestRunOnceIgnoreNamespaceMatching(t *testing.T) {
 	eviction.AssertNumberOfCalls(t, "InPlaceUpdate", 0)
 }
 
+func TestRunOnceWatchedNamespaces(t *testing.T) {
+	testCases := []struct {
+		name              string
+		watchedNamespaces []string
+		expectedEvictions int
+	}{
+		{
+			name:              "VPA in watched namespace is processed",
+			watchedNamespaces: []string{"other", "default"},
+			expectedEvictions: 5,
+		},
+		{
+			name:              "VPA outside of watched namespaces is skipped",
+			watchedNamespaces: []string{"other"},
+			expectedEvictions: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			replicas := int32(5)
+			containerName := "container1"
+			rc := apiv1.ReplicationController{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ReplicationController",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "default",
+				},
+				Spec: apiv1.ReplicationControllerSpec{
+					Replicas: &replicas,
+				},
+			}
+			pods := make([]*apiv1.Pod, replicas)
+			eviction := &test.PodsEvictionRestrictionMock{}
+			for i := range pods {
+				pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+					AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+					WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					Get()
+				pods[i].Labels = map[string]string{"app": "testingApp"}
+				eviction.On("CanEvict", pods[i]).Return(true)
+				eviction.On("Evict", pods[i], nil).Return(nil)
+			}
+
+			podLister := &test.PodListerMock{}
+			podLister.On("List").Return(pods, nil)
+
+			vpaObj := test.VerticalPodAutoscaler().
+				WithNamespace("default").
+				WithContainer(containerName).
+				WithTarget("2", "200M").
+				WithMinAllowed(containerName, "1", "100M").
+				WithMaxAllowed(containerName, "3", "1G").
+				WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+				Get()
+			vpaLister := &test.VerticalPodAutoscalerListerMock{}
+			vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil).Once()
+
+			mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+			if tc.expectedEvictions > 0 {
+				mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+			}
+
+			updater := &updater{
+				vpaLister: vpaLister,
+				podLister: podLister,
+				restrictionFactory: &restriction.FakePodsRestrictionFactory{
+					Eviction: eviction,
+					InPlace:  &test.PodsInPlaceRestrictionMock{},
+				},
+				evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+				inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+				evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+				recommendationProcessor: &test.FakeRecommendationProcessor{},
+				selectorFetcher:         mockSelectorFetcher,
+				controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+				priorityProcessor:       priority.NewProcessor(),
+				watchedNamespaces:       tc.watchedNamespaces,
+			}
+
+			updater.RunOnce(context.Background())
+			eviction.AssertNumberOfCalls(t, "Evict", tc.expectedEvictions)
+		})
+	}
+}
+
+func TestRunOnceTracing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	replicas := int32(5)
+	containerName := "container1"
+	rc := apiv1.ReplicationController{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ReplicationController",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	eviction := &test.PodsEvictionRestrictionMock{}
+	for i := range pods {
+		pods[i] = test.Pod().WithName("test_"+strconv.Itoa(i)).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			Get()
+		pods[i].Labels = map[string]string{"app": "testingApp"}
+		eviction.On("CanEvict", pods[i]).Return(true)
+		eviction.On("Evict", pods[i], nil).Return(nil)
+	}
+
+	podLister := &test.PodListerMock{}
+	podLister.On("List").Return(pods, nil)
+
+	vpaObj := test.VerticalPodAutoscaler().
+		WithNamespace("default").
+		WithContainer(containerName).
+		WithTarget("2", "200M").
+		WithMinAllowed(containerName, "1", "100M").
+		WithMaxAllowed(containerName, "3", "1G").
+		WithTargetRef(&v1.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	vpaLister := &test.VerticalPodAutoscalerListerMock{}
+	vpaLister.On("List").Return([]*vpa_types.VerticalPodAutoscaler{vpaObj}, nil).Once()
+
+	mockSelectorFetcher := target_mock.NewMockVpaTargetSelectorFetcher(ctrl)
+	mockSelectorFetcher.EXPECT().Fetch(gomock.Eq(vpaObj)).Return(parseLabelSelector("app = testingApp"), nil)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	updater := &updater{
+		vpaLister: vpaLister,
+		podLister: podLister,
+		restrictionFactory: &restriction.FakePodsRestrictionFactory{
+			Eviction: eviction,
+			InPlace:  &test.PodsInPlaceRestrictionMock{},
+		},
+		evictionRateLimiter:     rate.NewLimiter(rate.Inf, 0),
+		inPlaceRateLimiter:      rate.NewLimiter(rate.Inf, 0),
+		evictionAdmission:       priority.NewDefaultPodEvictionAdmission(),
+		recommendationProcessor: &test.FakeRecommendationProcessor{},
+		selectorFetcher:         mockSelectorFetcher,
+		controllerFetcher:       controllerfetcher.FakeControllerFetcher{},
+		priorityProcessor:       priority.NewProcessor(),
+		tracer:                  tracerProvider.Tracer(tracerName),
+	}
+
+	updater.RunOnce(context.Background())
+
+	spans := recorder.Ended()
+	names := make([]string, 0, len(spans))
+	spansByName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+		spansByName[span.Name()] = span
+	}
+	assert.Equal(t, []string{"ListVPAs", "FetchSelectors", "ComputePriorities", "Act", "RunOnce"}, names)
+
+	root := spansByName["RunOnce"]
+	assert.False(t, root.Parent().IsValid())
+	for _, name := range []string{"ListVPAs", "FetchSelectors", "ComputePriorities", "Act"} {
+		assert.Equal(t, root.SpanContext().SpanID(), spansByName[name].Parent().SpanID(), "%s should be a child of RunOnce", name)
+	}
+
+	assert.Contains(t, spansByName["ListVPAs"].Attributes(), attribute.Int("vpa.count", 1))
+	assert.Contains(t, spansByName["FetchSelectors"].Attributes(), attribute.Int("vpa.count", 1))
+	assert.Contains(t, spansByName["ComputePriorities"].Attributes(), attribute.Int("pod.count", 5))
+	assert.Contains(t, spansByName["ComputePriorities"].Attributes(), attribute.Int("pod.eviction_candidates", 5))
+	assert.Contains(t, spansByName["Act"].Attributes(), attribute.String("vpa.name", vpaObj.Name))
+	assert.Contains(t, spansByName["Act"].Attributes(), attribute.Int("pod.evicted", 5))
+}
+
 func TestNewEventRecorder(t *testing.T) {
 	fakeClient := fake.NewClientset()
-	er := newEventRecorder(fakeClient)
+	er, _ := newEventRecorder(fakeClient, "vpa-updater-shard-2")
 
 	maxRetries := 5
 	retryDelay := 100 * time.Millisecond
@@ -500,7 +2026,7 @@ func TestNewEventRecorder(t *testing.T) {
 			assert.Equal(t, tc.reason, event.Reason)
 			assert.Equal(t, tc.message, event.Message)
 			assert.Equal(t, apiv1.EventTypeNormal, event.Type)
-			assert.Equal(t, "vpa-updater", event.Source.Component)
+			assert.Equal(t, "vpa-updater-shard-2", event.Source.Component)
 		})
 	}
 }
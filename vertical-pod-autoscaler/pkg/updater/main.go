@@ -20,32 +20,50 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	kube_client "k8s.io/client-go/kubernetes"
+	autoscalinglister "k8s.io/client-go/listers/autoscaling/v2"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	kube_flag "k8s.io/component-base/cli/flag"
 	componentbaseconfig "k8s.io/component-base/config"
 	componentbaseoptions "k8s.io/component-base/config/options"
 	"k8s.io/klog/v2"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 
 	"k8s.io/autoscaler/vertical-pod-autoscaler/common"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/recommendation"
 	vpa_clientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/features"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target"
 	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/inplace"
 	updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/logic"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/priority"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
 	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
@@ -58,6 +76,23 @@ var (
 	updaterInterval = flag.Duration("updater-interval", 1*time.Minute,
 		`How often updater should run`)
 
+	shutdownGracePeriod = flag.Duration("shutdown-grace-period", 20*time.Second,
+		`Time given to the updates in flight to complete once the updater is asked to terminate. The updater stops acting on further pods right away. Should be shorter than the termination grace period of its pod.`)
+
+	runOnce = flag.Bool("run-once", false,
+		`If true, the updater runs a single loop and exits, e.g. to run it as a CronJob. The exit code is 1 if the loop ran into errors. VPA objects which errored are not retried.`)
+
+	enableTracing = flag.Bool("enable-tracing", false,
+		`If true, spans for the phases of each updater loop are exported over OTLP/gRPC, configured by the standard OTEL_EXPORTER_OTLP_* environment variables.`)
+
+	metricsExemplars = flag.Bool("metrics-exemplars", false,
+		`If true, metrics are served in the OpenMetrics format to scrapers asking for it. The counters of evictions and in-place updates then expose the trace ID of their last increment as an exemplar when tracing is enabled.`)
+	debugDecisions = flag.Bool("debug-decisions", false,
+		`If true, the decisions of the most recent loop about the pods of each VPA, with their update priority and the reason they weren't updated, are served in JSON at /debug/vpa/<namespace>/<name> on the metrics address.`)
+
+	readinessThreshold = flag.Duration("readiness-threshold", 0,
+		`The /ready endpoint reports not ready if the last updater loop completed longer ago than this. Defaults to three times updater-interval. Loops which ran into errors count too, their errors are counted by the loops_with_errors_total metric. Replicas which are not the leader are ready once their caches synced.`)
+
 	minReplicas = flag.Int("min-replicas", 2,
 		`Minimum number of replicas to perform update`)
 
@@ -70,6 +105,27 @@ var (
 
 	evictionRateBurst = flag.Int("eviction-rate-burst", 1, `Burst of pods that can be evicted.`)
 
+	recommendationMarginFraction = flag.Float64("recommendation-margin-fraction", 0,
+		`Fraction of the recommendation added as a safety margin, on top of the one of the recommender, to the resources pods are updated to, e.g. 0.15 to update pods to 1.15 times the recommendation. The margin is added before capping and rounding. Should match the flag of the admission controller.`)
+
+	recommendationCPUGranularity = flag.String("recommendation-cpu-granularity", "",
+		`If set, CPU recommendations are rounded to multiples of this quantity, e.g. 50m, before pods are updated, to avoid updates for tiny changes. The target is rounded up and still capped by the min and max allowed. Should match the flag of the admission controller.`)
+
+	recommendationMemoryGranularity = flag.String("recommendation-memory-granularity", "",
+		`If set, memory recommendations are rounded to multiples of this quantity, e.g. 32Mi, before pods are updated, to avoid updates for tiny changes. The target is rounded up and still capped by the min and max allowed. Should match the flag of the admission controller.`)
+
+	defaultResourcePolicyConfigMap = flag.String("default-resource-policy-configmap", "",
+		`ConfigMap given as namespace/name, or as name in the namespace of the VPA components, whose "minAllowed" and "maxAllowed" keys hold cluster-wide defaults, as JSON resource lists, e.g. {"cpu": "50m", "memory": "64Mi"}. They bound the resources of containers which the resource policy of their VPA doesn't bound. The ConfigMap is watched. Should match the flag of the admission controller. Leave empty to disable the defaults.`)
+
+	evictionRateSchedule = flag.String("eviction-rate-schedule", "",
+		`Comma-separated list of offset:qps:burst steps, e.g. "0s:10:20,10m:1:5", changing the eviction rate limit and burst once the given time passed since the daily start of the schedule. Offsets have to be shorter than a day. Before the first offset, eviction-rate-limit and eviction-rate-burst apply.`)
+
+	evictionRateScheduleStart = flag.String("eviction-rate-schedule-start", "00:00",
+		`Time of day, as HH:MM in eviction-rate-schedule-time-zone, at which the eviction-rate-schedule starts every day.`)
+
+	evictionRateScheduleTimeZone = flag.String("eviction-rate-schedule-time-zone", "UTC",
+		`Time zone of eviction-rate-schedule-start, e.g. Europe/Berlin.`)
+
 	address = flag.String("address", ":8943", "The address to expose Prometheus metrics.")
 
 	useAdmissionControllerStatus = flag.Bool("use-admission-controller-status", true,
@@ -82,7 +138,167 @@ var (
 			"Disruption budgets are still respected when any container has RestartContainer resize policy for any resource.",
 	)
 
+	evictionGracePeriodSeconds = flag.Int64("eviction-grace-period-seconds", -1,
+		`Overrides the termination grace period of evicted pods. A negative value keeps the grace period of the pod. The vpa-eviction-grace-seconds.k8s.io annotation of a pod takes precedence.`)
+
+	evictionForegroundDeletion = flag.Bool("eviction-foreground-deletion", false,
+		`If true, evicted pods are deleted with the Foreground propagation policy.`)
+
+	evictionTimeout = flag.Duration("eviction-timeout", 0,
+		`Maximum time to wait for an eviction request. A value of 0 disables the timeout.`)
+
+	annotateEvictedPods = flag.Bool("annotate-evicted-pods", false,
+		`If true, pods are annotated right before their eviction with the name and resourceVersion of the VPA triggering it, in the vpa-eviction-trigger.k8s.io annotation. The annotation is removed again if the eviction is refused.`)
+
+	inPlaceShrinkOnly = flag.Bool("in-place-shrink-only", false,
+		`If true, in-place updates only lower requests. Pods whose recommendation grows the requests of any resource are evicted instead, or not updated if their update mode is InPlaceOnly.`)
+
+	inPlaceResizeSubresource = flag.String("in-place-resize-subresource", restriction.ResizeSubresourceAuto,
+		`Subresource used to resize pods in place. "auto" detects whether the API server serves pods/resize, "none" patches the pod itself, any other value is used as the subresource name.`)
+
+	inPlaceActuationGracePeriod = flag.Duration("in-place-actuation-grace-period", 0,
+		`Time after an in-place resize request during which the resize is considered pending even if the pod reports it failed, as the kubelet may not have acted on the request yet. Failures reported after that fall back to eviction. A value of 0 disables the grace period.`)
+
+	preferInPlaceAtPDBLimit = flag.Bool("prefer-in-place-at-pdb-limit", false,
+		`If true, pods whose PodDisruptionBudget allows no more disruptions keep waiting for their in-place resize when it fails or doesn't complete in time, instead of falling back to an eviction the budget would refuse. eviction-tolerance still applies to in-place updates.`)
+
+	evictBarePods = flag.Bool("evict-bare-pods", false,
+		`If true, pods not managed by any controller are updated as well, even though an evicted pod is not recreated by a controller.`)
+
+	deferPodsWithStrippedTolerations = flag.Bool("defer-pods-with-stripped-tolerations", false,
+		`If true, pods missing a toleration of their controller's pod template, e.g. because an admission webhook removed it, are not updated.`)
+
+	updateObjective = flag.String("update-objective", string(priority.EnsureReliability),
+		`Which pods are updated first when not all of them can be updated: "ensure-reliability" prefers pods that want to grow, "save-cost" prefers pods that want to shrink.`)
+
+	preferLowPriorityPods = flag.Bool("prefer-low-priority-pods", false,
+		`If true, pods with a lower scheduling priority, as set by their PriorityClass, are updated first, across all VPAs, regardless of update-objective.`)
+
+	allowQOSDowngrade = flag.Bool("allow-qos-downgrade", false,
+		`If true, pods are updated even if the new resources would move them to a lower QoS class, e.g. from Guaranteed to Burstable.`)
+
+	maxPodLifetime = flag.Duration("max-pod-lifetime", 0,
+		`Pods running for longer than this are evicted even if their resources match the recommendation, so that they are periodically recreated. The usual eviction restrictions still apply. Pods of VPAs in the InPlaceOrRecreate mode are evicted rather than updated in-place, it doesn't apply to the InPlaceOnly mode, which never evicts. A value of 0 disables it.`)
+
+	sidecarContainerNamePattern = flag.String("sidecar-container-name-pattern", "",
+		`Regular expression matching the names of sidecar containers, e.g. injected by a service mesh, whose updates are subject to sidecar-update-threshold instead of pod-update-threshold. Sidecars aren't distinguished if empty.`)
+
+	sidecarUpdateThreshold = flag.Float64("sidecar-update-threshold", 0.5,
+		`Ignore updates driven only by sidecar containers, as matched by sidecar-container-name-pattern, that have priority lower than the value of this flag.`)
+
+	minChangeFraction = flag.Float64("min-change-fraction", 0,
+		`If set, pods are updated only if the recommendation differs from the current request of at least one controlled resource of a container by more than this fraction of the request, e.g. 0.2 for 20%. Pods annotated for an immediate update or exceeding max-pod-lifetime are updated regardless. A value of 0 disables the check.`)
+
+	restartCountThreshold = flag.Int("restart-count-threshold", 0,
+		`Pods with a container that restarted more times than this threshold within restart-count-window are not updated. A value of 0 disables the check.`)
+
+	restartCountWindow = flag.Duration("restart-count-window", 1*time.Hour,
+		`Window within which container restarts count towards restart-count-threshold. Restarts of pods which started before the window are counted from the first updater loop observing them.`)
+
+	minContainerCount = flag.Int("min-container-count", 0,
+		`Pods with fewer containers than this are not updated. A value of 0 disables the check.`)
+
+	maxDisruptedPodsPercentage = flag.Float64("max-disrupted-pods-percentage", 0,
+		`Maximum percentage of all pods controlled by VPA objects which may be disrupted at the same time, counting pods which aren't ready. The number of pods is rounded up, like the maxUnavailable of a PodDisruptionBudget, so at least one pod may be disrupted. Pods are not updated while the percentage is reached. A value of 0 disables the check.`)
+
+	maxDisruptedPodsPerZone = flag.Int("max-disrupted-pods-per-zone", 0,
+		`Maximum number of pods controlled by VPA objects which may be disrupted at the same time in each topology zone, counting pods which aren't ready, and replacements of evicted pods in the zone of the pod they replace until they are scheduled. The zone of a pod is read from the topology.kubernetes.io/zone label of its node. A value of 0 disables the check.`)
+
+	nodeUtilizationThreshold = flag.Float64("node-utilization-threshold", 0,
+		`If positive, pods whose requests go down are only updated while the CPU or memory usage of their node, as reported by the metrics API, is at least this fraction of its allocatable, e.g. 0.7, so that pods on underutilized nodes aren't disrupted just to be right-sized. Pods whose requests go up, and pods on nodes without metrics, are updated as usual. A value of 0 disables the check. The updater needs to list nodes in the metrics.k8s.io API group.`)
+
+	respectTopologySpread = flag.Bool("respect-topology-spread", false,
+		`If true, pods with topology spread constraints are not evicted while evicting them would skew the spread of the available pods of their VPA object beyond the maxSkew of a constraint. The domain of a pod is read from the label of its node named by the topology key.`)
+
+	deferUpdatesDuringHpaScaling = flag.Bool("defer-updates-during-hpa-scaling", false,
+		`If true, pods are not updated while a HorizontalPodAutoscaler targeting the same workload is scaling it, i.e. while its desired number of replicas differs from the current one, to avoid VPA and HPA amplifying each other's disruption.`)
+
+	respectResourceQuotas = flag.Bool("respect-resource-quotas", false,
+		`If true, pods are not evicted if their recommended requests, or the limits scaled along with them, would exceed a ResourceQuota of their namespace, which would keep their controller from recreating them. Only ResourceQuotas without scopes are checked.`)
+
+	localVolumePolicy = flag.String("local-volume-policy", string(updater.LocalVolumePolicyEvict),
+		`How to update pods using local PersistentVolumes, which may stay pending when evicted: "evict" updates them like any other pod, "prefer-in-place" updates them in-place if possible and evicts them otherwise, "never-evict" only updates them in-place. Pods of VPA objects in Initial mode are not updated in-place, so "never-evict" leaves them alone. The updater needs to list PersistentVolumeClaims and PersistentVolumes unless it is "evict".`)
+
+	safeToEvictCondition = flag.String("safe-to-evict-condition", "",
+		`Name of a pod condition which must be True for pods to be evicted, letting applications signal that it isn't safe to disrupt them right now. Pods which don't report the condition are evicted as usual. Empty disables the check.`)
+
+	selectorFetchRetries = flag.Int("selector-fetch-retries", 2,
+		`Number of times fetching the selector of a VPA object is retried within a loop, with a backoff, if it fails with a transient error such as a timeout. The VPA object is skipped in the loop once the retries are exhausted. A value of 0 disables the retries.`)
+
+	maxRecommendationAge = flag.Duration("max-recommendation-age", 0,
+		`Recommendations not confirmed by the recommender for longer than this are not acted on. The recommender confirms unchanged recommendations every 5 minutes, so it has to be longer than that. A value of 0 disables the check.`)
+
+	prioritizeSelectorChanges = flag.Bool("prioritize-selector-changes", true,
+		`If true, VPAs whose selector changed since the previous loop are processed first.`)
+
+	watchedNamespaces = flag.String("watched-namespaces", "",
+		`A comma-separated list of namespaces whose VPA objects are processed by this updater. Leave empty to process all namespaces. Can't be set together with --ignored-vpa-object-namespaces.`)
+
+	leaderElectShardLease = flag.Bool("leader-elect-shard-lease", false,
+		`If true and watched-namespaces is set, the leader election lease name gets a suffix derived from the watched namespaces, so that updater shards watching different namespaces each elect their own leader. The suffixed lease name is logged at startup and has to be allowed by the leader election RBAC role. The updater doesn't start if an active shard watches some of its namespaces too.`)
+
+	recommendationSnapshot = flag.String("recommendation-snapshot", "",
+		`Path to a JSON list of VPA objects, e.g. saved with kubectl. If set, the updater runs in dry-run mode and only reports how recommendations changed since the snapshot, without updating any pods.`)
+
+	evictionCoordinationAnnotation = flag.String("eviction-coordination-annotation", "",
+		`If set, pods are annotated with this key before eviction and only evicted in a later loop, once an external coordinator removed the annotation. Leave empty to evict pods right away.`)
+
+	controllerLockDuration = flag.Duration("controller-lock-duration", 0,
+		`If positive, the updater holds a Lease per controller, in the namespace of the VPA components, while acting on its pods, so that updater shards watching overlapping namespaces don't disrupt the same controller at once. A Lease not released by a shard blocks the others for this long. A value of 0 disables the locks. The updater needs to get, create and update Leases in that namespace.`)
+
+	evictionCircuitBreakerErrorThreshold = flag.Float64("eviction-circuit-breaker-error-threshold", 0,
+		`Fraction of evictions failing within eviction-circuit-breaker-window above which all evictions are paused. A value of 0 disables the circuit breaker.`)
+	evictionCircuitBreakerWindow = flag.Duration("eviction-circuit-breaker-window", 5*time.Minute,
+		`Sliding window over which the eviction error rate is computed for eviction-circuit-breaker-error-threshold.`)
+	evictionCircuitBreakerCooldown = flag.Duration("eviction-circuit-breaker-cooldown", 5*time.Minute,
+		`How long evictions are paused after too many of them failed, before a single probe eviction is attempted.`)
+
+	namespaceRateLimitsConfigMap = flag.String("namespace-rate-limits-configmap", "",
+		`Name of a ConfigMap in the namespace of the updater mapping namespaces to "qps:burst" rate limits of pod updates, e.g. "team-a: 0.5:2". Pods in these namespaces are subject to both their own rate limit and to eviction-rate-limit and eviction-rate-burst, pods in other namespaces only to the latter. A qps of 0 leaves a namespace subject to the global rate limits only. The ConfigMap is reloaded when it changes. Leave empty to apply the global rate limits to all namespaces.`)
+
+	killSwitchConfigMap = flag.String("kill-switch-configmap", "",
+		`ConfigMap given as namespace/name, or as name in the namespace of the updater, which stops all actions of the updater while its "disabled" key is "true", e.g. to stop VPA cluster-wide in an emergency. The ConfigMap is watched, so changes apply within a loop. Leave empty to disable the kill switch.`)
+
+	erroredVpaRequeueBaseDelay = flag.Duration("errored-vpa-requeue-base-delay", 0,
+		`If set, VPA objects whose processing failed in a loop, e.g. because their selector couldn't be fetched, are retried after this delay instead of waiting for the next loop. The delay doubles with every failed retry. Each retry is bounded by updater-interval. Retries are disabled if recommendation-snapshot is set. A value of 0 disables retries.`)
+	erroredVpaRequeueMaxDelay = flag.Duration("errored-vpa-requeue-max-delay", 30*time.Second,
+		`Maximum delay between retries of a VPA object whose processing keeps failing, see errored-vpa-requeue-base-delay.`)
+
+	podBackoffStrategy = flag.String("pod-backoff-strategy", "none",
+		`How long to wait before acting on a pod again after its eviction or in-place update failed: "none" retries in the next loop, "linear" waits pod-backoff-delay longer after every consecutive failure, "exponential" starts at pod-backoff-delay and doubles the wait after every consecutive failure.`)
+	podBackoffDelay = flag.Duration("pod-backoff-delay", time.Minute,
+		`Base delay of pod-backoff-strategy.`)
+	podBackoffMaxDelay = flag.Duration("pod-backoff-max-delay", 30*time.Minute,
+		`Maximum delay of pod-backoff-strategy. A value of 0 leaves the delay uncapped.`)
+	annotateBlockedPods = flag.Bool("annotate-blocked-pods", false,
+		`If true, pods which need an update but weren't updated, e.g. because of a PodDisruptionBudget, a rate limit or a backoff, are annotated with the reason in the vpa-blocked-reason.k8s.io annotation, removed once they can be updated.`)
+
+	actionHistorySize = flag.Int("action-history-size", 0,
+		`Number of the most recent pod updates kept in the status of each VPA object. A value of 0 disables the history.`)
+	actionHistoryFlushInterval = flag.Duration("action-history-flush-interval", 0,
+		`If set, the pod updates are buffered and written to the status of their VPA objects at most once per interval, reducing the writes to the API server. A value of 0 writes them right away.`)
+
+	eventSourceComponent = flag.String("event-source-component", "vpa-updater",
+		`Source component set on the events emitted by the updater, e.g. to tell apart the events of sharded updaters.`)
+	eventDeduplicationWindow = flag.Duration("event-deduplication-window", 0,
+		`If set, repeated events with the same reason on the same object within this window are collapsed into a single event with a count. A value of 0 records every event.`)
+	eventTemplatesFile = flag.String("event-templates", "",
+		`Path to a JSON file overriding the reasons and messages of the events emitted by the updater, keyed by their default reason, e.g. {"EvictedByVPA": {"reason": "VpaEviction", "message": "Evicted {{.Namespace}}/{{.Name}}: {{.Message}}"}}. Reasons and messages are Go templates rendered with the fields Reason, Message, Type, Kind, Namespace and Name of the default event.`)
+	initialModeDriftThreshold = flag.Float64("initial-mode-drift-threshold", 0,
+		`If set, pods of VPA objects in Initial mode are evicted if their requests drifted from the recommendation by at least this resource diff, computed like for pod-update-threshold. Set it well above pod-update-threshold to only correct large drifts without routine churn. A value of 0 never updates such pods.`)
+	vpaResource = flag.String("vpa-resource", "",
+		`If set, VPA objects are read from this resource, given as resource.version.group, e.g. "verticalpodautoscalers.v1.autoscaling.example.com", instead of the autoscaling.k8s.io API group. The resource must implement the VerticalPodAutoscaler schema, e.g. to consume the recommendations of a forked recommender publishing them in its own API group. The action history is written to the status of the objects of this resource too.`)
+	recommendationStabilityLoops = flag.Int("recommendation-stability-loops", 0,
+		`If set above 1, pods are only updated once the target recommendation for their containers stayed within recommendation-stability-tolerance for this many consecutive loops, so that oscillating recommendations don't cause repeated updates. Pods with the evict-now annotation are not held back. A value of 0 or 1 disables the check.`)
+	recommendationStabilityTolerance = flag.Float64("recommendation-stability-tolerance", 0.1,
+		`Fraction by which the target recommendation of a container resource may change between loops while still counting as stable, see recommendation-stability-loops.`)
+	controllerEventsInterval = flag.Duration("controller-events-interval", 0,
+		`If set, the updater also emits an event on the top-most controller, e.g. the Deployment, of each pod it evicts or updates in-place, at most once per interval on each controller. A value of 0 disables these events.`)
+
 	namespace = os.Getenv("NAMESPACE")
+
+	// shutdownTracing flushes the spans not exported yet, it is set when tracing is enabled.
+	shutdownTracing = func() {}
 )
 
 const (
@@ -90,6 +306,7 @@ const (
 	scaleCacheEntryLifetime      time.Duration = time.Hour
 	scaleCacheEntryFreshnessTime time.Duration = 10 * time.Minute
 	scaleCacheEntryJitterFactor  float64       = 1.
+	tracingShutdownTimeout       time.Duration = 5 * time.Second
 )
 
 func main() {
@@ -109,14 +326,44 @@ func main() {
 		klog.ErrorS(nil, "--vpa-object-namespace and --ignored-vpa-object-namespaces are mutually exclusive and can't be set together.")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
+	if len(parseNamespaces(*watchedNamespaces)) > 0 && len(commonFlags.IgnoredVpaObjectNamespaces) > 0 {
+		klog.ErrorS(nil, "--watched-namespaces and --ignored-vpa-object-namespaces are mutually exclusive and can't be set together.")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	if *maxRecommendationAge > 0 && *maxRecommendationAge <= vpa_api_util.RecommendationHeartbeatInterval {
+		klog.ErrorS(nil, "--max-recommendation-age has to be longer than the heartbeat interval of the recommender", "maxRecommendationAge", *maxRecommendationAge, "heartbeatInterval", vpa_api_util.RecommendationHeartbeatInterval)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	if *enableTracing {
+		shutdown, err := setUpTracing(context.Background())
+		if err != nil {
+			klog.ErrorS(err, "Failed to set up tracing")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+		shutdownTracing = shutdown
+	}
 
 	healthCheck := metrics.NewHealthCheck(*updaterInterval * 5)
-	server.Initialize(&commonFlags.EnableProfiling, healthCheck, address)
+	if *readinessThreshold == 0 {
+		*readinessThreshold = *updaterInterval * 3
+	}
+	readinessCheck := metrics.NewReadinessCheck(*readinessThreshold)
+	var decisions *updater.DecisionSnapshot
+	debugHandlers := map[string]http.Handler{}
+	if *debugDecisions {
+		decisions = updater.NewDecisionSnapshot()
+		debugHandlers[updater.DecisionsPath] = decisions
+	}
+	server.Initialize(&commonFlags.EnableProfiling, healthCheck, readinessCheck, address, *metricsExemplars, debugHandlers)
 
 	metrics_updater.Register()
 
+	stopCh := make(chan struct{})
+	defer close(stopCh)
 	if !leaderElection.LeaderElect {
-		run(healthCheck, commonFlags)
+		run(newUpdaterCaches(commonFlags, stopCh), healthCheck, readinessCheck, commonFlags, decisions, stopCh)
 	} else {
 		id, err := os.Hostname()
 		if err != nil {
@@ -125,39 +372,24 @@ func main() {
 		}
 		id = id + "_" + string(uuid.NewUUID())
 
-		config := common.CreateKubeConfigOrDie(commonFlags.KubeConfig, float32(commonFlags.KubeApiQps), int(commonFlags.KubeApiBurst))
-		kubeClient := kube_client.NewForConfigOrDie(config)
-
-		lock, err := resourcelock.New(
-			leaderElection.ResourceLock,
-			leaderElection.ResourceNamespace,
-			leaderElection.ResourceName,
-			kubeClient.CoreV1(),
-			kubeClient.CoordinationV1(),
-			resourcelock.ResourceLockConfig{
-				Identity: id,
+		// Replicas waiting to be elected are ready once their caches synced.
+		caches := newUpdaterCaches(commonFlags, stopCh)
+		readinessCheck.MarkStandby()
+
+		elector, err := newLeaderElector(context.TODO(), leaderElection, caches.kubeClient, id, parseNamespaces(*watchedNamespaces), *leaderElectShardLease, leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(_ context.Context) {
+				readinessCheck.MarkLeading()
+				run(caches, healthCheck, readinessCheck, commonFlags, decisions, stopCh)
+			},
+			OnStoppedLeading: func() {
+				klog.Fatal("lost master")
 			},
-		)
+		})
 		if err != nil {
-			klog.ErrorS(err, "Unable to create leader election lock")
+			klog.ErrorS(err, "Unable to create leader elector")
 			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 		}
-
-		leaderelection.RunOrDie(context.TODO(), leaderelection.LeaderElectionConfig{
-			Lock:            lock,
-			LeaseDuration:   leaderElection.LeaseDuration.Duration,
-			RenewDeadline:   leaderElection.RenewDeadline.Duration,
-			RetryPeriod:     leaderElection.RetryPeriod.Duration,
-			ReleaseOnCancel: true,
-			Callbacks: leaderelection.LeaderCallbacks{
-				OnStartedLeading: func(_ context.Context) {
-					run(healthCheck, commonFlags)
-				},
-				OnStoppedLeading: func() {
-					klog.Fatal("lost master")
-				},
-			},
-		})
+		elector.Run(context.TODO())
 	}
 }
 
@@ -179,20 +411,100 @@ func defaultLeaderElectionConfiguration() componentbaseconfig.LeaderElectionConf
 	}
 }
 
-func run(healthCheck *metrics.HealthCheck, commonFlag *common.CommonFlags) {
-	stopCh := make(chan struct{})
-	defer close(stopCh)
+// setUpTracing registers a global tracer provider exporting spans over OTLP/gRPC. It returns a function
+// flushing the spans not exported yet and shutting the provider down, which waits for at most
+// tracingShutdownTimeout so that an unreachable collector doesn't delay the exit of the updater.
+func setUpTracing(ctx context.Context) (func(), error) {
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "vpa-updater"))),
+	)
+	otel.SetTracerProvider(provider)
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			klog.ErrorS(err, "Failed to shut down tracing")
+		}
+	}, nil
+}
+
+// exit shuts tracing down, flushes the logs and exits with the given code. klog.FlushAndExit doesn't
+// run deferred functions, so the spans of the last loop would be lost without it.
+func exit(code int) {
+	shutdownTracing()
+	klog.FlushAndExit(klog.ExitFlushTimeout, code)
+}
+
+// updaterCaches holds the clients of the updater and the informer caches it reads from.
+type updaterCaches struct {
+	config                *rest.Config
+	kubeClient            kube_client.Interface
+	vpaClient             *vpa_clientset.Clientset
+	vpaLister             vpa_lister.VerticalPodAutoscalerLister
+	vpaResourceClient     dynamic.Interface
+	vpaResourceToRead     *schema.GroupVersionResource
+	targetSelectorFetcher target.VpaTargetSelectorFetcher
+	controllerFetcher     controllerfetcher.ControllerFetcher
+	limitRangeCalculator  limitrange.LimitRangeCalculator
+	nodeLister            v1lister.NodeLister
+	localVolumeConfig     updater.LocalVolumeConfig
+	resourceQuotaLister   v1lister.ResourceQuotaLister
+	hpaLister             autoscalinglister.HorizontalPodAutoscalerLister
+}
+
+// newUpdaterCaches creates the clients of the updater and starts its informers, returning once their
+// caches synced. With leader election, it runs before the replica is elected, so that it is ready to
+// take over without waiting for its caches.
+func newUpdaterCaches(commonFlag *common.CommonFlags, stopCh <-chan struct{}) *updaterCaches {
 	config := common.CreateKubeConfigOrDie(commonFlag.KubeConfig, float32(commonFlag.KubeApiQps), int(commonFlag.KubeApiBurst))
 	kubeClient := kube_client.NewForConfigOrDie(config)
 	vpaClient := vpa_clientset.NewForConfigOrDie(config)
+	caches := &updaterCaches{config: config, kubeClient: kubeClient, vpaClient: vpaClient}
+	if *vpaResource != "" {
+		resource, err := vpa_api_util.ParseVpaResource(*vpaResource)
+		if err != nil {
+			klog.ErrorS(err, "Failed to parse --vpa-resource")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+		caches.vpaResourceClient = dynamic.NewForConfigOrDie(config)
+		caches.vpaResourceToRead = &resource
+		caches.vpaLister = vpa_api_util.NewVpasListerForResource(caches.vpaResourceClient, resource, make(chan struct{}), commonFlag.VpaObjectNamespace)
+	} else {
+		caches.vpaLister = vpa_api_util.NewVpasLister(vpaClient, make(chan struct{}), commonFlag.VpaObjectNamespace)
+	}
 	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, defaultResyncPeriod, informers.WithNamespace(commonFlag.VpaObjectNamespace))
-	targetSelectorFetcher := target.NewVpaTargetSelectorFetcher(config, kubeClient, factory)
-	controllerFetcher := controllerfetcher.NewControllerFetcher(config, kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
-	var limitRangeCalculator limitrange.LimitRangeCalculator
+	caches.targetSelectorFetcher = target.NewVpaTargetSelectorFetcher(config, kubeClient, factory)
+	caches.controllerFetcher = controllerfetcher.NewControllerFetcher(config, kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
 	limitRangeCalculator, err := limitrange.NewLimitsRangeCalculator(factory)
 	if err != nil {
 		klog.ErrorS(err, "Failed to create limitRangeCalculator, falling back to not checking limits")
-		limitRangeCalculator = limitrange.NewNoopLimitsCalculator()
+		caches.limitRangeCalculator = limitrange.NewNoopLimitsCalculator()
+	} else {
+		caches.limitRangeCalculator = limitRangeCalculator
+	}
+	if *maxDisruptedPodsPerZone > 0 || *respectTopologySpread || *nodeUtilizationThreshold > 0 {
+		caches.nodeLister = factory.Core().V1().Nodes().Lister()
+	}
+	volumePolicy, err := updater.ParseLocalVolumePolicy(*localVolumePolicy)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse local volume policy")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	caches.localVolumeConfig = updater.LocalVolumeConfig{Policy: volumePolicy}
+	if volumePolicy != updater.LocalVolumePolicyEvict {
+		caches.localVolumeConfig.PvcLister = factory.Core().V1().PersistentVolumeClaims().Lister()
+		caches.localVolumeConfig.PvLister = factory.Core().V1().PersistentVolumes().Lister()
+	}
+	if *respectResourceQuotas {
+		caches.resourceQuotaLister = factory.Core().V1().ResourceQuotas().Lister()
+	}
+	if *deferUpdatesDuringHpaScaling {
+		caches.hpaLister = factory.Autoscaling().V2().HorizontalPodAutoscalers().Lister()
 	}
 
 	factory.Start(stopCh)
@@ -203,22 +515,141 @@ func run(healthCheck *metrics.HealthCheck, commonFlag *common.CommonFlags) {
 			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 		}
 	}
+	return caches
+}
 
+func run(caches *updaterCaches, healthCheck *metrics.HealthCheck, readinessCheck *metrics.ReadinessCheck, commonFlag *common.CommonFlags, decisions *updater.DecisionSnapshot, stopCh <-chan struct{}) {
+	var err error
 	admissionControllerStatusNamespace := status.AdmissionControllerStatusNamespace
 	if namespace != "" {
 		admissionControllerStatusNamespace = namespace
 	}
 
 	ignoredNamespaces := strings.Split(commonFlag.IgnoredVpaObjectNamespaces, ",")
+	watched := parseNamespaces(*watchedNamespaces)
 
-	recommendationProvider := recommendation.NewProvider(limitRangeCalculator, vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator))
+	var defaultResourcePolicy *vpa_api_util.DefaultResourcePolicy
+	if *defaultResourcePolicyConfigMap != "" {
+		defaultResourcePolicy, err = vpa_api_util.WatchDefaultResourcePolicy(caches.kubeClient, *defaultResourcePolicyConfigMap, admissionControllerStatusNamespace, stopCh)
+		if err != nil {
+			klog.ErrorS(err, "Failed to watch default resource policy")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+	recommendationProcessor := vpa_api_util.NewOverrideRecommendationProcessor(vpa_api_util.NewCappingRecommendationProcessorWithDefaults(caches.limitRangeCalculator, defaultResourcePolicy))
+	granularity, err := vpa_api_util.ParseRecommendationGranularity(*recommendationCPUGranularity, *recommendationMemoryGranularity)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse recommendation granularity")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	if len(granularity) > 0 {
+		recommendationProcessor = vpa_api_util.NewRoundingRecommendationProcessor(recommendationProcessor, granularity)
+	}
+	if *recommendationMarginFraction > 0 {
+		recommendationProcessor = vpa_api_util.NewMarginRecommendationProcessor(recommendationProcessor, *recommendationMarginFraction)
+	}
+	recommendationProvider := recommendation.NewProvider(caches.limitRangeCalculator, recommendationProcessor)
 
 	calculators := []patch.Calculator{inplace.NewResourceInPlaceUpdatesCalculator(recommendationProvider), inplace.NewInPlaceUpdatedCalculator()}
 
+	evictionAdmissions := []priority.PodEvictionAdmission{priority.NewScalingDirectionPodEvictionAdmission()}
+	if *restartCountThreshold > 0 {
+		evictionAdmissions = append(evictionAdmissions, priority.NewRestartCountPodEvictionAdmission(int32(*restartCountThreshold), *restartCountWindow))
+	}
+	if *minContainerCount > 0 {
+		evictionAdmissions = append(evictionAdmissions, priority.NewContainerCountPodEvictionAdmission(*minContainerCount))
+	}
+	if *maxDisruptedPodsPercentage > 0 {
+		evictionAdmissions = append(evictionAdmissions, priority.NewClusterDisruptionPodEvictionAdmission(*maxDisruptedPodsPercentage))
+	}
+	if *maxDisruptedPodsPerZone > 0 {
+		evictionAdmissions = append(evictionAdmissions, priority.NewZoneDisruptionPodEvictionAdmission(caches.nodeLister, *maxDisruptedPodsPerZone))
+	}
+	if *nodeUtilizationThreshold > 0 {
+		evictionAdmissions = append(evictionAdmissions, priority.NewNodeUtilizationPodEvictionAdmission(caches.nodeLister, resourceclient.NewForConfigOrDie(caches.config), *nodeUtilizationThreshold))
+	}
+	if *respectTopologySpread {
+		evictionAdmissions = append(evictionAdmissions, priority.NewTopologySpreadPodEvictionAdmission(caches.nodeLister))
+	}
+	if *deferUpdatesDuringHpaScaling {
+		evictionAdmissions = append(evictionAdmissions, priority.NewHpaScalingPodEvictionAdmission(caches.hpaLister))
+	}
+	evictionAdmission := priority.NewSequentialPodEvictionAdmission(evictionAdmissions)
+
+	evictionPolicy := restriction.EvictionPolicy{
+		ForegroundDeletion: *evictionForegroundDeletion,
+		Timeout:            *evictionTimeout,
+		AnnotateTrigger:    *annotateEvictedPods,
+	}
+	if *evictionGracePeriodSeconds >= 0 {
+		evictionPolicy.GracePeriodSeconds = evictionGracePeriodSeconds
+	}
+
+	controllerLockConfig := updater.ControllerLockConfig{LeaseDuration: *controllerLockDuration}
+	if controllerLockConfig.LeaseDuration > 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			klog.ErrorS(err, "Unable to get hostname")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+		controllerLockConfig.Identity = hostname + "_" + string(uuid.NewUUID())
+	}
+
+	var snapshot updater.RecommendationSnapshot
+	if len(*recommendationSnapshot) > 0 {
+		snapshot, err = updater.LoadRecommendationSnapshot(*recommendationSnapshot)
+		if err != nil {
+			klog.ErrorS(err, "Failed to load recommendation snapshot")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+
+	rateSchedule, err := updater.ParseRateLimitSchedule(*evictionRateSchedule, *evictionRateScheduleStart, *evictionRateScheduleTimeZone)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse --eviction-rate-schedule")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	objective, err := priority.ParseUpdateObjective(*updateObjective)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse --update-objective")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	updateConfig := priority.NewDefaultUpdateConfig()
+	updateConfig.Objective = objective
+	updateConfig.PreferLowPriorityPods = *preferLowPriorityPods
+	updateConfig.AllowQOSDowngrade = *allowQOSDowngrade
+	updateConfig.MaxPodLifetime = *maxPodLifetime
+	updateConfig.SidecarMinChangePriority = *sidecarUpdateThreshold
+	updateConfig.MinChangeFraction = *minChangeFraction
+	if len(*sidecarContainerNamePattern) > 0 {
+		updateConfig.SidecarContainerPattern, err = regexp.Compile(*sidecarContainerNamePattern)
+		if err != nil {
+			klog.ErrorS(err, "Failed to parse --sidecar-container-name-pattern")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+
+	backoffStrategy, err := updater.NewBackoffStrategy(*podBackoffStrategy, *podBackoffDelay, *podBackoffMaxDelay)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse --pod-backoff-strategy")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	var eventTemplates updater.EventTemplates
+	if len(*eventTemplatesFile) > 0 {
+		eventTemplates, err = updater.LoadEventTemplates(*eventTemplatesFile)
+		if err != nil {
+			klog.ErrorS(err, "Failed to load event templates")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+
 	// TODO: use SharedInformerFactory in updater
 	updater, err := updater.NewUpdater(
-		kubeClient,
-		vpaClient,
+		caches.kubeClient,
+		caches.vpaClient,
+		caches.vpaLister,
 		*minReplicas,
 		*evictionRateLimit,
 		*evictionRateBurst,
@@ -226,28 +657,105 @@ func run(healthCheck *metrics.HealthCheck, commonFlag *common.CommonFlags) {
 		*useAdmissionControllerStatus,
 		*inPlaceSkipDisruptionBudget,
 		admissionControllerStatusNamespace,
-		vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator),
-		priority.NewScalingDirectionPodEvictionAdmission(),
-		targetSelectorFetcher,
-		controllerFetcher,
+		recommendationProcessor,
+		evictionAdmission,
+		caches.targetSelectorFetcher,
+		caches.controllerFetcher,
 		priority.NewProcessor(),
 		commonFlag.VpaObjectNamespace,
 		ignoredNamespaces,
 		calculators,
+		updater.Options{
+			EvictionRateSchedule: rateSchedule,
+			EvictionPolicy:       evictionPolicy,
+			InPlacePolicy: restriction.InPlacePolicy{
+				ShrinkOnly:                    *inPlaceShrinkOnly,
+				ResizeSubresource:             *inPlaceResizeSubresource,
+				ActuationGracePeriod:          *inPlaceActuationGracePeriod,
+				PreferAtDisruptionBudgetLimit: *preferInPlaceAtPDBLimit,
+			},
+			GroupingPolicy: restriction.GroupingPolicy{
+				UpdateBarePods:                   *evictBarePods,
+				DeferPodsWithStrippedTolerations: *deferPodsWithStrippedTolerations,
+			},
+			UpdateConfig:                   updateConfig,
+			WatchedNamespaces:              watched,
+			PrioritizeSelectorChanges:      *prioritizeSelectorChanges,
+			MaxRecommendationAge:           *maxRecommendationAge,
+			RecommendationSnapshot:         snapshot,
+			EvictionCoordinationAnnotation: *evictionCoordinationAnnotation,
+			ActionHistorySize:              *actionHistorySize,
+			ActionHistoryFlushInterval:     *actionHistoryFlushInterval,
+			EventSourceComponent:           *eventSourceComponent,
+			EventDeduplicationWindow:       *eventDeduplicationWindow,
+			EvictionCircuitBreaker: updater.EvictionCircuitBreakerConfig{
+				ErrorThreshold: *evictionCircuitBreakerErrorThreshold,
+				Window:         *evictionCircuitBreakerWindow,
+				Cooldown:       *evictionCircuitBreakerCooldown,
+			},
+			ErroredVpaRequeue: updater.ErroredVpaRequeueConfig{
+				BaseDelay: *erroredVpaRequeueBaseDelay,
+				MaxDelay:  *erroredVpaRequeueMaxDelay,
+				Timeout:   *updaterInterval,
+			},
+			NamespaceRateLimitsConfigMap: *namespaceRateLimitsConfigMap,
+			PodBackoffStrategy:           backoffStrategy,
+			AnnotateBlockedPods:          *annotateBlockedPods,
+			ControllerEventsInterval:     *controllerEventsInterval,
+			InitialModeDriftThreshold:    *initialModeDriftThreshold,
+			RecommendationStability: updater.RecommendationStabilityConfig{
+				Loops:     *recommendationStabilityLoops,
+				Tolerance: *recommendationStabilityTolerance,
+			},
+			LocalVolumes:          caches.localVolumeConfig,
+			KillSwitchConfigMap:   *killSwitchConfigMap,
+			EventTemplates:        eventTemplates,
+			ResourceQuotaLister:   caches.resourceQuotaLister,
+			DefaultResourcePolicy: defaultResourcePolicy,
+			SelectorFetchRetries:  *selectorFetchRetries,
+			SafeToEvictCondition:  *safeToEvictCondition,
+			DecisionSnapshot:      decisions,
+			ControllerLock:        controllerLockConfig,
+			VpaResource:           caches.vpaResourceToRead,
+			VpaResourceClient:     caches.vpaResourceClient,
+			StopCh:                stopCh,
+		},
 	)
 	if err != nil {
 		klog.ErrorS(err, "Failed to create updater")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
+	if *runOnce {
+		if err := runUpdaterOnce(updater, *updaterInterval); err != nil {
+			klog.ErrorS(err, "Updater loop ran into errors")
+			exit(1)
+		}
+		exit(0)
+	}
+
 	// Start updating health check endpoint.
 	healthCheck.StartMonitoring()
 
-	ticker := time.Tick(*updaterInterval)
-	for range ticker {
-		ctx, cancel := context.WithTimeout(context.Background(), *updaterInterval)
-		updater.RunOnce(ctx)
-		healthCheck.UpdateLastActivity()
-		cancel()
-	}
+	retryCtx, stopRetries := context.WithCancel(context.Background())
+	var retries sync.WaitGroup
+	retries.Add(1)
+	go func() {
+		defer retries.Done()
+		updater.ProcessErroredVpas(retryCtx)
+	}()
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-signals
+		stopRetries()
+		close(stop)
+	}()
+	runUpdaterLoop(updater, *updaterInterval, *shutdownGracePeriod, stop, onLoopCompleted(healthCheck, readinessCheck))
+	// Wait for a retry in flight to stop, so that it doesn't act on pods while the updater exits.
+	retries.Wait()
+	updater.Shutdown()
+	exit(0)
 }
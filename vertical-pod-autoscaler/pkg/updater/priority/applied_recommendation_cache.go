@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// AppliedRecommendationCache remembers Pods which were found to already match the recommendation
+// of their VPA, so that they are not re-evaluated as long as neither the recommendation
+// nor the Pod resources change. The recommendation is the one processed for the Pod, so that
+// anything changing it, e.g. annotations or default resource policies, invalidates the entry.
+type AppliedRecommendationCache struct {
+	mutex   sync.Mutex
+	entries map[types.NamespacedName]appliedRecommendation
+}
+
+type appliedRecommendation struct {
+	podUID             types.UID
+	recommendationHash uint64
+	podResourcesHash   uint64
+}
+
+// NewAppliedRecommendationCache returns an empty AppliedRecommendationCache.
+func NewAppliedRecommendationCache() *AppliedRecommendationCache {
+	return &AppliedRecommendationCache{
+		entries: make(map[types.NamespacedName]appliedRecommendation),
+	}
+}
+
+// Matches returns true if the Pod was recorded as matching the recommendation processed for it
+// and neither the recommendation nor the Pod resources changed since.
+func (c *AppliedRecommendationCache) Matches(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, found := c.entries[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}]
+	return found && entry == newAppliedRecommendation(pod, vpa, recommendation)
+}
+
+// Record remembers that the Pod matches the recommendation processed for it.
+func (c *AppliedRecommendationCache) Record(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}] = newAppliedRecommendation(pod, vpa, recommendation)
+}
+
+// Retain drops entries of Pods which are not in the given list.
+func (c *AppliedRecommendationCache) Retain(pods []*apiv1.Pod) {
+	live := make(map[types.NamespacedName]bool, len(pods))
+	for _, pod := range pods {
+		live[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}] = true
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.entries {
+		if !live[key] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func newAppliedRecommendation(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) appliedRecommendation {
+	return appliedRecommendation{
+		podUID:             pod.UID,
+		recommendationHash: hashRecommendation(vpa, recommendation),
+		podResourcesHash:   hashPodResources(pod),
+	}
+}
+
+// hashRecommendation hashes the processed recommendation together with the VPA generation,
+// so that changes to the update policy also invalidate the cached result.
+func hashRecommendation(vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatInt(vpa.Generation, 10)))
+	if recommendation != nil {
+		for _, cr := range recommendation.ContainerRecommendations {
+			fmt.Fprintf(h, "|%s", cr.ContainerName)
+			writeResourceList(h, cr.Target)
+			writeResourceList(h, cr.LowerBound)
+			writeResourceList(h, cr.UpperBound)
+		}
+	}
+	return h.Sum64()
+}
+
+func hashPodResources(pod *apiv1.Pod) uint64 {
+	h := fnv.New64a()
+	for _, container := range pod.Spec.Containers {
+		fmt.Fprintf(h, "|%s", container.Name)
+		writeResourceList(h, container.Resources.Requests)
+		writeResourceList(h, container.Resources.Limits)
+	}
+	return h.Sum64()
+}
+
+func writeResourceList(h hash.Hash64, resources apiv1.ResourceList) {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		quantity := resources[apiv1.ResourceName(name)]
+		fmt.Fprintf(h, ";%s=%s", name, quantity.String())
+	}
+}
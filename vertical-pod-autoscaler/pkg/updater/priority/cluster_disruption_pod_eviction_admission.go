@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"math"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewClusterDisruptionPodEvictionAdmission creates a PodEvictionAdmission object.
+// It caps the percentage of all Pods controlled by VPAs which are disrupted at the same time,
+// complementing the per-controller limits with a cluster-wide one. Pods which aren't ready,
+// e.g. replacements of evicted Pods still starting, count as disrupted.
+func NewClusterDisruptionPodEvictionAdmission(maxDisruptedPercentage float64) PodEvictionAdmission {
+	return &clusterDisruptionPodEvictionAdmission{
+		maxDisruptedPercentage: maxDisruptedPercentage,
+		evicted:                sets.New[types.UID](),
+	}
+}
+
+type clusterDisruptionPodEvictionAdmission struct {
+	maxDisruptedPercentage float64
+	// controlled and disrupted are the number of Pods controlled by VPAs and of those disrupted,
+	// including the Pods evicted in this loop.
+	controlled int
+	disrupted  int
+	evicted    sets.Set[types.UID]
+}
+
+// LoopInit takes the census of the controlled Pods and of those currently disrupted.
+func (c *clusterDisruptionPodEvictionAdmission) LoopInit(_ []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	c.CleanUp()
+	for _, pods := range vpaControlledPods {
+		for _, pod := range pods {
+			c.controlled++
+			if !isPodReady(pod) {
+				c.disrupted++
+			}
+		}
+	}
+}
+
+// Admit admits a Pod if disrupting it keeps the disrupted Pods within the configured percentage.
+func (c *clusterDisruptionPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	return c.AllowsEviction(pod)
+}
+
+// AllowsEviction returns true if evicting the Pod keeps the disrupted Pods within the configured percentage.
+func (c *clusterDisruptionPodEvictionAdmission) AllowsEviction(pod *apiv1.Pod) bool {
+	if c.evicted.Has(pod.UID) {
+		return true
+	}
+	// Like the maxUnavailable of a PodDisruptionBudget, the percentage is rounded up so that small clusters are
+	// still updated.
+	maxDisrupted := int(math.Ceil(c.maxDisruptedPercentage * float64(c.controlled) / 100))
+	if c.disrupted+1 > maxDisrupted {
+		klog.V(4).InfoS("Deferring update of pod, too many pods are disrupted cluster-wide", "pod", klog.KObj(pod), "disrupted", c.disrupted, "controlled", c.controlled, "maxDisruptedPercentage", c.maxDisruptedPercentage)
+		return false
+	}
+	return true
+}
+
+// RecordEviction counts the evicted Pod as disrupted for the rest of the loop.
+func (c *clusterDisruptionPodEvictionAdmission) RecordEviction(pod *apiv1.Pod) {
+	if c.evicted.Has(pod.UID) {
+		return
+	}
+	c.disrupted++
+	c.evicted.Insert(pod.UID)
+}
+
+// CleanUp resets the census.
+func (c *clusterDisruptionPodEvictionAdmission) CleanUp() {
+	c.controlled = 0
+	c.disrupted = 0
+	c.evicted = sets.New[types.UID]()
+}
+
+func isPodReady(pod *apiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
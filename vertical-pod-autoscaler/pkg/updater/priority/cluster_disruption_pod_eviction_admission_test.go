@@ -0,0 +1,171 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestClusterDisruptionPodEvictionAdmission(t *testing.T) {
+	newPods := func(prefix string, count int, ready apiv1.ConditionStatus) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, count)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(fmt.Sprintf("%s-%d", prefix, i)).Get()
+			pods[i].UID = types.UID(pods[i].Name)
+			pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: ready}}
+		}
+		return pods
+	}
+
+	testCases := []struct {
+		name                   string
+		maxDisruptedPercentage float64
+		readyPods              int
+		notReadyPods           int
+		expectedAdmitted       int
+	}{
+		{
+			name:                   "admits pods up to the cap",
+			maxDisruptedPercentage: 20,
+			readyPods:              10,
+			expectedAdmitted:       2,
+		},
+		{
+			name:                   "pods which aren't ready count against the cap",
+			maxDisruptedPercentage: 20,
+			readyPods:              9,
+			notReadyPods:           1,
+			expectedAdmitted:       1,
+		},
+		{
+			name:                   "no pod is admitted once the cap is hit",
+			maxDisruptedPercentage: 20,
+			readyPods:              8,
+			notReadyPods:           2,
+			expectedAdmitted:       0,
+		},
+		{
+			name:                   "cap below a single pod is rounded up to one pod",
+			maxDisruptedPercentage: 5,
+			readyPods:              10,
+			expectedAdmitted:       1,
+		},
+		{
+			name:                   "small cluster is still updated one pod at a time",
+			maxDisruptedPercentage: 10,
+			readyPods:              3,
+			expectedAdmitted:       1,
+		},
+		{
+			name:                   "rounded up cap is hit by a pod which isn't ready",
+			maxDisruptedPercentage: 10,
+			readyPods:              2,
+			notReadyPods:           1,
+			expectedAdmitted:       0,
+		},
+		{
+			name:                   "cap is rounded up like maxUnavailable",
+			maxDisruptedPercentage: 25,
+			readyPods:              10,
+			expectedAdmitted:       3,
+		},
+		{
+			name:                   "cap of 100 percent admits all pods",
+			maxDisruptedPercentage: 100,
+			readyPods:              10,
+			expectedAdmitted:       10,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			readyPods := newPods("ready", tc.readyPods, apiv1.ConditionTrue)
+			notReadyPods := newPods("not-ready", tc.notReadyPods, apiv1.ConditionFalse)
+			vpa1 := test.VerticalPodAutoscaler().WithName("vpa1").WithContainer(containerName).Get()
+			vpa2 := test.VerticalPodAutoscaler().WithName("vpa2").WithContainer(containerName).Get()
+			// The pods of both VPAs count towards the same cap.
+			half := len(readyPods) / 2
+			controlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{
+				vpa1: append(readyPods[:half:half], notReadyPods...),
+				vpa2: readyPods[half:],
+			}
+
+			admission := NewClusterDisruptionPodEvictionAdmission(tc.maxDisruptedPercentage)
+			admission.LoopInit(nil, controlledPods)
+			admitted := 0
+			for _, pod := range readyPods {
+				if admission.Admit(pod, nil) {
+					admitted++
+					admission.(PodEvictionBudget).RecordEviction(pod)
+				}
+			}
+			assert.Equal(t, tc.expectedAdmitted, admitted)
+		})
+	}
+}
+
+func TestClusterDisruptionPodEvictionAdmission_LoopInitResetsCensus(t *testing.T) {
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).Get()
+	pods := make([]*apiv1.Pod, 4)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(fmt.Sprintf("pod-%d", i)).Get()
+		pods[i].UID = types.UID(pods[i].Name)
+		pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+	}
+	controlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods}
+
+	admission := NewClusterDisruptionPodEvictionAdmission(25)
+	budget := admission.(PodEvictionBudget)
+	admission.LoopInit(nil, controlledPods)
+	assert.True(t, admission.Admit(pods[0], nil))
+	budget.RecordEviction(pods[0])
+	assert.True(t, admission.Admit(pods[0], nil), "a pod evicted already is admitted again")
+	assert.False(t, admission.Admit(pods[1], nil))
+
+	admission.LoopInit(nil, controlledPods)
+	assert.True(t, admission.Admit(pods[1], nil), "evictions of the previous loop are forgotten")
+	budget.RecordEviction(pods[1])
+	assert.False(t, admission.Admit(pods[0], nil))
+}
+
+func TestClusterDisruptionPodEvictionAdmission_ChargedOnEviction(t *testing.T) {
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).Get()
+	pods := make([]*apiv1.Pod, 4)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(fmt.Sprintf("pod-%d", i)).Get()
+		pods[i].UID = types.UID(pods[i].Name)
+		pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+	}
+	controlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods}
+
+	admission := NewSequentialPodEvictionAdmission([]PodEvictionAdmission{NewClusterDisruptionPodEvictionAdmission(25)})
+	budget := admission.(PodEvictionBudget)
+	admission.LoopInit(nil, controlledPods)
+	assert.True(t, admission.Admit(pods[0], nil))
+	assert.True(t, admission.Admit(pods[1], nil), "admitting a pod doesn't charge the cap")
+	assert.True(t, budget.AllowsEviction(pods[0]))
+	budget.RecordEviction(pods[0])
+	assert.False(t, budget.AllowsEviction(pods[1]), "the eviction charged the cap")
+	assert.True(t, budget.AllowsEviction(pods[0]))
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewContainerCountPodEvictionAdmission creates a PodEvictionAdmission object.
+// It skips updates of Pods with fewer than minContainerCount containers.
+func NewContainerCountPodEvictionAdmission(minContainerCount int) PodEvictionAdmission {
+	return &containerCountPodEvictionAdmission{
+		minContainerCount: minContainerCount,
+	}
+}
+
+type containerCountPodEvictionAdmission struct {
+	minContainerCount int
+}
+
+// LoopInit is a no-op, containers are counted from the Pod spec on admission.
+func (c *containerCountPodEvictionAdmission) LoopInit([]*apiv1.Pod, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+}
+
+// Admit admits a Pod if it has at least the configured number of containers.
+func (c *containerCountPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	if len(pod.Spec.Containers) < c.minContainerCount {
+		klog.V(4).InfoS("Skipping update of pod with too few containers", "pod", klog.KObj(pod), "containers", len(pod.Spec.Containers), "minContainerCount", c.minContainerCount)
+		return false
+	}
+	return true
+}
+
+// CleanUp is a no-op, containerCountPodEvictionAdmission keeps no state.
+func (c *containerCountPodEvictionAdmission) CleanUp() {
+}
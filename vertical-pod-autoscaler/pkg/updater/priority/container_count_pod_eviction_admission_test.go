@@ -0,0 +1,75 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestContainerCountPodEvictionAdmission(t *testing.T) {
+	singleContainerPod := test.Pod().WithName("POD1").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).Get()).Get()
+	twoContainersPod := test.Pod().WithName("POD2").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("1")).Get()).
+		AddContainer(test.Container().WithName("container2").WithCPURequest(resource.MustParse("1")).Get()).Get()
+
+	testCases := []struct {
+		name              string
+		minContainerCount int
+		expectedPods      []*apiv1.Pod
+	}{
+		{
+			name:              "threshold of one admits all pods",
+			minContainerCount: 1,
+			expectedPods:      []*apiv1.Pod{singleContainerPod, twoContainersPod},
+		},
+		{
+			name:              "threshold of two skips single container pod",
+			minContainerCount: 2,
+			expectedPods:      []*apiv1.Pod{twoContainersPod},
+		},
+		{
+			name:              "threshold above container count skips all pods",
+			minContainerCount: 3,
+			expectedPods:      []*apiv1.Pod{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("10", "").Get()
+			priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+				"POD1": {ResourceDiff: 2.0},
+				"POD2": {ResourceDiff: 1.0},
+			})
+			calculator := NewUpdatePriorityCalculator(vpa, nil, &test.FakeRecommendationProcessor{}, priorityProcessor)
+			timestampNow := singleContainerPod.Status.StartTime.Add(time.Hour * 24)
+			calculator.AddPod(singleContainerPod, timestampNow)
+			calculator.AddPod(twoContainersPod, timestampNow)
+
+			admission := NewContainerCountPodEvictionAdmission(tc.minContainerCount)
+			admission.LoopInit(nil, nil)
+			assert.Exactly(t, tc.expectedPods, calculator.GetSortedPods(admission))
+		})
+	}
+}
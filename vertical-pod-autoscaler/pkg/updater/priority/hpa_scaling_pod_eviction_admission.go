@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	autoscalinglister "k8s.io/client-go/listers/autoscaling/v2"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewHpaScalingPodEvictionAdmission creates a PodEvictionAdmission object.
+// It defers updates of Pods whose VPA targets a workload which a HorizontalPodAutoscaler
+// is scaling right now, i.e. whose desired number of replicas differs from the current one,
+// so that VPA updates don't amplify the disruption of horizontal scaling.
+func NewHpaScalingPodEvictionAdmission(hpaLister autoscalinglister.HorizontalPodAutoscalerLister) PodEvictionAdmission {
+	return &hpaScalingPodEvictionAdmission{
+		hpaLister: hpaLister,
+		scaling:   sets.New[types.UID](),
+	}
+}
+
+type hpaScalingPodEvictionAdmission struct {
+	hpaLister autoscalinglister.HorizontalPodAutoscalerLister
+	// scaling holds the Pods whose workload is being scaled by an HPA in this loop.
+	scaling sets.Set[types.UID]
+}
+
+// LoopInit finds the Pods whose workload is being scaled by an HPA.
+func (h *hpaScalingPodEvictionAdmission) LoopInit(_ []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	h.CleanUp()
+	for vpa, pods := range vpaControlledPods {
+		hpa := h.scalingHpa(vpa)
+		if hpa == nil {
+			continue
+		}
+		klog.V(4).InfoS("Deferring updates of pods, their workload is being scaled by an HPA", "vpa", klog.KObj(vpa), "hpa", klog.KObj(hpa),
+			"currentReplicas", hpa.Status.CurrentReplicas, "desiredReplicas", hpa.Status.DesiredReplicas)
+		for _, pod := range pods {
+			h.scaling.Insert(pod.UID)
+		}
+	}
+}
+
+// Admit admits a Pod unless its workload is being scaled by an HPA.
+func (h *hpaScalingPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	return !h.scaling.Has(pod.UID)
+}
+
+// CleanUp forgets the Pods found in the last loop.
+func (h *hpaScalingPodEvictionAdmission) CleanUp() {
+	h.scaling = sets.New[types.UID]()
+}
+
+// scalingHpa returns the HPA targeting the same workload as the VPA if it is scaling it, or nil.
+func (h *hpaScalingPodEvictionAdmission) scalingHpa(vpa *vpa_types.VerticalPodAutoscaler) *autoscalingv2.HorizontalPodAutoscaler {
+	if vpa.Spec.TargetRef == nil {
+		return nil
+	}
+	hpas, err := h.hpaLister.HorizontalPodAutoscalers(vpa.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(4).InfoS("Failed to list HPAs, not deferring updates", "vpa", klog.KObj(vpa), "error", err)
+		return nil
+	}
+	for _, hpa := range hpas {
+		target := hpa.Spec.ScaleTargetRef
+		if target.Kind != vpa.Spec.TargetRef.Kind || target.Name != vpa.Spec.TargetRef.Name {
+			continue
+		}
+		if hpa.Status.DesiredReplicas != hpa.Status.CurrentReplicas {
+			return hpa
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	autoscalinglister "k8s.io/client-go/listers/autoscaling/v2"
+	"k8s.io/client-go/tools/cache"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestHpaScalingPodEvictionAdmission(t *testing.T) {
+	newHpa := func(name, deployment string, current, desired int32) *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: deployment, APIVersion: "apps/v1"},
+			},
+			Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: current, DesiredReplicas: desired},
+		}
+	}
+	newVpa := func(deployment string) *vpa_types.VerticalPodAutoscaler {
+		return test.VerticalPodAutoscaler().
+			WithName(deployment).
+			WithNamespace("default").
+			WithContainer(containerName).
+			WithTargetRef(&autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: deployment, APIVersion: "apps/v1"}).
+			Get()
+	}
+	newPod := func(name string) *apiv1.Pod {
+		pod := test.Pod().WithName(name).Get()
+		pod.UID = types.UID(name)
+		return pod
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scalingHpa := newHpa("scaling", "scaling", 2, 4)
+	assert.NoError(t, indexer.Add(scalingHpa))
+	assert.NoError(t, indexer.Add(newHpa("stable", "stable", 3, 3)))
+	hpaLister := autoscalinglister.NewHorizontalPodAutoscalerLister(indexer)
+
+	scalingPod := newPod("scaling-pod")
+	stablePod := newPod("stable-pod")
+	withoutHpaPod := newPod("without-hpa-pod")
+	vpaControlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{
+		newVpa("scaling"):     {scalingPod},
+		newVpa("stable"):      {stablePod},
+		newVpa("without-hpa"): {withoutHpaPod},
+	}
+
+	admission := NewHpaScalingPodEvictionAdmission(hpaLister)
+	admission.LoopInit(nil, vpaControlledPods)
+	assert.False(t, admission.Admit(scalingPod, nil), "pods of workloads which an HPA is scaling are deferred")
+	assert.True(t, admission.Admit(stablePod, nil), "pods of workloads at the replicas desired by their HPA are admitted")
+	assert.True(t, admission.Admit(withoutHpaPod, nil), "pods of workloads without an HPA are admitted")
+
+	settledHpa := scalingHpa.DeepCopy()
+	settledHpa.Status.CurrentReplicas = 4
+	assert.NoError(t, indexer.Update(settledHpa))
+	admission.LoopInit(nil, vpaControlledPods)
+	assert.True(t, admission.Admit(scalingPod, nil), "pods are admitted once the HPA finished scaling")
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"context"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	resourcehelpers "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/resources"
+	vpa_utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// nodeMetricsTimeout bounds the time spent fetching the usage of the Nodes in each loop.
+const nodeMetricsTimeout = 30 * time.Second
+
+// NewNodeUtilizationPodEvictionAdmission creates a PodEvictionAdmission object.
+// It admits Pods whose requests go down only if the Node they run on is pressured, i.e. its CPU or memory
+// usage reported by the metrics API is at least the given fraction of its allocatable, so that Pods on
+// underutilized Nodes aren't disrupted just to be right-sized. Pods whose requests go up, Pods not
+// scheduled yet, and Pods on Nodes without metrics are admitted.
+func NewNodeUtilizationPodEvictionAdmission(nodeLister v1lister.NodeLister, nodeMetrics resourceclient.NodeMetricsesGetter, threshold float64) PodEvictionAdmission {
+	return &nodeUtilizationPodEvictionAdmission{
+		nodeLister:  nodeLister,
+		nodeMetrics: nodeMetrics,
+		threshold:   threshold,
+		utilization: make(map[string]float64),
+	}
+}
+
+type nodeUtilizationPodEvictionAdmission struct {
+	nodeLister  v1lister.NodeLister
+	nodeMetrics resourceclient.NodeMetricsesGetter
+	threshold   float64
+	// utilization is the highest utilization of CPU and memory of each Node with metrics in this loop.
+	utilization map[string]float64
+}
+
+// LoopInit fetches the usage of all Nodes from the metrics API.
+func (n *nodeUtilizationPodEvictionAdmission) LoopInit(allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	n.LoopInitWithContext(context.Background(), allLivePods, vpaControlledPods)
+}
+
+// LoopInitWithContext fetches the usage of all Nodes from the metrics API, bounded by the context of the loop.
+func (n *nodeUtilizationPodEvictionAdmission) LoopInitWithContext(ctx context.Context, _ []*apiv1.Pod, _ map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	n.CleanUp()
+	ctx, cancel := context.WithTimeout(ctx, nodeMetricsTimeout)
+	defer cancel()
+	metricsList, err := n.nodeMetrics.NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to get node metrics, not gating updates on node utilization in this loop")
+		return
+	}
+	for _, metrics := range metricsList.Items {
+		node, err := n.nodeLister.Get(metrics.Name)
+		if err != nil {
+			klog.V(4).InfoS("Failed to get node with metrics", "node", metrics.Name, "error", err)
+			continue
+		}
+		utilization := 0.0
+		for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+			allocatable, found := node.Status.Allocatable[resourceName]
+			usage, hasUsage := metrics.Usage[resourceName]
+			if !found || allocatable.IsZero() || !hasUsage {
+				continue
+			}
+			utilization = max(utilization, usage.AsApproximateFloat64()/allocatable.AsApproximateFloat64())
+		}
+		n.utilization[node.Name] = utilization
+	}
+}
+
+// Admit admits a Pod if the recommendation raises any of its requests, or if the utilization of its Node is
+// at least the threshold, or unknown.
+func (n *nodeUtilizationPodEvictionAdmission) Admit(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) bool {
+	if raisesRequests(pod, recommendation) {
+		return true
+	}
+	utilization, found := n.utilization[pod.Spec.NodeName]
+	if !found || utilization >= n.threshold {
+		return true
+	}
+	klog.V(4).InfoS("Deferring update of pod, its node is not pressured", "pod", klog.KObj(pod), "node", pod.Spec.NodeName, "utilization", utilization, "threshold", n.threshold)
+	return false
+}
+
+// CleanUp forgets the utilization of the Nodes.
+func (n *nodeUtilizationPodEvictionAdmission) CleanUp() {
+	n.utilization = make(map[string]float64)
+}
+
+// raisesRequests returns true if the recommendation raises a request of any container of the Pod, or sets one
+// the container doesn't have. Such Pods are updated regardless of the utilization of their Node, as they may
+// be starved of resources.
+func raisesRequests(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources) bool {
+	for _, container := range pod.Spec.Containers {
+		recommendedResources := vpa_utils.GetRecommendationForContainer(container.Name, recommendation)
+		if recommendedResources == nil {
+			continue
+		}
+		containerRequests, _ := resourcehelpers.ContainerRequestsAndLimits(container.Name, pod)
+		for resourceName, target := range recommendedResources.Target {
+			request, found := containerRequests[resourceName]
+			if !found || target.Cmp(request) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestNodeUtilizationPodEvictionAdmission(t *testing.T) {
+	resources := func(cpu, memory string) apiv1.ResourceList {
+		return apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu), apiv1.ResourceMemory: resource.MustParse(memory)}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"cpu-pressured", "memory-pressured", "idle", "without-metrics"} {
+		node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: apiv1.NodeStatus{Allocatable: resources("4", "16Gi")}}
+		assert.NoError(t, indexer.Add(node))
+	}
+	nodeMetrics := &metricsv1beta1.NodeMetricsList{Items: []metricsv1beta1.NodeMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cpu-pressured"}, Usage: resources("3200m", "2Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "memory-pressured"}, Usage: resources("1", "14Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "idle"}, Usage: resources("1", "2Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}, Usage: resources("4", "16Gi")},
+	}}
+	var listErr error
+	metricsClient := &metricsfake.Clientset{}
+	metricsClient.AddReactor("list", "nodes", func(core.Action) (bool, runtime.Object, error) {
+		return true, nodeMetrics, listErr
+	})
+
+	admission := NewNodeUtilizationPodEvictionAdmission(v1lister.NewNodeLister(indexer), metricsClient.MetricsV1beta1(), 0.75)
+	admission.LoopInit(nil, nil)
+	podOn := func(node string) *apiv1.Pod {
+		pod := test.Pod().WithName("pod-" + node).Get()
+		pod.Spec.NodeName = node
+		return pod
+	}
+	assert.True(t, admission.Admit(podOn("cpu-pressured"), nil))
+	assert.True(t, admission.Admit(podOn("memory-pressured"), nil))
+	assert.False(t, admission.Admit(podOn("idle"), nil), "pods on nodes below the threshold are not admitted")
+	assert.True(t, admission.Admit(podOn("without-metrics"), nil), "pods on nodes without metrics are admitted")
+	assert.True(t, admission.Admit(podOn(""), nil), "pods not scheduled yet are admitted")
+
+	pod := podOn("idle")
+	pod.Spec.Containers = []apiv1.Container{test.Container().WithName("container").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()}
+	recommendation := func(cpu, memory string) *vpa_types.RecommendedPodResources {
+		return &vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			{ContainerName: "container", Target: resources(cpu, memory)},
+		}}
+	}
+	assert.False(t, admission.Admit(pod, recommendation("500m", "1Gi")), "pods whose requests go down are gated")
+	assert.True(t, admission.Admit(pod, recommendation("500m", "2Gi")), "pods whose requests go up are admitted")
+
+	listErr = errors.New("metrics API unavailable")
+	admission.LoopInit(nil, nil)
+	assert.True(t, admission.Admit(podOn("idle"), nil), "all pods are admitted without node metrics")
+}
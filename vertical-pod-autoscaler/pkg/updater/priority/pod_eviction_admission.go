@@ -17,6 +17,8 @@ limitations under the License.
 package priority
 
 import (
+	"context"
+
 	apiv1 "k8s.io/api/core/v1"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
@@ -34,6 +36,23 @@ type PodEvictionAdmission interface {
 	CleanUp()
 }
 
+// PodEvictionBudget is implemented by PodEvictionAdmissions which limit the number of disrupted pods.
+// Their Admit only checks that the budget has room for the pod, the budget is charged once the pod
+// is evicted. As the pods admitted in a loop share the budget, it's checked again before each eviction.
+type PodEvictionBudget interface {
+	// AllowsEviction returns true if the budget has room for evicting the pod now.
+	AllowsEviction(pod *apiv1.Pod) bool
+	// RecordEviction charges the eviction of the pod to the budget.
+	RecordEviction(pod *apiv1.Pod)
+}
+
+// ContextPodEvictionAdmission is implemented by PodEvictionAdmissions whose LoopInit calls APIs, so that
+// the calls are bounded by the context of the loop.
+type ContextPodEvictionAdmission interface {
+	// LoopInitWithContext is LoopInit bounded by the given context.
+	LoopInitWithContext(ctx context.Context, allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod)
+}
+
 // NewDefaultPodEvictionAdmission constructs new PodEvictionAdmission that admits all pods.
 func NewDefaultPodEvictionAdmission() PodEvictionAdmission {
 	return &noopPodEvictionAdmission{}
@@ -49,8 +68,16 @@ type sequentialPodEvictionAdmission struct {
 }
 
 func (a *sequentialPodEvictionAdmission) LoopInit(allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	a.LoopInitWithContext(context.Background(), allLivePods, vpaControlledPods)
+}
+
+func (a *sequentialPodEvictionAdmission) LoopInitWithContext(ctx context.Context, allLivePods []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
 	for _, admission := range a.admissions {
-		admission.LoopInit(allLivePods, vpaControlledPods)
+		if contextAdmission, ok := admission.(ContextPodEvictionAdmission); ok {
+			contextAdmission.LoopInitWithContext(ctx, allLivePods, vpaControlledPods)
+		} else {
+			admission.LoopInit(allLivePods, vpaControlledPods)
+		}
 	}
 }
 
@@ -64,6 +91,23 @@ func (a *sequentialPodEvictionAdmission) Admit(pod *apiv1.Pod, recommendation *v
 	return true
 }
 
+func (a *sequentialPodEvictionAdmission) AllowsEviction(pod *apiv1.Pod) bool {
+	for _, admission := range a.admissions {
+		if budget, ok := admission.(PodEvictionBudget); ok && !budget.AllowsEviction(pod) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *sequentialPodEvictionAdmission) RecordEviction(pod *apiv1.Pod) {
+	for _, admission := range a.admissions {
+		if budget, ok := admission.(PodEvictionBudget); ok {
+			budget.RecordEviction(pod)
+		}
+	}
+}
+
 func (a *sequentialPodEvictionAdmission) CleanUp() {
 	for _, admission := range a.admissions {
 		admission.CleanUp()
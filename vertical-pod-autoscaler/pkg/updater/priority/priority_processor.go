@@ -51,14 +51,8 @@ func (*defaultPriorityProcessor) GetUpdatePriority(pod *apiv1.Pod, vpa *vpa_type
 	// Sum of recommendations over all containers, per resource type.
 	totalRecommendedPerResource := make(map[apiv1.ResourceName]int64)
 
-	hasObservedContainers, vpaContainerSet := parseVpaObservedContainers(pod)
-
-	for _, podContainer := range pod.Spec.Containers {
-		if hasObservedContainers && !vpaContainerSet.Has(podContainer.Name) {
-			klog.V(4).InfoS("Not listed in VPA observed containers label. Skipping container priority calculations", "label", annotations.VpaObservedContainersLabel, "observedContainers", pod.GetAnnotations()[annotations.VpaObservedContainersLabel], "containerName", podContainer.Name, "vpa", klog.KObj(vpa))
-			continue
-		}
-		recommendedRequest := vpa_api_util.GetRecommendationForContainer(podContainer.Name, recommendation)
+	for _, container := range podContainerRequests(pod) {
+		recommendedRequest := vpa_api_util.GetRecommendationForContainer(container.name, recommendation)
 		if recommendedRequest == nil {
 			continue
 		}
@@ -66,8 +60,7 @@ func (*defaultPriorityProcessor) GetUpdatePriority(pod *apiv1.Pod, vpa *vpa_type
 			totalRecommendedPerResource[resourceName] += recommended.MilliValue()
 			lowerBound, hasLowerBound := recommendedRequest.LowerBound[resourceName]
 			upperBound, hasUpperBound := recommendedRequest.UpperBound[resourceName]
-			requests, _ := resourcehelpers.ContainerRequestsAndLimits(podContainer.Name, pod)
-			if request, hasRequest := requests[resourceName]; hasRequest {
+			if request, hasRequest := container.requests[resourceName]; hasRequest {
 				totalRequestPerResource[resourceName] += request.MilliValue()
 				if recommended.MilliValue() > request.MilliValue() {
 					scaleUp = true
@@ -91,9 +84,37 @@ func (*defaultPriorityProcessor) GetUpdatePriority(pod *apiv1.Pod, vpa *vpa_type
 		totalRequest := math.Max(float64(totalRequestPerResource[resource]), 1.0)
 		resourceDiff += math.Abs(totalRequest-float64(totalRecommended)) / totalRequest
 	}
+	schedulingPriority := int32(0)
+	if pod.Spec.Priority != nil {
+		schedulingPriority = *pod.Spec.Priority
+	}
 	return PodPriority{
 		OutsideRecommendedRange: outsideRecommendedRange,
 		ScaleUp:                 scaleUp,
 		ResourceDiff:            resourceDiff,
+		SchedulingPriority:      schedulingPriority,
+	}
+}
+
+// containerRequests holds the current requests of a container of a pod.
+type containerRequests struct {
+	name     string
+	requests apiv1.ResourceList
+}
+
+// podContainerRequests returns the current requests of the containers of the pod observed by VPA.
+// Init containers are left out: the admission controller doesn't set their requests when the pod is
+// recreated, so a diff on them would never go away and would make the pod eligible for an update forever.
+func podContainerRequests(pod *apiv1.Pod) []containerRequests {
+	hasObservedContainers, vpaContainerSet := parseVpaObservedContainers(pod)
+	result := make([]containerRequests, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		if hasObservedContainers && !vpaContainerSet.Has(container.Name) {
+			klog.V(4).InfoS("Not listed in VPA observed containers label. Skipping container", "label", annotations.VpaObservedContainersLabel, "observedContainers", pod.GetAnnotations()[annotations.VpaObservedContainersLabel], "containerName", container.Name, "pod", klog.KObj(pod))
+			continue
+		}
+		requests, _ := resourcehelpers.ContainerRequestsAndLimits(container.Name, pod)
+		result = append(result, containerRequests{name: container.Name, requests: requests})
 	}
+	return result
 }
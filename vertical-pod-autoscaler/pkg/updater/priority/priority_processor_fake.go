@@ -46,5 +46,6 @@ func (f *fakePriorityProcessor) GetUpdatePriority(pod *corev1.Pod, vpa *vpa_type
 		ScaleUp:                 prio.ScaleUp,
 		ResourceDiff:            prio.ResourceDiff,
 		OutsideRecommendedRange: prio.OutsideRecommendedRange,
+		SchedulingPriority:      prio.SchedulingPriority,
 	}
 }
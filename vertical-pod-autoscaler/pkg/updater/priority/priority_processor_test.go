@@ -238,6 +238,17 @@ func TestGetUpdatePriority_NoRecommendationForContainer(t *testing.T) {
 	assert.NotNil(t, result)
 }
 
+func TestGetUpdatePriority_SchedulingPriority(t *testing.T) {
+	p := NewProcessor()
+	pod := test.Pod().WithName("POD1").AddContainer(test.Container().WithName("test-container").WithCPURequest(resource.MustParse("5")).Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithName("test-vpa").WithContainer("test-container").WithTarget("5", "").Get()
+	assert.Equal(t, int32(0), p.GetUpdatePriority(pod, vpa, vpa.Status.Recommendation).SchedulingPriority)
+
+	schedulingPriority := int32(1000)
+	pod.Spec.Priority = &schedulingPriority
+	assert.Equal(t, schedulingPriority, p.GetUpdatePriority(pod, vpa, vpa.Status.Recommendation).SchedulingPriority)
+}
+
 func TestGetUpdatePriority_VpaObservedContainers(t *testing.T) {
 	const (
 		// There is no VpaObservedContainers annotation
@@ -295,3 +306,19 @@ func TestGetUpdatePriority_VpaObservedContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUpdatePriority_InitContainers(t *testing.T) {
+	pod := test.Pod().WithName("POD1").
+		AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).Get()).
+		AddInitContainer(test.Container().WithName("init").WithCPURequest(resource.MustParse("1")).Get()).
+		Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).Get()
+	recommendation := &vpa_types.RecommendedPodResources{ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+		test.Recommendation().WithContainer(containerName).WithTarget("2", "").GetContainerResources(),
+		test.Recommendation().WithContainer("init").WithTarget("4", "").GetContainerResources(),
+	}}
+
+	priority := NewProcessor().GetUpdatePriority(pod, vpa, recommendation)
+	assert.False(t, priority.ScaleUp, "init containers don't count towards the update priority")
+	assert.Zero(t, priority.ResourceDiff)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	resourcehelpers "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/resources"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+var qosClassRank = map[apiv1.PodQOSClass]int{
+	apiv1.PodQOSBestEffort: 0,
+	apiv1.PodQOSBurstable:  1,
+	apiv1.PodQOSGuaranteed: 2,
+}
+
+// wouldDowngradeQOS returns true if applying the recommendation to the pod would move it
+// to a lower QoS class, e.g. a Guaranteed pod with only its requests controlled by VPA.
+func wouldDowngradeQOS(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, recommendation *vpa_types.RecommendedPodResources) bool {
+	current := make([]vpa_api_util.ContainerResources, 0, len(pod.Spec.Containers))
+	updated := make([]vpa_api_util.ContainerResources, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		requests, limits := resourcehelpers.ContainerRequestsAndLimits(container.Name, pod)
+		current = append(current, vpa_api_util.ContainerResources{Requests: requests, Limits: limits})
+
+		containerRecommendation := vpa_api_util.GetRecommendationForContainer(container.Name, recommendation)
+		if containerRecommendation == nil {
+			updated = append(updated, vpa_api_util.ContainerResources{Requests: requests, Limits: limits})
+			continue
+		}
+		newRequests := requests.DeepCopy()
+		if newRequests == nil {
+			newRequests = apiv1.ResourceList{}
+		}
+		for resourceName, target := range containerRecommendation.Target {
+			newRequests[resourceName] = target
+		}
+		newLimits := limits
+		if vpa_api_util.GetContainerControlledValues(container.Name, vpa.Spec.ResourcePolicy) == vpa_types.ContainerControlledValuesRequestsAndLimits {
+			proportionalLimits, _ := vpa_api_util.GetProportionalLimit(limits, requests, newRequests, apiv1.ResourceList{})
+			newLimits = limits.DeepCopy()
+			for resourceName, limit := range proportionalLimits {
+				newLimits[resourceName] = limit
+			}
+		}
+		updated = append(updated, vpa_api_util.ContainerResources{Requests: newRequests, Limits: newLimits})
+	}
+	return qosClassRank[qosClass(updated)] < qosClassRank[qosClass(current)]
+}
+
+// qosClass returns the QoS class of a pod with containers using the given resources,
+// following the rules applied by the API server to compute pod.Status.QOSClass.
+func qosClass(containers []vpa_api_util.ContainerResources) apiv1.PodQOSClass {
+	bestEffort := true
+	guaranteed := true
+	for _, container := range containers {
+		for _, resourceName := range []apiv1.ResourceName{apiv1.ResourceCPU, apiv1.ResourceMemory} {
+			request, hasRequest := container.Requests[resourceName]
+			limit, hasLimit := container.Limits[resourceName]
+			if hasRequest && !request.IsZero() || hasLimit && !limit.IsZero() {
+				bestEffort = false
+			}
+			if !hasLimit || limit.IsZero() || hasRequest && request.Cmp(limit) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case bestEffort:
+		return apiv1.PodQOSBestEffort
+	case guaranteed:
+		return apiv1.PodQOSGuaranteed
+	default:
+		return apiv1.PodQOSBurstable
+	}
+}
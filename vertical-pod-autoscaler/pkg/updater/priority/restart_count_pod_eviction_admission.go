@@ -0,0 +1,117 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewRestartCountPodEvictionAdmission creates a PodEvictionAdmission object.
+// It defers updates of Pods that have a container which restarted more than restartCountThreshold times
+// within restartWindow, as disrupting such unstable Pods further is risky.
+func NewRestartCountPodEvictionAdmission(restartCountThreshold int32, restartWindow time.Duration) PodEvictionAdmission {
+	return &restartCountPodEvictionAdmission{
+		restartCountThreshold: restartCountThreshold,
+		restartWindow:         restartWindow,
+		clock:                 &clock.RealClock{},
+		samples:               make(map[types.UID]map[string][]restartCountSample),
+	}
+}
+
+// restartCountSample is the restart count of a container observed at some time.
+type restartCountSample struct {
+	time         time.Time
+	restartCount int32
+}
+
+type restartCountPodEvictionAdmission struct {
+	restartCountThreshold int32
+	restartWindow         time.Duration
+	clock                 clock.Clock
+	// samples holds the restart counts observed in the previous loops, per container of each Pod, oldest
+	// first. The oldest sample is the newest one observed before the window, if any.
+	samples map[types.UID]map[string][]restartCountSample
+}
+
+// LoopInit records the restart counts of the containers of the live pods.
+func (r *restartCountPodEvictionAdmission) LoopInit(allLivePods []*apiv1.Pod, _ map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	now := r.clock.Now()
+	windowStart := now.Add(-r.restartWindow)
+	live := make(map[types.UID]bool, len(allLivePods))
+	for _, pod := range allLivePods {
+		live[pod.UID] = true
+		containers := r.samples[pod.UID]
+		if containers == nil {
+			containers = make(map[string][]restartCountSample)
+			r.samples[pod.UID] = containers
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			samples := append(containers[containerStatus.Name], restartCountSample{time: now, restartCount: containerStatus.RestartCount})
+			for len(samples) > 1 && !samples[1].time.After(windowStart) {
+				samples = samples[1:]
+			}
+			containers[containerStatus.Name] = samples
+		}
+	}
+	for uid := range r.samples {
+		if !live[uid] {
+			delete(r.samples, uid)
+		}
+	}
+}
+
+// Admit admits a Pod unless one of its containers restarted more than the configured threshold within the window.
+func (r *restartCountPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if restarts := r.recentRestarts(pod, containerStatus); restarts > r.restartCountThreshold {
+			klog.V(4).InfoS("Deferring update of pod with high recent restart count", "pod", klog.KObj(pod), "container", containerStatus.Name, "recentRestarts", restarts, "window", r.restartWindow)
+			return false
+		}
+	}
+	return true
+}
+
+// recentRestarts returns the number of restarts of the container within the window. Restarts before
+// the first loop which observed the container are only counted if the pod started within the window.
+func (r *restartCountPodEvictionAdmission) recentRestarts(pod *apiv1.Pod, containerStatus apiv1.ContainerStatus) int32 {
+	windowStart := r.clock.Now().Add(-r.restartWindow)
+	lastTermination := containerStatus.LastTerminationState.Terminated
+	if lastTermination == nil || lastTermination.FinishedAt.Time.Before(windowStart) {
+		return 0
+	}
+	if pod.Status.StartTime != nil && !pod.Status.StartTime.Time.Before(windowStart) {
+		// All the restarts of the container happened within the window.
+		return containerStatus.RestartCount
+	}
+	samples := r.samples[pod.UID][containerStatus.Name]
+	if len(samples) == 0 {
+		return 0
+	}
+	return max(containerStatus.RestartCount-samples[0].restartCount, 0)
+}
+
+// CleanUp forgets the observed restart counts.
+func (r *restartCountPodEvictionAdmission) CleanUp() {
+	r.samples = make(map[types.UID]map[string][]restartCountSample)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	baseclocktest "k8s.io/utils/clock/testing"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRestartCountPodEvictionAdmission(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	containerStatus := func(restartCount int32, lastTerminated time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:         "container",
+			RestartCount: restartCount,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(lastTerminated)},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		started         time.Time
+		containerStatus corev1.ContainerStatus
+		expectedAdmit   bool
+	}{
+		{
+			name:            "high restart count of pod started within window is deferred",
+			started:         now.Add(-30 * time.Minute),
+			containerStatus: containerStatus(10, now.Add(-5*time.Minute)),
+			expectedAdmit:   false,
+		},
+		{
+			name:            "high restart count outside window is admitted",
+			started:         now.Add(-3 * time.Hour),
+			containerStatus: containerStatus(10, now.Add(-2*time.Hour)),
+			expectedAdmit:   true,
+		},
+		{
+			name:            "restart count at threshold is admitted",
+			started:         now.Add(-30 * time.Minute),
+			containerStatus: containerStatus(5, now.Add(-5*time.Minute)),
+			expectedAdmit:   true,
+		},
+		{
+			name:            "restarts of pod started before window and not observed yet are admitted",
+			started:         now.Add(-48 * time.Hour),
+			containerStatus: containerStatus(10, now.Add(-5*time.Minute)),
+			expectedAdmit:   true,
+		},
+		{
+			name:            "never terminated container is admitted",
+			started:         now.Add(-30 * time.Minute),
+			containerStatus: corev1.ContainerStatus{Name: "container", RestartCount: 10},
+			expectedAdmit:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			admission := NewRestartCountPodEvictionAdmission(5, time.Hour).(*restartCountPodEvictionAdmission)
+			admission.clock = baseclocktest.NewFakeClock(now)
+			pod := test.Pod().WithName("test-pod").AddContainerStatus(tc.containerStatus).Get()
+			pod.Status.StartTime = &metav1.Time{Time: tc.started}
+
+			admission.LoopInit([]*corev1.Pod{pod}, nil)
+			assert.Equal(t, tc.expectedAdmit, admission.Admit(pod, nil))
+		})
+	}
+}
+
+func TestRestartCountPodEvictionAdmission_RestartsWithinWindow(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := baseclocktest.NewFakeClock(now)
+	admission := NewRestartCountPodEvictionAdmission(5, time.Hour).(*restartCountPodEvictionAdmission)
+	admission.clock = fakeClock
+
+	// The pod runs for days, and restarted a lot long ago.
+	pod := test.Pod().WithName("test-pod").Get()
+	pod.UID = "test-pod-uid"
+	pod.Status.StartTime = &metav1.Time{Time: now.Add(-48 * time.Hour)}
+	restart := func(restartCount int32) {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:         "container",
+			RestartCount: restartCount,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(fakeClock.Now())},
+			},
+		}}
+	}
+
+	restart(20)
+	admission.LoopInit([]*corev1.Pod{pod}, nil)
+	assert.True(t, admission.Admit(pod, nil), "restarts before the first observation are not counted")
+
+	for _, restartCount := range []int32{22, 24} {
+		fakeClock.Step(20 * time.Minute)
+		restart(restartCount)
+		admission.LoopInit([]*corev1.Pod{pod}, nil)
+		assert.True(t, admission.Admit(pod, nil), "%v restarts within the window are admitted", restartCount-20)
+	}
+
+	fakeClock.Step(10 * time.Minute)
+	restart(26)
+	admission.LoopInit([]*corev1.Pod{pod}, nil)
+	assert.False(t, admission.Admit(pod, nil), "6 restarts within the window are deferred")
+
+	fakeClock.Step(40 * time.Minute)
+	restart(27)
+	admission.LoopInit([]*corev1.Pod{pod}, nil)
+	assert.True(t, admission.Admit(pod, nil), "the restarts observed before the window no longer count")
+
+	admission.LoopInit(nil, nil)
+	assert.Empty(t, admission.samples, "pods which are gone are forgotten")
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"maps"
+	"slices"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewTopologySpreadPodEvictionAdmission creates a PodEvictionAdmission object.
+// It keeps evictions from skewing the spread of Pods beyond the maxSkew of their topology spread
+// constraints. The spread is counted among the available Pods controlled by the same VPA and matched
+// by the label selector of the constraint, in the domains these Pods run in. The domain of a Pod is
+// the label of its Node named by the topology key. Pods which aren't ready aren't available.
+func NewTopologySpreadPodEvictionAdmission(nodeLister v1lister.NodeLister) PodEvictionAdmission {
+	return &topologySpreadPodEvictionAdmission{
+		nodeLister: nodeLister,
+		siblings:   make(map[types.UID][]*apiv1.Pod),
+		disrupted:  sets.New[types.UID](),
+	}
+}
+
+type topologySpreadPodEvictionAdmission struct {
+	nodeLister v1lister.NodeLister
+	// siblings are the Pods controlled by the VPA of each Pod.
+	siblings map[types.UID][]*apiv1.Pod
+	// disrupted are the Pods which aren't ready, and the Pods admitted in this loop.
+	disrupted sets.Set[types.UID]
+}
+
+// LoopInit takes the census of the Pods controlled by each VPA.
+func (t *topologySpreadPodEvictionAdmission) LoopInit(_ []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	t.CleanUp()
+	for _, pods := range vpaControlledPods {
+		for _, pod := range pods {
+			t.siblings[pod.UID] = pods
+			if !isPodReady(pod) {
+				t.disrupted.Insert(pod.UID)
+			}
+		}
+	}
+}
+
+// Admit admits a Pod if evicting it keeps the skew of each of its topology spread constraints within
+// the maxSkew of the constraint, or doesn't make it worse. Admitted Pods count as unavailable for the
+// rest of the loop, even if they end up not being updated.
+func (t *topologySpreadPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	if t.disrupted.Has(pod.UID) {
+		return true
+	}
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if !t.keepsSpread(pod, constraint) {
+			klog.V(4).InfoS("Deferring update of pod, evicting it would skew the spread of its pods", "pod", klog.KObj(pod), "topologyKey", constraint.TopologyKey, "maxSkew", constraint.MaxSkew)
+			return false
+		}
+	}
+	t.disrupted.Insert(pod.UID)
+	return true
+}
+
+// CleanUp resets the census.
+func (t *topologySpreadPodEvictionAdmission) CleanUp() {
+	t.siblings = make(map[types.UID][]*apiv1.Pod)
+	t.disrupted = sets.New[types.UID]()
+}
+
+// keepsSpread returns true if evicting the Pod keeps the spread of the constraint acceptable.
+func (t *topologySpreadPodEvictionAdmission) keepsSpread(pod *apiv1.Pod, constraint apiv1.TopologySpreadConstraint) bool {
+	selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+	if err != nil {
+		klog.V(4).InfoS("Invalid label selector of topology spread constraint, ignoring it", "pod", klog.KObj(pod), "error", err)
+		return true
+	}
+	available := make(map[string]int)
+	for _, sibling := range t.siblings[pod.UID] {
+		if !selector.Matches(labels.Set(sibling.Labels)) {
+			continue
+		}
+		domain := t.podDomain(sibling, constraint.TopologyKey)
+		if domain == "" {
+			continue
+		}
+		if !t.disrupted.Has(sibling.UID) {
+			available[domain]++
+		} else if _, found := available[domain]; !found {
+			available[domain] = 0
+		}
+	}
+	domain := t.podDomain(pod, constraint.TopologyKey)
+	if _, found := available[domain]; !found {
+		// The Pod doesn't count in the spread of the constraint.
+		return true
+	}
+	before := skew(available)
+	available[domain]--
+	after := skew(available)
+	return after <= int(constraint.MaxSkew) || after <= before
+}
+
+// podDomain returns the value of the topology key label of the Node the Pod runs on, or an
+// empty string if it isn't known.
+func (t *topologySpreadPodEvictionAdmission) podDomain(pod *apiv1.Pod, topologyKey string) string {
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+	node, err := t.nodeLister.Get(pod.Spec.NodeName)
+	if err != nil {
+		klog.V(4).InfoS("Failed to get node of pod, not counting it in the topology spread", "pod", klog.KObj(pod), "node", pod.Spec.NodeName, "error", err)
+		return ""
+	}
+	return node.Labels[topologyKey]
+}
+
+// skew returns the difference between the largest and the smallest number of available Pods in a domain.
+func skew(available map[string]int) int {
+	counts := slices.Collect(maps.Values(available))
+	if len(counts) == 0 {
+		return 0
+	}
+	return slices.Max(counts) - slices.Min(counts)
+}
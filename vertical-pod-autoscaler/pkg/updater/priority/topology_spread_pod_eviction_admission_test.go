@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestTopologySpreadPodEvictionAdmission(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*apiv1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-c", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-c"}}},
+	} {
+		assert.NoError(t, indexer.Add(node))
+	}
+	nodeLister := v1lister.NewNodeLister(indexer)
+
+	spread := []apiv1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       apiv1.LabelTopologyZone,
+		WhenUnsatisfiable: apiv1.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}}
+	newPods := func(prefix, node string, count int, ready apiv1.ConditionStatus, constraints []apiv1.TopologySpreadConstraint) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, count)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(fmt.Sprintf("%s-%s-%d", prefix, node, i)).WithLabels(map[string]string{"app": "web"}).Get()
+			pods[i].UID = types.UID(pods[i].Name)
+			pods[i].Spec.NodeName = node
+			pods[i].Spec.TopologySpreadConstraints = constraints
+			pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: ready}}
+		}
+		return pods
+	}
+	zoneA := newPods("web", "node-a", 2, apiv1.ConditionTrue, spread)
+	zoneB := newPods("web", "node-b", 2, apiv1.ConditionTrue, spread)
+	zoneC := newPods("web", "node-c", 2, apiv1.ConditionTrue, spread)
+	zoneCNotReady := newPods("web-not-ready", "node-c", 1, apiv1.ConditionFalse, spread)
+	unconstrained := newPods("unconstrained", "node-a", 2, apiv1.ConditionTrue, nil)
+	for _, pod := range unconstrained {
+		pod.Labels = map[string]string{"app": "batch"}
+	}
+	// Pods of another VPA don't count in the spread.
+	otherVpaPods := newPods("other", "node-a", 4, apiv1.ConditionTrue, spread)
+	var pods []*apiv1.Pod
+	for _, p := range [][]*apiv1.Pod{zoneA, zoneB, zoneC, zoneCNotReady, unconstrained} {
+		pods = append(pods, p...)
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("web").WithContainer(containerName).Get()
+	otherVpa := test.VerticalPodAutoscaler().WithName("other").WithContainer(containerName).Get()
+	vpaControlledPods := map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods, otherVpa: otherVpaPods}
+
+	admission := NewTopologySpreadPodEvictionAdmission(nodeLister)
+	admission.LoopInit(nil, vpaControlledPods)
+	assert.True(t, admission.Admit(zoneA[0], nil), "the skew stays within maxSkew")
+	assert.False(t, admission.Admit(zoneA[1], nil), "the skew would exceed maxSkew")
+	assert.True(t, admission.Admit(zoneB[0], nil))
+	assert.True(t, admission.Admit(zoneCNotReady[0], nil), "pods which aren't ready don't count in the spread")
+	assert.False(t, admission.Admit(zoneA[1], nil), "zone-c has more available pods than zone-a")
+	assert.True(t, admission.Admit(zoneC[0], nil))
+	assert.True(t, admission.Admit(zoneA[1], nil), "the other zones have fewer available pods now")
+	assert.True(t, admission.Admit(zoneA[0], nil), "a pod admitted already is admitted again")
+	for _, pod := range unconstrained {
+		assert.True(t, admission.Admit(pod, nil), "pods without constraints are not limited")
+	}
+	assert.True(t, admission.Admit(otherVpaPods[0], nil))
+
+	admission.LoopInit(nil, vpaControlledPods)
+	assert.True(t, admission.Admit(zoneB[1], nil), "admissions of the previous loop are forgotten")
+	assert.False(t, admission.Admit(zoneB[0], nil))
+}
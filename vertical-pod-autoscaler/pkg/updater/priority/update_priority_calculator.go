@@ -18,6 +18,10 @@ package priority
 
 import (
 	"flag"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -42,6 +46,25 @@ var (
 		`Evict pod that has OOMed in less than evict-after-oom-threshold since start.`)
 )
 
+// UpdateObjective biases the order in which pods are updated.
+type UpdateObjective string
+
+const (
+	// EnsureReliability updates pods that want to grow first.
+	EnsureReliability UpdateObjective = "ensure-reliability"
+	// SaveCost updates pods that want to shrink first.
+	SaveCost UpdateObjective = "save-cost"
+)
+
+// ParseUpdateObjective returns the UpdateObjective named by value, or an error if there is none.
+func ParseUpdateObjective(value string) (UpdateObjective, error) {
+	switch objective := UpdateObjective(value); objective {
+	case EnsureReliability, SaveCost:
+		return objective, nil
+	}
+	return "", fmt.Errorf("unknown update objective %q, expected %q or %q", value, EnsureReliability, SaveCost)
+}
+
 // UpdatePriorityCalculator is responsible for prioritizing updates on pods.
 // It can returns a sorted list of pods in order of update priority.
 // Update priority is proportional to fraction by which resources should be increased / decreased.
@@ -53,6 +76,8 @@ type UpdatePriorityCalculator struct {
 	config                  *UpdateConfig
 	recommendationProcessor vpa_api_util.RecommendationProcessor
 	priorityProcessor       PriorityProcessor
+	appliedRecommendations  *AppliedRecommendationCache
+	ignoreMaxPodLifetime    bool
 }
 
 // UpdateConfig holds configuration for UpdatePriorityCalculator
@@ -60,6 +85,33 @@ type UpdateConfig struct {
 	// MinChangePriority is the minimum change priority that will trigger a update.
 	// TODO: should have separate for Mem and CPU?
 	MinChangePriority float64
+	// Objective biases the update order between pods that want to grow and pods that want to shrink.
+	// Defaults to EnsureReliability.
+	Objective UpdateObjective
+	// PreferLowPriorityPods makes pods with a lower scheduling priority take precedence over all other criteria.
+	PreferLowPriorityPods bool
+	// AllowQOSDowngrade allows updates which would move a pod to a lower QoS class.
+	AllowQOSDowngrade bool
+	// MaxPodLifetime, if positive, makes pods running for longer than it eligible for update
+	// regardless of the recommendation.
+	MaxPodLifetime time.Duration
+	// SidecarContainerPattern, if set, matches the names of sidecar containers. A pod which would be
+	// updated only because of its sidecars is updated only if their change priority is at least
+	// SidecarMinChangePriority.
+	SidecarContainerPattern *regexp.Regexp
+	// SidecarMinChangePriority is the minimum change priority of sidecar containers that will trigger an update.
+	SidecarMinChangePriority float64
+	// MinChangeFraction, if positive, makes pods eligible for update only if the recommendation differs
+	// from the request of at least one resource of a container by more than this fraction of the request.
+	MinChangeFraction float64
+}
+
+// NewDefaultUpdateConfig returns the UpdateConfig used when none is given to NewUpdatePriorityCalculator.
+func NewDefaultUpdateConfig() *UpdateConfig {
+	return &UpdateConfig{
+		MinChangePriority: *defaultUpdateThreshold,
+		Objective:         EnsureReliability,
+	}
 }
 
 // NewUpdatePriorityCalculator creates new UpdatePriorityCalculator for the given VPA object
@@ -71,7 +123,7 @@ func NewUpdatePriorityCalculator(vpa *vpa_types.VerticalPodAutoscaler,
 	recommendationProcessor vpa_api_util.RecommendationProcessor,
 	priorityProcessor PriorityProcessor) UpdatePriorityCalculator {
 	if config == nil {
-		config = &UpdateConfig{MinChangePriority: *defaultUpdateThreshold}
+		config = NewDefaultUpdateConfig()
 	}
 	return UpdatePriorityCalculator{
 		vpa:                     vpa,
@@ -80,17 +132,50 @@ func NewUpdatePriorityCalculator(vpa *vpa_types.VerticalPodAutoscaler,
 		priorityProcessor:       priorityProcessor}
 }
 
+// SetAppliedRecommendationCache makes the calculator skip Pods which were found to match
+// the current recommendation in a previous loop, and remember the ones found to match it now.
+func (calc *UpdatePriorityCalculator) SetAppliedRecommendationCache(cache *AppliedRecommendationCache) {
+	calc.appliedRecommendations = cache
+}
+
+// IgnoreMaxPodLifetime makes the calculator accept Pods regardless of how long they run,
+// for Pods updated in place, which an update doesn't renew.
+func (calc *UpdatePriorityCalculator) IgnoreMaxPodLifetime() {
+	calc.ignoreMaxPodLifetime = true
+}
+
+// ExceedsMaxPodLifetime returns true if the Pod runs for longer than the MaxPodLifetime of the config.
+func (c *UpdateConfig) ExceedsMaxPodLifetime(pod *apiv1.Pod, now time.Time) bool {
+	return c != nil && exceedsLifetime(pod, now, c.MaxPodLifetime)
+}
+
+func exceedsLifetime(pod *apiv1.Pod, now time.Time, maxLifetime time.Duration) bool {
+	return maxLifetime > 0 && pod.Status.StartTime != nil && !now.Before(pod.Status.StartTime.Add(maxLifetime))
+}
+
 // AddPod adds pod to the UpdatePriorityCalculator.
 func (calc *UpdatePriorityCalculator) AddPod(pod *apiv1.Pod, now time.Time) {
+	expired := !calc.ignoreMaxPodLifetime && exceedsLifetime(pod, now, calc.config.MaxPodLifetime)
+	evictNow := annotations.IsVpaEvictNowRequested(pod.Annotations)
 	processedRecommendation, _, err := calc.recommendationProcessor.Apply(calc.vpa, pod)
 	if err != nil {
 		klog.V(2).ErrorS(err, "Cannot process recommendation for pod", "pod", klog.KObj(pod))
 		return
 	}
+	if !expired && calc.appliedRecommendations != nil && calc.appliedRecommendations.Matches(pod, calc.vpa, processedRecommendation) {
+		klog.V(4).InfoS("Not updating pod, it already matches the unchanged recommendation", "pod", klog.KObj(pod))
+		return
+	}
 
 	hasObservedContainers, vpaContainerSet := parseVpaObservedContainers(pod)
 
 	updatePriority := calc.priorityProcessor.GetUpdatePriority(pod, calc.vpa, processedRecommendation)
+	// A pod within the recommended range and without any resource diff is never accepted
+	// for update below, unless every diff is allowed, so there is no need to evaluate it again.
+	if calc.appliedRecommendations != nil && !updatePriority.OutsideRecommendedRange &&
+		updatePriority.ResourceDiff == 0 && calc.config.MinChangePriority > 0 {
+		calc.appliedRecommendations.Record(pod, calc.vpa, processedRecommendation)
+	}
 
 	quickOOM := false
 	for i := range pod.Status.ContainerStatuses {
@@ -117,41 +202,166 @@ func (calc *UpdatePriorityCalculator) AddPod(pod *apiv1.Pod, now time.Time) {
 		}
 	}
 
-	// The update is allowed in following cases:
+	if !expired && calc.config.MinChangeFraction > 0 &&
+		!exceedsChangeFraction(pod, processedRecommendation, calc.config.MinChangeFraction) {
+		klog.V(4).InfoS("Not updating pod, no resource would change by more than the minimum change fraction", "pod", klog.KObj(pod), "minChangeFraction", calc.config.MinChangeFraction)
+		return
+	}
+
+	// The update is allowed in following cases, if a resource changes by more than MinChangeFraction:
 	// - the request is outside the recommended range for some container.
 	// - the pod lives for at least 24h and the resource diff is >= MinChangePriority.
+	//   If sidecar containers are configured, their requests and resource diff are evaluated separately,
+	//   against SidecarMinChangePriority.
 	// - a vpa scaled container OOMed in less than evictAfterOOMThreshold.
-	if !updatePriority.OutsideRecommendedRange && !quickOOM {
-		if pod.Status.StartTime == nil {
+	// - the pod lives for longer than MaxPodLifetime.
+	// Pods with the VpaEvictNowAnnotation annotation don't need to live for 24h.
+	if expired {
+		klog.V(2).InfoS("Pod exceeded its maximum lifetime", "pod", klog.KObj(pod), "maxPodLifetime", calc.config.MaxPodLifetime)
+	} else if outsideRange, diffAboveThreshold := calc.updateEligibility(pod, processedRecommendation, updatePriority); !outsideRange && !quickOOM {
+		if !evictNow && pod.Status.StartTime == nil {
 			// TODO: Set proper condition on the VPA.
 			klog.V(4).InfoS("Not updating pod, missing field pod.Status.StartTime", "pod", klog.KObj(pod))
 			return
 		}
-		if now.Before(pod.Status.StartTime.Add(*podLifetimeUpdateThreshold)) {
+		if !evictNow && now.Before(pod.Status.StartTime.Add(*podLifetimeUpdateThreshold)) {
 			klog.V(4).InfoS("Not updating a short-lived pod, request within recommended range", "pod", klog.KObj(pod))
 			return
 		}
-		if updatePriority.ResourceDiff < calc.config.MinChangePriority {
+		if !diffAboveThreshold {
 			klog.V(4).InfoS("Not updating pod, resource diff too low", "pod", klog.KObj(pod), "updatePriority", updatePriority)
 			return
 		}
 	}
 
 	// If the pod has quick OOMed then evict only if the resources will change
-	if quickOOM && updatePriority.ResourceDiff == 0 {
+	if !expired && quickOOM && updatePriority.ResourceDiff == 0 {
 		klog.V(4).InfoS("Not updating pod because resource would not change", "pod", klog.KObj(pod))
 		return
 	}
+
+	if !calc.config.AllowQOSDowngrade && wouldDowngradeQOS(pod, calc.vpa, processedRecommendation) {
+		klog.V(2).InfoS("Not updating pod, the recommendation would downgrade its QoS class", "pod", klog.KObj(pod))
+		return
+	}
+	if evictNow {
+		klog.V(2).InfoS("Immediate update of pod requested by annotation", "pod", klog.KObj(pod), "annotation", annotations.VpaEvictNowAnnotation)
+	}
 	klog.V(2).InfoS("Pod accepted for update", "pod", klog.KObj(pod), "updatePriority", updatePriority.ResourceDiff, "processedRecommendations", calc.GetProcessedRecommendationTargets(processedRecommendation))
 	calc.pods = append(calc.pods, prioritizedPod{
 		pod:            pod,
 		priority:       updatePriority,
-		recommendation: processedRecommendation})
+		recommendation: processedRecommendation,
+		evictNow:       evictNow,
+		orderKey:       podOrderKey(pod)})
+}
+
+// podOrderKey returns the key breaking ties between pods of the same priority, so that the order
+// doesn't depend on the order in which pods were listed. It hashes the pod UID rather than using
+// the pod name, which would always favor the same pods of a controller.
+func podOrderKey(pod *apiv1.Pod) uint64 {
+	h := fnv.New64a()
+	if pod.UID != "" {
+		_, _ = h.Write([]byte(pod.UID))
+	} else {
+		_, _ = h.Write([]byte(pod.Namespace + "/" + pod.Name))
+	}
+	return h.Sum64()
+}
+
+// exceedsChangeFraction returns true if the recommendation differs from the request of any resource of
+// a container by more than the given fraction of the request. Resources without a request always differ.
+func exceedsChangeFraction(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources, fraction float64) bool {
+	for _, container := range podContainerRequests(pod) {
+		containerRecommendation := vpa_api_util.GetRecommendationForContainer(container.name, recommendation)
+		if containerRecommendation == nil {
+			continue
+		}
+		for resourceName, target := range containerRecommendation.Target {
+			request, found := container.requests[resourceName]
+			if !found || request.IsZero() {
+				return true
+			}
+			diff := math.Abs(float64(target.MilliValue()-request.MilliValue())) / float64(request.MilliValue())
+			if diff > fraction {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateEligibility returns whether the requests of the pod are outside the recommended range
+// and whether its resource diff is above the threshold. If sidecar containers are configured,
+// they are evaluated separately from the other containers, against SidecarMinChangePriority.
+func (calc *UpdatePriorityCalculator) updateEligibility(pod *apiv1.Pod, recommendation *vpa_types.RecommendedPodResources,
+	updatePriority PodPriority) (outsideRange bool, diffAboveThreshold bool) {
+	if calc.config.SidecarContainerPattern == nil {
+		return updatePriority.OutsideRecommendedRange, updatePriority.ResourceDiff >= calc.config.MinChangePriority
+	}
+	appRecommendation, sidecarRecommendation := splitSidecarRecommendations(recommendation, calc.config.SidecarContainerPattern)
+	if len(sidecarRecommendation.ContainerRecommendations) == 0 {
+		return updatePriority.OutsideRecommendedRange, updatePriority.ResourceDiff >= calc.config.MinChangePriority
+	}
+	appPriority := calc.priorityProcessor.GetUpdatePriority(pod, calc.vpa, appRecommendation)
+	sidecarPriority := calc.priorityProcessor.GetUpdatePriority(pod, calc.vpa, sidecarRecommendation)
+	sidecarAboveThreshold := sidecarPriority.ResourceDiff >= calc.config.SidecarMinChangePriority
+	klog.V(4).InfoS("Evaluating sidecar containers separately", "pod", klog.KObj(pod), "appUpdatePriority", appPriority, "sidecarUpdatePriority", sidecarPriority)
+	return appPriority.OutsideRecommendedRange || (sidecarPriority.OutsideRecommendedRange && sidecarAboveThreshold),
+		appPriority.ResourceDiff >= calc.config.MinChangePriority || sidecarAboveThreshold
+}
+
+// splitSidecarRecommendations splits the recommendation into the recommendations of app
+// containers and of sidecar containers, whose names match the pattern.
+func splitSidecarRecommendations(recommendation *vpa_types.RecommendedPodResources, pattern *regexp.Regexp) (app, sidecars *vpa_types.RecommendedPodResources) {
+	app, sidecars = &vpa_types.RecommendedPodResources{}, &vpa_types.RecommendedPodResources{}
+	if recommendation == nil {
+		return app, sidecars
+	}
+	for _, containerRecommendation := range recommendation.ContainerRecommendations {
+		if pattern.MatchString(containerRecommendation.ContainerName) {
+			sidecars.ContainerRecommendations = append(sidecars.ContainerRecommendations, containerRecommendation)
+		} else {
+			app.ContainerRecommendations = append(app.ContainerRecommendations, containerRecommendation)
+		}
+	}
+	return app, sidecars
+}
+
+// GetPodPriority returns the update priority of the pod, and false if the pod wasn't accepted for update.
+func (calc *UpdatePriorityCalculator) GetPodPriority(pod *apiv1.Pod) (PodPriority, bool) {
+	for _, podPrio := range calc.pods {
+		if podPrio.pod == pod {
+			return podPrio.priority, true
+		}
+	}
+	return PodPriority{}, false
 }
 
-// GetSortedPods returns a list of pods ordered by update priority (highest update priority first)
+// GetSortedPods returns a list of pods ordered by update priority (highest update priority first).
+// Pods with the VpaEvictNowAnnotation annotation come before all others.
 func (calc *UpdatePriorityCalculator) GetSortedPods(admission PodEvictionAdmission) []*apiv1.Pod {
-	sort.Sort(byPriorityDesc(calc.pods))
+	sort.SliceStable(calc.pods, func(i, j int) bool {
+		if calc.pods[i].evictNow != calc.pods[j].evictNow {
+			return calc.pods[i].evictNow
+		}
+		if calc.config.PreferLowPriorityPods && calc.pods[i].priority.SchedulingPriority != calc.pods[j].priority.SchedulingPriority {
+			return calc.pods[i].priority.SchedulingPriority < calc.pods[j].priority.SchedulingPriority
+		}
+		if calc.pods[j].priority.lessForObjective(calc.pods[i].priority, calc.config.Objective) {
+			return true
+		}
+		if calc.pods[i].priority.lessForObjective(calc.pods[j].priority, calc.config.Objective) {
+			return false
+		}
+		if calc.pods[i].orderKey != calc.pods[j].orderKey {
+			return calc.pods[i].orderKey < calc.pods[j].orderKey
+		}
+		if calc.pods[i].pod.Namespace != calc.pods[j].pod.Namespace {
+			return calc.pods[i].pod.Namespace < calc.pods[j].pod.Namespace
+		}
+		return calc.pods[i].pod.Name < calc.pods[j].pod.Name
+	})
 
 	result := []*apiv1.Pod{}
 	for _, podPrio := range calc.pods {
@@ -216,41 +426,41 @@ type prioritizedPod struct {
 	pod            *apiv1.Pod
 	priority       PodPriority
 	recommendation *vpa_types.RecommendedPodResources
+	// evictNow is set if the pod requested an immediate update with the VpaEvictNowAnnotation annotation.
+	evictNow bool
+	// orderKey breaks ties between pods of the same priority.
+	orderKey uint64
 }
 
 // PodPriority contains data for a pod update that can be used to prioritize between updates.
 type PodPriority struct {
 	// Is any container outside of the recommended range.
-	OutsideRecommendedRange bool
+	OutsideRecommendedRange bool `json:"outsideRecommendedRange"`
 	// Does any container want to grow.
-	ScaleUp bool
+	ScaleUp bool `json:"scaleUp"`
 	// Relative difference between the total requested and total recommended resources.
-	ResourceDiff float64
-}
-
-type byPriorityDesc []prioritizedPod
-
-func (list byPriorityDesc) Len() int {
-	return len(list)
-}
-func (list byPriorityDesc) Swap(i, j int) {
-	list[i], list[j] = list[j], list[i]
-}
-
-// Less implements reverse ordering by priority (highest priority first).
-// This means we return true if priority at index j is lower than at index i.
-func (list byPriorityDesc) Less(i, j int) bool {
-	return list[j].priority.Less(list[i].priority)
+	ResourceDiff float64 `json:"resourceDiff"`
+	// Scheduling priority of the pod, as set by its PriorityClass.
+	SchedulingPriority int32 `json:"schedulingPriority"`
 }
 
 // Less returns true if p is lower than other.
 func (p PodPriority) Less(other PodPriority) bool {
-	// 1. If any container wants to grow, the pod takes precedence.
+	return p.lessForObjective(other, EnsureReliability)
+}
+
+// lessForObjective returns true if p is lower than other under the given update objective.
+func (p PodPriority) lessForObjective(other PodPriority, objective UpdateObjective) bool {
+	// 1. If any container wants to grow, the pod takes precedence,
+	// unless saving cost is preferred, in which case shrinking pods go first.
 	// TODO: A better policy would be to prioritize scaling down when
 	// (a) the pod is pending
 	// (b) there is general resource shortage
 	// and prioritize scaling up otherwise.
 	if p.ScaleUp != other.ScaleUp {
+		if objective == SaveCost {
+			return p.ScaleUp
+		}
 		return other.ScaleUp
 	}
 	// 2. A pod with larger value of resourceDiff takes precedence.
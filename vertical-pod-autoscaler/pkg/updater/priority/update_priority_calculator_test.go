@@ -18,6 +18,7 @@ package priority
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
@@ -62,6 +64,35 @@ func TestSortPriority(t *testing.T) {
 	assert.Exactly(t, []*apiv1.Pod{pod3, pod1, pod4, pod2}, result, "Wrong priority order")
 }
 
+func TestSortPriorityStableOrder(t *testing.T) {
+	pods := make([]*apiv1.Pod, 6)
+	priorities := make(map[string]PodPriority)
+	for i := range pods {
+		name := fmt.Sprintf("POD%d", i)
+		pods[i] = test.Pod().WithName(name).AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).Get()).Get()
+		pods[i].UID = types.UID(name)
+		// Two pods of every priority, so that the order of the pods within a priority is decided by their keys.
+		priorities[name] = PodPriority{ResourceDiff: float64(i / 2)}
+	}
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("10", "").Get()
+	timestampNow := pods[0].Status.StartTime.Add(time.Hour * 24)
+
+	sortPods := func(order []int) []*apiv1.Pod {
+		calculator := NewUpdatePriorityCalculator(vpa, nil, &test.FakeRecommendationProcessor{}, NewFakeProcessor(priorities))
+		for _, i := range order {
+			calculator.AddPod(pods[i], timestampNow)
+		}
+		return calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+	}
+
+	expected := sortPods([]int{0, 1, 2, 3, 4, 5})
+	for _, order := range [][]int{{5, 4, 3, 2, 1, 0}, {2, 0, 5, 3, 1, 4}, {0, 1, 2, 3, 4, 5}} {
+		assert.Exactly(t, expected, sortPods(order), "the order depends on the order the pods were added in %v", order)
+	}
+	assert.ElementsMatch(t, []*apiv1.Pod{pods[4], pods[5]}, expected[:2], "pods are sorted by priority first")
+	assert.ElementsMatch(t, []*apiv1.Pod{pods[2], pods[3]}, expected[2:4], "pods are sorted by priority first")
+}
+
 func TestSortPriorityResourcesDecrease(t *testing.T) {
 	pod1 := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
 	pod2 := test.Pod().WithName("POD2").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("8")).Get()).Get()
@@ -89,6 +120,360 @@ func TestSortPriorityResourcesDecrease(t *testing.T) {
 	assert.Exactly(t, []*apiv1.Pod{pod1, pod3, pod2}, result, "Wrong priority order")
 }
 
+func TestSortPriorityUpdateObjective(t *testing.T) {
+	pod1 := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
+	pod2 := test.Pod().WithName("POD2").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("8")).Get()).Get()
+	pod3 := test.Pod().WithName("POD3").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("10")).Get()).Get()
+
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("5", "").Get()
+
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+		"POD1": {ScaleUp: true, ResourceDiff: 0.25},
+		"POD2": {ScaleUp: false, ResourceDiff: 0.25},
+		"POD3": {ScaleUp: false, ResourceDiff: 0.5},
+	})
+
+	testCases := []struct {
+		objective     UpdateObjective
+		expectedOrder []*apiv1.Pod
+	}{
+		{
+			// Growing pod1 goes first, then shrinking pods by resource diff.
+			objective:     EnsureReliability,
+			expectedOrder: []*apiv1.Pod{pod1, pod3, pod2},
+		},
+		{
+			// Shrinking pods go first by resource diff, then growing pod1.
+			objective:     SaveCost,
+			expectedOrder: []*apiv1.Pod{pod3, pod2, pod1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.objective), func(t *testing.T) {
+			calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, Objective: tc.objective},
+				&test.FakeRecommendationProcessor{}, priorityProcessor)
+
+			timestampNow := pod1.Status.StartTime.Add(time.Hour * 24)
+			calculator.AddPod(pod1, timestampNow)
+			calculator.AddPod(pod2, timestampNow)
+			calculator.AddPod(pod3, timestampNow)
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			assert.Exactly(t, tc.expectedOrder, result, "Wrong priority order")
+		})
+	}
+}
+
+func TestParseUpdateObjective(t *testing.T) {
+	for _, objective := range []UpdateObjective{EnsureReliability, SaveCost} {
+		parsed, err := ParseUpdateObjective(string(objective))
+		assert.NoError(t, err)
+		assert.Equal(t, objective, parsed)
+	}
+	for _, value := range []string{"", "save-costs", "Save-Cost"} {
+		_, err := ParseUpdateObjective(value)
+		assert.Error(t, err, "unknown objective %q is rejected", value)
+	}
+}
+
+func TestSortPriorityPreferLowPriorityPods(t *testing.T) {
+	pod1 := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
+	pod2 := test.Pod().WithName("POD2").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("8")).Get()).Get()
+	pod3 := test.Pod().WithName("POD3").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("10")).Get()).Get()
+
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("5", "").Get()
+
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+		"POD1": {ScaleUp: true, ResourceDiff: 0.25, SchedulingPriority: 1000000},
+		"POD2": {ScaleUp: false, ResourceDiff: 0.25, SchedulingPriority: 0},
+		"POD3": {ScaleUp: false, ResourceDiff: 0.5, SchedulingPriority: 1000},
+	})
+
+	testCases := []struct {
+		name                  string
+		preferLowPriorityPods bool
+		expectedOrder         []*apiv1.Pod
+	}{
+		{
+			name:                  "scheduling priority ignored",
+			preferLowPriorityPods: false,
+			expectedOrder:         []*apiv1.Pod{pod1, pod3, pod2},
+		},
+		{
+			name:                  "lowest scheduling priority first",
+			preferLowPriorityPods: true,
+			expectedOrder:         []*apiv1.Pod{pod2, pod3, pod1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, PreferLowPriorityPods: tc.preferLowPriorityPods},
+				&test.FakeRecommendationProcessor{}, priorityProcessor)
+
+			timestampNow := pod1.Status.StartTime.Add(time.Hour * 24)
+			calculator.AddPod(pod1, timestampNow)
+			calculator.AddPod(pod2, timestampNow)
+			calculator.AddPod(pod3, timestampNow)
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			assert.Exactly(t, tc.expectedOrder, result, "Wrong priority order")
+		})
+	}
+}
+
+func TestDeferQOSDowngrade(t *testing.T) {
+	pod := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).
+		WithCPURequest(resource.MustParse("4")).WithCPULimit(resource.MustParse("4")).
+		WithMemRequest(resource.MustParse("100M")).WithMemLimit(resource.MustParse("100M")).Get()).Get()
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+		"POD1": {ScaleUp: false, ResourceDiff: 0.5},
+	})
+
+	testCases := []struct {
+		name              string
+		controlledValues  vpa_types.ContainerControlledValues
+		allowQOSDowngrade bool
+		expectedPods      []*apiv1.Pod
+	}{
+		{
+			name:             "requests and limits scaled together keep the pod Guaranteed",
+			controlledValues: vpa_types.ContainerControlledValuesRequestsAndLimits,
+			expectedPods:     []*apiv1.Pod{pod},
+		},
+		{
+			name:             "lowering only requests would make the pod Burstable",
+			controlledValues: vpa_types.ContainerControlledValuesRequestsOnly,
+			expectedPods:     []*apiv1.Pod{},
+		},
+		{
+			name:              "downgrade explicitly allowed",
+			controlledValues:  vpa_types.ContainerControlledValuesRequestsOnly,
+			allowQOSDowngrade: true,
+			expectedPods:      []*apiv1.Pod{pod},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("2", "100M").
+				WithControlledValues(containerName, tc.controlledValues).Get()
+			calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, AllowQOSDowngrade: tc.allowQOSDowngrade},
+				&test.FakeRecommendationProcessor{}, priorityProcessor)
+
+			calculator.AddPod(pod, pod.Status.StartTime.Add(time.Hour*24))
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			assert.Exactly(t, tc.expectedPods, result)
+		})
+	}
+}
+
+func TestMaxPodLifetime(t *testing.T) {
+	pod := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("4", "").Get()
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{"POD1": {
+		ResourceDiff: 0.0,
+	}})
+
+	testCases := []struct {
+		name           string
+		maxPodLifetime time.Duration
+		age            time.Duration
+		expectedPods   []*apiv1.Pod
+	}{
+		{
+			name:           "old pod at target is refreshed",
+			maxPodLifetime: 7 * 24 * time.Hour,
+			age:            8 * 24 * time.Hour,
+			expectedPods:   []*apiv1.Pod{pod},
+		},
+		{
+			name:           "young pod at target is not updated",
+			maxPodLifetime: 7 * 24 * time.Hour,
+			age:            24 * time.Hour,
+			expectedPods:   []*apiv1.Pod{},
+		},
+		{
+			name:         "max pod lifetime disabled",
+			age:          8 * 24 * time.Hour,
+			expectedPods: []*apiv1.Pod{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, MaxPodLifetime: tc.maxPodLifetime},
+				&test.FakeRecommendationProcessor{}, priorityProcessor)
+			// Pods found to match the recommendation before are refreshed too.
+			cache := NewAppliedRecommendationCache()
+			cache.Record(pod, vpa, vpa.Status.Recommendation)
+			calculator.SetAppliedRecommendationCache(cache)
+
+			calculator.AddPod(pod, pod.Status.StartTime.Add(tc.age))
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			assert.Exactly(t, tc.expectedPods, result)
+		})
+	}
+}
+
+func TestMinChangeFraction(t *testing.T) {
+	newPod := func(name, cpu, memory string) *apiv1.Pod {
+		return test.Pod().WithName(name).
+			AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse(cpu)).WithMemRequest(resource.MustParse(memory)).Get()).Get()
+	}
+	subThreshold := newPod("POD1", "9", "950M")
+	cpuAboveThreshold := newPod("POD2", "7", "1G")
+	evictNowPod := newPod("POD3", "9", "950M")
+	evictNowPod.Annotations = map[string]string{annotations.VpaEvictNowAnnotation: "true"}
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("10", "1G").Get()
+	// All pods are outside the recommended range and would be updated without the minimum change fraction.
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+		"POD1": {ResourceDiff: 0.16, OutsideRecommendedRange: true},
+		"POD2": {ResourceDiff: 0.43, OutsideRecommendedRange: true},
+		"POD3": {ResourceDiff: 0.16, OutsideRecommendedRange: true},
+	})
+
+	testCases := []struct {
+		name              string
+		minChangeFraction float64
+		expectedPods      []*apiv1.Pod
+	}{
+		{
+			name:              "pods whose resources change by less than the fraction are not updated",
+			minChangeFraction: 0.2,
+			expectedPods:      []*apiv1.Pod{cpuAboveThreshold},
+		},
+		{
+			name:              "minimum change fraction disabled",
+			minChangeFraction: 0,
+			expectedPods:      []*apiv1.Pod{evictNowPod, cpuAboveThreshold, subThreshold},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, MinChangeFraction: tc.minChangeFraction},
+				&test.FakeRecommendationProcessor{}, priorityProcessor)
+			timestampNow := subThreshold.Status.StartTime.Add(time.Hour * 24)
+			for _, pod := range []*apiv1.Pod{subThreshold, cpuAboveThreshold, evictNowPod} {
+				calculator.AddPod(pod, timestampNow)
+			}
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			assert.Exactly(t, tc.expectedPods, result)
+		})
+	}
+}
+
+func TestEvictNowAnnotation(t *testing.T) {
+	pod1 := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("2")).Get()).Get()
+	pod2 := test.Pod().WithName("POD2").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
+	evictNowPod := test.Pod().WithName("POD3").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("10")).Get()).
+		WithAnnotations(map[string]string{annotations.VpaEvictNowAnnotation: "true"}).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("10", "").Get()
+	priorityProcessor := NewFakeProcessor(map[string]PodPriority{
+		"POD1": {ResourceDiff: 4.0, ScaleUp: true},
+		"POD2": {ResourceDiff: 1.5, ScaleUp: true},
+		// The annotated pod is within the recommended range and too young to be updated otherwise.
+		"POD3": {ResourceDiff: 0.5, ScaleUp: true},
+	})
+	calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1, PreferLowPriorityPods: true},
+		&test.FakeRecommendationProcessor{}, priorityProcessor)
+
+	timestampNow := pod1.Status.StartTime.Add(time.Hour * 24)
+	calculator.AddPod(pod1, timestampNow)
+	calculator.AddPod(pod2, timestampNow)
+	calculator.AddPod(evictNowPod, evictNowPod.Status.StartTime.Add(time.Minute))
+
+	result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+	assert.Exactly(t, []*apiv1.Pod{evictNowPod, pod1, pod2}, result, "the annotated pod is updated first")
+
+	calculator = NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1}, &test.FakeRecommendationProcessor{}, priorityProcessor)
+	cache := NewAppliedRecommendationCache()
+	cache.Record(evictNowPod, vpa, vpa.Status.Recommendation)
+	calculator.SetAppliedRecommendationCache(cache)
+	calculator.AddPod(evictNowPod, evictNowPod.Status.StartTime.Add(time.Minute))
+	assert.Empty(t, calculator.GetSortedPods(NewDefaultPodEvictionAdmission()), "the annotated pod isn't updated again once it got the recommendation")
+
+	calculator = NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1}, &test.FakeRecommendationProcessor{},
+		NewFakeProcessor(map[string]PodPriority{"POD3": {ResourceDiff: 0.0}}))
+	calculator.AddPod(evictNowPod, evictNowPod.Status.StartTime.Add(time.Minute))
+	assert.Empty(t, calculator.GetSortedPods(NewDefaultPodEvictionAdmission()), "the annotated pod isn't updated if it's already at target")
+
+	calculator = NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1}, &test.FakeRecommendationProcessor{}, priorityProcessor)
+	calculator.AddPod(evictNowPod, evictNowPod.Status.StartTime.Add(time.Minute))
+	assert.Empty(t, calculator.GetSortedPods(&pod1Admission{}), "the annotated pod is still subject to admission")
+}
+
+func TestSidecarUpdateThreshold(t *testing.T) {
+	pod := test.Pod().WithName("POD1").
+		AddContainer(test.Container().WithName("app").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).
+		AddContainer(test.Container().WithName("istio-proxy").WithCPURequest(resource.MustParse("100m")).WithMemRequest(resource.MustParse("128Mi")).Get()).
+		Get()
+	now := pod.Status.StartTime.Add(24 * time.Hour)
+
+	testCases := []struct {
+		name          string
+		pattern       *regexp.Regexp
+		appTarget     string
+		sidecarTarget string
+		expectUpdate  bool
+	}{
+		{
+			name:          "sidecar change below the sidecar threshold",
+			pattern:       regexp.MustCompile("^istio-proxy$"),
+			appTarget:     "1",
+			sidecarTarget: "130m",
+			expectUpdate:  false,
+		},
+		{
+			name:          "sidecar change above the sidecar threshold",
+			pattern:       regexp.MustCompile("^istio-proxy$"),
+			appTarget:     "1",
+			sidecarTarget: "160m",
+			expectUpdate:  true,
+		},
+		{
+			name:          "app change above the threshold",
+			pattern:       regexp.MustCompile("^istio-proxy$"),
+			appTarget:     "1200m",
+			sidecarTarget: "100m",
+			expectUpdate:  true,
+		},
+		{
+			name:          "sidecar change diluted by the app without sidecar classification",
+			appTarget:     "1",
+			sidecarTarget: "160m",
+			expectUpdate:  false,
+		},
+		{
+			name:          "pattern matching no container",
+			pattern:       regexp.MustCompile("^envoy$"),
+			appTarget:     "1200m",
+			sidecarTarget: "100m",
+			expectUpdate:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpa := test.VerticalPodAutoscaler().WithContainer("app").
+				WithTarget(tc.appTarget, "1Gi").WithLowerBound("100m", "100Mi").WithUpperBound("10", "10Gi").
+				AppendRecommendation(test.Recommendation().WithContainer("istio-proxy").
+					WithTarget(tc.sidecarTarget, "128Mi").WithLowerBound("10m", "10Mi").WithUpperBound("10", "10Gi").GetContainerResources()).
+				Get()
+			config := &UpdateConfig{MinChangePriority: 0.1, SidecarContainerPattern: tc.pattern, SidecarMinChangePriority: 0.5}
+			calculator := NewUpdatePriorityCalculator(vpa, config, &test.FakeRecommendationProcessor{}, NewProcessor())
+
+			calculator.AddPod(pod, now)
+
+			result := calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+			if tc.expectUpdate {
+				assert.Exactly(t, []*apiv1.Pod{pod}, result)
+			} else {
+				assert.Empty(t, result)
+			}
+		})
+	}
+}
+
 func TestUpdateNotRequired(t *testing.T) {
 	pod1 := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
 	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("4", "").Get()
@@ -128,6 +513,45 @@ func TestUseProcessor(t *testing.T) {
 	assert.Exactly(t, []*apiv1.Pod{}, result, "Pod should not be updated")
 }
 
+type countingPriorityProcessor struct {
+	PriorityProcessor
+	calls int
+}
+
+func (p *countingPriorityProcessor) GetUpdatePriority(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler,
+	recommendation *vpa_types.RecommendedPodResources) PodPriority {
+	p.calls++
+	return p.PriorityProcessor.GetUpdatePriority(pod, vpa, recommendation)
+}
+
+func TestAppliedRecommendationCacheSkipsPodAtTarget(t *testing.T) {
+	pod := test.Pod().WithName("POD1").AddContainer(test.Container().WithName(containerName).WithCPURequest(resource.MustParse("4")).Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).WithTarget("4", "").Get()
+	priorityProcessor := &countingPriorityProcessor{PriorityProcessor: NewFakeProcessor(map[string]PodPriority{"POD1": {ResourceDiff: 0.0}})}
+	cache := NewAppliedRecommendationCache()
+	timestampNow := pod.Status.StartTime.Add(time.Hour * 24)
+
+	addPod := func(processed *vpa_types.RecommendedPodResources) []*apiv1.Pod {
+		recommendationProcessor := &test.RecommendationProcessorMock{}
+		recommendationProcessor.On("Apply").Return(processed, nil)
+		calculator := NewUpdatePriorityCalculator(vpa, &UpdateConfig{MinChangePriority: 0.1}, recommendationProcessor, priorityProcessor)
+		calculator.SetAppliedRecommendationCache(cache)
+		calculator.AddPod(pod, timestampNow)
+		return calculator.GetSortedPods(NewDefaultPodEvictionAdmission())
+	}
+
+	assert.Empty(t, addPod(vpa.Status.Recommendation), "Pod at target should not be updated")
+	assert.Equal(t, 1, priorityProcessor.calls)
+
+	assert.Empty(t, addPod(vpa.Status.Recommendation), "Pod at target should not be updated")
+	assert.Equal(t, 1, priorityProcessor.calls, "Pod matching the unchanged recommendation should not be evaluated again")
+
+	// The processed recommendation may change without the VPA changing, e.g. through annotations.
+	processed := test.Recommendation().WithContainer(containerName).WithTarget("5", "").Get()
+	addPod(processed)
+	assert.Equal(t, 2, priorityProcessor.calls, "Pod should be evaluated again after the processed recommendation changed")
+}
+
 // Verify that a pod that lives for more than podLifetimeUpdateThreshold is
 // updated if it has at least one container with the request:
 // 1. outside the [MinRecommended...MaxRecommended] range or
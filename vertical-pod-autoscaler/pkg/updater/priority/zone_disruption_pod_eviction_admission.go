@@ -0,0 +1,143 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewZoneDisruptionPodEvictionAdmission creates a PodEvictionAdmission object.
+// It caps the number of Pods controlled by VPAs which are disrupted at the same time in each
+// topology zone, so that rollouts don't drain the capacity of a single zone. The zone of a Pod
+// is the zone label of its Node. Pods which aren't ready count as disrupted in their zone.
+// Replacements of admitted Pods which aren't scheduled yet count as disrupted in the zone of the
+// Pod they replace, until they are scheduled. Other Pods not scheduled yet or on Nodes without a
+// zone are not capped.
+func NewZoneDisruptionPodEvictionAdmission(nodeLister v1lister.NodeLister, maxDisruptedPerZone int) PodEvictionAdmission {
+	z := &zoneDisruptionPodEvictionAdmission{
+		nodeLister:          nodeLister,
+		maxDisruptedPerZone: maxDisruptedPerZone,
+	}
+	z.CleanUp()
+	return z
+}
+
+type zoneDisruptionPodEvictionAdmission struct {
+	nodeLister          v1lister.NodeLister
+	maxDisruptedPerZone int
+	// disrupted is the number of disrupted Pods in each zone, including the Pods admitted in this loop.
+	disrupted map[string]int
+	admitted  sets.Set[types.UID]
+	// admittedZones are the zones of the Pods admitted in this loop, by the UID of their controller.
+	admittedZones map[types.UID][]string
+	// replacementZones are the zones of the Pods admitted in earlier loops whose replacements aren't
+	// scheduled yet, by the UID of their controller, oldest first.
+	replacementZones map[types.UID][]string
+}
+
+// LoopInit takes the census of the Pods currently disrupted in each zone.
+func (z *zoneDisruptionPodEvictionAdmission) LoopInit(_ []*apiv1.Pod, vpaControlledPods map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod) {
+	for controller, zones := range z.admittedZones {
+		z.replacementZones[controller] = append(z.replacementZones[controller], zones...)
+	}
+	z.disrupted = make(map[string]int)
+	z.admitted = sets.New[types.UID]()
+	z.admittedZones = make(map[types.UID][]string)
+
+	unscheduled := make(map[types.UID]int)
+	for _, pods := range vpaControlledPods {
+		for _, pod := range pods {
+			if isPodReady(pod) {
+				continue
+			}
+			if pod.Status.Phase == apiv1.PodPending && pod.Spec.NodeName == "" {
+				if controller := metav1.GetControllerOf(pod); controller != nil {
+					unscheduled[controller.UID]++
+				}
+				continue
+			}
+			if zone := z.podZone(pod); zone != "" {
+				z.disrupted[zone]++
+			}
+		}
+	}
+	// The scheduler can't be told where to place replacements, so they are expected in the zone of the
+	// Pod they replace. The oldest replacements are assumed to be scheduled first.
+	for controller, zones := range z.replacementZones {
+		if pending := unscheduled[controller]; pending < len(zones) {
+			zones = zones[len(zones)-pending:]
+		}
+		if len(zones) == 0 {
+			delete(z.replacementZones, controller)
+			continue
+		}
+		z.replacementZones[controller] = zones
+		for _, zone := range zones {
+			z.disrupted[zone]++
+		}
+	}
+}
+
+// Admit admits a Pod if disrupting it keeps the disrupted Pods in its zone within the configured limit.
+// Admitted Pods count as disrupted for the rest of the loop, even if they end up not being updated.
+func (z *zoneDisruptionPodEvictionAdmission) Admit(pod *apiv1.Pod, _ *vpa_types.RecommendedPodResources) bool {
+	if z.admitted.Has(pod.UID) {
+		return true
+	}
+	zone := z.podZone(pod)
+	if zone == "" {
+		return true
+	}
+	if z.disrupted[zone] >= z.maxDisruptedPerZone {
+		klog.V(4).InfoS("Deferring update of pod, too many pods are disrupted in its zone", "pod", klog.KObj(pod), "zone", zone, "disrupted", z.disrupted[zone], "maxDisruptedPerZone", z.maxDisruptedPerZone)
+		return false
+	}
+	z.disrupted[zone]++
+	z.admitted.Insert(pod.UID)
+	if controller := metav1.GetControllerOf(pod); controller != nil {
+		z.admittedZones[controller.UID] = append(z.admittedZones[controller.UID], zone)
+	}
+	return true
+}
+
+// CleanUp resets the census and forgets the expected zones of replacements.
+func (z *zoneDisruptionPodEvictionAdmission) CleanUp() {
+	z.disrupted = make(map[string]int)
+	z.admitted = sets.New[types.UID]()
+	z.admittedZones = make(map[types.UID][]string)
+	z.replacementZones = make(map[types.UID][]string)
+}
+
+// podZone returns the zone of the Node the Pod runs on, or an empty string if it isn't known.
+func (z *zoneDisruptionPodEvictionAdmission) podZone(pod *apiv1.Pod) string {
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+	node, err := z.nodeLister.Get(pod.Spec.NodeName)
+	if err != nil {
+		klog.V(4).InfoS("Failed to get node of pod, not capping its zone", "pod", klog.KObj(pod), "node", pod.Spec.NodeName, "error", err)
+		return ""
+	}
+	return node.Labels[apiv1.LabelTopologyZone]
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priority
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestZoneDisruptionPodEvictionAdmission(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*apiv1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-c", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-c"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-without-zone"}},
+	} {
+		assert.NoError(t, indexer.Add(node))
+	}
+	nodeLister := v1lister.NewNodeLister(indexer)
+
+	newPods := func(node string, count int, ready apiv1.ConditionStatus) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, count)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(fmt.Sprintf("%s-%s-%d", node, ready, i)).Get()
+			pods[i].UID = types.UID(pods[i].Name)
+			pods[i].Spec.NodeName = node
+			pods[i].Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: ready}}
+		}
+		return pods
+	}
+	zoneA := newPods("node-a", 4, apiv1.ConditionTrue)
+	zoneB := newPods("node-b", 4, apiv1.ConditionTrue)
+	zoneC := newPods("node-c", 4, apiv1.ConditionTrue)
+	zoneCNotReady := newPods("node-c", 1, apiv1.ConditionFalse)
+	withoutZone := newPods("node-without-zone", 3, apiv1.ConditionTrue)
+	unknownNode := newPods("node-unknown", 3, apiv1.ConditionTrue)
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).Get()
+	var pods []*apiv1.Pod
+	for _, p := range [][]*apiv1.Pod{zoneA, zoneB, zoneC, zoneCNotReady, withoutZone, unknownNode} {
+		pods = append(pods, p...)
+	}
+
+	admission := NewZoneDisruptionPodEvictionAdmission(nodeLister, 2)
+	admission.LoopInit(nil, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods})
+	countAdmitted := func(pods []*apiv1.Pod) int {
+		admitted := 0
+		for _, pod := range pods {
+			if admission.Admit(pod, nil) {
+				admitted++
+			}
+		}
+		return admitted
+	}
+	assert.Equal(t, 2, countAdmitted(zoneA))
+	assert.Equal(t, 2, countAdmitted(zoneB), "zones are capped independently")
+	assert.Equal(t, 1, countAdmitted(zoneC), "pods which aren't ready count against the cap of their zone")
+	assert.Equal(t, len(withoutZone), countAdmitted(withoutZone), "pods on nodes without a zone are not capped")
+	assert.Equal(t, len(unknownNode), countAdmitted(unknownNode), "pods on unknown nodes are not capped")
+	assert.True(t, admission.Admit(zoneA[0], nil), "a pod admitted already is admitted again")
+
+	admission.LoopInit(nil, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods})
+	assert.Equal(t, 2, countAdmitted(zoneA[2:]), "admissions of the previous loop are forgotten")
+}
+
+func TestZoneDisruptionPodEvictionAdmission_PendingReplacements(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*apiv1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{apiv1.LabelTopologyZone: "zone-b"}}},
+	} {
+		assert.NoError(t, indexer.Add(node))
+	}
+	nodeLister := v1lister.NewNodeLister(indexer)
+
+	rs := &metav1.ObjectMeta{Name: "rs", Namespace: "default", UID: "rs-uid"}
+	rsType := &metav1.TypeMeta{Kind: "ReplicaSet", APIVersion: "apps/v1"}
+	newPod := func(name, node string, ready apiv1.ConditionStatus) *apiv1.Pod {
+		pod := test.Pod().WithName(name).WithCreator(rs, rsType).WithNodeName(node).
+			WithPodConditions([]apiv1.PodCondition{{Type: apiv1.PodReady, Status: ready}}).Get()
+		pod.UID = types.UID(name)
+		return pod
+	}
+	newReplacement := func(name string) *apiv1.Pod {
+		pod := newPod(name, "", apiv1.ConditionFalse)
+		pod.Status.Phase = apiv1.PodPending
+		return pod
+	}
+	zoneA := []*apiv1.Pod{newPod("a-0", "node-a", apiv1.ConditionTrue), newPod("a-1", "node-a", apiv1.ConditionTrue)}
+	zoneB := []*apiv1.Pod{newPod("b-0", "node-b", apiv1.ConditionTrue)}
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).Get()
+
+	admission := NewZoneDisruptionPodEvictionAdmission(nodeLister, 1)
+	admission.LoopInit(nil, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: append(append([]*apiv1.Pod{}, zoneA...), zoneB...)})
+	assert.True(t, admission.Admit(zoneA[0], nil))
+
+	// a-0 was evicted, its replacement isn't scheduled yet.
+	pods := []*apiv1.Pod{zoneA[1], zoneB[0], newReplacement("replacement-0")}
+	admission.LoopInit(nil, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods})
+	assert.False(t, admission.Admit(zoneA[1], nil), "a pending replacement counts as disrupted in the zone of the pod it replaces")
+	assert.True(t, admission.Admit(zoneB[0], nil), "a pending replacement doesn't count in other zones")
+
+	// The replacement got scheduled and is ready, b-0 was evicted in the meantime.
+	pods = []*apiv1.Pod{zoneA[1], newPod("replacement-0", "node-a", apiv1.ConditionTrue), newReplacement("replacement-1")}
+	admission.LoopInit(nil, map[*vpa_types.VerticalPodAutoscaler][]*apiv1.Pod{vpa: pods})
+	assert.True(t, admission.Admit(zoneA[1], nil), "scheduled replacements don't count anymore")
+	assert.False(t, admission.Admit(newPod("b-1", "node-b", apiv1.ConditionTrue), nil), "the replacement of the newest eviction still counts")
+}
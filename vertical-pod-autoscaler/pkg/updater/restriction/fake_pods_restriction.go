@@ -18,8 +18,10 @@ package restriction
 
 import (
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
 )
 
 // FakePodsRestrictionFactory is a fake implementation of the PodsRestrictionFactory interface.
@@ -44,3 +46,58 @@ func (f *FakePodsRestrictionFactory) NewPodsInPlaceRestriction(creatorToSingleGr
 func (f *FakePodsRestrictionFactory) GetCreatorMaps(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) (map[podReplicaCreator]singleGroupStats, map[string]podReplicaCreator, error) {
 	return nil, nil, nil
 }
+
+// FuncPodsEvictionRestriction is a fake implementation of the PodsEvictionRestriction interface
+// whose behavior is set by its function fields, for tests which don't use mocks.
+// The zero value allows evicting every pod and evicts it successfully.
+type FuncPodsEvictionRestriction struct {
+	// CanEvictFunc, if set, is called by CanEvict. Every pod can be evicted otherwise.
+	CanEvictFunc func(pod *apiv1.Pod) bool
+	// EvictFunc, if set, is called by Evict. Evictions succeed otherwise.
+	EvictFunc func(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error
+}
+
+// CanEvict calls CanEvictFunc.
+func (f *FuncPodsEvictionRestriction) CanEvict(pod *apiv1.Pod) bool {
+	if f.CanEvictFunc == nil {
+		return true
+	}
+	return f.CanEvictFunc(pod)
+}
+
+// Evict calls EvictFunc.
+func (f *FuncPodsEvictionRestriction) Evict(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error {
+	if f.EvictFunc == nil {
+		return nil
+	}
+	return f.EvictFunc(pod, vpa, eventRecorder)
+}
+
+// FuncPodsInPlaceRestriction is a fake implementation of the PodsInPlaceRestriction interface
+// whose behavior is set by its function fields, for tests which don't use mocks.
+// The zero value approves updating every pod in place and updates it successfully.
+type FuncPodsInPlaceRestriction struct {
+	// CanInPlaceUpdateFunc, if set, is called by CanInPlaceUpdate. Every pod is approved otherwise.
+	CanInPlaceUpdateFunc func(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason)
+	// InPlaceUpdateFunc, if set, is called by InPlaceUpdate. Updates succeed otherwise.
+	InPlaceUpdateFunc func(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error
+}
+
+// CanInPlaceUpdate calls CanInPlaceUpdateFunc.
+func (f *FuncPodsInPlaceRestriction) CanInPlaceUpdate(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason) {
+	if f.CanInPlaceUpdateFunc == nil {
+		return utils.InPlaceApproved, utils.InPlaceReasonWithinTolerance
+	}
+	return f.CanInPlaceUpdateFunc(pod)
+}
+
+// InPlaceUpdate calls InPlaceUpdateFunc.
+func (f *FuncPodsInPlaceRestriction) InPlaceUpdate(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error {
+	if f.InPlaceUpdateFunc == nil {
+		return nil
+	}
+	return f.InPlaceUpdateFunc(pod, vpa, eventRecorder)
+}
+
+var _ PodsEvictionRestriction = &FuncPodsEvictionRestriction{}
+var _ PodsInPlaceRestriction = &FuncPodsInPlaceRestriction{}
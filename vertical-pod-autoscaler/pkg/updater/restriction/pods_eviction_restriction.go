@@ -18,18 +18,21 @@ package restriction
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 )
 
 // PodsEvictionRestriction controls pods evictions. It ensures that we will not evict too
@@ -43,6 +46,54 @@ type PodsEvictionRestriction interface {
 	CanEvict(pod *apiv1.Pod) bool
 }
 
+// VpaDisruptionBudgetRestriction is implemented by PodsEvictionRestrictions which enforce the
+// disruption budget set by the annotations of a VPA, so that refusals because of it can be told apart.
+type VpaDisruptionBudgetRestriction interface {
+	// ExceedsVpaDisruptionBudget returns true if the disruption budget of the VPA of the pod allows no more evictions.
+	ExceedsVpaDisruptionBudget(pod *apiv1.Pod) bool
+}
+
+// EvictionPolicy controls how disruptive evictions are.
+type EvictionPolicy struct {
+	// GracePeriodSeconds overrides the termination grace period of evicted pods, if set.
+	// The VpaEvictionGracePeriodAnnotation of a pod takes precedence over it.
+	GracePeriodSeconds *int64
+	// ForegroundDeletion makes evicted pods be deleted with the Foreground propagation policy.
+	ForegroundDeletion bool
+	// Timeout bounds the time spent waiting for an eviction request. Zero means no bound.
+	Timeout time.Duration
+	// AnnotateTrigger makes pods be annotated, right before their eviction, with the VPA
+	// and its resourceVersion whose recommendation triggered the eviction. The annotation is
+	// removed again if the eviction is refused.
+	AnnotateTrigger bool
+}
+
+func (p EvictionPolicy) deleteOptions(pod *apiv1.Pod) *metav1.DeleteOptions {
+	gracePeriodSeconds := p.gracePeriodSeconds(pod)
+	if gracePeriodSeconds == nil && !p.ForegroundDeletion {
+		return nil
+	}
+	options := &metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds}
+	if p.ForegroundDeletion {
+		propagationPolicy := metav1.DeletePropagationForeground
+		options.PropagationPolicy = &propagationPolicy
+	}
+	return options
+}
+
+// gracePeriodSeconds returns the grace period set by the annotation of the pod, falling back to the
+// one of the policy if the pod doesn't set a valid one.
+func (p EvictionPolicy) gracePeriodSeconds(pod *apiv1.Pod) *int64 {
+	gracePeriodSeconds, err := annotations.GetVpaEvictionGracePeriodSeconds(pod.Annotations)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring eviction grace period of pod", "pod", klog.KObj(pod), "error", err)
+	}
+	if gracePeriodSeconds != nil {
+		return gracePeriodSeconds
+	}
+	return p.GracePeriodSeconds
+}
+
 // PodsEvictionRestrictionImpl is the implementation of the PodsEvictionRestriction interface.
 type PodsEvictionRestrictionImpl struct {
 	client                       kube_client.Interface
@@ -50,6 +101,10 @@ type PodsEvictionRestrictionImpl struct {
 	creatorToSingleGroupStatsMap map[podReplicaCreator]singleGroupStats
 	clock                        clock.Clock
 	lastInPlaceAttemptTimeMap    map[string]time.Time
+	evictionPolicy               EvictionPolicy
+	// inPlaceActuationGracePeriod is the ActuationGracePeriod of the InPlacePolicy, which applies to
+	// pods being resized in place.
+	inPlaceActuationGracePeriod time.Duration
 }
 
 // CanEvict checks if pod can be safely evicted
@@ -58,11 +113,20 @@ func (e *PodsEvictionRestrictionImpl) CanEvict(pod *apiv1.Pod) bool {
 	if present {
 		singleGroupStats, present := e.creatorToSingleGroupStatsMap[cr]
 		if pod.Status.Phase == apiv1.PodPending {
+			if IsUnscheduled(pod) {
+				// Evicting a Pod the scheduler can't place only recreates it with the same problem.
+				klog.V(4).InfoS("Not evicting pending pod, it isn't scheduled yet", "pod", klog.KObj(pod))
+				return false
+			}
 			return true
 		}
 		if present {
+			if !singleGroupStats.vpaBudget.allows() {
+				klog.V(4).InfoS("Not evicting pod, the disruption budget of its VPA is exhausted", "pod", klog.KObj(pod))
+				return false
+			}
 			if isInPlaceUpdating(pod) {
-				return CanEvictInPlacingPod(pod, singleGroupStats, e.lastInPlaceAttemptTimeMap, e.clock)
+				return CanEvictInPlacingPod(pod, singleGroupStats, e.lastInPlaceAttemptTimeMap, e.clock, e.inPlaceActuationGracePeriod)
 			}
 			return singleGroupStats.isPodDisruptable()
 		}
@@ -70,6 +134,16 @@ func (e *PodsEvictionRestrictionImpl) CanEvict(pod *apiv1.Pod) bool {
 	return false
 }
 
+// ExceedsVpaDisruptionBudget returns true if the disruption budget of the VPA of the pod allows no more evictions.
+func (e *PodsEvictionRestrictionImpl) ExceedsVpaDisruptionBudget(pod *apiv1.Pod) bool {
+	cr, present := e.podToReplicaCreatorMap[getPodID(pod)]
+	if !present {
+		return false
+	}
+	singleGroupStats, present := e.creatorToSingleGroupStatsMap[cr]
+	return present && !singleGroupStats.vpaBudget.allows()
+}
+
 // Evict sends eviction instruction to api client. Returns error if pod cannot be evicted or if client returned error
 // Does not check if pod was actually evicted after eviction grace period.
 func (e *PodsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error {
@@ -78,26 +152,55 @@ func (e *PodsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, vpa *vpa_type
 		return fmt.Errorf("pod not suitable for eviction %s/%s: not in replicated pods map", podToEvict.Namespace, podToEvict.Name)
 	}
 
+	if IsUnscheduled(podToEvict) {
+		return fmt.Errorf("cannot evict pod %s/%s: not scheduled yet", podToEvict.Namespace, podToEvict.Name)
+	}
+
 	if !e.CanEvict(podToEvict) {
 		return fmt.Errorf("cannot evict pod %s/%s: eviction budget exceeded", podToEvict.Namespace, podToEvict.Name)
 	}
 
+	annotated := false
+	if e.evictionPolicy.AnnotateTrigger {
+		// The eviction goes ahead anyway, the annotation only helps to audit it.
+		trigger := annotations.GetVpaEvictionTriggerValue(vpa.Name, vpa.ResourceVersion)
+		if err := e.patchTriggerAnnotation(podToEvict, &trigger); err != nil {
+			klog.ErrorS(err, "Failed to annotate pod with the VPA triggering its eviction", "pod", klog.KObj(podToEvict))
+		} else {
+			annotated = true
+		}
+	}
+
 	eviction := &policyv1.Eviction{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: podToEvict.Namespace,
 			Name:      podToEvict.Name,
 		},
+		DeleteOptions: e.evictionPolicy.deleteOptions(podToEvict),
+	}
+	ctx := context.TODO()
+	if e.evictionPolicy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.evictionPolicy.Timeout)
+		defer cancel()
 	}
-	err := e.client.CoreV1().Pods(podToEvict.Namespace).EvictV1(context.TODO(), eviction)
+	err := e.client.CoreV1().Pods(podToEvict.Namespace).EvictV1(ctx, eviction)
 	if err != nil {
 		klog.ErrorS(err, "Failed to evict pod", "pod", klog.KObj(podToEvict))
+		if annotated {
+			// The pod keeps running, so it mustn't claim to have been evicted.
+			if err := e.patchTriggerAnnotation(podToEvict, nil); err != nil {
+				klog.ErrorS(err, "Failed to remove the eviction trigger annotation of pod", "pod", klog.KObj(podToEvict))
+			}
+		}
 		return err
 	}
+	provenance := recommendationProvenance(vpa)
 	eventRecorder.Event(podToEvict, apiv1.EventTypeNormal, "EvictedByVPA",
-		"Pod was evicted by VPA Updater to apply resource recommendation.")
+		"Pod was evicted by VPA Updater to apply resource recommendation ("+provenance+").")
 
 	eventRecorder.Event(vpa, apiv1.EventTypeNormal, "EvictedPod",
-		"VPA Updater evicted Pod "+podToEvict.Name+" to apply resource recommendation.")
+		"VPA Updater evicted Pod "+podToEvict.Name+" to apply resource recommendation ("+provenance+").")
 
 	if podToEvict.Status.Phase != apiv1.PodPending {
 		singleGroupStats, present := e.creatorToSingleGroupStatsMap[cr]
@@ -105,8 +208,37 @@ func (e *PodsEvictionRestrictionImpl) Evict(podToEvict *apiv1.Pod, vpa *vpa_type
 			return fmt.Errorf("internal error - cannot find stats for replication group %v", cr)
 		}
 		singleGroupStats.evicted = singleGroupStats.evicted + 1
+		singleGroupStats.vpaBudget.recordEviction()
 		e.creatorToSingleGroupStatsMap[cr] = singleGroupStats
 	}
 
 	return nil
 }
+
+// patchTriggerAnnotation sets the VpaEvictionTriggerAnnotation of the Pod to value, or removes it
+// if value is nil.
+func (e *PodsEvictionRestrictionImpl) patchTriggerAnnotation(pod *apiv1.Pod, value *string) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]*string{
+				annotations.VpaEvictionTriggerAnnotation: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	if e.evictionPolicy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.evictionPolicy.Timeout)
+		defer cancel()
+	}
+	_, err = e.client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// IsUnscheduled returns true if the Pod is pending and was never bound to a Node.
+func IsUnscheduled(pod *apiv1.Pod) bool {
+	return pod.Status.Phase == apiv1.PodPending && pod.Spec.NodeName == ""
+}
@@ -23,11 +23,20 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	baseclocktest "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 )
 
@@ -158,6 +167,84 @@ func TestEvictAtLeastOne(t *testing.T) {
 	}
 }
 
+func TestEvictWithVpaDisruptionBudget(t *testing.T) {
+	replicas := int32(3)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController"},
+		Spec:       apiv1.ReplicationControllerSpec{Replicas: &replicas},
+	}
+	rs := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "default"},
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicaSet"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}
+	ready := []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+	newPods := func() []*apiv1.Pod {
+		var pods []*apiv1.Pod
+		for i := 0; i < int(replicas); i++ {
+			pods = append(pods, test.Pod().WithName("rc-"+getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).WithPodConditions(ready).Get())
+			pods = append(pods, test.Pod().WithName("rs-"+getTestPodName(i)).WithCreator(&rs.ObjectMeta, &rs.TypeMeta).WithPodConditions(ready).Get())
+		}
+		return pods
+	}
+	countEvicted := func(vpa *vpa_types.VerticalPodAutoscaler, pods []*apiv1.Pod) int {
+		factory, err := getRestrictionFactory(&rc, &rs, nil, nil, 2, 0.5, nil, nil, nil, false)
+		assert.NoError(t, err)
+		creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+		assert.NoError(t, err)
+		eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+		evicted := 0
+		for _, pod := range pods {
+			if pod.Status.Phase == apiv1.PodPending {
+				continue
+			}
+			if eviction.CanEvict(pod) {
+				assert.NoError(t, eviction.Evict(pod, vpa, test.FakeEventRecorder()))
+				evicted++
+			} else {
+				assert.Error(t, eviction.Evict(pod, vpa, test.FakeEventRecorder()))
+			}
+		}
+		return evicted
+	}
+	withAnnotation := func(annotation, value string) *vpa_types.VerticalPodAutoscaler {
+		vpa := getBasicVpa()
+		vpa.Annotations = map[string]string{annotation: value}
+		return vpa
+	}
+
+	assert.Equal(t, 2, countEvicted(getBasicVpa(), newPods()), "without a budget, the eviction tolerance of each group applies")
+	assert.Equal(t, 1, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "1"), newPods()), "the budget is shared by the groups of the VPA")
+	assert.Equal(t, 2, countEvicted(withAnnotation(annotations.VpaMinAvailableAnnotation, "50%"), newPods()))
+	assert.Equal(t, 2, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "5"), newPods()), "the eviction tolerance still applies")
+	assert.Equal(t, 0, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "invalid"), newPods()), "an invalid budget blocks evictions")
+
+	pods := newPods()
+	pods[0].Status.Phase = apiv1.PodPending
+	assert.Equal(t, 0, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "1"), pods), "pods which aren't running count against the budget")
+
+	pods = newPods()
+	pods[0].Status.Conditions = nil
+	assert.Equal(t, 0, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "1"), pods), "pods which aren't ready count against the budget")
+
+	pods = newPods()
+	pods[0].Status.Conditions = append(pods[0].Status.Conditions, apiv1.PodCondition{Type: apiv1.PodResizeInProgress, Status: apiv1.ConditionTrue})
+	assert.Equal(t, 0, countEvicted(withAnnotation(annotations.VpaMaxUnavailableAnnotation, "1"), pods), "pods resizing in place count against the budget")
+
+	vpa := withAnnotation(annotations.VpaMaxUnavailableAnnotation, "1")
+	pods = newPods()
+	factory, err := getRestrictionFactory(&rc, &rs, nil, nil, 2, 0.5, nil, nil, nil, false)
+	assert.NoError(t, err)
+	creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+	assert.NoError(t, err)
+	eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+	budget := eviction.(VpaDisruptionBudgetRestriction)
+	assert.False(t, budget.ExceedsVpaDisruptionBudget(pods[0]))
+	assert.NoError(t, eviction.Evict(pods[0], vpa, test.FakeEventRecorder()))
+	assert.True(t, budget.ExceedsVpaDisruptionBudget(pods[1]), "the refusal is attributed to the budget")
+}
+
 func TestEvictEmitEvent(t *testing.T) {
 	rc := apiv1.ReplicationController{
 		ObjectMeta: metav1.ObjectMeta{
@@ -192,12 +279,12 @@ func TestEvictEmitEvent(t *testing.T) {
 			vpa:               basicVpa,
 			pods: []podWithExpectations{
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
@@ -257,6 +344,284 @@ func TestEvictEmitEvent(t *testing.T) {
 	}
 }
 
+func TestEvictUnscheduledPod(t *testing.T) {
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+	unscheduled := test.Pod().WithName("unscheduled").WithCreator(&rc.ObjectMeta, &rc.TypeMeta).WithPhase(apiv1.PodPending).Get()
+	scheduled := test.Pod().WithName("scheduled").WithCreator(&rc.ObjectMeta, &rc.TypeMeta).WithPhase(apiv1.PodPending).WithNodeName("node").Get()
+	pods = append(pods, unscheduled, scheduled)
+	basicVpa := getBasicVpa()
+
+	factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+	assert.NoError(t, err)
+	creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, basicVpa)
+	assert.NoError(t, err)
+	eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+	assert.False(t, eviction.CanEvict(unscheduled), "pods which were never scheduled are not evicted")
+	assert.True(t, eviction.CanEvict(scheduled), "pending pods bound to a node are evicted")
+
+	eventRecorder := test.MockEventRecorder()
+	assert.ErrorContains(t, eviction.Evict(unscheduled, basicVpa, eventRecorder), "not scheduled yet")
+	eventRecorder.AssertNumberOfCalls(t, "Event", 0)
+	assert.True(t, IsUnscheduled(unscheduled))
+	assert.False(t, IsUnscheduled(scheduled))
+	assert.False(t, IsUnscheduled(pods[0]))
+
+}
+
+func TestEvictEventIncludesRecommendationProvenance(t *testing.T) {
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+
+	providedAt := time.Date(2025, time.March, 1, 10, 30, 0, 0, time.UTC)
+	vpa := test.VerticalPodAutoscaler().WithContainer("any").
+		WithRecommender("custom-recommender").
+		AppendCondition(vpa_types.RecommendationProvided, apiv1.ConditionTrue, "", "", providedAt).
+		Get()
+	// The recommendation was confirmed by a heartbeat of the recommender after the condition transitioned.
+	vpa.Status.Conditions[0].LastProbeTime = metav1.NewTime(providedAt.Add(time.Hour))
+
+	factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+	assert.NoError(t, err)
+	creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+	assert.NoError(t, err)
+	eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+	eventRecorder := record.NewFakeRecorder(10)
+	assert.NoError(t, eviction.Evict(pods[0], vpa, eventRecorder))
+
+	for _, reason := range []string{"EvictedByVPA", "EvictedPod"} {
+		select {
+		case event := <-eventRecorder.Events:
+			assert.Contains(t, event, reason)
+			assert.Contains(t, event, `recommender "custom-recommender"`)
+			assert.Contains(t, event, "last updated at 2025-03-01T11:30:00Z")
+		case <-time.After(1 * time.Second):
+			assert.Fail(t, "timeout waiting for event", reason)
+		}
+	}
+}
+
+func TestEvictWithEvictionPolicy(t *testing.T) {
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+	vpa := test.VerticalPodAutoscaler().WithContainer("any").Get()
+	foreground := metav1.DeletePropagationForeground
+
+	testCases := []struct {
+		name                  string
+		evictionPolicy        EvictionPolicy
+		podAnnotations        map[string]string
+		expectedDeleteOptions *metav1.DeleteOptions
+	}{
+		{
+			name:                  "default policy",
+			evictionPolicy:        EvictionPolicy{},
+			expectedDeleteOptions: nil,
+		},
+		{
+			name:                  "grace period override",
+			evictionPolicy:        EvictionPolicy{GracePeriodSeconds: ptr.To(int64(5))},
+			expectedDeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(5))},
+		},
+		{
+			name:                  "foreground deletion with timeout",
+			evictionPolicy:        EvictionPolicy{GracePeriodSeconds: ptr.To(int64(0)), ForegroundDeletion: true, Timeout: time.Minute},
+			expectedDeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0)), PropagationPolicy: &foreground},
+		},
+		{
+			name:                  "grace period annotation",
+			podAnnotations:        map[string]string{annotations.VpaEvictionGracePeriodAnnotation: "90"},
+			expectedDeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(90))},
+		},
+		{
+			name:                  "grace period annotation overrides policy",
+			evictionPolicy:        EvictionPolicy{GracePeriodSeconds: ptr.To(int64(5))},
+			podAnnotations:        map[string]string{annotations.VpaEvictionGracePeriodAnnotation: "0"},
+			expectedDeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))},
+		},
+		{
+			name:                  "invalid grace period annotation is ignored",
+			evictionPolicy:        EvictionPolicy{GracePeriodSeconds: ptr.To(int64(5))},
+			podAnnotations:        map[string]string{annotations.VpaEvictionGracePeriodAnnotation: "-1"},
+			expectedDeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(5))},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+			assert.NoError(t, err)
+			factoryImpl := factory.(*PodsRestrictionFactoryImpl)
+			factoryImpl.evictionPolicy = tc.evictionPolicy
+
+			var evictions []*policyv1.Eviction
+			factoryImpl.client.(*fake.Clientset).PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() == "eviction" {
+					evictions = append(evictions, action.(core.CreateAction).GetObject().(*policyv1.Eviction))
+				}
+				return true, nil, nil
+			})
+
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+			assert.NoError(t, err)
+			eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+			pod := pods[0].DeepCopy()
+			pod.Annotations = tc.podAnnotations
+			assert.NoError(t, eviction.Evict(pod, vpa, test.FakeEventRecorder()))
+
+			if assert.Len(t, evictions, 1) {
+				assert.Equal(t, tc.expectedDeleteOptions, evictions[0].DeleteOptions)
+			}
+		})
+	}
+}
+
+func TestEvictAnnotatesTrigger(t *testing.T) {
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("my-vpa").WithContainer("any").Get()
+	vpa.ResourceVersion = "123456"
+
+	for _, annotateTrigger := range []bool{false, true} {
+		factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+		assert.NoError(t, err)
+		factoryImpl := factory.(*PodsRestrictionFactoryImpl)
+		factoryImpl.evictionPolicy = EvictionPolicy{AnnotateTrigger: annotateTrigger}
+
+		var actions []string
+		var patch []byte
+		factoryImpl.client.(*fake.Clientset).PrependReactor("*", "pods", func(action core.Action) (bool, runtime.Object, error) {
+			actions = append(actions, action.GetVerb())
+			if patchAction, ok := action.(core.PatchAction); ok {
+				patch = patchAction.GetPatch()
+			}
+			return true, nil, nil
+		})
+
+		creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+		assert.NoError(t, err)
+		eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+		assert.NoError(t, eviction.Evict(pods[0], vpa, test.FakeEventRecorder()))
+
+		if !annotateTrigger {
+			assert.Equal(t, []string{"create"}, actions, "pods are not annotated by default")
+			continue
+		}
+		assert.Equal(t, []string{"patch", "create"}, actions, "the pod is annotated before its eviction")
+		assert.JSONEq(t, `{"metadata":{"annotations":{"vpa-eviction-trigger.k8s.io":"vpa=my-vpa,resourceVersion=123456"}}}`, string(patch))
+	}
+}
+
+func TestEvictRemovesTriggerAnnotationWhenRefused(t *testing.T) {
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+	vpa := test.VerticalPodAutoscaler().WithName("my-vpa").WithContainer("any").Get()
+	vpa.ResourceVersion = "123456"
+
+	factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+	assert.NoError(t, err)
+	factoryImpl := factory.(*PodsRestrictionFactoryImpl)
+	factoryImpl.evictionPolicy = EvictionPolicy{AnnotateTrigger: true}
+
+	var actions []string
+	var patches []string
+	factoryImpl.client.(*fake.Clientset).PrependReactor("*", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		actions = append(actions, action.GetVerb())
+		if patchAction, ok := action.(core.PatchAction); ok {
+			patches = append(patches, string(patchAction.GetPatch()))
+			return true, nil, nil
+		}
+		return true, nil, apierrors.NewTooManyRequests("disruption budget exceeded", 10)
+	})
+
+	creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+	assert.NoError(t, err)
+	eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+	assert.Error(t, eviction.Evict(pods[0], vpa, test.FakeEventRecorder()))
+
+	assert.Equal(t, []string{"patch", "create", "patch"}, actions, "the annotation is removed after the refused eviction")
+	if assert.Len(t, patches, 2) {
+		assert.JSONEq(t, `{"metadata":{"annotations":{"vpa-eviction-trigger.k8s.io":"vpa=my-vpa,resourceVersion=123456"}}}`, patches[0])
+		assert.JSONEq(t, `{"metadata":{"annotations":{"vpa-eviction-trigger.k8s.io":null}}}`, patches[1])
+	}
+}
+
 // This test ensures that in-place-skip-disruption-budget only affects in-place
 // updates and does not bypass eviction tolerance when performing pod evictions.
 func TestEvictTooFewReplicasWithInPlaceSkipDisruptionBudget(t *testing.T) {
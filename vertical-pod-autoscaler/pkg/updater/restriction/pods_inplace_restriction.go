@@ -21,12 +21,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	kube_client "k8s.io/client-go/kubernetes"
+	policylister "k8s.io/client-go/listers/policy/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -38,6 +46,47 @@ import (
 	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
 )
 
+const (
+	// ResizeSubresourceAuto makes the updater detect which resize subresource the API server serves.
+	ResizeSubresourceAuto = "auto"
+	// ResizeSubresourceNone makes the updater patch the pod itself, as API servers that predate the resize
+	// subresource expect.
+	ResizeSubresourceNone = "none"
+	// resizeSubresource is the name of the pods/resize subresource.
+	resizeSubresource = "resize"
+	// containersPatchPath is the prefix of the paths of patches to containers.
+	containersPatchPath = "/spec/containers/"
+)
+
+// ErrInPlaceUnsupportedResource is returned by InPlaceUpdate if the recommendation changes a resource
+// which can't be resized in place, e.g. ephemeral-storage. The pod has to be evicted to apply it.
+var ErrInPlaceUnsupportedResource = errors.New("resource can't be resized in place")
+
+// ErrInPlaceGrowthDisallowed is returned by InPlaceUpdate if the InPlacePolicy only allows shrinking
+// resources in place and the recommendation grows the requests of a resource. The pod has to be
+// evicted to apply it.
+var ErrInPlaceGrowthDisallowed = errors.New("in-place updates may only shrink resources")
+
+// InPlacePolicy configures how pods are updated in place.
+type InPlacePolicy struct {
+	// ShrinkOnly makes pods whose recommendation grows the requests of any resource be left
+	// to eviction, so that in-place updates only lower requests.
+	ShrinkOnly bool
+	// ResizeSubresource is the subresource resize patches are sent to. ResizeSubresourceAuto, or an
+	// empty value, detects whether the API server serves pods/resize, ResizeSubresourceNone patches
+	// the pod itself.
+	ResizeSubresource string
+	// ActuationGracePeriod is the time after a resize request during which the resize is considered
+	// pending even if the pod reports it failed, as the kubelet may not have acted on it yet.
+	ActuationGracePeriod time.Duration
+	// PreferAtDisruptionBudgetLimit makes pods whose PodDisruptionBudget allows no more disruptions
+	// keep waiting for their resize when it fails, instead of falling back to eviction.
+	PreferAtDisruptionBudgetLimit bool
+}
+
+// inPlaceUnsupportedResources are the resources whose requests and limits can't be resized in place.
+var inPlaceUnsupportedResources = []apiv1.ResourceName{apiv1.ResourceEphemeralStorage}
+
 // TODO: Make these configurable by flags
 const (
 	// DeferredResizeUpdateTimeout defines the duration during which an in-place resize request
@@ -57,7 +106,8 @@ type PodsInPlaceRestriction interface {
 	// Returns error if client returned error.
 	InPlaceUpdate(pod *apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler, eventRecorder record.EventRecorder) error
 	// CanInPlaceUpdate checks if pod can be safely updated in-place. If not, it will return a decision to potentially evict the pod.
-	CanInPlaceUpdate(pod *apiv1.Pod) utils.InPlaceDecision
+	// The reason explains the decision.
+	CanInPlaceUpdate(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason)
 }
 
 // PodsInPlaceRestrictionImpl is the implementation of the PodsInPlaceRestriction interface.
@@ -69,42 +119,169 @@ type PodsInPlaceRestrictionImpl struct {
 	clock                        clock.Clock
 	lastInPlaceAttemptTimeMap    map[string]time.Time
 	inPlaceSkipDisruptionBudget  bool
+	// resizeSubresource is the subresource resize patches are sent to, empty to patch the pod itself.
+	resizeSubresource string
+	// pdbLister, if set, makes pods whose PodDisruptionBudget is at its limit keep waiting for their
+	// in-place resize rather than fall back to an eviction the budget would refuse.
+	pdbLister     policylister.PodDisruptionBudgetLister
+	inPlacePolicy InPlacePolicy
+}
+
+// isAtDisruptionBudgetLimit returns true if a PodDisruptionBudget selecting the pod allows no more disruptions.
+func (ip *PodsInPlaceRestrictionImpl) isAtDisruptionBudgetLimit(pod *apiv1.Pod) bool {
+	pdbs, err := ip.pdbLister.PodDisruptionBudgets(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list PodDisruptionBudgets", "namespace", pod.Namespace)
+		return false
+	}
+	for _, pdb := range pdbs {
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			klog.V(2).InfoS("Ignoring PodDisruptionBudget with invalid selector", "pdb", klog.KObj(pdb), "error", err)
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) && pdb.Status.DisruptionsAllowed <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutUnsupportedResourcePatches drops the patches of resources which can't be resized in place.
+// It returns an error wrapping ErrInPlaceUnsupportedResource if any of them changes a request or limit.
+func withoutUnsupportedResourcePatches(pod *apiv1.Pod, patches []resource_updates.PatchRecord) ([]resource_updates.PatchRecord, error) {
+	result := make([]resource_updates.PatchRecord, 0, len(patches))
+	for _, p := range patches {
+		key, field, ok := parseContainerResourcePatch(p.Path)
+		if !ok || !slices.Contains(inPlaceUnsupportedResources, key.resourceName) {
+			result = append(result, p)
+			continue
+		}
+		if key.containerIndex >= len(pod.Spec.Containers) {
+			return nil, fmt.Errorf("%w: %s", ErrInPlaceUnsupportedResource, key.resourceName)
+		}
+		resources := pod.Spec.Containers[key.containerIndex].Resources
+		current, found := resources.Requests[key.resourceName]
+		if field == "limits" {
+			current, found = resources.Limits[key.resourceName]
+		}
+		newValue, ok := patchQuantity(p.Value)
+		if !found || !ok || newValue.Cmp(current) != 0 {
+			return nil, fmt.Errorf("%w: %s %s of container %s would change", ErrInPlaceUnsupportedResource,
+				key.resourceName, field, pod.Spec.Containers[key.containerIndex].Name)
+		}
+		klog.V(4).InfoS("Skipping in-place patch of resource which can't be resized", "pod", klog.KObj(pod), "path", p.Path)
+	}
+	return result, nil
+}
+
+// containerResourcePatch identifies the resource of a container a patch applies to.
+type containerResourcePatch struct {
+	containerIndex int
+	resourceName   apiv1.ResourceName
+}
+
+// parseContainerResourcePatch parses the path of a patch setting the request or limit of a
+// container resource, e.g. /spec/containers/0/resources/requests/cpu.
+func parseContainerResourcePatch(path string) (key containerResourcePatch, field string, ok bool) {
+	rest, found := strings.CutPrefix(path, containersPatchPath)
+	if !found {
+		return containerResourcePatch{}, "", false
+	}
+	parts := strings.SplitN(rest, "/", 4)
+	if len(parts) != 4 || parts[1] != "resources" {
+		return containerResourcePatch{}, "", false
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return containerResourcePatch{}, "", false
+	}
+	return containerResourcePatch{containerIndex: index, resourceName: apiv1.ResourceName(parts[3])}, parts[2], true
+}
+
+// patchQuantity returns the quantity set by a patch.
+func patchQuantity(value interface{}) (resource.Quantity, bool) {
+	switch v := value.(type) {
+	case resource.Quantity:
+		return v, true
+	case string:
+		q, err := resource.ParseQuantity(v)
+		return q, err == nil
+	default:
+		return resource.Quantity{}, false
+	}
+}
+
+// growingResources returns the container resources whose requests would grow with the patches,
+// or whose growth can't be ruled out.
+func growingResources(pod *apiv1.Pod, patches []resource_updates.PatchRecord) []string {
+	var growing []string
+	for _, p := range patches {
+		key, field, ok := parseContainerResourcePatch(p.Path)
+		if !ok || field != "requests" {
+			continue
+		}
+		newRequest, ok := patchQuantity(p.Value)
+		if !ok || key.containerIndex >= len(pod.Spec.Containers) {
+			growing = append(growing, string(key.resourceName))
+			continue
+		}
+		container := pod.Spec.Containers[key.containerIndex]
+		currentRequest, found := container.Resources.Requests[key.resourceName]
+		if !found || newRequest.Cmp(currentRequest) > 0 {
+			growing = append(growing, fmt.Sprintf("%s of container %s", key.resourceName, container.Name))
+		}
+	}
+	return growing
 }
 
 // CanInPlaceUpdate checks if pod can be safely updated
-func (ip *PodsInPlaceRestrictionImpl) CanInPlaceUpdate(pod *apiv1.Pod) utils.InPlaceDecision {
+func (ip *PodsInPlaceRestrictionImpl) CanInPlaceUpdate(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason) {
 	if !features.Enabled(features.InPlaceOrRecreate) {
-		return utils.InPlaceEvict
+		return utils.InPlaceEvict, utils.InPlaceReasonFeatureDisabled
 	}
 
 	cr, present := ip.podToReplicaCreatorMap[getPodID(pod)]
 	if present {
 		singleGroupStats, present := ip.creatorToSingleGroupStatsMap[cr]
+		if containers := singleGroupStats.inPlaceDisabledContainers[getPodID(pod)]; len(containers) > 0 {
+			klog.V(4).InfoS("In-place updates are disabled for containers of the pod, falling back to eviction", "pod", klog.KObj(pod), "containers", containers)
+			return utils.InPlaceEvict, utils.InPlaceReasonContainerOptedOut
+		}
 		if pod.Status.Phase == apiv1.PodPending {
-			return utils.InPlaceDeferred
+			return utils.InPlaceDeferred, utils.InPlaceReasonPodPending
 		}
 		if present {
 			if isInPlaceUpdating(pod) {
-				canEvict := CanEvictInPlacingPod(pod, singleGroupStats, ip.lastInPlaceAttemptTimeMap, ip.clock)
+				canEvict := CanEvictInPlacingPod(pod, singleGroupStats, ip.lastInPlaceAttemptTimeMap, ip.clock, ip.inPlacePolicy.ActuationGracePeriod)
+				if canEvict && ip.pdbLister != nil && ip.isAtDisruptionBudgetLimit(pod) {
+					klog.V(4).InfoS("PodDisruptionBudget of pod allows no disruptions, waiting for the resize instead of evicting", "pod", klog.KObj(pod))
+					return utils.InPlaceDeferred, utils.InPlaceReasonAtDisruptionBudgetLimit
+				}
 				if canEvict {
-					return utils.InPlaceEvict
+					return utils.InPlaceEvict, utils.InPlaceReasonResizeFailed
 				}
-				return utils.InPlaceDeferred
+				return utils.InPlaceDeferred, utils.InPlaceReasonResizeInProgress
 			}
 			if ip.inPlaceSkipDisruptionBudget {
 				if utils.IsNonDisruptiveResize(pod) {
 					klog.V(4).InfoS("in-place-skip-disruption-budget enabled, skipping disruption budget check for in-place update")
-					return utils.InPlaceApproved
+					return utils.InPlaceApproved, utils.InPlaceReasonDisruptionBudgetSkipped
 				}
 				klog.V(4).InfoS("in-place-skip-disruption-budget enabled, but pod has RestartContainer resize policy", "pod", klog.KObj(pod))
 			}
 			if singleGroupStats.isPodDisruptable() {
-				return utils.InPlaceApproved
+				return utils.InPlaceApproved, utils.InPlaceReasonWithinTolerance
 			}
+			klog.V(4).InfoS("Can't in-place update pod, but not falling back to eviction. Waiting for next loop", "pod", klog.KObj(pod))
+			return utils.InPlaceDeferred, utils.InPlaceReasonToleranceExceeded
 		}
 	}
 	klog.V(4).InfoS("Can't in-place update pod, but not falling back to eviction. Waiting for next loop", "pod", klog.KObj(pod))
-	return utils.InPlaceDeferred
+	return utils.InPlaceDeferred, utils.InPlaceReasonUnknownCreator
 }
 
 // InPlaceUpdate sends calculates patches and sends resize request to api client. Returns error if pod cannot be in-place updated or if client returned error.
@@ -115,8 +292,8 @@ func (ip *PodsInPlaceRestrictionImpl) InPlaceUpdate(podToUpdate *apiv1.Pod, vpa
 		return fmt.Errorf("pod not suitable for in-place update %v: not in replicated pods map", podToUpdate.Name)
 	}
 
-	if ip.CanInPlaceUpdate(podToUpdate) != utils.InPlaceApproved {
-		return fmt.Errorf("cannot in-place update pod %s", klog.KObj(podToUpdate))
+	if decision, reason := ip.CanInPlaceUpdate(podToUpdate); decision != utils.InPlaceApproved {
+		return fmt.Errorf("cannot in-place update pod %s: %s", klog.KObj(podToUpdate), reason)
 	}
 
 	// separate patches since we have to patch resize and spec separately
@@ -138,27 +315,29 @@ func (ip *PodsInPlaceRestrictionImpl) InPlaceUpdate(podToUpdate *apiv1.Pod, vpa
 		}
 	}
 
-	if len(resizePatches) == 0 {
-		return errors.New("no resource patches were calculated to apply")
-	}
-
-	patch, err := json.Marshal(resizePatches)
+	resizePatches, err := withoutUnsupportedResourcePatches(podToUpdate, resizePatches)
 	if err != nil {
 		return err
 	}
+	if ip.inPlacePolicy.ShrinkOnly {
+		if growing := growingResources(podToUpdate, resizePatches); len(growing) > 0 {
+			return fmt.Errorf("%w: requests of %s would grow", ErrInPlaceGrowthDisallowed, strings.Join(growing, ", "))
+		}
+	}
+	if len(resizePatches) == 0 {
+		return errors.New("no resource patches were calculated to apply")
+	}
 
-	res, err := ip.client.CoreV1().Pods(podToUpdate.Namespace).Patch(context.TODO(), podToUpdate.Name, k8stypes.JSONPatchType, patch, metav1.PatchOptions{}, "resize")
-	if err != nil {
+	if err := ip.resize(podToUpdate, resizePatches, eventRecorder); err != nil {
 		return err
 	}
-	klog.V(4).InfoS("In-place patched pod /resize subresource using patches", "pod", klog.KObj(res), "patches", string(patch))
 
 	if len(annotationPatches) > 0 {
 		patch, err := json.Marshal(annotationPatches)
 		if err != nil {
 			return err
 		}
-		res, err = ip.client.CoreV1().Pods(podToUpdate.Namespace).Patch(context.TODO(), podToUpdate.Name, k8stypes.JSONPatchType, patch, metav1.PatchOptions{})
+		res, err := ip.client.CoreV1().Pods(podToUpdate.Namespace).Patch(context.TODO(), podToUpdate.Name, k8stypes.JSONPatchType, patch, metav1.PatchOptions{})
 		if err != nil {
 			klog.V(4).ErrorS(err, "Failed to patch pod annotations", "pod", klog.KObj(res), "patches", string(patch))
 		} else {
@@ -166,7 +345,8 @@ func (ip *PodsInPlaceRestrictionImpl) InPlaceUpdate(podToUpdate *apiv1.Pod, vpa
 		}
 	}
 
-	eventRecorder.Event(podToUpdate, apiv1.EventTypeNormal, "InPlaceResizedByVPA", "Pod was resized in place by VPA Updater.")
+	eventRecorder.Event(podToUpdate, apiv1.EventTypeNormal, "InPlaceResizedByVPA",
+		"Pod was resized in place by VPA Updater ("+recommendationProvenance(vpa)+").")
 
 	singleGroupStats, present := ip.creatorToSingleGroupStatsMap[cr]
 	if !present {
@@ -179,8 +359,97 @@ func (ip *PodsInPlaceRestrictionImpl) InPlaceUpdate(podToUpdate *apiv1.Pod, vpa
 	return nil
 }
 
+// resize sends the resize patches of the pod. If the API server rejects them as invalid, the patches of
+// each container are sent on their own, so that a container whose resize is invalid doesn't keep the
+// others from being resized. The failed containers are reported with an event, and an error is
+// returned only if no container could be resized.
+func (ip *PodsInPlaceRestrictionImpl) resize(pod *apiv1.Pod, patches []resource_updates.PatchRecord, eventRecorder record.EventRecorder) error {
+	err := ip.sendResizePatch(pod, patches)
+	if err == nil || !apierrors.IsInvalid(err) {
+		return err
+	}
+	containerPatches := splitPatchesByContainer(pod, patches)
+	if len(containerPatches) < 2 {
+		return err
+	}
+	klog.V(2).InfoS("In-place resize of pod rejected, resizing its containers one by one", "pod", klog.KObj(pod), "error", err)
+	var failedContainers []string
+	var errs []error
+	for _, cp := range containerPatches {
+		if err := ip.sendResizePatch(pod, cp.patches); err != nil {
+			klog.V(2).InfoS("In-place resize of container rejected", "pod", klog.KObj(pod), "container", cp.containerName, "error", err)
+			failedContainers = append(failedContainers, cp.containerName)
+			errs = append(errs, fmt.Errorf("container %s: %w", cp.containerName, err))
+		}
+	}
+	if len(failedContainers) == len(containerPatches) {
+		return errors.Join(errs...)
+	}
+	if len(failedContainers) > 0 {
+		eventRecorder.Event(pod, apiv1.EventTypeWarning, "InPlaceResizePartiallyFailed",
+			fmt.Sprintf("VPA Updater failed to resize containers %s in place: %v", strings.Join(failedContainers, ", "), errors.Join(errs...)))
+	}
+	return nil
+}
+
+// sendResizePatch sends a single resize patch of the pod.
+func (ip *PodsInPlaceRestrictionImpl) sendResizePatch(pod *apiv1.Pod, patches []resource_updates.PatchRecord) error {
+	patch, err := json.Marshal(patches)
+	if err != nil {
+		return err
+	}
+	var subresources []string
+	if ip.resizeSubresource != "" {
+		subresources = append(subresources, ip.resizeSubresource)
+	}
+	res, err := ip.client.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, k8stypes.JSONPatchType, patch, metav1.PatchOptions{}, subresources...)
+	if err != nil {
+		return err
+	}
+	klog.V(4).InfoS("In-place patched pod using patches", "pod", klog.KObj(res), "subresource", ip.resizeSubresource, "patches", string(patch))
+	return nil
+}
+
+// containerPatches are the resize patches of a single container.
+type containerPatches struct {
+	containerName string
+	patches       []resource_updates.PatchRecord
+}
+
+// splitPatchesByContainer groups the resize patches by the container they apply to, in the order
+// of the patches. Patches which don't apply to a single container are kept in every group.
+func splitPatchesByContainer(pod *apiv1.Pod, patches []resource_updates.PatchRecord) []containerPatches {
+	var shared []resource_updates.PatchRecord
+	var groups []containerPatches
+	groupOf := make(map[int]int)
+	for _, p := range patches {
+		rest, found := strings.CutPrefix(p.Path, containersPatchPath)
+		index, err := strconv.Atoi(strings.SplitN(rest, "/", 2)[0])
+		if !found || err != nil {
+			shared = append(shared, p)
+			continue
+		}
+		group, found := groupOf[index]
+		if !found {
+			name := strconv.Itoa(index)
+			if index < len(pod.Spec.Containers) {
+				name = pod.Spec.Containers[index].Name
+			}
+			group = len(groups)
+			groupOf[index] = group
+			groups = append(groups, containerPatches{containerName: name})
+		}
+		groups[group].patches = append(groups[group].patches, p)
+	}
+	for i := range groups {
+		groups[i].patches = append(slices.Clone(shared), groups[i].patches...)
+	}
+	return groups
+}
+
 // CanEvictInPlacingPod checks if the pod can be evicted while it is currently in the middle of an in-place update.
-func CanEvictInPlacingPod(pod *apiv1.Pod, singleGroupStats singleGroupStats, lastInPlaceAttemptTimeMap map[string]time.Time, clock clock.Clock) bool {
+// Failures reported within actuationGracePeriod after the last resize request are ignored.
+func CanEvictInPlacingPod(pod *apiv1.Pod, singleGroupStats singleGroupStats, lastInPlaceAttemptTimeMap map[string]time.Time, clock clock.Clock, actuationGracePeriod time.Duration) bool {
 	if !isInPlaceUpdating(pod) {
 		return false
 	}
@@ -192,6 +461,12 @@ func CanEvictInPlacingPod(pod *apiv1.Pod, singleGroupStats singleGroupStats, las
 	}
 
 	if singleGroupStats.isPodDisruptable() {
+		// The conditions of the pod may still describe a previous resize until the kubelet acts on the
+		// last request, so they're not trusted until the grace period after the request passed.
+		if exists && clock.Since(lastUpdate) < actuationGracePeriod {
+			klog.V(4).InfoS("In-place update requested recently, waiting for the kubelet to act on it", "pod", klog.KObj(pod), "gracePeriod", actuationGracePeriod)
+			return false
+		}
 		// if currently inPlaceUpdating, we should only fallback to eviction if the update has failed. i.e: one of the following conditions:
 		// - Infeasible
 		// - Deferred + more than 5 minutes has elapsed since the lastInPlaceUpdateTime
@@ -226,6 +501,9 @@ func CanEvictInPlacingPod(pod *apiv1.Pod, singleGroupStats singleGroupStats, las
 					klog.V(4).InfoS("In-place update condition unknown, falling back to eviction", "pod", klog.KObj(pod), "condition", resizeInProgressCondition)
 					return true
 				}
+			} else if isLegacyResizeInProgress(pod) && clock.Since(lastUpdate) > InProgressResizeUpdateTimeout {
+				klog.V(4).InfoS(fmt.Sprintf("In-place update in progress for more than %v, falling back to eviction", InProgressResizeUpdateTimeout), "pod", klog.KObj(pod))
+				return true
 			}
 		}
 		return false
@@ -233,3 +511,34 @@ func CanEvictInPlacingPod(pod *apiv1.Pod, singleGroupStats singleGroupStats, las
 	klog.V(4).InfoS("Would be able to evict, but already resizing", "pod", klog.KObj(pod))
 	return false
 }
+
+// getResizeSubresource returns the subresource resize patches should be sent to, honoring the
+// override. An empty result means the pod itself should be patched.
+func getResizeSubresource(discoveryClient discovery.DiscoveryInterface, override string) string {
+	switch override {
+	case ResizeSubresourceAuto, "":
+		return detectResizeSubresource(discoveryClient)
+	case ResizeSubresourceNone:
+		return ""
+	default:
+		return override
+	}
+}
+
+// detectResizeSubresource checks whether the API server serves the pods/resize subresource. If discovery fails the
+// subresource is assumed to be served.
+func detectResizeSubresource(discoveryClient discovery.DiscoveryInterface) string {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(apiv1.SchemeGroupVersion.String())
+	if err != nil {
+		klog.ErrorS(err, "Failed to discover pod subresources, assuming the resize subresource is served")
+		return resizeSubresource
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "pods/"+resizeSubresource {
+			klog.V(1).InfoS("Detected pods/resize subresource, resizing pods in place through it")
+			return resizeSubresource
+		}
+	}
+	klog.V(1).InfoS("API server doesn't serve the pods/resize subresource, resizing pods in place by patching them directly")
+	return ""
+}
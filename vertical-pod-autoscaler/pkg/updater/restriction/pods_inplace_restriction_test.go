@@ -17,20 +17,35 @@ limitations under the License.
 package restriction
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	policylister "k8s.io/client-go/listers/policy/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	baseclocktest "k8s.io/utils/clock/testing"
 
+	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/features"
 	utils "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/utils"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
 )
 
@@ -41,6 +56,7 @@ type CanInPlaceUpdateTestParams struct {
 	evictionTolerance       float64
 	lastInPlaceAttempt      time.Time
 	expectedInPlaceDecision utils.InPlaceDecision
+	expectedInPlaceReason   utils.InPlaceDecisionReason
 }
 
 func TestCanInPlaceUpdate(t *testing.T) {
@@ -62,6 +78,11 @@ func TestCanInPlaceUpdate(t *testing.T) {
 		return test.Pod().WithName(fmt.Sprintf("test-%v", index)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta)
 	}
 	// NOTE: the pod we are checking for CanInPlaceUpdate will always be the first one for these tests
+	legacyResizeInProgressPod := func() *apiv1.Pod {
+		pod := generatePod().Get()
+		pod.Status.Resize = apiv1.PodResizeStatusInProgress //nolint:staticcheck
+		return pod
+	}
 	whichPodIdxForCanInPlaceUpdate := 0
 
 	testCases := []CanInPlaceUpdateTestParams{
@@ -76,6 +97,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.Time{},
 			expectedInPlaceDecision: utils.InPlaceApproved,
+			expectedInPlaceReason:   utils.InPlaceReasonWithinTolerance,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceDeferred - no pods can be in-placed, one missing",
@@ -87,6 +109,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.Time{},
 			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonToleranceExceeded,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceApproved - small tolerance, all running",
@@ -99,6 +122,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.1,
 			lastInPlaceAttempt:      time.Time{},
 			expectedInPlaceDecision: utils.InPlaceApproved,
+			expectedInPlaceReason:   utils.InPlaceReasonWithinTolerance,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceApproved - small tolerance, one missing",
@@ -110,6 +134,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.Time{},
 			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonToleranceExceeded,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceDeferred - resize Deferred, conditions not met to fallback",
@@ -128,6 +153,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.UnixMilli(3600000), // 1 hour from epoch
 			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeInProgress,
 		},
 		{
 			name: ("CanInPlaceUpdate=InPlaceEvict - resize inProgress for more too long"),
@@ -145,6 +171,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.UnixMilli(0), // epoch (too long ago...)
 			expectedInPlaceDecision: utils.InPlaceEvict,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeFailed,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceDeferred - resize InProgress, conditions not met to fallback",
@@ -162,6 +189,33 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.UnixMilli(3600000), // 1 hour from epoch
 			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeInProgress,
+		},
+		{
+			name: "CanInPlaceUpdate=InPlaceDeferred - resize InProgress in the deprecated resize status",
+			pods: []*apiv1.Pod{
+				legacyResizeInProgressPod(),
+				generatePod().Get(),
+				generatePod().Get(),
+			},
+			replicas:                3,
+			evictionTolerance:       0.5,
+			lastInPlaceAttempt:      time.UnixMilli(3600000), // 1 hour from epoch
+			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeInProgress,
+		},
+		{
+			name: "CanInPlaceUpdate=InPlaceEvict - resize InProgress in the deprecated resize status for too long",
+			pods: []*apiv1.Pod{
+				legacyResizeInProgressPod(),
+				generatePod().Get(),
+				generatePod().Get(),
+			},
+			replicas:                3,
+			evictionTolerance:       0.5,
+			lastInPlaceAttempt:      time.UnixMilli(0), // epoch (too long ago...)
+			expectedInPlaceDecision: utils.InPlaceEvict,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeFailed,
 		},
 		{
 			name: "CanInPlaceUpdate=InPlaceEvict - infeasible",
@@ -180,6 +234,7 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			evictionTolerance:       0.5,
 			lastInPlaceAttempt:      time.Time{},
 			expectedInPlaceDecision: utils.InPlaceEvict,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeFailed,
 		},
 	}
 	for _, tc := range testCases {
@@ -199,8 +254,9 @@ func TestCanInPlaceUpdate(t *testing.T) {
 			assert.NoError(t, err)
 			inPlace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
-			result := inPlace.CanInPlaceUpdate(selectedPod)
-			assert.Equal(t, tc.expectedInPlaceDecision, result)
+			decision, reason := inPlace.CanInPlaceUpdate(selectedPod)
+			assert.Equal(t, tc.expectedInPlaceDecision, decision)
+			assert.Equal(t, tc.expectedInPlaceReason, reason)
 		})
 	}
 }
@@ -239,7 +295,9 @@ func TestInPlaceDisabledFeatureGate(t *testing.T) {
 	inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
 	for _, pod := range pods {
-		assert.Equal(t, utils.InPlaceEvict, inplace.CanInPlaceUpdate(pod))
+		decision, reason := inplace.CanInPlaceUpdate(pod)
+		assert.Equal(t, utils.InPlaceEvict, decision)
+		assert.Equal(t, utils.InPlaceReasonFeatureDisabled, reason)
 	}
 }
 
@@ -279,7 +337,8 @@ func TestInPlaceTooFewReplicas(t *testing.T) {
 	inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
 	for _, pod := range pods {
-		assert.Equal(t, utils.InPlaceDeferred, inplace.CanInPlaceUpdate(pod))
+		decision, _ := inplace.CanInPlaceUpdate(pod)
+		assert.Equal(t, utils.InPlaceDeferred, decision)
 	}
 
 	for _, pod := range pods {
@@ -324,7 +383,8 @@ func TestEvictionToleranceForInPlace(t *testing.T) {
 	inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
 	for _, pod := range pods {
-		assert.Equal(t, utils.InPlaceApproved, inplace.CanInPlaceUpdate(pod))
+		decision, _ := inplace.CanInPlaceUpdate(pod)
+		assert.Equal(t, utils.InPlaceApproved, decision)
 	}
 
 	for _, pod := range pods[:4] {
@@ -385,7 +445,9 @@ func TestEvictionToleranceForInPlaceWithSkipDisruptionBudget(t *testing.T) {
 
 	// All in-place updates should be approved
 	for _, pod := range pods {
-		assert.Equal(t, utils.InPlaceApproved, inplace.CanInPlaceUpdate(pod))
+		decision, reason := inplace.CanInPlaceUpdate(pod)
+		assert.Equal(t, utils.InPlaceApproved, decision)
+		assert.Equal(t, utils.InPlaceReasonDisruptionBudgetSkipped, reason)
 	}
 
 	// And all updates should succeed without being blocked by eviction tolerance
@@ -570,7 +632,8 @@ func TestInPlaceAtLeastOne(t *testing.T) {
 	inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
 
 	for _, pod := range pods {
-		assert.Equal(t, utils.InPlaceApproved, inplace.CanInPlaceUpdate(pod))
+		decision, _ := inplace.CanInPlaceUpdate(pod)
+		assert.Equal(t, utils.InPlaceApproved, decision)
 	}
 
 	for _, pod := range pods[:1] {
@@ -627,3 +690,626 @@ func TestInPlaceUpdate_EventEmission(t *testing.T) {
 		assert.Fail(t, "timeout waiting for event")
 	}
 }
+
+func TestInPlaceUpdate_ResizeSubresource(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	testCases := []struct {
+		name                string
+		override            string
+		servedResources     []metav1.APIResource
+		expectedSubresource string
+	}{
+		{
+			name:                "detects served resize subresource",
+			override:            ResizeSubresourceAuto,
+			servedResources:     []metav1.APIResource{{Name: "pods"}, {Name: "pods/resize"}},
+			expectedSubresource: resizeSubresource,
+		},
+		{
+			name:                "patches the pod when resize subresource is not served",
+			override:            ResizeSubresourceAuto,
+			servedResources:     []metav1.APIResource{{Name: "pods"}, {Name: "pods/status"}},
+			expectedSubresource: "",
+		},
+		{
+			name:                "override takes precedence over detection",
+			override:            ResizeSubresourceNone,
+			servedResources:     []metav1.APIResource{{Name: "pods"}, {Name: "pods/resize"}},
+			expectedSubresource: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(5)
+			rc := apiv1.ReplicationController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "default",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "ReplicationController",
+				},
+				Spec: apiv1.ReplicationControllerSpec{
+					Replicas: &replicas,
+				},
+			}
+			pods := make([]*apiv1.Pod, replicas)
+			for i := range pods {
+				pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+			}
+
+			client := fake.NewSimpleClientset()
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+				{GroupVersion: "v1", APIResources: tc.servedResources},
+			}
+			var patchedSubresources []string
+			client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				patchedSubresources = append(patchedSubresources, action.GetSubresource())
+				return true, pods[0], nil
+			})
+
+			basicVpa := getBasicVpa()
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, GetFakeCalculatorsWithFakeResourceCalc(), false)
+			assert.NoError(t, err)
+			factoryImpl := factory.(*PodsRestrictionFactoryImpl)
+			factoryImpl.client = client
+			factoryImpl.resizeSubresource = getResizeSubresource(client.Discovery(), tc.override)
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, basicVpa)
+			assert.NoError(t, err)
+			inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			assert.NoError(t, inplace.InPlaceUpdate(pods[0], basicVpa, test.FakeEventRecorder()))
+			if assert.NotEmpty(t, patchedSubresources) {
+				assert.Equal(t, tc.expectedSubresource, patchedSubresources[0])
+			}
+		})
+	}
+}
+
+func TestPreferInPlaceAtDisruptionBudgetLimit(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	replicas := int32(5)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	newPods := func(running int, resizeFailed bool) []*apiv1.Pod {
+		pods := make([]*apiv1.Pod, running)
+		for i := range pods {
+			pods[i] = test.Pod().WithName(getTestPodName(i)).WithLabels(map[string]string{"app": "test"}).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+		}
+		if resizeFailed {
+			pods[0].Status.Conditions = []apiv1.PodCondition{{
+				Type:   apiv1.PodResizePending,
+				Status: apiv1.ConditionTrue,
+				Reason: apiv1.PodReasonInfeasible,
+			}}
+		}
+		return pods
+	}
+
+	newPDB := func(app string, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pdb",
+				Namespace: "default",
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{
+				DisruptionsAllowed: disruptionsAllowed,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		pods             []*apiv1.Pod
+		pdb              *policyv1.PodDisruptionBudget
+		noLister         bool
+		expectedDecision utils.InPlaceDecision
+		expectedReason   utils.InPlaceDecisionReason
+	}{
+		{
+			name:             "failed resize of a pod whose PDB is at its limit",
+			pods:             newPods(5, true),
+			pdb:              newPDB("test", 0),
+			expectedDecision: utils.InPlaceDeferred,
+			expectedReason:   utils.InPlaceReasonAtDisruptionBudgetLimit,
+		},
+		{
+			name:             "failed resize of a pod whose PDB allows disruptions",
+			pods:             newPods(5, true),
+			pdb:              newPDB("test", 1),
+			expectedDecision: utils.InPlaceEvict,
+			expectedReason:   utils.InPlaceReasonResizeFailed,
+		},
+		{
+			name:             "failed resize of a pod not selected by the PDB",
+			pods:             newPods(5, true),
+			pdb:              newPDB("other", 0),
+			expectedDecision: utils.InPlaceEvict,
+			expectedReason:   utils.InPlaceReasonResizeFailed,
+		},
+		{
+			name:             "failed resize without the PDB lister",
+			pods:             newPods(5, true),
+			pdb:              newPDB("test", 0),
+			noLister:         true,
+			expectedDecision: utils.InPlaceEvict,
+			expectedReason:   utils.InPlaceReasonResizeFailed,
+		},
+		{
+			// Only 3 out of 5 replicas are running.
+			name:             "PDB at its limit doesn't lift the eviction tolerance",
+			pods:             newPods(3, false),
+			pdb:              newPDB("test", 0),
+			expectedDecision: utils.InPlaceDeferred,
+			expectedReason:   utils.InPlaceReasonToleranceExceeded,
+		},
+		{
+			name:             "PDB at its limit within the eviction tolerance",
+			pods:             newPods(5, false),
+			pdb:              newPDB("test", 0),
+			expectedDecision: utils.InPlaceApproved,
+			expectedReason:   utils.InPlaceReasonWithinTolerance,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			basicVpa := getIPORVpa()
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2 /* minReplicas */, 0, baseclocktest.NewFakeClock(time.Time{}), map[string]time.Time{}, GetFakeCalculatorsWithFakeResourceCalc(), false)
+			assert.NoError(t, err)
+			if !tc.noLister {
+				indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+				assert.NoError(t, indexer.Add(tc.pdb))
+				factory.(*PodsRestrictionFactoryImpl).pdbLister = policylister.NewPodDisruptionBudgetLister(indexer)
+			}
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(tc.pods, basicVpa)
+			assert.NoError(t, err)
+			inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			decision, reason := inplace.CanInPlaceUpdate(tc.pods[0])
+			assert.Equal(t, tc.expectedDecision, decision)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
+
+func TestInPlaceUpdate_ShrinkOnly(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	resourcePatch := func(field, resourceName, value string) resource_admission.PatchRecord {
+		return resource_admission.PatchRecord{
+			Op:    "add",
+			Path:  fmt.Sprintf("/spec/containers/0/resources/%s/%s", field, resourceName),
+			Value: value,
+		}
+	}
+	shrinkCPU := []resource_admission.PatchRecord{resourcePatch("requests", "cpu", "500m"), resourcePatch("limits", "cpu", "1")}
+	growMemory := []resource_admission.PatchRecord{resourcePatch("requests", "memory", "2Gi"), resourcePatch("limits", "memory", "4Gi")}
+	testCases := []struct {
+		name            string
+		shrinkOnly      bool
+		patches         []resource_admission.PatchRecord
+		expectedPatches []resource_admission.PatchRecord
+		expectError     bool
+	}{
+		{
+			name:            "only shrinking resources",
+			shrinkOnly:      true,
+			patches:         shrinkCPU,
+			expectedPatches: shrinkCPU,
+		},
+		{
+			name:        "shrinking and growing resources",
+			shrinkOnly:  true,
+			patches:     append(append([]resource_admission.PatchRecord{}, shrinkCPU...), growMemory...),
+			expectError: true,
+		},
+		{
+			name:        "only growing resources",
+			shrinkOnly:  true,
+			patches:     growMemory,
+			expectError: true,
+		},
+		{
+			name:            "growing resources are patched without shrink-only",
+			shrinkOnly:      false,
+			patches:         append(append([]resource_admission.PatchRecord{}, shrinkCPU...), growMemory...),
+			expectedPatches: append(append([]resource_admission.PatchRecord{}, shrinkCPU...), growMemory...),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(5)
+			rc := apiv1.ReplicationController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "default",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "ReplicationController",
+				},
+				Spec: apiv1.ReplicationControllerSpec{
+					Replicas: &replicas,
+				},
+			}
+			pods := make([]*apiv1.Pod, replicas)
+			for i := range pods {
+				pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					AddContainer(test.Container().WithName("container").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()).
+					Get()
+			}
+
+			client := fake.NewSimpleClientset()
+			var resizePatches [][]byte
+			client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				resizePatches = append(resizePatches, action.(core.PatchAction).GetPatch())
+				return true, pods[0], nil
+			})
+
+			vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("container").WithTarget("500m", "2Gi").Get()
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, []patch.Calculator{&fakeResizePatchCalculator{patches: tc.patches}}, false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).client = client
+			factory.(*PodsRestrictionFactoryImpl).inPlacePolicy = InPlacePolicy{ShrinkOnly: tc.shrinkOnly}
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+			assert.NoError(t, err)
+			inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			err = inplace.InPlaceUpdate(pods[0], vpa, test.FakeEventRecorder())
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInPlaceGrowthDisallowed)
+				assert.Empty(t, resizePatches, "the pod shouldn't be patched")
+				return
+			}
+			assert.NoError(t, err)
+			expected, err := json.Marshal(tc.expectedPatches)
+			assert.NoError(t, err)
+			if assert.NotEmpty(t, resizePatches) {
+				assert.JSONEq(t, string(expected), string(resizePatches[0]))
+			}
+		})
+	}
+}
+
+func TestInPlaceUpdate_PerContainerFallback(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	resourcePatch := func(container int, value string) resource_admission.PatchRecord {
+		return resource_admission.PatchRecord{
+			Op:    "add",
+			Path:  fmt.Sprintf("/spec/containers/%d/resources/requests/cpu", container),
+			Value: value,
+		}
+	}
+	testCases := []struct {
+		name                  string
+		rejectedContainers    []int
+		expectedResizePatches [][]resource_admission.PatchRecord
+		expectError           bool
+		expectedEvent         string
+	}{
+		{
+			name:                  "all containers are resized at once",
+			expectedResizePatches: [][]resource_admission.PatchRecord{{resourcePatch(0, "500m"), resourcePatch(1, "200m")}},
+			expectedEvent:         "Normal InPlaceResizedByVPA",
+		},
+		{
+			name:               "the other containers are resized if one is rejected",
+			rejectedContainers: []int{1},
+			expectedResizePatches: [][]resource_admission.PatchRecord{
+				{resourcePatch(0, "500m"), resourcePatch(1, "200m")},
+				{resourcePatch(0, "500m")},
+				{resourcePatch(1, "200m")},
+			},
+			expectedEvent: "Warning InPlaceResizePartiallyFailed VPA Updater failed to resize containers sidecar in place",
+		},
+		{
+			name:               "every container is rejected",
+			rejectedContainers: []int{0, 1},
+			expectedResizePatches: [][]resource_admission.PatchRecord{
+				{resourcePatch(0, "500m"), resourcePatch(1, "200m")},
+				{resourcePatch(0, "500m")},
+				{resourcePatch(1, "200m")},
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(5)
+			rc := apiv1.ReplicationController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "default",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "ReplicationController",
+				},
+				Spec: apiv1.ReplicationControllerSpec{
+					Replicas: &replicas,
+				},
+			}
+			pods := make([]*apiv1.Pod, replicas)
+			for i := range pods {
+				pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+					AddContainer(test.Container().WithName("app").WithCPURequest(resource.MustParse("1")).Get()).
+					AddContainer(test.Container().WithName("sidecar").WithCPURequest(resource.MustParse("100m")).Get()).
+					Get()
+			}
+
+			client := fake.NewSimpleClientset()
+			var resizePatches []string
+			client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				patch := string(action.(core.PatchAction).GetPatch())
+				if !strings.Contains(patch, "/spec/containers/") {
+					return true, pods[0], nil
+				}
+				resizePatches = append(resizePatches, patch)
+				for _, container := range tc.rejectedContainers {
+					if strings.Contains(patch, fmt.Sprintf("/spec/containers/%d/", container)) {
+						return true, nil, apierrors.NewInvalid(apiv1.SchemeGroupVersion.WithKind("Pod").GroupKind(), pods[0].Name, nil)
+					}
+				}
+				return true, pods[0], nil
+			})
+
+			vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("app").WithTarget("500m", "1Gi").Get()
+			calculator := &fakeResizePatchCalculator{patches: []resource_admission.PatchRecord{resourcePatch(0, "500m"), resourcePatch(1, "200m")}}
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, []patch.Calculator{calculator}, false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).client = client
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+			assert.NoError(t, err)
+			inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+			eventRecorder := record.NewFakeRecorder(10)
+
+			err = inplace.InPlaceUpdate(pods[0], vpa, eventRecorder)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if assert.Len(t, resizePatches, len(tc.expectedResizePatches)) {
+				for i, patches := range tc.expectedResizePatches {
+					expected, err := json.Marshal(patches)
+					assert.NoError(t, err)
+					assert.JSONEq(t, string(expected), resizePatches[i])
+				}
+			}
+			if tc.expectedEvent != "" {
+				select {
+				case event := <-eventRecorder.Events:
+					assert.True(t, strings.HasPrefix(event, tc.expectedEvent), "unexpected event %q", event)
+				default:
+					assert.Fail(t, "no event was recorded")
+				}
+			}
+		})
+	}
+}
+
+func TestInPlaceUpdate_UnsupportedResource(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	resourcePatch := func(field, resourceName, value string) resource_admission.PatchRecord {
+		return resource_admission.PatchRecord{
+			Op:    "add",
+			Path:  fmt.Sprintf("/spec/containers/0/resources/%s/%s", field, resourceName),
+			Value: value,
+		}
+	}
+	cpu := resourcePatch("requests", "cpu", "500m")
+	testCases := []struct {
+		name            string
+		patches         []resource_admission.PatchRecord
+		expectedPatches []resource_admission.PatchRecord
+		expectError     bool
+	}{
+		{
+			name:        "changed ephemeral-storage request",
+			patches:     []resource_admission.PatchRecord{cpu, resourcePatch("requests", "ephemeral-storage", "2Gi")},
+			expectError: true,
+		},
+		{
+			name:        "new ephemeral-storage limit",
+			patches:     []resource_admission.PatchRecord{cpu, resourcePatch("limits", "ephemeral-storage", "2Gi")},
+			expectError: true,
+		},
+		{
+			name:            "unchanged ephemeral-storage request is dropped",
+			patches:         []resource_admission.PatchRecord{cpu, resourcePatch("requests", "ephemeral-storage", "1Gi")},
+			expectedPatches: []resource_admission.PatchRecord{cpu},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicas := int32(5)
+			rc := apiv1.ReplicationController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "default",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "ReplicationController",
+				},
+				Spec: apiv1.ReplicationControllerSpec{
+					Replicas: &replicas,
+				},
+			}
+			pods := make([]*apiv1.Pod, replicas)
+			for i := range pods {
+				container := test.Container().WithName("container").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("1Gi")).Get()
+				container.Resources.Requests[apiv1.ResourceEphemeralStorage] = resource.MustParse("1Gi")
+				pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).AddContainer(container).Get()
+			}
+
+			client := fake.NewSimpleClientset()
+			var resizePatches [][]byte
+			client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+				resizePatches = append(resizePatches, action.(core.PatchAction).GetPatch())
+				return true, pods[0], nil
+			})
+
+			vpa := test.VerticalPodAutoscaler().WithName("vpa").WithContainer("container").WithTarget("500m", "1Gi").Get()
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, nil, nil, []patch.Calculator{&fakeResizePatchCalculator{patches: tc.patches}}, false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).client = client
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+			assert.NoError(t, err)
+			inplace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			err = inplace.InPlaceUpdate(pods[0], vpa, test.FakeEventRecorder())
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInPlaceUnsupportedResource)
+				assert.Empty(t, resizePatches, "the pod shouldn't be patched")
+				return
+			}
+			assert.NoError(t, err)
+			expected, err := json.Marshal(tc.expectedPatches)
+			assert.NoError(t, err)
+			if assert.NotEmpty(t, resizePatches) {
+				assert.JSONEq(t, string(expected), string(resizePatches[0]))
+			}
+		})
+	}
+}
+
+func TestCanInPlaceUpdate_ActuationGracePeriod(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	replicas := int32(3)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	pods := make([]*apiv1.Pod, replicas)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).Get()
+	}
+	pods[0].Status.Conditions = []apiv1.PodCondition{{
+		Type:   apiv1.PodResizePending,
+		Status: apiv1.ConditionTrue,
+		Reason: apiv1.PodReasonInfeasible,
+	}}
+
+	testCases := []struct {
+		name                    string
+		sinceLastAttempt        time.Duration
+		expectedInPlaceDecision utils.InPlaceDecision
+		expectedInPlaceReason   utils.InPlaceDecisionReason
+	}{
+		{
+			name:                    "failure reported right after the request is pending",
+			sinceLastAttempt:        0,
+			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeInProgress,
+		},
+		{
+			name:                    "failure reported just before the grace period passed is pending",
+			sinceLastAttempt:        time.Minute - time.Millisecond,
+			expectedInPlaceDecision: utils.InPlaceDeferred,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeInProgress,
+		},
+		{
+			name:                    "failure reported once the grace period passed falls back to eviction",
+			sinceLastAttempt:        time.Minute,
+			expectedInPlaceDecision: utils.InPlaceEvict,
+			expectedInPlaceReason:   utils.InPlaceReasonResizeFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lastInPlaceAttempt := time.UnixMilli(3600000)
+			clock := baseclocktest.NewFakeClock(lastInPlaceAttempt.Add(tc.sinceLastAttempt))
+			lipatm := map[string]time.Time{getPodID(pods[0]): lastInPlaceAttempt}
+
+			factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 0.5, clock, lipatm, GetFakeCalculatorsWithFakeResourceCalc(), false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).inPlacePolicy.ActuationGracePeriod = time.Minute
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, getIPORVpa())
+			assert.NoError(t, err)
+			inPlace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			decision, reason := inPlace.CanInPlaceUpdate(pods[0])
+			assert.Equal(t, tc.expectedInPlaceDecision, decision)
+			assert.Equal(t, tc.expectedInPlaceReason, reason)
+		})
+	}
+}
+
+func TestCanInPlaceUpdate_ContainerOptedOut(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, features.MutableFeatureGate, features.InPlaceOrRecreate, true)
+
+	replicas := int32(3)
+	rc := apiv1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rc",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicationController",
+		},
+		Spec: apiv1.ReplicationControllerSpec{
+			Replicas: &replicas,
+		},
+	}
+	newPod := func(name string, podAnnotations map[string]string) *apiv1.Pod {
+		return test.Pod().WithName(name).WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			AddContainer(test.Container().WithName("jvm").Get()).
+			AddContainer(test.Container().WithName("sidecar").Get()).
+			WithAnnotations(podAnnotations).Get()
+	}
+	optedOut := newPod("opted-out", map[string]string{annotations.VpaInPlaceDisabledContainersAnnotation: "jvm"})
+	unknownContainer := newPod("unknown-container", map[string]string{annotations.VpaInPlaceDisabledContainersAnnotation: "other"})
+	unmanagedContainer := newPod("unmanaged-container", map[string]string{annotations.VpaInPlaceDisabledContainersAnnotation: "sidecar"})
+	plain := newPod("plain", nil)
+	pods := []*apiv1.Pod{optedOut, unknownContainer, unmanagedContainer, plain}
+
+	// The VPA recommends resources for the sidecar, but its resource policy turns it off.
+	vpa := test.VerticalPodAutoscaler().WithContainer("jvm").WithTarget("1", "1Gi").WithContainer("sidecar").
+		WithScalingMode("sidecar", vpa_types.ContainerScalingModeOff).
+		AppendRecommendation(vpa_types.RecommendedContainerResources{
+			ContainerName: "sidecar",
+			Target:        apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m")},
+		}).
+		WithUpdateMode(vpa_types.UpdateModeInPlaceOrRecreate).Get()
+	factory, err := getRestrictionFactory(&rc, nil, nil, nil, 2, 1, nil, nil, GetFakeCalculatorsWithFakeResourceCalc(), false)
+	assert.NoError(t, err)
+	creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, vpa)
+	assert.NoError(t, err)
+	inPlace := factory.NewPodsInPlaceRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+	decision, reason := inPlace.CanInPlaceUpdate(optedOut)
+	assert.Equal(t, utils.InPlaceEvict, decision, "pods with a container opted out of in-place updates are evicted")
+	assert.Equal(t, utils.InPlaceReasonContainerOptedOut, reason)
+	assert.Error(t, inPlace.InPlaceUpdate(optedOut, vpa, test.FakeEventRecorder()))
+
+	decision, _ = inPlace.CanInPlaceUpdate(unknownContainer)
+	assert.Equal(t, utils.InPlaceApproved, decision, "containers the pod doesn't have are ignored")
+	decision, _ = inPlace.CanInPlaceUpdate(unmanagedContainer)
+	assert.Equal(t, utils.InPlaceApproved, decision, "containers the VPA doesn't update are ignored")
+	decision, _ = inPlace.CanInPlaceUpdate(plain)
+	assert.Equal(t, utils.InPlaceApproved, decision)
+}
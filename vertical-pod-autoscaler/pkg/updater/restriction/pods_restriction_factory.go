@@ -19,24 +19,32 @@ package restriction
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	appsinformer "k8s.io/client-go/informers/apps/v1"
 	coreinformer "k8s.io/client-go/informers/core/v1"
+	policyinformer "k8s.io/client-go/informers/policy/v1"
 	kube_client "k8s.io/client-go/kubernetes"
+	policylister "k8s.io/client-go/listers/policy/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
 )
 
 const (
 	resyncPeriod time.Duration = 1 * time.Minute
+	// defaultRecommenderName is the recommender that handles VPAs which don't select one explicitly.
+	defaultRecommenderName = "default"
 )
 
 // ControllerKind is the type of controller that can manage a pod.
@@ -48,8 +56,20 @@ const (
 	replicaSet            controllerKind = "ReplicaSet"
 	daemonSet             controllerKind = "DaemonSet"
 	job                   controllerKind = "Job"
+	// barePod is the kind of the group formed by a pod without a controller, see GroupingPolicy.UpdateBarePods.
+	barePod controllerKind = "Pod"
 )
 
+// GroupingPolicy configures which pods are grouped by their controller to be updated.
+type GroupingPolicy struct {
+	// UpdateBarePods makes each pod not managed by any controller form a group of its own, so that
+	// it's updated as well, even though an evicted pod is not recreated.
+	UpdateBarePods bool
+	// DeferPodsWithStrippedTolerations keeps pods missing a toleration of the pod template of their
+	// controller from being updated.
+	DeferPodsWithStrippedTolerations bool
+}
+
 type podReplicaCreator struct {
 	Namespace string
 	Name      string
@@ -70,16 +90,23 @@ type PodsRestrictionFactoryImpl struct {
 	ssInformer                  cache.SharedIndexInformer // informer for Stateful Sets
 	rsInformer                  cache.SharedIndexInformer // informer for Replica Sets
 	dsInformer                  cache.SharedIndexInformer // informer for Daemon Sets
+	pdbLister                   policylister.PodDisruptionBudgetLister
 	minReplicas                 int
 	evictionToleranceFraction   float64
 	clock                       clock.Clock
 	lastInPlaceAttemptTimeMap   map[string]time.Time
 	patchCalculators            []patch.Calculator
 	inPlaceSkipDisruptionBudget bool
+	resizeSubresource           string
+	evictionPolicy              EvictionPolicy
+	inPlacePolicy               InPlacePolicy
+	groupingPolicy              GroupingPolicy
+	// recommendationProcessor, if set, processes the recommendations the restrictions are based on.
+	recommendationProcessor vpa_api_util.RecommendationProcessor
 }
 
 // NewPodsRestrictionFactory creates a new PodsRestrictionFactory.
-func NewPodsRestrictionFactory(client kube_client.Interface, minReplicas int, evictionToleranceFraction float64, patchCalculators []patch.Calculator, inPlaceSkipDisruptionBudget bool) (PodsRestrictionFactory, error) {
+func NewPodsRestrictionFactory(client kube_client.Interface, minReplicas int, evictionToleranceFraction float64, patchCalculators []patch.Calculator, inPlaceSkipDisruptionBudget bool, evictionPolicy EvictionPolicy, inPlacePolicy InPlacePolicy, groupingPolicy GroupingPolicy, recommendationProcessor vpa_api_util.RecommendationProcessor) (PodsRestrictionFactory, error) {
 	rcInformer, err := setupInformer(client, replicationController)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create rcInformer: %v", err)
@@ -96,18 +123,31 @@ func NewPodsRestrictionFactory(client kube_client.Interface, minReplicas int, ev
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dsInformer: %v", err)
 	}
+	var pdbLister policylister.PodDisruptionBudgetLister
+	if inPlacePolicy.PreferAtDisruptionBudgetLimit {
+		pdbLister, err = setupPDBLister(client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create PodDisruptionBudget lister: %v", err)
+		}
+	}
 	return &PodsRestrictionFactoryImpl{
 		client:                      client,
 		rcInformer:                  rcInformer, // informer for Replication Controllers
 		ssInformer:                  ssInformer, // informer for Stateful Sets
 		rsInformer:                  rsInformer, // informer for Replica Sets
 		dsInformer:                  dsInformer, // informer for Daemon Sets
+		pdbLister:                   pdbLister,
 		minReplicas:                 minReplicas,
 		evictionToleranceFraction:   evictionToleranceFraction,
 		clock:                       &clock.RealClock{},
 		lastInPlaceAttemptTimeMap:   make(map[string]time.Time),
 		patchCalculators:            patchCalculators,
 		inPlaceSkipDisruptionBudget: inPlaceSkipDisruptionBudget,
+		resizeSubresource:           getResizeSubresource(client.Discovery(), inPlacePolicy.ResizeSubresource),
+		evictionPolicy:              evictionPolicy,
+		inPlacePolicy:               inPlacePolicy,
+		groupingPolicy:              groupingPolicy,
+		recommendationProcessor:     recommendationProcessor,
 	}, nil
 }
 
@@ -181,6 +221,50 @@ func (f *PodsRestrictionFactoryImpl) getReplicaCount(creator podReplicaCreator)
 	return 0, nil
 }
 
+// getPodTemplate returns the pod template of the given creator, or nil if it isn't available.
+func (f *PodsRestrictionFactoryImpl) getPodTemplate(creator podReplicaCreator) *apiv1.PodTemplateSpec {
+	var informer cache.SharedIndexInformer
+	switch creator.Kind {
+	case replicationController:
+		informer = f.rcInformer
+	case replicaSet:
+		informer = f.rsInformer
+	case statefulSet:
+		informer = f.ssInformer
+	case daemonSet:
+		informer = f.dsInformer
+	default:
+		return nil
+	}
+	obj, exists, err := informer.GetStore().GetByKey(creator.Namespace + "/" + creator.Name)
+	if err != nil || !exists {
+		return nil
+	}
+	switch controller := obj.(type) {
+	case *apiv1.ReplicationController:
+		return controller.Spec.Template
+	case *appsv1.ReplicaSet:
+		return &controller.Spec.Template
+	case *appsv1.StatefulSet:
+		return &controller.Spec.Template
+	case *appsv1.DaemonSet:
+		return &controller.Spec.Template
+	}
+	return nil
+}
+
+// hasStrippedTolerations checks whether the pod lacks any of the tolerations of its pod template.
+func hasStrippedTolerations(pod *apiv1.Pod, template *apiv1.PodTemplateSpec) bool {
+	for i := range template.Spec.Tolerations {
+		if !slices.ContainsFunc(pod.Spec.Tolerations, func(toleration apiv1.Toleration) bool {
+			return toleration.MatchToleration(&template.Spec.Tolerations[i])
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetCreatorMaps is a helper function that returns a map of pod replica creators to their single group stats
 // and a map of pod ids to pod replica creator from a list of pods and it's corresponding VPA.
 func (f *PodsRestrictionFactoryImpl) GetCreatorMaps(pods []*apiv1.Pod, vpa *vpa_types.VerticalPodAutoscaler) (map[podReplicaCreator]singleGroupStats, map[string]podReplicaCreator, error) {
@@ -193,8 +277,12 @@ func (f *PodsRestrictionFactoryImpl) GetCreatorMaps(pods []*apiv1.Pod, vpa *vpa_
 			continue
 		}
 		if creator == nil {
-			klog.V(0).InfoS("Pod is not managed by any controller", "pod", klog.KObj(pod))
-			continue
+			if !f.groupingPolicy.UpdateBarePods {
+				klog.V(4).InfoS("Pod is not managed by any controller", "pod", klog.KObj(pod))
+				continue
+			}
+			// Each bare pod forms a group of its own.
+			creator = &podReplicaCreator{Namespace: pod.Namespace, Name: pod.Name, Kind: barePod}
 		}
 		livePods[*creator] = append(livePods[*creator], pod)
 	}
@@ -211,14 +299,14 @@ func (f *PodsRestrictionFactoryImpl) GetCreatorMaps(pods []*apiv1.Pod, vpa *vpa_
 
 	for creator, replicas := range livePods {
 		actual := len(replicas)
-		if actual < required {
+		if actual < required && creator.Kind != barePod {
 			klog.V(2).InfoS("Too few replicas", "kind", creator.Kind, "object", klog.KRef(creator.Namespace, creator.Name), "livePods", actual, "requiredPods", required, "globalMinReplicas", f.minReplicas)
 			continue
 		}
 
 		var configured int
-		if creator.Kind == job {
-			// Job has no replicas configuration, so we will use actual number of live pods as replicas count.
+		if creator.Kind == job || creator.Kind == barePod {
+			// Jobs and bare pods have no replicas configuration, so we will use actual number of live pods as replicas count.
 			configured = actual
 		} else {
 			var err error
@@ -232,18 +320,50 @@ func (f *PodsRestrictionFactoryImpl) GetCreatorMaps(pods []*apiv1.Pod, vpa *vpa_
 		singleGroup := singleGroupStats{}
 		singleGroup.configured = configured
 		singleGroup.evictionTolerance = int(float64(configured) * f.evictionToleranceFraction) // truncated
+		var template *apiv1.PodTemplateSpec
+		if f.groupingPolicy.DeferPodsWithStrippedTolerations {
+			template = f.getPodTemplate(creator)
+		}
 		for _, pod := range replicas {
-			podToReplicaCreatorMap[getPodID(pod)] = creator
-			if pod.Status.Phase == apiv1.PodPending {
+			if template != nil && hasStrippedTolerations(pod, template) {
+				// The pod is still counted in the group stats, but is not considered for update,
+				// as a recreated or resized pod might lose the tolerations as well and become unschedulable.
+				klog.V(2).InfoS("Deferring update of pod with tolerations missing from its pod template", "pod", klog.KObj(pod), "kind", creator.Kind, "object", klog.KRef(creator.Namespace, creator.Name))
+			} else {
+				podToReplicaCreatorMap[getPodID(pod)] = creator
+			}
+			pending := pod.Status.Phase == apiv1.PodPending
+			if pending {
 				singleGroup.pending = singleGroup.pending + 1
 			}
+			if containers := f.inPlaceDisabledContainers(vpa, pod); len(containers) > 0 {
+				if singleGroup.inPlaceDisabledContainers == nil {
+					singleGroup.inPlaceDisabledContainers = make(map[string][]string)
+				}
+				singleGroup.inPlaceDisabledContainers[getPodID(pod)] = containers
+			}
 			if isInPlaceUpdating(pod) {
 				singleGroup.inPlaceUpdateOngoing = singleGroup.inPlaceUpdateOngoing + 1
+			} else if !pending && isPodReady(pod) {
+				singleGroup.available = singleGroup.available + 1
 			}
 		}
 		singleGroup.running = len(replicas) - singleGroup.pending
 		creatorToSingleGroupStatsMap[creator] = singleGroup
 	}
+
+	budget, err := newVpaDisruptionBudget(vpa, creatorToSingleGroupStatsMap)
+	if err != nil {
+		// The updater reports the invalid budget on the VPA.
+		klog.V(4).InfoS("Blocking evictions of VPA with an invalid disruption budget", "vpa", klog.KObj(vpa), "error", err)
+	}
+	if budget != nil {
+		// All the groups share the budget, so that it caps the disruptions across the VPA.
+		for creator, singleGroup := range creatorToSingleGroupStatsMap {
+			singleGroup.vpaBudget = budget
+			creatorToSingleGroupStatsMap[creator] = singleGroup
+		}
+	}
 	return creatorToSingleGroupStatsMap, podToReplicaCreatorMap, nil
 }
 
@@ -255,6 +375,8 @@ func (f *PodsRestrictionFactoryImpl) NewPodsEvictionRestriction(creatorToSingleG
 		creatorToSingleGroupStatsMap: creatorToSingleGroupStatsMap,
 		clock:                        f.clock,
 		lastInPlaceAttemptTimeMap:    f.lastInPlaceAttemptTimeMap,
+		evictionPolicy:               f.evictionPolicy,
+		inPlaceActuationGracePeriod:  f.inPlacePolicy.ActuationGracePeriod,
 	}
 }
 
@@ -268,6 +390,9 @@ func (f *PodsRestrictionFactoryImpl) NewPodsInPlaceRestriction(creatorToSingleGr
 		lastInPlaceAttemptTimeMap:    f.lastInPlaceAttemptTimeMap,
 		patchCalculators:             f.patchCalculators,
 		inPlaceSkipDisruptionBudget:  f.inPlaceSkipDisruptionBudget,
+		resizeSubresource:            f.resizeSubresource,
+		pdbLister:                    f.pdbLister,
+		inPlacePolicy:                f.inPlacePolicy,
 	}
 }
 
@@ -278,6 +403,20 @@ func getPodID(pod *apiv1.Pod) string {
 	return pod.Namespace + "/" + pod.Name
 }
 
+// recommendationProvenance describes which recommender produced the recommendation of the given VPA and when
+// it last updated it, so it can be included in the messages of the events emitted when the recommendation is applied.
+func recommendationProvenance(vpa *vpa_types.VerticalPodAutoscaler) string {
+	recommender := defaultRecommenderName
+	if len(vpa.Spec.Recommenders) > 0 && vpa.Spec.Recommenders[0] != nil {
+		recommender = vpa.Spec.Recommenders[0].Name
+	}
+	updatedAt := "unknown time"
+	if lastUpdateTime, found := vpa_api_util.RecommendationLastUpdateTime(vpa); found {
+		updatedAt = lastUpdateTime.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("recommender %q, last updated at %s", recommender, updatedAt)
+}
+
 func getPodReplicaCreator(pod *apiv1.Pod) (*podReplicaCreator, error) {
 	creator := managingControllerRef(pod)
 	if creator == nil {
@@ -292,14 +431,12 @@ func getPodReplicaCreator(pod *apiv1.Pod) (*podReplicaCreator, error) {
 }
 
 func managingControllerRef(pod *apiv1.Pod) *metav1.OwnerReference {
-	var managingController metav1.OwnerReference
 	for _, ownerReference := range pod.GetOwnerReferences() {
-		if *ownerReference.Controller {
-			managingController = ownerReference
-			break
+		if ownerReference.Controller != nil && *ownerReference.Controller {
+			return &ownerReference
 		}
 	}
-	return &managingController
+	return nil
 }
 
 func setupInformer(kubeClient kube_client.Interface, kind controllerKind) (cache.SharedIndexInformer, error) {
@@ -329,6 +466,17 @@ func setupInformer(kubeClient kube_client.Interface, kind controllerKind) (cache
 	return informer, nil
 }
 
+func setupPDBLister(kubeClient kube_client.Interface) (policylister.PodDisruptionBudgetLister, error) {
+	informer := policyinformer.NewPodDisruptionBudgetInformer(kubeClient, apiv1.NamespaceAll,
+		resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	stopCh := make(chan struct{})
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return nil, fmt.Errorf("failed to sync PodDisruptionBudget cache")
+	}
+	return policylister.NewPodDisruptionBudgetLister(informer.GetIndexer()), nil
+}
+
 type singleGroupStats struct {
 	configured             int
 	pending                int
@@ -337,6 +485,10 @@ type singleGroupStats struct {
 	evicted                int
 	inPlaceUpdateOngoing   int // number of pods from last loop that are still in-place updating
 	inPlaceUpdateInitiated int // number of pods from the current loop that have newly requested in-place resize
+	available              int // number of pods which are ready and not resizing in place
+	// inPlaceDisabledContainers holds the managed containers of pods annotated not to be resized in place, by pod ID.
+	inPlaceDisabledContainers map[string][]string
+	vpaBudget                 *vpaDisruptionBudget // shared by all the groups of the VPA, nil if the VPA sets none
 }
 
 // isPodDisruptable checks if all pods are running and eviction tolerance is small, we can
@@ -349,6 +501,106 @@ func (s *singleGroupStats) isPodDisruptable() bool {
 	// we don't want to block pods from being considered for eviction if tolerance is small and some pods are potentially stuck resizing
 }
 
+// vpaDisruptionBudget caps the number of pods of a VPA evicted while too many of its pods are unavailable,
+// across all of its groups and independently of any PodDisruptionBudget. It is set by the
+// VpaMaxUnavailableAnnotation or the VpaMinAvailableAnnotation of the VPA, and applies on top of
+// the eviction tolerance of each group.
+type vpaDisruptionBudget struct {
+	// allowed is the number of pods which may be evicted in this loop.
+	allowed int
+	evicted int
+}
+
+// newVpaDisruptionBudget returns the budget set by the annotations of the VPA, or nil if it sets none.
+// Percentages are relative to the configured replicas of the groups, pods which aren't ready or are
+// resizing in place count as unavailable. An invalid budget allows no evictions and is returned with
+// the error, so that a typo doesn't lift the limit the VPA meant to set.
+func newVpaDisruptionBudget(vpa *vpa_types.VerticalPodAutoscaler, creatorToSingleGroupStatsMap map[podReplicaCreator]singleGroupStats) (*vpaDisruptionBudget, error) {
+	maxUnavailable, minAvailable, err := annotations.GetVpaDisruptionBudget(vpa.Annotations)
+	if err != nil {
+		return &vpaDisruptionBudget{}, err
+	}
+	if maxUnavailable == nil && minAvailable == nil {
+		return nil, nil
+	}
+	expected, available := 0, 0
+	for _, singleGroup := range creatorToSingleGroupStatsMap {
+		expected += singleGroup.configured
+		available += singleGroup.available
+	}
+	budget := &vpaDisruptionBudget{}
+	if maxUnavailable != nil {
+		allowedUnavailable, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, expected, true)
+		if err != nil {
+			return budget, err
+		}
+		budget.allowed = allowedUnavailable - (expected - available)
+	} else {
+		requiredAvailable, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, expected, true)
+		if err != nil {
+			return budget, err
+		}
+		budget.allowed = available - requiredAvailable
+	}
+	klog.V(4).InfoS("Disruption budget of VPA", "vpa", klog.KObj(vpa), "expectedPods", expected, "availablePods", available, "allowedEvictions", budget.allowed)
+	return budget, nil
+}
+
+// allows returns true if one more pod may be evicted within the budget.
+func (b *vpaDisruptionBudget) allows() bool {
+	return b == nil || b.evicted < b.allowed
+}
+
+// recordEviction charges an eviction against the budget.
+func (b *vpaDisruptionBudget) recordEviction() {
+	if b != nil {
+		b.evicted++
+	}
+}
+
+// inPlaceDisabledContainers returns the containers of the pod which are annotated not to be resized in place and
+// are managed by the VPA, i.e. have a recommendation and aren't turned off by its resource policy. Containers the
+// VPA doesn't update don't keep the pod from being resized in place.
+func (f *PodsRestrictionFactoryImpl) inPlaceDisabledContainers(vpa *vpa_types.VerticalPodAutoscaler, pod *apiv1.Pod) []string {
+	names := annotations.GetVpaInPlaceDisabledContainers(pod.Annotations)
+	if len(names) == 0 {
+		return nil
+	}
+	recommendation := vpa.Status.Recommendation
+	if f.recommendationProcessor != nil {
+		processed, _, err := f.recommendationProcessor.Apply(vpa, pod)
+		if err != nil {
+			klog.V(4).InfoS("Cannot process recommendation for pod", "pod", klog.KObj(pod), "error", err)
+		} else {
+			recommendation = processed
+		}
+	}
+	var disabled []string
+	for _, name := range names {
+		if !slices.ContainsFunc(pod.Spec.Containers, func(container apiv1.Container) bool { return container.Name == name }) {
+			continue
+		}
+		if policy := vpa_api_util.GetContainerResourcePolicy(name, vpa.Spec.ResourcePolicy); policy != nil && policy.Mode != nil && *policy.Mode == vpa_types.ContainerScalingModeOff {
+			continue
+		}
+		if vpa_api_util.GetRecommendationForContainer(name, recommendation) == nil {
+			continue
+		}
+		disabled = append(disabled, name)
+	}
+	return disabled
+}
+
+// isPodReady checks whether the given pod reports the Ready condition.
+func isPodReady(pod *apiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // isInPlaceUpdating checks whether or not the given pod is currently in the middle of an in-place update
 func isInPlaceUpdating(podToCheck *apiv1.Pod) bool {
 	for _, c := range podToCheck.Status.Conditions {
@@ -356,5 +608,11 @@ func isInPlaceUpdating(podToCheck *apiv1.Pod) bool {
 			return c.Status == apiv1.ConditionTrue
 		}
 	}
-	return false
+	return isLegacyResizeInProgress(podToCheck)
+}
+
+// isLegacyResizeInProgress checks whether the pod reports an in-progress resize in the
+// deprecated resize status, which kubelets older than 1.33 set instead of the resize conditions.
+func isLegacyResizeInProgress(pod *apiv1.Pod) bool {
+	return pod.Status.Resize == apiv1.PodResizeStatusInProgress //nolint:staticcheck
 }
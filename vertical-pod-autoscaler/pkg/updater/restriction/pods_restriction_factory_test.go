@@ -222,7 +222,7 @@ func TestDisruptReplicatedByController(t *testing.T) {
 					evictionSuccess: false,
 				},
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
@@ -245,17 +245,17 @@ func TestDisruptReplicatedByController(t *testing.T) {
 					evictionSuccess: false,
 				},
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
 				{
-					pod:             generatePod().WithPhase(apiv1.PodPending).Get(),
+					pod:             generatePod().WithPhase(apiv1.PodPending).WithNodeName("node").Get(),
 					canEvict:        true,
 					evictionSuccess: true,
 				},
@@ -510,7 +510,8 @@ func TestDisruptReplicatedByController(t *testing.T) {
 			updateMode := vpa_api_util.GetUpdateMode(testCase.vpa)
 			for i, p := range testCase.pods {
 				if updateMode == vpa_types.UpdateModeInPlaceOrRecreate {
-					assert.Equalf(t, p.canInPlaceUpdate, inplace.CanInPlaceUpdate(p.pod), "unexpected CanInPlaceUpdate result for pod-%v %#v", testCase.name, i, p.pod)
+					decision, _ := inplace.CanInPlaceUpdate(p.pod)
+					assert.Equalf(t, p.canInPlaceUpdate, decision, "unexpected CanInPlaceUpdate result for pod-%v %#v", testCase.name, i, p.pod)
 				} else {
 					assert.Equalf(t, p.canEvict, eviction.CanEvict(p.pod), "unexpected CanEvict result for pod-%v %#v", i, p.pod)
 				}
@@ -703,6 +704,77 @@ func TestEvictReplicatedByJob(t *testing.T) {
 	}
 }
 
+func TestDeferPodsWithStrippedTolerations(t *testing.T) {
+	replicas := int32(5)
+	livePods := 5
+	toleration := apiv1.Toleration{Key: "dedicated", Operator: apiv1.TolerationOpEqual, Value: "batch", Effect: apiv1.TaintEffectNoSchedule}
+
+	rs := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rs",
+			Namespace: "default",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ReplicaSet",
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+			Template: apiv1.PodTemplateSpec{
+				Spec: apiv1.PodSpec{Tolerations: []apiv1.Toleration{toleration}},
+			},
+		},
+	}
+
+	pods := make([]*apiv1.Pod, livePods)
+	for i := range pods {
+		pods[i] = test.Pod().WithName(getTestPodName(i)).WithCreator(&rs.ObjectMeta, &rs.TypeMeta).Get()
+		pods[i].Spec.Tolerations = []apiv1.Toleration{toleration}
+	}
+	// A webhook removed the toleration from the first pod.
+	pods[0].Spec.Tolerations = nil
+
+	for _, deferPods := range []bool{false, true} {
+		t.Run(fmt.Sprintf("defer-pods-with-stripped-tolerations=%v", deferPods), func(t *testing.T) {
+			basicVpa := getBasicVpa()
+			factory, err := getRestrictionFactory(nil, &rs, nil, nil, 2, 0.5, nil, nil, nil, false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).groupingPolicy.DeferPodsWithStrippedTolerations = deferPods
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps(pods, basicVpa)
+			assert.NoError(t, err)
+			eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			assert.Equal(t, !deferPods, eviction.CanEvict(pods[0]))
+			for _, pod := range pods[1:] {
+				assert.True(t, eviction.CanEvict(pod))
+			}
+		})
+	}
+}
+
+func TestEvictBarePods(t *testing.T) {
+	barePod := test.Pod().WithName("bare-pod").Get()
+
+	for _, evictBare := range []bool{false, true} {
+		t.Run(fmt.Sprintf("evict-bare-pods=%v", evictBare), func(t *testing.T) {
+			basicVpa := getBasicVpa()
+			factory, err := getRestrictionFactory(nil, nil, nil, nil, 2, 0.5, nil, nil, nil, false)
+			assert.NoError(t, err)
+			factory.(*PodsRestrictionFactoryImpl).groupingPolicy.UpdateBarePods = evictBare
+			creatorToSingleGroupStatsMap, podToReplicaCreatorMap, err := factory.GetCreatorMaps([]*apiv1.Pod{barePod}, basicVpa)
+			assert.NoError(t, err)
+			eviction := factory.NewPodsEvictionRestriction(creatorToSingleGroupStatsMap, podToReplicaCreatorMap)
+
+			assert.Equal(t, evictBare, eviction.CanEvict(barePod))
+			err = eviction.Evict(barePod, basicVpa, test.FakeEventRecorder())
+			if evictBare {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
 func getRestrictionFactory(rc *apiv1.ReplicationController, rs *appsv1.ReplicaSet,
 	ss *appsv1.StatefulSet, ds *appsv1.DaemonSet, minReplicas int,
 	evictionToleranceFraction float64, clock clock.Clock, lipuatm map[string]time.Time, patchCalculators []patch.Calculator, inPlaceSkipDisruptionBudget bool) (PodsRestrictionFactory, error) {
@@ -752,6 +824,7 @@ func getRestrictionFactory(rc *apiv1.ReplicationController, rs *appsv1.ReplicaSe
 		lastInPlaceAttemptTimeMap:   lipuatm,
 		patchCalculators:            patchCalculators,
 		inPlaceSkipDisruptionBudget: inPlaceSkipDisruptionBudget,
+		resizeSubresource:           resizeSubresource,
 	}, nil
 }
 
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/logic"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
+	metrics_updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics/updater"
+)
+
+// runUpdaterLoop runs a loop of the updater every interval until stop is closed, calling
+// onLoopCompleted with the errors of each loop after it. A loop running when stop is closed is drained: it doesn't
+// act on further pods, but the updates it already started get up to gracePeriod to complete
+// before its context is cancelled.
+func runUpdaterLoop(u updater.Updater, interval, gracePeriod time.Duration, stop <-chan struct{}, onLoopCompleted func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		done := make(chan struct{})
+		var loopErr error
+		go func() {
+			defer close(done)
+			loopErr = u.RunOnce(ctx)
+		}()
+		select {
+		case <-done:
+			cancel()
+			onLoopCompleted(loopErr)
+		case <-stop:
+			klog.V(0).InfoS("Shutting down, waiting for the updates in flight to complete", "gracePeriod", gracePeriod)
+			u.Drain()
+			select {
+			case <-done:
+			case <-time.After(gracePeriod):
+				klog.V(0).InfoS("Updates in flight didn't complete within the grace period, aborting them")
+				cancel()
+				<-done
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// onLoopCompleted returns the callback of runUpdaterLoop which marks the updater alive and ready after each
+// loop. Loops which ran into errors count too, as these are often about a single VPA, e.g. one whose target
+// was deleted, and readiness is meant to detect loops which hang. Their errors are counted by a metric instead.
+func onLoopCompleted(healthCheck *metrics.HealthCheck, readinessCheck *metrics.ReadinessCheck) func(err error) {
+	return func(err error) {
+		healthCheck.UpdateLastActivity()
+		readinessCheck.MarkLoopCompleted()
+		if err != nil {
+			metrics_updater.RecordLoopWithErrors()
+		}
+	}
+}
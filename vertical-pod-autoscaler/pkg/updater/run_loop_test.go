@@ -0,0 +1,157 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
+)
+
+// inFlightUpdater blocks in RunOnce, as if it was evicting a pod, until released or its context is done.
+type inFlightUpdater struct {
+	started  chan struct{}
+	release  chan struct{}
+	drained  atomic.Bool
+	loopErrs chan error
+}
+
+func newInFlightUpdater() *inFlightUpdater {
+	return &inFlightUpdater{
+		started:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		loopErrs: make(chan error, 1),
+	}
+}
+
+func (f *inFlightUpdater) RunOnce(ctx context.Context) error {
+	f.started <- struct{}{}
+	select {
+	case <-f.release:
+		f.loopErrs <- nil
+	case <-ctx.Done():
+		f.loopErrs <- ctx.Err()
+	}
+	return nil
+}
+
+func (f *inFlightUpdater) ProcessErroredVpas(context.Context) {}
+
+func (f *inFlightUpdater) Shutdown() {}
+
+func (f *inFlightUpdater) Drain() {
+	f.drained.Store(true)
+}
+
+func TestRunUpdaterLoop_DrainsInFlightUpdatesOnTermination(t *testing.T) {
+	u := newInFlightUpdater()
+	stop := make(chan struct{})
+	returned := make(chan struct{})
+	go func() {
+		// The interval bounds the loop too, it's long enough for the test to release the update in flight before.
+		runUpdaterLoop(u, 500*time.Millisecond, time.Minute, stop, func(error) {})
+		close(returned)
+	}()
+
+	<-u.started
+	close(stop)
+	assert.Eventually(t, u.drained.Load, 5*time.Second, time.Millisecond, "the loop stops acting on further pods")
+	select {
+	case <-returned:
+		assert.Fail(t, "the loop returned before the update in flight completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(u.release)
+	<-returned
+	assert.NoError(t, <-u.loopErrs, "the update in flight completed undisturbed")
+}
+
+func TestRunUpdaterLoop_AbortsInFlightUpdatesAfterGracePeriod(t *testing.T) {
+	u := newInFlightUpdater()
+	stop := make(chan struct{})
+	returned := make(chan struct{})
+	go func() {
+		// The interval bounds the loop too, it's longer than the grace period so that the update is cancelled by the latter.
+		runUpdaterLoop(u, 500*time.Millisecond, 10*time.Millisecond, stop, func(error) {})
+		close(returned)
+	}()
+
+	<-u.started
+	close(stop)
+	<-returned
+	assert.True(t, u.drained.Load())
+	assert.ErrorIs(t, <-u.loopErrs, context.Canceled, "the update in flight is cancelled once the grace period passed")
+}
+
+func TestRunUpdaterLoop_StopsBetweenLoops(t *testing.T) {
+	u := &fakeUpdater{}
+	stop := make(chan struct{})
+	close(stop)
+	runUpdaterLoop(u, time.Hour, time.Minute, stop, func(error) {})
+	assert.Equal(t, 0, u.loops)
+}
+
+func TestRunUpdaterLoop_ReportsLoopErrors(t *testing.T) {
+	u := &fakeUpdater{err: errors.New("eviction failed")}
+	stop := make(chan struct{})
+	loopErrs := make(chan error, 1)
+	go func() {
+		defer close(loopErrs)
+		runUpdaterLoop(u, 10*time.Millisecond, time.Minute, stop, func(err error) {
+			select {
+			case loopErrs <- err:
+			default:
+			}
+		})
+	}()
+
+	assert.EqualError(t, <-loopErrs, "eviction failed", "the errors of the loop are passed on, so that it doesn't count as successful")
+	close(stop)
+	for range loopErrs {
+	}
+}
+
+func TestRunUpdaterLoop_ReadyDespiteLoopErrors(t *testing.T) {
+	// The selector of one VPA can't be fetched because its target was deleted, which fails every loop.
+	u := &fakeUpdater{err: fmt.Errorf("failed to fetch selector of VPA default/vpa: %w", errors.New("deployments.apps \"app\" not found"))}
+	healthCheck := metrics.NewHealthCheck(time.Minute)
+	readinessCheck := metrics.NewReadinessCheck(time.Minute)
+	stop := make(chan struct{})
+	returned := make(chan struct{})
+	go func() {
+		runUpdaterLoop(u, 10*time.Millisecond, time.Minute, stop, onLoopCompleted(healthCheck, readinessCheck))
+		close(returned)
+	}()
+
+	assert.Eventually(t, func() bool {
+		recorder := httptest.NewRecorder()
+		readinessCheck.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return recorder.Code == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond, "loops with errors make the updater ready")
+	close(stop)
+	<-returned
+}
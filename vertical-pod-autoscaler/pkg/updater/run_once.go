@@ -0,0 +1,33 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"time"
+
+	updater "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/logic"
+)
+
+// runUpdaterOnce runs a single loop of the updater, e.g. from a CronJob, and returns the
+// errors it ran into once the events it recorded were handed over.
+func runUpdaterOnce(u updater.Updater, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	defer u.Shutdown()
+	return u.RunOnce(ctx)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUpdater struct {
+	err       error
+	loops     int
+	shutdowns int
+	deadline  time.Time
+}
+
+func (f *fakeUpdater) RunOnce(ctx context.Context) error {
+	f.loops++
+	f.deadline, _ = ctx.Deadline()
+	return f.err
+}
+
+func (f *fakeUpdater) ProcessErroredVpas(context.Context) {}
+
+func (f *fakeUpdater) Shutdown() {
+	f.shutdowns++
+}
+
+func (f *fakeUpdater) Drain() {}
+
+func TestRunUpdaterOnce(t *testing.T) {
+	u := &fakeUpdater{}
+	start := time.Now()
+	assert.NoError(t, runUpdaterOnce(u, time.Minute))
+	assert.Equal(t, 1, u.loops, "exactly one loop is run")
+	assert.Equal(t, 1, u.shutdowns, "pending events are flushed before exiting")
+	assert.WithinDuration(t, start.Add(time.Minute), u.deadline, 5*time.Second)
+}
+
+func TestRunUpdaterOnce_ReturnsLoopErrors(t *testing.T) {
+	loopErr := errors.New("eviction failed")
+	u := &fakeUpdater{err: loopErr}
+	assert.ErrorIs(t, runUpdaterOnce(u, time.Minute), loopErr)
+	assert.Equal(t, 1, u.loops)
+	assert.Equal(t, 1, u.shutdowns)
+}
@@ -27,3 +27,31 @@ const (
 	// InPlaceEvict means we will attempt to evict the pod.
 	InPlaceEvict InPlaceDecision = "InPlaceEvict"
 )
+
+// InPlaceDecisionReason explains why an InPlaceDecision was made.
+type InPlaceDecisionReason string
+
+const (
+	// InPlaceReasonFeatureDisabled means the InPlaceOrRecreate feature gate is disabled.
+	InPlaceReasonFeatureDisabled InPlaceDecisionReason = "FeatureDisabled"
+	// InPlaceReasonContainerOptedOut means a container of the pod is annotated not to be resized in place.
+	InPlaceReasonContainerOptedOut InPlaceDecisionReason = "ContainerOptedOut"
+	// InPlaceReasonUnknownCreator means the pod doesn't belong to a replica set known to the updater.
+	InPlaceReasonUnknownCreator InPlaceDecisionReason = "UnknownCreator"
+	// InPlaceReasonPodPending means the pod isn't running yet.
+	InPlaceReasonPodPending InPlaceDecisionReason = "PodPending"
+	// InPlaceReasonResizeInProgress means a previous resize of the pod didn't complete yet.
+	InPlaceReasonResizeInProgress InPlaceDecisionReason = "ResizeInProgress"
+	// InPlaceReasonResizeFailed means a previous resize of the pod failed, or didn't complete in time.
+	InPlaceReasonResizeFailed InPlaceDecisionReason = "ResizeFailed"
+	// InPlaceReasonDisruptionBudgetSkipped means the resize doesn't restart containers and
+	// in-place-skip-disruption-budget is enabled.
+	InPlaceReasonDisruptionBudgetSkipped InPlaceDecisionReason = "DisruptionBudgetSkipped"
+	// InPlaceReasonAtDisruptionBudgetLimit means a previous resize of the pod failed, but the
+	// PodDisruptionBudget of the pod allows no disruptions, so it isn't evicted.
+	InPlaceReasonAtDisruptionBudgetLimit InPlaceDecisionReason = "AtDisruptionBudgetLimit"
+	// InPlaceReasonWithinTolerance means updating the pod keeps enough pods of its replica set alive.
+	InPlaceReasonWithinTolerance InPlaceDecisionReason = "WithinTolerance"
+	// InPlaceReasonToleranceExceeded means updating the pod would leave too few pods of its replica set alive.
+	InPlaceReasonToleranceExceeded InPlaceDecisionReason = "ToleranceExceeded"
+)
@@ -0,0 +1,24 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+const (
+	// VpaBlockedReasonAnnotation is a pod annotation set by the updater, when asked to, explaining why it
+	// didn't update the pod in its last loop although the pod needs an update. It's removed once the pod
+	// can be updated.
+	VpaBlockedReasonAnnotation = "vpa-blocked-reason.k8s.io"
+)
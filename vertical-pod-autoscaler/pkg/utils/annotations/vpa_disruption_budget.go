@@ -0,0 +1,63 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// VpaMaxUnavailableAnnotation is a VPA annotation setting how many of the pods of the VPA may be
+	// unavailable at the same time while the updater evicts them, as a number or a percentage of the pods.
+	VpaMaxUnavailableAnnotation = "vpa-max-unavailable.k8s.io"
+	// VpaMinAvailableAnnotation is a VPA annotation setting how many of the pods of the VPA must stay
+	// available while the updater evicts them, as a number or a percentage of the pods.
+	VpaMinAvailableAnnotation = "vpa-min-available.k8s.io"
+)
+
+// GetVpaDisruptionBudget returns the budget set by the VpaMaxUnavailableAnnotation or the
+// VpaMinAvailableAnnotation of the given VPA annotations. Both are nil if neither is set,
+// at most one of them is set at the same time.
+func GetVpaDisruptionBudget(vpaAnnotations map[string]string) (maxUnavailable, minAvailable *intstr.IntOrString, err error) {
+	maxUnavailable, err = parseDisruptionBudgetValue(vpaAnnotations, VpaMaxUnavailableAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	minAvailable, err = parseDisruptionBudgetValue(vpaAnnotations, VpaMinAvailableAnnotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if maxUnavailable != nil && minAvailable != nil {
+		return nil, nil, fmt.Errorf("annotations %s and %s are mutually exclusive", VpaMaxUnavailableAnnotation, VpaMinAvailableAnnotation)
+	}
+	return maxUnavailable, minAvailable, nil
+}
+
+func parseDisruptionBudgetValue(vpaAnnotations map[string]string, annotation string) (*intstr.IntOrString, error) {
+	value, found := vpaAnnotations[annotation]
+	if !found {
+		return nil, nil
+	}
+	budget := intstr.Parse(value)
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(&budget, 100, true)
+	if err != nil || scaled < 0 {
+		return nil, fmt.Errorf("invalid value %q of annotation %s, expected a non-negative number or percentage", value, annotation)
+	}
+	return &budget, nil
+}
@@ -0,0 +1,29 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+const (
+	// VpaEvictNowAnnotation is a pod annotation which, when set to "true", makes the updater act on
+	// the pod before all other pods of its VPA, without waiting for the pod to age. The pod still
+	// needs to be eligible for an update, so the annotation is ignored once the pod got its recommendation.
+	VpaEvictNowAnnotation = "vpa-evict-now.k8s.io"
+)
+
+// IsVpaEvictNowRequested returns true if the given pod annotations request an immediate update.
+func IsVpaEvictNowRequested(podAnnotations map[string]string) bool {
+	return podAnnotations[VpaEvictNowAnnotation] == "true"
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// VpaEvictionGracePeriodAnnotation is a pod annotation setting, in seconds, the termination grace period
+	// given to the pod when the updater evicts it. It takes precedence over the grace period set for all pods.
+	VpaEvictionGracePeriodAnnotation = "vpa-eviction-grace-seconds.k8s.io"
+)
+
+// GetVpaEvictionGracePeriodSeconds returns the grace period set by the VpaEvictionGracePeriodAnnotation
+// of the given pod annotations, or nil if it isn't set.
+func GetVpaEvictionGracePeriodSeconds(podAnnotations map[string]string) (*int64, error) {
+	value, found := podAnnotations[VpaEvictionGracePeriodAnnotation]
+	if !found {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return nil, fmt.Errorf("invalid value %q of annotation %s, expected a non-negative number of seconds", value, VpaEvictionGracePeriodAnnotation)
+	}
+	return &seconds, nil
+}
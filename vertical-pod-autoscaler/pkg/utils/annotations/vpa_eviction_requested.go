@@ -0,0 +1,25 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+const (
+	// VpaEvictionRequestedAnnotation is a pod annotation set by the updater, together with the eviction
+	// coordination annotation, when it requests an external coordinator to acknowledge the eviction of the
+	// pod. It outlives the acknowledgement, so that an updater which restarted still knows the request was
+	// made and acknowledged.
+	VpaEvictionRequestedAnnotation = "vpa-eviction-requested.k8s.io"
+)
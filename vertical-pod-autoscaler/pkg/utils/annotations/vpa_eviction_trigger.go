@@ -0,0 +1,32 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import "fmt"
+
+const (
+	// VpaEvictionTriggerAnnotation is a pod annotation set by the updater, when asked to, right before
+	// evicting the pod. It identifies the VPA, and the version of it, whose recommendation caused the eviction.
+	// It's removed again if the eviction is refused.
+	VpaEvictionTriggerAnnotation = "vpa-eviction-trigger.k8s.io"
+)
+
+// GetVpaEvictionTriggerValue returns the value of the VpaEvictionTriggerAnnotation for the VPA with
+// the given name and resourceVersion.
+func GetVpaEvictionTriggerValue(vpaName, resourceVersion string) string {
+	return fmt.Sprintf("vpa=%s,resourceVersion=%s", vpaName, resourceVersion)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import "strings"
+
+const (
+	// VpaInPlaceDisabledContainersAnnotation is a pod annotation listing, comma-separated, the containers
+	// which must not be resized in place, e.g. because they only read their memory limit at start. Pods
+	// with any of them managed by their VPA are updated by eviction, even in the InPlaceOrRecreate mode.
+	VpaInPlaceDisabledContainersAnnotation = "vpa-in-place-disabled-containers.k8s.io"
+)
+
+// GetVpaInPlaceDisabledContainers returns the names of the containers listed in the
+// VpaInPlaceDisabledContainersAnnotation of the given pod annotations.
+func GetVpaInPlaceDisabledContainers(podAnnotations map[string]string) []string {
+	value, found := podAnnotations[VpaInPlaceDisabledContainersAnnotation]
+	if !found {
+		return nil
+	}
+	var containers []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			containers = append(containers, name)
+		}
+	}
+	return containers
+}
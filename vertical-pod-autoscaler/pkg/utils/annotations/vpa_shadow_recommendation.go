@@ -0,0 +1,24 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+const (
+	// VpaShadowRecommendationAnnotation is a VPA annotation naming another VPA object in the same namespace,
+	// whose recommendation the updater compares to the one of the annotated VPA without ever applying it.
+	// The shadow VPA is usually in the Off mode and selects the recommender under evaluation in its spec.recommenders.
+	VpaShadowRecommendationAnnotation = "vpa-shadow-recommendation.k8s.io"
+)
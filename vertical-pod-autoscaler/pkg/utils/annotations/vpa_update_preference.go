@@ -0,0 +1,49 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import "fmt"
+
+const (
+	// VpaUpdatePreferenceAnnotation is a VPA annotation choosing how the updater first tries to update the pods
+	// of a VPA in the InPlaceOrRecreate mode, for workloads which handle restarts better than resizes.
+	VpaUpdatePreferenceAnnotation = "vpa-update-preference.k8s.io"
+)
+
+// UpdatePreference is the value of the VpaUpdatePreferenceAnnotation.
+type UpdatePreference string
+
+const (
+	// UpdatePreferenceInPlace tries to update the pods in place first, falling back to eviction. This is the default.
+	UpdatePreferenceInPlace UpdatePreference = "inplace"
+	// UpdatePreferenceEvict evicts the pods first, updating in place only the pods which can't be evicted.
+	UpdatePreferenceEvict UpdatePreference = "evict"
+)
+
+// GetVpaUpdatePreference returns the preference set by the VpaUpdatePreferenceAnnotation of the given
+// VPA annotations, or UpdatePreferenceInPlace if it isn't set.
+func GetVpaUpdatePreference(vpaAnnotations map[string]string) (UpdatePreference, error) {
+	value, found := vpaAnnotations[VpaUpdatePreferenceAnnotation]
+	if !found {
+		return UpdatePreferenceInPlace, nil
+	}
+	switch preference := UpdatePreference(value); preference {
+	case UpdatePreferenceInPlace, UpdatePreferenceEvict:
+		return preference, nil
+	}
+	return UpdatePreferenceInPlace, fmt.Errorf("invalid value %q of annotation %s, expected %s or %s", value, VpaUpdatePreferenceAnnotation, UpdatePreferenceInPlace, UpdatePreferenceEvict)
+}
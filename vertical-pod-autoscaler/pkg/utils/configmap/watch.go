@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// resyncPeriod is how often watched ConfigMaps are re-read even without changes.
+	resyncPeriod = 10 * time.Minute
+	// DefaultSyncTimeout is how long Watch waits for the ConfigMap to be read for the first time.
+	DefaultSyncTimeout = time.Minute
+)
+
+// Watch calls onChange with the data of the ConfigMap namespace/name when it's created or changes, and
+// with nil data when it's deleted. It returns once the ConfigMap was read for the first time, so that
+// callers don't act on stale settings, or with an error if it couldn't be read within syncTimeout, e.g.
+// because the ConfigMap isn't readable with the RBAC of the component. onChange isn't called if the
+// ConfigMap doesn't exist. The ConfigMap is watched until stopCh is closed.
+func Watch(kubeClient kube_client.Interface, namespace, name string, syncTimeout time.Duration, stopCh <-chan struct{}, onChange func(data map[string]string)) error {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return kubeClient.CoreV1().ConfigMaps(namespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return kubeClient.CoreV1().ConfigMaps(namespace).Watch(context.TODO(), options)
+		},
+	}
+	update := func(obj interface{}) {
+		if configMap, ok := obj.(*apiv1.ConfigMap); ok && configMap.Name == name {
+			onChange(configMap.Data)
+		}
+	}
+	informer := cache.NewSharedInformer(cache.ToListWatcherWithWatchListSemantics(listWatch, kubeClient), &apiv1.ConfigMap{}, resyncPeriod)
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    update,
+		UpdateFunc: func(_, obj interface{}) { update(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if configMap, ok := obj.(*apiv1.ConfigMap); ok && configMap.Name == name {
+				onChange(nil)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch ConfigMap %s/%s: %v", namespace, name, err)
+	}
+	go informer.Run(stopCh)
+
+	syncCtx, cancel := context.WithTimeout(wait.ContextForChannel(stopCh), syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), registration.HasSynced) {
+		return fmt.Errorf("failed to read ConfigMap %s/%s within %v", namespace, name, syncTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestWatch(t *testing.T) {
+	client := fake.NewClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	var mutex sync.Mutex
+	var data map[string]string
+	current := func() map[string]string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return data
+	}
+	configMap := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpa-config", Namespace: "kube-system"},
+		Data:       map[string]string{"key": "first"},
+	}
+	_, err := client.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), configMap, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	err = Watch(client, "kube-system", "vpa-config", 5*time.Second, stopCh, func(d map[string]string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		data = d
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "first"}, current(), "the ConfigMap is read before Watch returns")
+
+	configMap.Data = map[string]string{"key": "second"}
+	_, err = client.CoreV1().ConfigMaps("kube-system").Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return current()["key"] == "second" }, 5*time.Second, 10*time.Millisecond, "changes are seen")
+
+	err = client.CoreV1().ConfigMaps("kube-system").Delete(context.TODO(), "vpa-config", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return current() == nil }, 5*time.Second, 10*time.Millisecond, "deletions are seen")
+}
+
+func TestWatch_Forbidden(t *testing.T) {
+	client := fake.NewClientset()
+	client.PrependReactor("list", "configmaps", func(core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "vpa-config", nil)
+	})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	err := Watch(client, "kube-system", "vpa-config", 100*time.Millisecond, stopCh, func(map[string]string) {})
+	assert.Error(t, err, "a ConfigMap which can't be read fails the watch")
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// ReadinessCheck reports whether the main loop of the monitored component completed recently.
+// Unlike HealthCheck, it isn't ready until the first loop completes. Replicas waiting to be elected
+// leader don't run the loop and are ready once marked as standby.
+type ReadinessCheck struct {
+	threshold     time.Duration
+	lastCompleted time.Time
+	standby       bool
+	clock         clock.PassiveClock
+	mutex         sync.Mutex
+}
+
+// NewReadinessCheck builds new ReadinessCheck object, which reports not ready when
+// the last loop completed more than threshold ago.
+func NewReadinessCheck(threshold time.Duration) *ReadinessCheck {
+	return newReadinessCheckWithClock(threshold, clock.RealClock{})
+}
+
+func newReadinessCheckWithClock(threshold time.Duration, clock clock.PassiveClock) *ReadinessCheck {
+	return &ReadinessCheck{
+		threshold: threshold,
+		clock:     clock,
+	}
+}
+
+// MarkLoopCompleted records that the main loop completed now.
+func (rc *ReadinessCheck) MarkLoopCompleted() {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.lastCompleted = rc.clock.Now()
+}
+
+// MarkStandby records that the component waits to be elected leader with its caches synced,
+// which makes it ready until MarkLeading is called.
+func (rc *ReadinessCheck) MarkStandby() {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.standby = true
+}
+
+// MarkLeading records that the component was elected leader, from then on it's ready only
+// if its last loop completed recently.
+func (rc *ReadinessCheck) MarkLeading() {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.standby = false
+}
+
+// ready returns true if the component is on standby or if the last loop completed within the threshold.
+func (rc *ReadinessCheck) ready() (bool, string) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if rc.standby {
+		return true, ""
+	}
+	if rc.lastCompleted.IsZero() {
+		return false, "no loop completed yet"
+	}
+	ago := rc.clock.Since(rc.lastCompleted)
+	if ago > rc.threshold {
+		return false, fmt.Sprintf("last loop completed %v ago", ago)
+	}
+	return true, ""
+}
+
+// ServeHTTP implements http.Handler interface to provide a readiness endpoint.
+func (rc *ReadinessCheck) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ready, reason := rc.ready(); !ready {
+		http.Error(w, fmt.Sprintf("Not ready: %s", reason), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("OK")); err != nil {
+		klog.ErrorS(err, "Failed to write response message")
+	}
+}
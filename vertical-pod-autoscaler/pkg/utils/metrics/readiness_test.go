@@ -0,0 +1,66 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	baseclocktest "k8s.io/utils/clock/testing"
+)
+
+func readinessStatus(rc *ReadinessCheck) int {
+	recorder := httptest.NewRecorder()
+	rc.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	return recorder.Code
+}
+
+func TestReadinessCheck(t *testing.T) {
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	rc := newReadinessCheckWithClock(time.Minute, fakeClock)
+
+	assert.Equal(t, http.StatusServiceUnavailable, readinessStatus(rc), "not ready before the first loop completes")
+
+	rc.MarkLoopCompleted()
+	assert.Equal(t, http.StatusOK, readinessStatus(rc))
+
+	fakeClock.Step(time.Minute)
+	assert.Equal(t, http.StatusOK, readinessStatus(rc), "ready up to the threshold")
+
+	fakeClock.Step(time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, readinessStatus(rc), "not ready once the loop hangs")
+
+	rc.MarkLoopCompleted()
+	assert.Equal(t, http.StatusOK, readinessStatus(rc), "ready again after the next loop")
+}
+
+func TestReadinessCheck_Standby(t *testing.T) {
+	fakeClock := baseclocktest.NewFakeClock(time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC))
+	rc := newReadinessCheckWithClock(time.Minute, fakeClock)
+
+	rc.MarkStandby()
+	assert.Equal(t, http.StatusOK, readinessStatus(rc), "ready on standby without any loop")
+
+	rc.MarkLeading()
+	assert.Equal(t, http.StatusServiceUnavailable, readinessStatus(rc), "not ready once leading until the first loop completes")
+
+	rc.MarkLoopCompleted()
+	assert.Equal(t, http.StatusOK, readinessStatus(rc))
+}
@@ -18,9 +18,12 @@ limitations under the License.
 package updater
 
 import (
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
@@ -132,6 +135,138 @@ var (
 		}, []string{"vpa_size_log2", "reason", "vpa_name", "vpa_namespace"},
 	)
 
+	inPlaceDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "in_place_decisions_total",
+			Help:      "Number of decisions whether to update Pods in-place, by decision and reason.",
+		}, []string{"decision", "reason"},
+	)
+
+	failedFallbackEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "fallback_eviction_failures_total",
+			Help:      "Number of Pods whose in-place update failed and whose fallback eviction failed as well.",
+		}, []string{"vpa_size_log2", "vpa_name", "vpa_namespace"},
+	)
+
+	skippedOverlappingLoops = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "overlapping_loops_skipped_total",
+			Help:      "Number of Updater loops skipped because the previous loop was still running.",
+		},
+	)
+
+	loopsWithErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "loops_with_errors_total",
+			Help:      "Number of Updater loops which ran into errors, e.g. failed evictions.",
+		},
+	)
+
+	lastSuccessfulAPIServerContact = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_successful_apiserver_contact_seconds",
+			Help:      "Unix timestamp of the last successful API server call made by Updater.",
+		},
+	)
+
+	recommendationAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "recommendation_age_seconds",
+			Help:      "Time since the recommendation of a VPA was last updated, as observed by Updater.",
+		}, []string{"vpa_name", "vpa_namespace"},
+	)
+
+	matchedPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "matched_pods",
+			Help:      "Number of live Pods matched by the selector of a VPA, as observed by Updater.",
+		}, []string{"vpa_name", "vpa_namespace"},
+	)
+
+	rateLimitedActions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "rate_limited_actions",
+			Help:      "Number of updates of Pods of a VPA in the last loop which found the rate limiter out of tokens, and had to wait for it or were deferred.",
+		}, []string{"vpa_name", "vpa_namespace"},
+	)
+
+	evictionCircuitBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "eviction_circuit_breaker_open",
+			Help:      "Whether evictions are paused because too many of them failed recently (1) or not (0).",
+		},
+	)
+
+	evictionCircuitBreakerTrips = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "eviction_circuit_breaker_trips_total",
+			Help:      "Number of times evictions were paused because too many of them failed recently.",
+		},
+	)
+
+	killSwitchEngaged = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "kill_switch_engaged",
+			Help:      "Whether all actions of the Updater are stopped by the kill switch ConfigMap (1) or not (0).",
+		},
+	)
+
+	clampedRecommendations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "recommendation_clamped_total",
+			Help:      "Number of resources of updated Pods whose recommendation was clamped to the minAllowed or maxAllowed of their VPA.",
+		}, []string{"bound"},
+	)
+
+	blockedByPredicate = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "blocked_by_predicate_total",
+			Help:      "Number of times the Updater didn't act on a Pod because a custom predicate refused it.",
+		}, []string{"reason"},
+	)
+
+	rescheduleLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reschedule_latency_seconds",
+			Help:      "Time from the eviction of a Pod until a replacement Pod of the same controller became ready.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12), // 1s, 2s, 4s, ... 2048s
+		},
+	)
+
+	shadowTargetRatio = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "shadow_target_ratio",
+			Help:      "Ratio of the target recommended by the shadow VPA to the active target, per container of VPAs with a shadow, observed every loop.",
+			Buckets:   []float64{0.25, 0.5, 0.67, 0.8, 0.9, 0.95, 1.0, 1.05, 1.1, 1.25, 1.5, 2.0, 4.0},
+		}, []string{"resource"},
+	)
+
+	phaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "phase_duration_seconds",
+			Help:      "Time spent in each phase of the Updater loop, summed over the VPA objects processed in the loop.",
+			Buckets: []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1.0, 2.0, 5.0, 10.0,
+				20.0, 30.0, 40.0, 50.0, 60.0, 70.0, 80.0, 90.0, 100.0, 120.0, 150.0, 240.0, 300.0},
+		}, []string{"phase"},
+	)
+
 	functionLatency = metrics.CreateExecutionTimeMetric(metricsNamespace,
 		"Time spent in various parts of VPA Updater main loop.")
 )
@@ -150,6 +285,22 @@ func Register() {
 		vpasWithInPlaceUpdatablePodsCount,
 		vpasWithInPlaceUpdatedPodsCount,
 		failedInPlaceUpdateAttempts,
+		inPlaceDecisions,
+		failedFallbackEvictions,
+		skippedOverlappingLoops,
+		loopsWithErrors,
+		lastSuccessfulAPIServerContact,
+		recommendationAge,
+		matchedPods,
+		rateLimitedActions,
+		evictionCircuitBreakerOpen,
+		evictionCircuitBreakerTrips,
+		killSwitchEngaged,
+		clampedRecommendations,
+		blockedByPredicate,
+		rescheduleLatency,
+		shadowTargetRatio,
+		phaseDuration,
 		functionLatency,
 	}
 	prometheus.MustRegister(collectors...)
@@ -179,6 +330,17 @@ func newModeAndSizeBasedGauge(gauge *prometheus.GaugeVec) *UpdateModeAndSizeBase
 	return g
 }
 
+// NewUnobservedSizeBasedGauge returns a wrapper which counts like the ones of the main loop,
+// but whose Observe does nothing, so that partial loops don't overwrite the per-loop values.
+func NewUnobservedSizeBasedGauge() *SizeBasedGauge {
+	return newSizeBasedGauge(nil)
+}
+
+// NewUnobservedModeAndSizeBasedGauge is the UpdateModeAndSizeBasedGauge counterpart of NewUnobservedSizeBasedGauge.
+func NewUnobservedModeAndSizeBasedGauge() *UpdateModeAndSizeBasedGauge {
+	return newModeAndSizeBasedGauge(nil)
+}
+
 // NewControlledPodsCounter returns a wrapper for counting Pods controlled by Updater
 func NewControlledPodsCounter() *UpdateModeAndSizeBasedGauge {
 	controlledCount.Reset()
@@ -203,16 +365,18 @@ func NewVpasWithEvictedPodsCounter() *UpdateModeAndSizeBasedGauge {
 	return newModeAndSizeBasedGauge(vpasWithEvictedPodsCount)
 }
 
-// AddEvictedPod increases the counter of pods evicted by Updater, by given VPA size
-func AddEvictedPod(vpaSize int, vpaName string, vpaNamespace string, mode vpa_types.UpdateMode) {
+// AddEvictedPod increases the counter of pods evicted by Updater, by given VPA size.
+// The increment carries the trace ID of the context as an exemplar.
+func AddEvictedPod(ctx context.Context, vpaSize int, vpaName string, vpaNamespace string, mode vpa_types.UpdateMode) {
 	log2 := metrics.GetVpaSizeLog2(vpaSize)
-	evictedCount.WithLabelValues(strconv.Itoa(log2), string(mode), vpaName, vpaNamespace).Inc()
+	incWithTraceExemplar(ctx, evictedCount.WithLabelValues(strconv.Itoa(log2), string(mode), vpaName, vpaNamespace))
 }
 
-// RecordFailedEviction increases the counter of failed eviction attempts by given VPA size, name, namespace, update mode and reason
-func RecordFailedEviction(vpaSize int, vpaName string, vpaNamespace string, mode vpa_types.UpdateMode, reason string) {
+// RecordFailedEviction increases the counter of failed eviction attempts by given VPA size, name, namespace, update mode and reason.
+// The increment carries the trace ID of the context as an exemplar.
+func RecordFailedEviction(ctx context.Context, vpaSize int, vpaName string, vpaNamespace string, mode vpa_types.UpdateMode, reason string) {
 	log2 := metrics.GetVpaSizeLog2(vpaSize)
-	failedEvictionAttempts.WithLabelValues(strconv.Itoa(log2), string(mode), reason, vpaName, vpaNamespace).Inc()
+	incWithTraceExemplar(ctx, failedEvictionAttempts.WithLabelValues(strconv.Itoa(log2), string(mode), reason, vpaName, vpaNamespace))
 }
 
 // NewInPlaceUpdatablePodsCounter returns a wrapper for counting Pods which are matching in-place update criteria
@@ -233,10 +397,23 @@ func NewVpasWithInPlaceUpdatedPodsCounter() *SizeBasedGauge {
 	return newSizeBasedGauge(vpasWithInPlaceUpdatedPodsCount)
 }
 
-// AddInPlaceUpdatedPod increases the counter of pods updated in place by Updater, by given VPA size
-func AddInPlaceUpdatedPod(vpaSize int, vpaName string, vpaNamespace string) {
+// AddInPlaceUpdatedPod increases the counter of pods updated in place by Updater, by given VPA size.
+// The increment carries the trace ID of the context as an exemplar.
+func AddInPlaceUpdatedPod(ctx context.Context, vpaSize int, vpaName string, vpaNamespace string) {
 	log2 := metrics.GetVpaSizeLog2(vpaSize)
-	inPlaceUpdatedCount.WithLabelValues(strconv.Itoa(log2), vpaName, vpaNamespace).Inc()
+	incWithTraceExemplar(ctx, inPlaceUpdatedCount.WithLabelValues(strconv.Itoa(log2), vpaName, vpaNamespace))
+}
+
+// incWithTraceExemplar increases the counter, attaching the trace ID of the context as an exemplar
+// if the context carries a span. Exemplars are only exposed in the OpenMetrics format.
+func incWithTraceExemplar(ctx context.Context, counter prometheus.Counter) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	adder, ok := counter.(prometheus.ExemplarAdder)
+	if !ok || !spanContext.HasTraceID() {
+		counter.Inc()
+		return
+	}
+	adder.AddWithExemplar(1, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
 }
 
 // RecordFailedInPlaceUpdate increases the counter of failed in-place update attempts by given VPA size, name, namespace and reason
@@ -245,6 +422,107 @@ func RecordFailedInPlaceUpdate(vpaSize int, vpaName string, vpaNamespace string,
 	failedInPlaceUpdateAttempts.WithLabelValues(strconv.Itoa(log2), reason, vpaName, vpaNamespace).Inc()
 }
 
+// RecordFailedFallbackEviction increases the counter of failed evictions of pods whose in-place update failed, by given VPA size, name and namespace
+func RecordFailedFallbackEviction(vpaSize int, vpaName string, vpaNamespace string) {
+	log2 := metrics.GetVpaSizeLog2(vpaSize)
+	failedFallbackEvictions.WithLabelValues(strconv.Itoa(log2), vpaName, vpaNamespace).Inc()
+}
+
+// RecordClampedRecommendation increases the counter of resources of updated Pods whose recommendation was clamped to the given bound
+func RecordClampedRecommendation(bound string) {
+	clampedRecommendations.WithLabelValues(bound).Inc()
+}
+
+// RecordBlockedByPredicate increases the counter of Pods not acted on because a custom predicate refused them for the given reason
+func RecordBlockedByPredicate(reason string) {
+	blockedByPredicate.WithLabelValues(reason).Inc()
+}
+
+// RecordPhaseDuration records the time spent in a phase of an Updater loop
+func RecordPhaseDuration(phase string, duration time.Duration) {
+	phaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// RecordSkippedOverlappingLoop increases the counter of Updater loops skipped because the previous loop was still running
+func RecordSkippedOverlappingLoop() {
+	skippedOverlappingLoops.Inc()
+}
+
+// RecordLoopWithErrors increases the counter of Updater loops which ran into errors
+func RecordLoopWithErrors() {
+	loopsWithErrors.Inc()
+}
+
+// RecordInPlaceDecision increases the counter of decisions whether to update Pods in-place
+func RecordInPlaceDecision(decision string, reason string) {
+	inPlaceDecisions.WithLabelValues(decision, reason).Inc()
+}
+
+// RecordSuccessfulAPIServerContact sets the timestamp of the last successful API server call to now
+func RecordSuccessfulAPIServerContact() {
+	lastSuccessfulAPIServerContact.SetToCurrentTime()
+}
+
+// ResetRecommendationAges drops the recommendation ages recorded in the previous loop
+func ResetRecommendationAges() {
+	recommendationAge.Reset()
+}
+
+// RecordRecommendationAge sets the age of the recommendation of the given VPA
+func RecordRecommendationAge(vpaName string, vpaNamespace string, age time.Duration) {
+	recommendationAge.WithLabelValues(vpaName, vpaNamespace).Set(age.Seconds())
+}
+
+// ResetMatchedPods drops the matched Pods counts recorded in the previous loop
+func ResetMatchedPods() {
+	matchedPods.Reset()
+}
+
+// RecordMatchedPods sets the number of Pods matched by the selector of the given VPA
+func RecordMatchedPods(vpaName string, vpaNamespace string, count int) {
+	matchedPods.WithLabelValues(vpaName, vpaNamespace).Set(float64(count))
+}
+
+// ResetRateLimitedActions drops the rate limited actions counts recorded in the previous loop
+func ResetRateLimitedActions() {
+	rateLimitedActions.Reset()
+}
+
+// RecordRateLimitedActions sets the number of updates of Pods of the given VPA which found the rate limiter out of tokens
+func RecordRateLimitedActions(vpaName string, vpaNamespace string, count int) {
+	rateLimitedActions.WithLabelValues(vpaName, vpaNamespace).Set(float64(count))
+}
+
+// RecordEvictionCircuitBreakerTripped increases the counter of circuit breaker trips and marks it as open
+func RecordEvictionCircuitBreakerTripped() {
+	evictionCircuitBreakerTrips.Inc()
+	evictionCircuitBreakerOpen.Set(1)
+}
+
+// RecordEvictionCircuitBreakerClosed marks the eviction circuit breaker as closed
+func RecordEvictionCircuitBreakerClosed() {
+	evictionCircuitBreakerOpen.Set(0)
+}
+
+// RecordKillSwitchEngaged records whether all actions are stopped by the kill switch
+func RecordKillSwitchEngaged(engaged bool) {
+	if engaged {
+		killSwitchEngaged.Set(1)
+	} else {
+		killSwitchEngaged.Set(0)
+	}
+}
+
+// RecordRescheduleLatency records the time it took for an evicted Pod to be replaced by a ready one
+func RecordRescheduleLatency(latency time.Duration) {
+	rescheduleLatency.Observe(latency.Seconds())
+}
+
+// RecordShadowTargetRatio records the ratio of the shadow target of a container to its active target
+func RecordShadowTargetRatio(resource string, ratio float64) {
+	shadowTargetRatio.WithLabelValues(resource).Observe(ratio)
+}
+
 // Add increases the counter for the given VPA size
 func (g *SizeBasedGauge) Add(vpaSize int, value int) {
 	log2 := metrics.GetVpaSizeLog2(vpaSize)
@@ -253,6 +531,9 @@ func (g *SizeBasedGauge) Add(vpaSize int, value int) {
 
 // Observe stores the recorded values into metrics object associated with the wrapper
 func (g *SizeBasedGauge) Observe() {
+	if g.gauge == nil {
+		return
+	}
 	for log2, value := range g.values {
 		g.gauge.WithLabelValues(strconv.Itoa(log2)).Set(float64(value))
 	}
@@ -267,6 +548,9 @@ func (g *UpdateModeAndSizeBasedGauge) Add(vpaSize int, vpaUpdateMode vpa_types.U
 // Observe stores the recorded values into metrics object associated with the
 // wrapper
 func (g *UpdateModeAndSizeBasedGauge) Observe() {
+	if g.gauge == nil {
+		return
+	}
 	for log2, valueMap := range g.values {
 		for vpaMode, value := range valueMap {
 			g.gauge.WithLabelValues(strconv.Itoa(log2), string(vpaMode)).Set(float64(value))
@@ -17,10 +17,15 @@ limitations under the License.
 package updater
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 )
@@ -55,7 +60,7 @@ func TestAddEvictedPod(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
 			t.Cleanup(evictedCount.Reset)
-			AddEvictedPod(tc.vpaSize, tc.vpaName, tc.vpaNamespace, tc.mode)
+			AddEvictedPod(context.Background(), tc.vpaSize, tc.vpaName, tc.vpaNamespace, tc.mode)
 			val := testutil.ToFloat64(evictedCount.WithLabelValues(tc.log2, string(tc.mode), tc.vpaName, tc.vpaNamespace))
 			if val != 1 {
 				t.Errorf("Unexpected value for evictedCount metric with labels (%s, %s): got %v, want 1", tc.log2, string(tc.mode), val)
@@ -64,6 +69,34 @@ func TestAddEvictedPod(t *testing.T) {
 	}
 }
 
+func TestAddEvictedPodTraceExemplar(t *testing.T) {
+	t.Cleanup(evictedCount.Reset)
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	AddEvictedPod(context.Background(), 1, "vpa", "default", vpa_types.UpdateModeRecreate)
+	counter := evictedCount.WithLabelValues("0", string(vpa_types.UpdateModeRecreate), "vpa", "default")
+	metric := &dto.Metric{}
+	if assert.NoError(t, counter.Write(metric)) {
+		assert.Nil(t, metric.GetCounter().GetExemplar(), "no exemplar without a trace")
+	}
+
+	AddEvictedPod(ctx, 1, "vpa", "default", vpa_types.UpdateModeRecreate)
+	metric = &dto.Metric{}
+	if assert.NoError(t, counter.Write(metric)) {
+		assert.Equal(t, float64(2), metric.GetCounter().GetValue())
+		exemplar := metric.GetCounter().GetExemplar()
+		if assert.NotNil(t, exemplar) && assert.Len(t, exemplar.GetLabel(), 1) {
+			assert.Equal(t, "trace_id", exemplar.GetLabel()[0].GetName())
+			assert.Equal(t, traceID.String(), exemplar.GetLabel()[0].GetValue())
+		}
+	}
+}
+
 func TestRecordFailedEviction(t *testing.T) {
 	testCases := []struct {
 		desc         string
@@ -95,7 +128,7 @@ func TestRecordFailedEviction(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
 			t.Cleanup(failedEvictionAttempts.Reset)
-			RecordFailedEviction(tc.vpaSize, tc.vpaName, tc.vpaNamespace, tc.mode, tc.reason)
+			RecordFailedEviction(context.Background(), tc.vpaSize, tc.vpaName, tc.vpaNamespace, tc.mode, tc.reason)
 			val := testutil.ToFloat64(failedEvictionAttempts.WithLabelValues(tc.log2, string(tc.mode), tc.reason, tc.vpaName, tc.vpaNamespace))
 			if val != 1 {
 				t.Errorf("Unexpected value for FailedEviction metric with labels (%s, %s): got %v, want 1", tc.log2, tc.reason, val)
@@ -131,7 +164,7 @@ func TestAddInPlaceUpdatedPod(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
 			t.Cleanup(inPlaceUpdatedCount.Reset)
-			AddInPlaceUpdatedPod(tc.vpaSize, tc.vpaName, tc.vpaNamespace)
+			AddInPlaceUpdatedPod(context.Background(), tc.vpaSize, tc.vpaName, tc.vpaNamespace)
 			val := testutil.ToFloat64(inPlaceUpdatedCount.WithLabelValues(tc.log2, tc.vpaName, tc.vpaNamespace))
 			if val != 1 {
 				t.Errorf("Unexpected value for InPlaceUpdatedPod metric with labels (%s): got %v, want 1", tc.log2, val)
@@ -179,6 +212,92 @@ func TestRecordFailedInPlaceUpdate(t *testing.T) {
 	}
 }
 
+func TestRecordFailedFallbackEviction(t *testing.T) {
+	t.Cleanup(failedFallbackEvictions.Reset)
+	RecordFailedFallbackEviction(5, "vpa-5", "vpa-5-ns")
+	RecordFailedFallbackEviction(5, "vpa-5", "vpa-5-ns")
+	val := testutil.ToFloat64(failedFallbackEvictions.WithLabelValues("2", "vpa-5", "vpa-5-ns"))
+	if val != 2 {
+		t.Errorf("Unexpected value for FailedFallbackEviction metric with labels (2, vpa-5, vpa-5-ns): got %v, want 2", val)
+	}
+}
+
+func TestRecordClampedRecommendation(t *testing.T) {
+	t.Cleanup(clampedRecommendations.Reset)
+	RecordClampedRecommendation("min")
+	RecordClampedRecommendation("max")
+	RecordClampedRecommendation("max")
+	if val := testutil.ToFloat64(clampedRecommendations.WithLabelValues("max")); val != 2 {
+		t.Errorf("Unexpected value for ClampedRecommendations metric with label (max): got %v, want 2", val)
+	}
+}
+
+func TestRecordBlockedByPredicate(t *testing.T) {
+	t.Cleanup(blockedByPredicate.Reset)
+	RecordBlockedByPredicate("CIRunning")
+	RecordBlockedByPredicate("CIRunning")
+	if val := testutil.ToFloat64(blockedByPredicate.WithLabelValues("CIRunning")); val != 2 {
+		t.Errorf("Unexpected value for BlockedByPredicate metric with label (CIRunning): got %v, want 2", val)
+	}
+}
+
+func TestRecordPhaseDuration(t *testing.T) {
+	t.Cleanup(phaseDuration.Reset)
+	RecordPhaseDuration("list", time.Second)
+	RecordPhaseDuration("act", 2*time.Second)
+	RecordPhaseDuration("act", time.Second)
+	if count := testutil.CollectAndCount(phaseDuration); count != 2 {
+		t.Errorf("Unexpected number of PhaseDuration series: got %v, want 2", count)
+	}
+}
+
+func TestRecordShadowTargetRatio(t *testing.T) {
+	t.Cleanup(shadowTargetRatio.Reset)
+	RecordShadowTargetRatio("cpu", 1.5)
+	RecordShadowTargetRatio("cpu", 0.5)
+	RecordShadowTargetRatio("memory", 1)
+	metric := &dto.Metric{}
+	if err := shadowTargetRatio.WithLabelValues("cpu").(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatalf("Failed to read ShadowTargetRatio metric: %v", err)
+	}
+	if count, sum := metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum(); count != 2 || sum != 2 {
+		t.Errorf("Unexpected ShadowTargetRatio metric with label (cpu): got count %v and sum %v, want 2 and 2", count, sum)
+	}
+	if count := testutil.CollectAndCount(shadowTargetRatio); count != 2 {
+		t.Errorf("Unexpected number of ShadowTargetRatio series: got %v, want 2", count)
+	}
+}
+
+func TestRecordSkippedOverlappingLoop(t *testing.T) {
+	before := testutil.ToFloat64(skippedOverlappingLoops)
+	RecordSkippedOverlappingLoop()
+	if val := testutil.ToFloat64(skippedOverlappingLoops) - before; val != 1 {
+		t.Errorf("Unexpected increase of SkippedOverlappingLoops metric: got %v, want 1", val)
+	}
+}
+
+func TestRecordSuccessfulAPIServerContact(t *testing.T) {
+	t.Cleanup(func() { lastSuccessfulAPIServerContact.Set(0) })
+	before := float64(time.Now().Unix())
+	RecordSuccessfulAPIServerContact()
+	if val := testutil.ToFloat64(lastSuccessfulAPIServerContact); val < before {
+		t.Errorf("Unexpected value for LastSuccessfulAPIServerContact metric: got %v, want at least %v", val, before)
+	}
+}
+
+func TestRecordRecommendationAge(t *testing.T) {
+	t.Cleanup(recommendationAge.Reset)
+	RecordRecommendationAge("vpa-5", "vpa-5-ns", 90*time.Second)
+	val := testutil.ToFloat64(recommendationAge.WithLabelValues("vpa-5", "vpa-5-ns"))
+	if val != 90 {
+		t.Errorf("Unexpected value for RecommendationAge metric with labels (vpa-5, vpa-5-ns): got %v, want 90", val)
+	}
+	ResetRecommendationAges()
+	if count := testutil.CollectAndCount(recommendationAge); count != 0 {
+		t.Errorf("Unexpected number of RecommendationAge series after reset: got %v, want 0", count)
+	}
+}
+
 func TestUpdateModeAndSizeBasedGauge(t *testing.T) {
 	type addition struct {
 		vpaSize int
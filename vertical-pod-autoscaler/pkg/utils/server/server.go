@@ -21,21 +21,28 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog/v2"
 
 	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/metrics"
 )
 
-// Initialize sets up Prometheus to expose metrics & (optionally) health-check and profiling on the given address
-func Initialize(enableProfiling *bool, healthCheck *metrics.HealthCheck, address *string) {
+// Initialize sets up Prometheus to expose metrics & (optionally) health-check, readiness and profiling on the given address.
+// If enableOpenMetrics is set, metrics are served in the OpenMetrics format to scrapers asking for it, which exposes exemplars.
+// The debugHandlers, keyed by their path pattern, are served as well.
+func Initialize(enableProfiling *bool, healthCheck *metrics.HealthCheck, readinessCheck *metrics.ReadinessCheck, address *string, enableOpenMetrics bool, debugHandlers map[string]http.Handler) {
 	go func() {
 		mux := http.NewServeMux()
 
-		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: enableOpenMetrics})))
 		if healthCheck != nil {
 			mux.Handle("/health-check", healthCheck)
 		}
+		if readinessCheck != nil {
+			mux.Handle("/ready", readinessCheck)
+		}
 
 		if *enableProfiling {
 			mux.HandleFunc("/debug/pprof/", http.HandlerFunc(pprof.Index))
@@ -44,6 +51,9 @@ func Initialize(enableProfiling *bool, healthCheck *metrics.HealthCheck, address
 			mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 			mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 		}
+		for pattern, handler := range debugHandlers {
+			mux.Handle(pattern, handler)
+		}
 
 		err := http.ListenAndServe(*address, mux)
 		klog.ErrorS(err, "Failed to start metrics")
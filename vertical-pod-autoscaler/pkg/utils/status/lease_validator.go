@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"time"
+
+	apicoordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	typedcoordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/utils/clock"
+)
+
+// leaseValidator considers the status valid as long as a coordination Lease,
+// such as the one used for leader election, keeps being renewed.
+type leaseValidator struct {
+	client    typedcoordinationv1.LeaseInterface
+	leaseName string
+	timeout   time.Duration
+	clock     clock.PassiveClock
+}
+
+// NewLeaseValidator returns a validator which reports the status as valid
+// when the given Lease has been renewed within timeout.
+func NewLeaseValidator(c clientset.Interface, leaseNamespace, leaseName string, timeout time.Duration) Validator {
+	return &leaseValidator{
+		client:    c.CoordinationV1().Leases(leaseNamespace),
+		leaseName: leaseName,
+		timeout:   timeout,
+		clock:     clock.RealClock{},
+	}
+}
+
+// IsStatusValid verifies if the Lease was renewed within the timeout
+// configured for the validator. The statusTimeout argument is ignored.
+func (v *leaseValidator) IsStatusValid(ctx context.Context, _ time.Duration) (bool, error) {
+	var lease *apicoordinationv1.Lease
+	getFn := func(ctx context.Context) error {
+		var err error
+		lease, err = v.client.Get(ctx, v.leaseName, metav1.GetOptions{})
+		return err
+	}
+	if err := retryWithExponentialBackOff(ctx, getFn); err != nil {
+		return false, err
+	}
+	return isLeaseRenewed(lease, v.timeout, v.clock.Now()), nil
+}
+
+func isLeaseRenewed(lease *apicoordinationv1.Lease, timeout time.Duration, now time.Time) bool {
+	return lease.Spec.RenewTime != nil && lease.Spec.RenewTime.Add(timeout).After(now)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apicoordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	baseclocktest "k8s.io/utils/clock/testing"
+)
+
+func TestLeaseValidator(t *testing.T) {
+	const (
+		leaseName      = "vpa-updater"
+		leaseNamespace = "kube-system"
+		timeout        = 30 * time.Second
+	)
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	newLease := func(renewTime *metav1.MicroTime) *apicoordinationv1.Lease {
+		return &apicoordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              leaseName,
+				Namespace:         leaseNamespace,
+				CreationTimestamp: metav1.Time{Time: now},
+			},
+			Spec: apicoordinationv1.LeaseSpec{
+				RenewTime: renewTime,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		lease         *apicoordinationv1.Lease
+		expectedValid bool
+		expectedErr   bool
+	}{
+		{
+			name:          "fresh lease",
+			lease:         newLease(&metav1.MicroTime{Time: now.Add(-10 * time.Second)}),
+			expectedValid: true,
+		},
+		{
+			name:          "stale lease",
+			lease:         newLease(&metav1.MicroTime{Time: now.Add(-time.Minute)}),
+			expectedValid: false,
+		},
+		{
+			name:          "never renewed lease",
+			lease:         newLease(nil),
+			expectedValid: false,
+		},
+		{
+			name:          "missing lease",
+			expectedValid: false,
+			expectedErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := fake.NewClientset()
+			if tc.lease != nil {
+				_, err := fc.CoordinationV1().Leases(leaseNamespace).Create(context.Background(), tc.lease, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			validator := NewLeaseValidator(fc, leaseNamespace, leaseName, timeout).(*leaseValidator)
+			validator.clock = baseclocktest.NewFakePassiveClock(now)
+
+			valid, err := validator.IsStatusValid(context.Background(), AdmissionControllerStatusTimeout)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedValid, valid)
+		})
+	}
+}
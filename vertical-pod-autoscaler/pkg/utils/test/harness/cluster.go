@@ -0,0 +1,235 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package harness provides a fake cluster running the real admission logic, for tests checking the
+// updater and the admission controller together. It lives apart from package test, which the
+// admission controller's own tests import.
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	admissionv1 "k8s.io/api/admission/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	resource_admission "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/patch"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/pod/recommendation"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/admission-controller/resource/vpa"
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpa_lister "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/updater/restriction"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/limitrange"
+	vpa_api_util "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/vpa"
+)
+
+// Cluster is a fake cluster holding VPA objects and Pods. Pods are admitted by the admission controller's
+// Pod handler when they are created, and are recreated from the spec they were first created with when
+// they are evicted, like a controller would, so they get the recommendation current at that time.
+type Cluster struct {
+	mutex     sync.Mutex
+	vpas      cache.Indexer
+	pods      cache.Indexer
+	selectors map[types.NamespacedName]labels.Selector
+	// templates are the specs Pods were created with, before the admission.
+	templates  map[types.NamespacedName]*apiv1.Pod
+	admission  resource_admission.Handler
+	generation int
+	evicted    []string
+}
+
+// NewCluster returns an empty Cluster.
+func NewCluster() *Cluster {
+	c := &Cluster{
+		vpas:      cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		pods:      cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		selectors: make(map[types.NamespacedName]labels.Selector),
+		templates: make(map[types.NamespacedName]*apiv1.Pod),
+	}
+	limitRangeCalculator := limitrange.NewNoopLimitsCalculator()
+	recommendationProvider := recommendation.NewProvider(limitRangeCalculator, vpa_api_util.NewCappingRecommendationProcessor(limitRangeCalculator))
+	c.admission = pod.NewResourceHandler(
+		pod.NewDefaultPreProcessor(),
+		vpa.NewMatcher(c.VpaLister(), c.SelectorFetcher(), c.ControllerFetcher()),
+		[]patch.Calculator{patch.NewResourceUpdatesCalculator(recommendationProvider), patch.NewObservedContainersCalculator()},
+	)
+	return c
+}
+
+// AddVpa adds the VPA object, selecting the Pods matched by the selector.
+func (c *Cluster) AddVpa(vpa *vpa_types.VerticalPodAutoscaler, selector labels.Selector) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.selectors[types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}] = selector
+	return c.vpas.Add(vpa.DeepCopy())
+}
+
+// SetRecommendation replaces the recommendation of the VPA object, as the recommender would.
+func (c *Cluster) SetRecommendation(namespace, name string, recommendation *vpa_types.RecommendedPodResources) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	obj, found, err := c.vpas.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("VPA object %s/%s not found", namespace, name)
+	}
+	vpa := obj.(*vpa_types.VerticalPodAutoscaler).DeepCopy()
+	vpa.Status.Recommendation = recommendation.DeepCopy()
+	return c.vpas.Update(vpa)
+}
+
+// CreatePod admits the Pod and adds it to the cluster. It returns the Pod as admitted.
+func (c *Cluster) CreatePod(pod *apiv1.Pod) (*apiv1.Pod, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.templates[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}] = pod.DeepCopy()
+	return c.createPod(pod)
+}
+
+// Pod returns the Pod with the given name, or nil if there is none.
+func (c *Cluster) Pod(namespace, name string) *apiv1.Pod {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	obj, found, _ := c.pods.GetByKey(namespace + "/" + name)
+	if !found {
+		return nil
+	}
+	return obj.(*apiv1.Pod)
+}
+
+// Evicted returns the names of the evicted Pods, in the order of their evictions.
+func (c *Cluster) Evicted() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.evicted...)
+}
+
+// VpaLister returns a lister of the VPA objects of the cluster.
+func (c *Cluster) VpaLister() vpa_lister.VerticalPodAutoscalerLister {
+	return vpa_lister.NewVerticalPodAutoscalerLister(c.vpas)
+}
+
+// PodLister returns a lister of the Pods of the cluster.
+func (c *Cluster) PodLister() v1lister.PodLister {
+	return v1lister.NewPodLister(c.pods)
+}
+
+// SelectorFetcher returns a fetcher of the selectors the VPA objects were added with.
+func (c *Cluster) SelectorFetcher() target.VpaTargetSelectorFetcher {
+	return selectorFetcher{cluster: c}
+}
+
+// ControllerFetcher returns a fetcher treating the owners of Pods as their topmost controllers.
+func (c *Cluster) ControllerFetcher() controllerfetcher.ControllerFetcher {
+	return controllerfetcher.FakeControllerFetcher{}
+}
+
+// RestrictionFactory returns a factory of restrictions allowing to evict every Pod. Evicted Pods are
+// recreated right away. In-place updates aren't supported by the cluster and are refused.
+func (c *Cluster) RestrictionFactory() restriction.PodsRestrictionFactory {
+	return &restriction.FakePodsRestrictionFactory{
+		Eviction: &restriction.FuncPodsEvictionRestriction{EvictFunc: c.evict},
+		InPlace: &restriction.FuncPodsInPlaceRestriction{
+			InPlaceUpdateFunc: func(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+				return fmt.Errorf("pod %s/%s can't be updated in-place in the fake cluster", pod.Namespace, pod.Name)
+			},
+		},
+	}
+}
+
+func (c *Cluster) evict(pod *apiv1.Pod, _ *vpa_types.VerticalPodAutoscaler, _ record.EventRecorder) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	template, found := c.templates[key]
+	if !found {
+		return fmt.Errorf("pod %s not found", key)
+	}
+	if err := c.pods.Delete(pod); err != nil {
+		return err
+	}
+	c.evicted = append(c.evicted, pod.Name)
+	_, err := c.createPod(template)
+	return err
+}
+
+// createPod admits a copy of the Pod with a new UID and adds it to the cluster.
+func (c *Cluster) createPod(pod *apiv1.Pod) (*apiv1.Pod, error) {
+	c.generation++
+	pod = pod.DeepCopy()
+	pod.UID = types.UID(fmt.Sprintf("%s-%d", pod.Name, c.generation))
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	patches, err := c.admission.GetPatches(context.Background(), &admissionv1.AdmissionRequest{
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Namespace: pod.Namespace,
+		Object:    runtime.RawExtension{Raw: raw},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to admit pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	if len(patches) > 0 {
+		patchJSON, err := json.Marshal(patches)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := jsonpatch.DecodePatch(patchJSON)
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = decoded.Apply(raw); err != nil {
+			return nil, fmt.Errorf("failed to apply admission patches to pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		pod = &apiv1.Pod{}
+		if err := json.Unmarshal(raw, pod); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.pods.Add(pod); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+type selectorFetcher struct {
+	cluster *Cluster
+}
+
+func (f selectorFetcher) Fetch(_ context.Context, vpa *vpa_types.VerticalPodAutoscaler) (labels.Selector, error) {
+	selector, found := f.cluster.selectors[types.NamespacedName{Namespace: vpa.Namespace, Name: vpa.Name}]
+	if !found {
+		return nil, fmt.Errorf("no selector for VPA object %s/%s", vpa.Namespace, vpa.Name)
+	}
+	return selector, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/annotations"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestCluster(t *testing.T) {
+	rc := apiv1.ReplicationController{
+		TypeMeta:   metav1.TypeMeta{Kind: "ReplicationController", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rc", Namespace: "default"},
+	}
+	cluster := NewCluster()
+	vpa := test.VerticalPodAutoscaler().
+		WithName("vpa").
+		WithNamespace("default").
+		WithContainer("app").
+		WithTarget("2", "200M").
+		WithTargetRef(&autoscaling.CrossVersionObjectReference{Kind: rc.Kind, Name: rc.Name, APIVersion: rc.APIVersion}).
+		Get()
+	require.NoError(t, cluster.AddVpa(vpa, labels.SelectorFromSet(labels.Set{"app": "web"})))
+
+	newPod := func(name string, podLabels map[string]string) *apiv1.Pod {
+		return test.Pod().WithName(name).
+			AddContainer(test.Container().WithName("app").WithCPURequest(resource.MustParse("1")).WithMemRequest(resource.MustParse("100M")).Get()).
+			WithCreator(&rc.ObjectMeta, &rc.TypeMeta).
+			WithLabels(podLabels).
+			Get()
+	}
+	matched, err := cluster.CreatePod(newPod("matched", map[string]string{"app": "web"}))
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("2"), matched.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU], "the recommendation is applied on admission")
+	assert.Equal(t, "app", matched.Annotations[annotations.VpaObservedContainersLabel])
+	unmatched, err := cluster.CreatePod(newPod("unmatched", map[string]string{"app": "db"}))
+	require.NoError(t, err)
+	assert.Equal(t, resource.MustParse("1"), unmatched.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU], "pods not matched by a VPA object are left alone")
+
+	require.NoError(t, cluster.SetRecommendation("default", "vpa", test.Recommendation().WithContainer("app").WithTarget("3", "300M").Get()))
+	assert.Equal(t, resource.MustParse("2"), cluster.Pod("default", "matched").Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU], "running pods are left alone")
+	eviction := cluster.RestrictionFactory().NewPodsEvictionRestriction(nil, nil)
+	require.NoError(t, eviction.Evict(matched, vpa, nil))
+	recreated := cluster.Pod("default", "matched")
+	assert.NotEqual(t, matched.UID, recreated.UID)
+	assert.Equal(t, resource.MustParse("3"), recreated.Spec.Containers[0].Resources.Requests[apiv1.ResourceCPU], "evicted pods are admitted again")
+	assert.Equal(t, []string{"matched"}, cluster.Evicted())
+
+	assert.Error(t, cluster.SetRecommendation("default", "missing", nil))
+}
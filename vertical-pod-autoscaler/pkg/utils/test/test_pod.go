@@ -32,6 +32,7 @@ type PodBuilder interface {
 	WithLabels(labels map[string]string) PodBuilder
 	WithAnnotations(annotations map[string]string) PodBuilder
 	WithPhase(phase apiv1.PodPhase) PodBuilder
+	WithNodeName(nodeName string) PodBuilder
 	WithQOSClass(class apiv1.PodQOSClass) PodBuilder
 	WithPodConditions(conditions []apiv1.PodCondition) PodBuilder
 	Get() *apiv1.Pod
@@ -54,6 +55,7 @@ type podBuilderImpl struct {
 	labels                map[string]string
 	annotations           map[string]string
 	phase                 apiv1.PodPhase
+	nodeName              string
 	containerStatuses     []apiv1.ContainerStatus
 	initContainerStatuses []apiv1.ContainerStatus
 	qosClass              apiv1.PodQOSClass
@@ -103,6 +105,12 @@ func (pb *podBuilderImpl) WithPhase(phase apiv1.PodPhase) PodBuilder {
 	return &r
 }
 
+func (pb *podBuilderImpl) WithNodeName(nodeName string) PodBuilder {
+	r := *pb
+	r.nodeName = nodeName
+	return &r
+}
+
 func (pb *podBuilderImpl) AddContainerStatus(containerStatus apiv1.ContainerStatus) PodBuilder {
 	r := *pb
 	r.containerStatuses = append(r.containerStatuses, containerStatus)
@@ -139,6 +147,7 @@ func (pb *podBuilderImpl) Get() *apiv1.Pod {
 		Spec: apiv1.PodSpec{
 			Containers:     pb.containers,
 			InitContainers: pb.initContainers,
+			NodeName:       pb.nodeName,
 		},
 		Status: apiv1.PodStatus{
 			StartTime:  &startTime,
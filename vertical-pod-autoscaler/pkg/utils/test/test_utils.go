@@ -134,9 +134,9 @@ func (m *PodsInPlaceRestrictionMock) InPlaceUpdate(pod *apiv1.Pod, vpa *vpa_type
 }
 
 // CanInPlaceUpdate is a mock implementation of PodsInPlaceRestriction.CanInPlaceUpdate
-func (m *PodsInPlaceRestrictionMock) CanInPlaceUpdate(pod *apiv1.Pod) utils.InPlaceDecision {
+func (m *PodsInPlaceRestrictionMock) CanInPlaceUpdate(pod *apiv1.Pod) (utils.InPlaceDecision, utils.InPlaceDecisionReason) {
 	args := m.Called(pod)
-	return args.Get(0).(utils.InPlaceDecision)
+	return args.Get(0).(utils.InPlaceDecision), args.Get(1).(utils.InPlaceDecisionReason)
 }
 
 // PodListerMock is a mock of PodLister
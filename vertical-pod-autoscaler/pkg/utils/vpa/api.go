@@ -27,9 +27,13 @@ import (
 	core "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -46,35 +50,84 @@ type VpaWithSelector struct {
 	Selector labels.Selector
 }
 
+// RecommenderFieldManager is the field manager of the VPA status patches written by the recommender.
+const RecommenderFieldManager = "vpa-recommender"
+
 type patchRecord struct {
 	Op    string `json:"op,inline"`
 	Path  string `json:"path,inline"`
 	Value any    `json:"value"`
 }
 
-func patchVpaStatus(vpaClient vpa_api.VerticalPodAutoscalerInterface, vpaName string, patches []patchRecord) (result *vpa_types.VerticalPodAutoscaler, err error) {
-	bytes, err := json.Marshal(patches)
+// UpdateVpaStatusIfNeeded updates the recommendation and the conditions in the status of the VPA API object.
+// The recent actions are written by the updater, so they are neither compared nor patched, and a stale
+// copy of them in newStatus can't overwrite the actions the updater recorded since.
+func UpdateVpaStatusIfNeeded(vpaClient vpa_api.VerticalPodAutoscalerInterface, vpaName string, newStatus,
+	oldStatus *vpa_types.VerticalPodAutoscalerStatus) (result *vpa_types.VerticalPodAutoscaler, err error) {
+	if apiequality.Semantic.DeepEqual(newStatus.Recommendation, oldStatus.Recommendation) &&
+		apiequality.Semantic.DeepEqual(newStatus.Conditions, oldStatus.Conditions) {
+		return nil, nil
+	}
+	// A merge patch replaces the listed fields only, unset ones are sent as null to be removed.
+	patch := map[string]any{
+		"status": map[string]any{
+			"recommendation": newStatus.Recommendation,
+			"conditions":     newStatus.Conditions,
+		},
+	}
+	bytes, err := json.Marshal(patch)
 	if err != nil {
-		klog.ErrorS(err, "Cannot marshal VPA status patches", "patches", patches)
+		klog.ErrorS(err, "Cannot marshal VPA status patch", "patch", patch)
 		return nil, err
 	}
-
-	return vpaClient.Patch(context.TODO(), vpaName, types.JSONPatchType, bytes, meta.PatchOptions{}, "status")
+	return vpaClient.Patch(context.TODO(), vpaName, types.MergePatchType, bytes, meta.PatchOptions{FieldManager: RecommenderFieldManager}, "status")
 }
 
-// UpdateVpaStatusIfNeeded updates the status field of the VPA API object.
-func UpdateVpaStatusIfNeeded(vpaClient vpa_api.VerticalPodAutoscalerInterface, vpaName string, newStatus,
-	oldStatus *vpa_types.VerticalPodAutoscalerStatus) (result *vpa_types.VerticalPodAutoscaler, err error) {
-	patches := []patchRecord{{
-		Op:    "add",
-		Path:  "/status",
-		Value: *newStatus,
-	}}
+// RecommendationHeartbeatInterval is how often the recommender refreshes the LastProbeTime of the
+// RecommendationProvided condition of VPAs whose status doesn't change otherwise, so that a stable
+// recommendation can be told apart from one a stopped recommender no longer updates.
+const RecommendationHeartbeatInterval = 5 * time.Minute
+
+// SetRecommendationHeartbeat sets the LastProbeTime of the RecommendationProvided condition of newStatus to
+// now, unless the one in oldStatus is more recent than RecommendationHeartbeatInterval and is kept instead,
+// so that the heartbeat alone doesn't write the status in every loop of the recommender.
+func SetRecommendationHeartbeat(newStatus, oldStatus *vpa_types.VerticalPodAutoscalerStatus, now time.Time) {
+	lastProbeTime := meta.NewTime(now)
+	for _, condition := range oldStatus.Conditions {
+		if condition.Type == vpa_types.RecommendationProvided && now.Sub(condition.LastProbeTime.Time) < RecommendationHeartbeatInterval {
+			lastProbeTime = condition.LastProbeTime
+		}
+	}
+	for i := range newStatus.Conditions {
+		if newStatus.Conditions[i].Type == vpa_types.RecommendationProvided {
+			newStatus.Conditions[i].LastProbeTime = lastProbeTime
+		}
+	}
+}
 
-	if !apiequality.Semantic.DeepEqual(*oldStatus, *newStatus) {
-		return patchVpaStatus(vpaClient, vpaName, patches)
+// RecommendationLastUpdateTime returns the last time the recommender confirmed the recommendation of the VPA:
+// the last time it wrote the VPA status, or the last transition or heartbeat of the RecommendationProvided
+// condition if it is more recent. Status writes of other field managers, e.g. the updater recording its
+// actions, are ignored.
+func RecommendationLastUpdateTime(vpa *vpa_types.VerticalPodAutoscaler) (time.Time, bool) {
+	var lastUpdateTime time.Time
+	for _, entry := range vpa.ManagedFields {
+		if entry.Manager == RecommenderFieldManager && entry.Subresource == "status" && entry.Time != nil && entry.Time.After(lastUpdateTime) {
+			lastUpdateTime = entry.Time.Time
+		}
+	}
+	for _, condition := range vpa.Status.Conditions {
+		if condition.Type != vpa_types.RecommendationProvided {
+			continue
+		}
+		if condition.LastTransitionTime.After(lastUpdateTime) {
+			lastUpdateTime = condition.LastTransitionTime.Time
+		}
+		if condition.LastProbeTime.After(lastUpdateTime) {
+			lastUpdateTime = condition.LastProbeTime.Time
+		}
 	}
-	return nil, nil
+	return lastUpdateTime, !lastUpdateTime.IsZero()
 }
 
 // NewVpasLister returns VerticalPodAutoscalerLister configured to fetch all VPA objects from namespace,
@@ -107,6 +160,69 @@ func NewVpasLister(vpaClient *vpa_clientset.Clientset, stopChannel <-chan struct
 	return vpaLister
 }
 
+// NewVpasListerForResource returns VerticalPodAutoscalerLister configured to fetch all objects of the given
+// resource from namespace, e.g. to read the recommendations of a forked recommender publishing them
+// in its own API group. The resource must implement the VerticalPodAutoscaler schema.
+// Set namespace to k8sapiv1.NamespaceAll to select all namespaces.
+// The method blocks until vpaLister is initially populated.
+func NewVpasListerForResource(dynamicClient dynamic.Interface, resource schema.GroupVersionResource, stopChannel <-chan struct{}, namespace string) vpa_lister.VerticalPodAutoscalerLister {
+	client := dynamicClient.Resource(resource).Namespace(namespace)
+	vpaListWatch := cache.ToListWatcherWithWatchListSemantics(&cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options meta.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, options)
+		},
+		WatchFuncWithContext: client.Watch,
+	}, dynamicClient)
+	informerOptions := cache.InformerOptions{
+		ObjectType:    &unstructured.Unstructured{},
+		ListerWatcher: vpaListWatch,
+		Handler:       &cache.ResourceEventHandlerFuncs{},
+		ResyncPeriod:  1 * time.Hour,
+		Indexers:      cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		Transform:     unstructuredToVpa,
+	}
+
+	store, controller := cache.NewInformerWithOptions(informerOptions)
+	indexer, ok := store.(cache.Indexer)
+	if !ok {
+		klog.ErrorS(nil, "Expected Indexer, but got a Store that does not implement Indexer")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	vpaLister := vpa_lister.NewVerticalPodAutoscalerLister(indexer)
+	go controller.Run(stopChannel)
+	if !cache.WaitForCacheSync(stopChannel, controller.HasSynced) {
+		klog.ErrorS(nil, "Failed to sync VPA cache during initialization", "resource", resource)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	} else {
+		klog.InfoS("Initial VPA synced successfully", "resource", resource)
+	}
+	return vpaLister
+}
+
+// unstructuredToVpa converts the objects read by NewVpasListerForResource to VPA objects before they are stored.
+func unstructuredToVpa(obj interface{}) (interface{}, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		// Tombstones of deleted objects are stored as they are.
+		return obj, nil
+	}
+	vpa := &vpa_types.VerticalPodAutoscaler{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), vpa); err != nil {
+		return nil, fmt.Errorf("failed to convert %s %s to a VPA object: %w", u.GroupVersionKind(), klog.KObj(u), err)
+	}
+	return vpa, nil
+}
+
+// ParseVpaResource parses a resource given as resource.version.group, e.g.
+// "verticalpodautoscalers.v1.autoscaling.example.com", for NewVpasListerForResource.
+func ParseVpaResource(resource string) (schema.GroupVersionResource, error) {
+	gvr, _ := schema.ParseResourceArg(resource)
+	if gvr == nil || gvr.Group == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q, expected resource.version.group", resource)
+	}
+	return *gvr, nil
+}
+
 // NewVpaCheckpointLister returns VerticalPodAutoscalerCheckpointLister configured to fetch all VPACheckpoint objects from namespace,
 // set namespace to k8sapiv1.NamespaceAll to select all namespaces.
 // The method blocks until vpaCheckpointLister is initially populated.
@@ -27,7 +27,11 @@ import (
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamic_fake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/utils/ptr"
 
 	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
@@ -93,6 +97,16 @@ func TestUpdateVpaIfNeeded(t *testing.T) {
 				AppendCondition(vpa_types.RecommendationProvided, core.ConditionTrue, "reason", "msg", anytime).
 				AppendCondition(vpa_types.LowConfidence, core.ConditionTrue, "reason", "msg", anytime).Get(),
 			expectedUpdate: true,
+		}, {
+			caseName:   "Doesn't update on recent actions change.",
+			updatedVpa: updatedVpa,
+			observedVpa: func() *vpa_types.VerticalPodAutoscaler {
+				vpa := observedVpaBuilder.WithTarget("5", "200").
+					AppendCondition(vpa_types.RecommendationProvided, core.ConditionTrue, "reason", "msg", anytime).Get()
+				vpa.Status.RecentActions = []vpa_types.VerticalPodAutoscalerAction{{PodName: "pod"}}
+				return vpa
+			}(),
+			expectedUpdate: false,
 		},
 	}
 	for _, tc := range testCases {
@@ -104,6 +118,10 @@ func TestUpdateVpaIfNeeded(t *testing.T) {
 			actions := fakeClient.Actions()
 			if tc.expectedUpdate {
 				assert.Equal(t, 1, len(actions), "Unexpected number of actions")
+				updated, err := fakeClient.AutoscalingV1().VerticalPodAutoscalers(tc.updatedVpa.Namespace).Get(context.TODO(), tc.updatedVpa.Name, meta.GetOptions{})
+				assert.NoError(t, err)
+				assert.Equal(t, tc.updatedVpa.Status.Recommendation, updated.Status.Recommendation)
+				assert.Equal(t, tc.updatedVpa.Status.Conditions, updated.Status.Conditions)
 			} else {
 				assert.Equal(t, 0, len(actions), "Unexpected number of actions")
 			}
@@ -399,3 +417,93 @@ func TestFindParentControllerForPod(t *testing.T) {
 		})
 	}
 }
+
+func TestNewVpasListerForResource(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: "autoscaling.example.com", Version: "v1", Resource: "verticalpodautoscalers"}
+	vpa := test.VerticalPodAutoscaler().WithName("vpa").WithNamespace("default").WithContainer(containerName).WithTarget("1", "100M").Get()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vpa)
+	assert.NoError(t, err)
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion("autoscaling.example.com/v1")
+	obj.SetKind("VerticalPodAutoscaler")
+	dynamicClient := dynamic_fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{resource: "VerticalPodAutoscalerList"}, obj)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	vpaLister := NewVpasListerForResource(dynamicClient, resource, stopCh, core.NamespaceAll)
+	vpas, err := vpaLister.List(labels.Everything())
+	assert.NoError(t, err)
+	if assert.Len(t, vpas, 1) {
+		assert.Equal(t, "vpa", vpas[0].Name)
+		assert.Equal(t, vpa.Status.Recommendation, vpas[0].Status.Recommendation)
+	}
+
+	other := obj.DeepCopy()
+	other.SetName("other")
+	_, err = dynamicClient.Resource(resource).Namespace("default").Create(context.TODO(), other, meta.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := vpaLister.VerticalPodAutoscalers("default").Get("other")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "objects created later are watched")
+}
+
+func TestParseVpaResource(t *testing.T) {
+	resource, err := ParseVpaResource("verticalpodautoscalers.v1.autoscaling.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Group: "autoscaling.example.com", Version: "v1", Resource: "verticalpodautoscalers"}, resource)
+
+	_, err = ParseVpaResource("verticalpodautoscalers")
+	assert.Error(t, err)
+}
+
+func TestSetRecommendationHeartbeat(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	statusWithProbe := func(lastProbeTime time.Time) *vpa_types.VerticalPodAutoscalerStatus {
+		return &vpa_types.VerticalPodAutoscalerStatus{Conditions: []vpa_types.VerticalPodAutoscalerCondition{{
+			Type:          vpa_types.RecommendationProvided,
+			Status:        core.ConditionTrue,
+			LastProbeTime: meta.NewTime(lastProbeTime),
+		}}}
+	}
+	testCases := []struct {
+		name              string
+		oldStatus         *vpa_types.VerticalPodAutoscalerStatus
+		expectedProbeTime time.Time
+	}{
+		{
+			name:              "recent heartbeat is kept",
+			oldStatus:         statusWithProbe(now.Add(-time.Minute)),
+			expectedProbeTime: now.Add(-time.Minute),
+		},
+		{
+			name:              "old heartbeat is refreshed",
+			oldStatus:         statusWithProbe(now.Add(-RecommendationHeartbeatInterval)),
+			expectedProbeTime: now,
+		},
+		{
+			name:              "missing heartbeat is set",
+			oldStatus:         &vpa_types.VerticalPodAutoscalerStatus{},
+			expectedProbeTime: now,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newStatus := statusWithProbe(time.Time{})
+			SetRecommendationHeartbeat(newStatus, tc.oldStatus, now)
+			assert.True(t, tc.expectedProbeTime.Equal(newStatus.Conditions[0].LastProbeTime.Time))
+		})
+	}
+}
+
+func TestRecommendationLastUpdateTime_Heartbeat(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	vpa := test.VerticalPodAutoscaler().WithContainer(containerName).
+		AppendCondition(vpa_types.RecommendationProvided, core.ConditionTrue, "", "", now.Add(-24*time.Hour)).Get()
+	vpa.Status.Conditions[0].LastProbeTime = meta.NewTime(now.Add(-time.Minute))
+
+	lastUpdateTime, found := RecommendationLastUpdateTime(vpa)
+	assert.True(t, found)
+	assert.True(t, now.Add(-time.Minute).Equal(lastUpdateTime), "the heartbeat of a recommendation which didn't change counts")
+}
@@ -19,6 +19,7 @@ package api
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -32,9 +33,28 @@ import (
 // NewCappingRecommendationProcessor constructs new RecommendationsProcessor that adjusts recommendation
 // for given pod to obey VPA resources policy and container limits
 func NewCappingRecommendationProcessor(limitsRangeCalculator limitrange.LimitRangeCalculator) RecommendationProcessor {
-	return &cappingRecommendationProcessor{limitsRangeCalculator: limitsRangeCalculator}
+	return NewCappingRecommendationProcessorWithDefaults(limitsRangeCalculator, nil)
 }
 
+// NewCappingRecommendationProcessorWithDefaults constructs a capping RecommendationProcessor which also
+// obeys the cluster-wide defaults of the minAllowed and maxAllowed missing from VPA resource policies.
+func NewCappingRecommendationProcessorWithDefaults(limitsRangeCalculator limitrange.LimitRangeCalculator, defaults *DefaultResourcePolicy) RecommendationProcessor {
+	return &cappingRecommendationProcessor{limitsRangeCalculator: limitsRangeCalculator, defaults: defaults}
+}
+
+const (
+	// RecommendationValueAnnotation on the VPA object selects which value of the recommendation
+	// is applied to pods, one of RecommendationValueTarget (the default), RecommendationValueLowerBound
+	// or RecommendationValueUpperBound.
+	RecommendationValueAnnotation = "vpa.kubernetes.io/recommendation-value"
+	// RecommendationValueTarget applies the target recommendation.
+	RecommendationValueTarget = "target"
+	// RecommendationValueLowerBound applies the lower bound of the recommendation, to avoid over-provisioning.
+	RecommendationValueLowerBound = "lowerBound"
+	// RecommendationValueUpperBound applies the upper bound of the recommendation, for safety-critical workloads.
+	RecommendationValueUpperBound = "upperBound"
+)
+
 type cappingAction string
 
 var (
@@ -51,6 +71,7 @@ func toCappingAnnotation(resourceName apiv1.ResourceName, action cappingAction)
 
 type cappingRecommendationProcessor struct {
 	limitsRangeCalculator limitrange.LimitRangeCalculator
+	defaults              *DefaultResourcePolicy
 }
 
 // Apply returns a recommendation for the given pod, adjusted to obey policy and limits.
@@ -77,9 +98,11 @@ func (c *cappingRecommendationProcessor) Apply(
 		// Policies have been specified. Create an empty recommendation so that the policies can be applied correctly.
 		podRecommendation = new(vpa_types.RecommendedPodResources)
 	}
+	policy = c.defaults.Apply(policy, pod)
 	updatedRecommendations := []vpa_types.RecommendedContainerResources{}
 	containerToAnnotationsMap := ContainerToAnnotationsMap{}
-	limitAdjustedRecommendation, err := c.capProportionallyToPodLimitRange(podRecommendation.ContainerRecommendations, pod)
+	selectedRecommendation := selectRecommendationValue(vpa, podRecommendation.ContainerRecommendations)
+	limitAdjustedRecommendation, err := c.capProportionallyToPodLimitRange(selectedRecommendation, pod)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -110,6 +133,36 @@ func (c *cappingRecommendationProcessor) Apply(
 	return &vpa_types.RecommendedPodResources{ContainerRecommendations: updatedRecommendations}, containerToAnnotationsMap, nil
 }
 
+// selectRecommendationValue returns the recommendations with the target replaced by the bound
+// selected by the RecommendationValueAnnotation of the VPA object. Resources missing from
+// the bound keep their target.
+func selectRecommendationValue(vpa *vpa_types.VerticalPodAutoscaler, recommendations []vpa_types.RecommendedContainerResources) []vpa_types.RecommendedContainerResources {
+	value, found := vpa.Annotations[RecommendationValueAnnotation]
+	if !found || value == RecommendationValueTarget {
+		return recommendations
+	}
+	if value != RecommendationValueLowerBound && value != RecommendationValueUpperBound {
+		klog.V(2).InfoS("Ignoring unknown recommendation value, using target", "vpa", klog.KObj(vpa), "annotation", RecommendationValueAnnotation, "value", value)
+		return recommendations
+	}
+	selected := make([]vpa_types.RecommendedContainerResources, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		updated := recommendation.DeepCopy()
+		bound := updated.LowerBound
+		if value == RecommendationValueUpperBound {
+			bound = updated.UpperBound
+		}
+		if updated.Target == nil && len(bound) > 0 {
+			updated.Target = apiv1.ResourceList{}
+		}
+		for resourceName, quantity := range bound {
+			updated.Target[resourceName] = quantity.DeepCopy()
+		}
+		selected = append(selected, *updated)
+	}
+	return selected
+}
+
 // getCappedRecommendationForContainer returns a recommendation for the given container, adjusted to obey policy and limits.
 func getCappedRecommendationForContainer(
 	pod *apiv1.Pod,
@@ -287,6 +340,53 @@ func ApplyVPAPolicy(podRecommendation *vpa_types.RecommendedPodResources,
 	return &vpa_types.RecommendedPodResources{ContainerRecommendations: updatedRecommendations}, nil
 }
 
+// RecommendationBound is a bound of the resource policy of a VPA.
+type RecommendationBound string
+
+const (
+	// MinAllowedBound is the minAllowed of a container resource policy.
+	MinAllowedBound RecommendationBound = "min"
+	// MaxAllowedBound is the maxAllowed of a container resource policy.
+	MaxAllowedBound RecommendationBound = "max"
+)
+
+// ClampedResource is a resource of a container whose recommendation is clamped by its resource policy.
+type ClampedResource struct {
+	ContainerName string
+	Resource      apiv1.ResourceName
+	Bound         RecommendationBound
+}
+
+// GetClampedResources returns the resources whose target recommendation equals the minAllowed or
+// maxAllowed of their container resource policy, i.e. whose value is set by the policy rather than
+// by the usage of the container.
+func GetClampedResources(recommendation *vpa_types.RecommendedPodResources, policy *vpa_types.PodResourcePolicy) []ClampedResource {
+	if recommendation == nil {
+		return nil
+	}
+	var clamped []ClampedResource
+	for _, containerRecommendation := range recommendation.ContainerRecommendations {
+		containerPolicy := GetContainerResourcePolicy(containerRecommendation.ContainerName, policy)
+		if containerPolicy == nil {
+			continue
+		}
+		for resourceName, target := range containerRecommendation.Target {
+			if minAllowed, found := containerPolicy.MinAllowed[resourceName]; found && !minAllowed.IsZero() && target.Cmp(minAllowed) <= 0 {
+				clamped = append(clamped, ClampedResource{containerRecommendation.ContainerName, resourceName, MinAllowedBound})
+			} else if maxAllowed, found := containerPolicy.MaxAllowed[resourceName]; found && !maxAllowed.IsZero() && target.Cmp(maxAllowed) >= 0 {
+				clamped = append(clamped, ClampedResource{containerRecommendation.ContainerName, resourceName, MaxAllowedBound})
+			}
+		}
+	}
+	sort.Slice(clamped, func(i, j int) bool {
+		if clamped[i].ContainerName != clamped[j].ContainerName {
+			return clamped[i].ContainerName < clamped[j].ContainerName
+		}
+		return clamped[i].Resource < clamped[j].Resource
+	})
+	return clamped
+}
+
 func getRecommendationForContainer(containerName string, resources []vpa_types.RecommendedContainerResources) *vpa_types.RecommendedContainerResources {
 	for _, containerRec := range resources {
 		if containerRec.ContainerName == containerName {
@@ -263,6 +263,37 @@ func TestRecommendationCappedToMinMaxPolicy(t *testing.T) {
 	}, res.ContainerRecommendations[0].UpperBound)
 }
 
+func TestGetClampedResources(t *testing.T) {
+	recommendation := &vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			test.Recommendation().WithContainer("app").WithTarget("100m", "1Gi").GetContainerResources(),
+			test.Recommendation().WithContainer("sidecar").WithTarget("50m", "200Mi").GetContainerResources(),
+			test.Recommendation().WithContainer("unbounded").WithTarget("1", "1Gi").GetContainerResources(),
+		},
+	}
+	policy := &vpa_types.PodResourcePolicy{
+		ContainerPolicies: []vpa_types.ContainerResourcePolicy{
+			{
+				ContainerName: "app",
+				MinAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m")},
+				MaxAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2"), apiv1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			{
+				ContainerName: "sidecar",
+				MinAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("10m"), apiv1.ResourceMemory: resource.MustParse("100Mi")},
+				MaxAllowed:    apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("0")},
+			},
+		},
+	}
+
+	assert.Equal(t, []ClampedResource{
+		{ContainerName: "app", Resource: apiv1.ResourceCPU, Bound: MinAllowedBound},
+		{ContainerName: "app", Resource: apiv1.ResourceMemory, Bound: MaxAllowedBound},
+	}, GetClampedResources(recommendation, policy))
+	assert.Empty(t, GetClampedResources(recommendation, nil), "recommendations without a policy are not clamped")
+	assert.Empty(t, GetClampedResources(nil, policy))
+}
+
 var podRecommendation *vpa_types.RecommendedPodResources = &vpa_types.RecommendedPodResources{
 	ContainerRecommendations: []vpa_types.RecommendedContainerResources{
 		{
@@ -340,6 +371,76 @@ var (
 	}
 )
 
+func TestApplyRecommendationValueAnnotation(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+	podRecommendation := vpa_types.RecommendedPodResources{
+		ContainerRecommendations: []vpa_types.RecommendedContainerResources{
+			{
+				ContainerName: "ctr-name",
+				Target: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("300m"),
+					apiv1.ResourceMemory: resource.MustParse("500Mi"),
+				},
+				LowerBound: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("200m"),
+					apiv1.ResourceMemory: resource.MustParse("400Mi"),
+				},
+				UpperBound: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("500m"),
+					apiv1.ResourceMemory: resource.MustParse("800Mi"),
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedTarget apiv1.ResourceList
+	}{
+		{
+			name:           "no annotation applies target",
+			expectedTarget: podRecommendation.ContainerRecommendations[0].Target,
+		},
+		{
+			name:           "target",
+			annotations:    map[string]string{RecommendationValueAnnotation: RecommendationValueTarget},
+			expectedTarget: podRecommendation.ContainerRecommendations[0].Target,
+		},
+		{
+			name:           "lower bound",
+			annotations:    map[string]string{RecommendationValueAnnotation: RecommendationValueLowerBound},
+			expectedTarget: podRecommendation.ContainerRecommendations[0].LowerBound,
+		},
+		{
+			name:           "upper bound",
+			annotations:    map[string]string{RecommendationValueAnnotation: RecommendationValueUpperBound},
+			expectedTarget: podRecommendation.ContainerRecommendations[0].UpperBound,
+		},
+		{
+			name:           "unknown value applies target",
+			annotations:    map[string]string{RecommendationValueAnnotation: "median"},
+			expectedTarget: podRecommendation.ContainerRecommendations[0].Target,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpa := test.VerticalPodAutoscaler().
+				WithContainer("ctr-name").
+				WithAnnotations(tc.annotations).
+				Get()
+			vpa.Status.Recommendation = podRecommendation.DeepCopy()
+
+			res, _, err := NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}).Apply(vpa, pod)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTarget, res.ContainerRecommendations[0].Target)
+			assert.Equal(t, podRecommendation.ContainerRecommendations[0].LowerBound, res.ContainerRecommendations[0].LowerBound)
+			assert.Equal(t, podRecommendation.ContainerRecommendations[0].UpperBound, res.ContainerRecommendations[0].UpperBound)
+			assert.Equal(t, podRecommendation.ContainerRecommendations[0].Target, vpa.Status.Recommendation.ContainerRecommendations[0].Target, "the VPA object must not be modified")
+		})
+	}
+}
+
 func TestApplyVPAPolicy(t *testing.T) {
 	tests := []struct {
 		Name              string
@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/configmap"
+)
+
+const (
+	// DefaultMinAllowedKey is the key of the default resource policy ConfigMap holding the minAllowed
+	// of containers, as a JSON resource list, e.g. {"cpu": "50m", "memory": "64Mi"}.
+	DefaultMinAllowedKey = "minAllowed"
+	// DefaultMaxAllowedKey is the key of the default resource policy ConfigMap holding the maxAllowed
+	// of containers, as a JSON resource list.
+	DefaultMaxAllowedKey = "maxAllowed"
+)
+
+// DefaultResourcePolicy holds the cluster-wide minAllowed and maxAllowed of containers, which apply to
+// the resources the resource policy of their VPA doesn't bound.
+type DefaultResourcePolicy struct {
+	mutex      sync.RWMutex
+	minAllowed apiv1.ResourceList
+	maxAllowed apiv1.ResourceList
+}
+
+// Set replaces the defaults with the ones in the data of the ConfigMap. Invalid values are ignored.
+func (d *DefaultResourcePolicy) Set(data map[string]string) {
+	minAllowed := parseDefaultAllowed(data, DefaultMinAllowedKey)
+	maxAllowed := parseDefaultAllowed(data, DefaultMaxAllowedKey)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.minAllowed, d.maxAllowed = minAllowed, maxAllowed
+	klog.V(1).InfoS("Default resource policy changed", "minAllowed", minAllowed, "maxAllowed", maxAllowed)
+}
+
+func parseDefaultAllowed(data map[string]string, key string) apiv1.ResourceList {
+	value, found := data[key]
+	if !found {
+		return nil
+	}
+	var allowed apiv1.ResourceList
+	if err := json.Unmarshal([]byte(value), &allowed); err != nil {
+		klog.ErrorS(err, "Ignoring invalid value of the default resource policy", "key", key, "value", value)
+		return nil
+	}
+	return allowed
+}
+
+// Apply returns the resource policy with the defaults merged under the policy of each container of the pod.
+// The minAllowed and maxAllowed of a resource set by the VPA, for the container or with the "*" wildcard,
+// take precedence over the defaults, and defaults conflicting with them, e.g. a default maxAllowed below the
+// minAllowed of the VPA, are dropped. Containers whose policy turns scaling off are left alone.
+func (d *DefaultResourcePolicy) Apply(policy *vpa_types.PodResourcePolicy, pod *apiv1.Pod) *vpa_types.PodResourcePolicy {
+	if d == nil || pod == nil {
+		return policy
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if len(d.minAllowed) == 0 && len(d.maxAllowed) == 0 {
+		return policy
+	}
+	merged := &vpa_types.PodResourcePolicy{}
+	if policy != nil {
+		merged = policy.DeepCopy()
+	}
+	for _, container := range pod.Spec.Containers {
+		containerPolicy := GetContainerResourcePolicy(container.Name, policy)
+		if containerPolicy == nil {
+			containerPolicy = &vpa_types.ContainerResourcePolicy{}
+		} else if containerPolicy.Mode != nil && *containerPolicy.Mode == vpa_types.ContainerScalingModeOff {
+			continue
+		}
+		containerPolicy = containerPolicy.DeepCopy()
+		containerPolicy.ContainerName = container.Name
+		explicitMin, explicitMax := containerPolicy.MinAllowed.DeepCopy(), containerPolicy.MaxAllowed.DeepCopy()
+		containerPolicy.MinAllowed = mergeDefaultAllowed(containerPolicy.MinAllowed, d.minAllowed, func(resourceName apiv1.ResourceName, quantity resource.Quantity) bool {
+			maxAllowed, found := explicitMax[resourceName]
+			return found && quantity.Cmp(maxAllowed) > 0
+		})
+		containerPolicy.MaxAllowed = mergeDefaultAllowed(containerPolicy.MaxAllowed, d.maxAllowed, func(resourceName apiv1.ResourceName, quantity resource.Quantity) bool {
+			minAllowed, found := explicitMin[resourceName]
+			return found && quantity.Cmp(minAllowed) < 0
+		})
+		setContainerResourcePolicy(merged, *containerPolicy)
+	}
+	return merged
+}
+
+// mergeDefaultAllowed adds the defaults of the resources missing from allowed, unless they conflict with the VPA.
+func mergeDefaultAllowed(allowed, defaults apiv1.ResourceList, conflicts func(apiv1.ResourceName, resource.Quantity) bool) apiv1.ResourceList {
+	for resourceName, quantity := range defaults {
+		if _, found := allowed[resourceName]; found || conflicts(resourceName, quantity) {
+			continue
+		}
+		if allowed == nil {
+			allowed = apiv1.ResourceList{}
+		}
+		allowed[resourceName] = quantity.DeepCopy()
+	}
+	return allowed
+}
+
+// setContainerResourcePolicy replaces the policy of the container, or adds it if the container has none.
+func setContainerResourcePolicy(policy *vpa_types.PodResourcePolicy, containerPolicy vpa_types.ContainerResourcePolicy) {
+	for i := range policy.ContainerPolicies {
+		if policy.ContainerPolicies[i].ContainerName == containerPolicy.ContainerName {
+			policy.ContainerPolicies[i] = containerPolicy
+			return
+		}
+	}
+	policy.ContainerPolicies = append(policy.ContainerPolicies, containerPolicy)
+}
+
+// WatchDefaultResourcePolicy returns a DefaultResourcePolicy kept in sync with the ConfigMap given as
+// namespace/name, or as name in defaultNamespace. There are no defaults while the ConfigMap doesn't exist.
+// It returns an error if the ConfigMap can't be read, as components applying different defaults would disagree
+// on the resources of pods.
+func WatchDefaultResourcePolicy(kubeClient kube_client.Interface, configMap, defaultNamespace string, stopCh <-chan struct{}) (*DefaultResourcePolicy, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default resource policy ConfigMap %q: %v", configMap, err)
+	}
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	defaults := &DefaultResourcePolicy{}
+	if err := configmap.Watch(kubeClient, namespace, name, configmap.DefaultSyncTimeout, stopCh, defaults.Set); err != nil {
+		return nil, fmt.Errorf("failed to watch default resource policy: %v", err)
+	}
+	return defaults, nil
+}
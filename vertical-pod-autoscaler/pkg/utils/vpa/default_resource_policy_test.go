@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestDefaultResourcePolicy(t *testing.T) {
+	resources := func(cpu, memory string) apiv1.ResourceList {
+		list := apiv1.ResourceList{}
+		if cpu != "" {
+			list[apiv1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			list[apiv1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return list
+	}
+	defaults := &DefaultResourcePolicy{}
+	defaults.Set(map[string]string{
+		DefaultMinAllowedKey: `{"cpu": "100m", "memory": "100Mi"}`,
+		DefaultMaxAllowedKey: `{"cpu": "1", "memory": "1Gi"}`,
+	})
+
+	testCases := []struct {
+		name     string
+		policies []vpa_types.ContainerResourcePolicy
+		expected apiv1.ResourceList
+	}{
+		{
+			name:     "defaults apply without a policy",
+			expected: resources("100m", "1Gi"),
+		},
+		{
+			name: "the policy of the container takes precedence",
+			policies: []vpa_types.ContainerResourcePolicy{
+				{ContainerName: "ctr-name", MinAllowed: resources("20m", "")},
+			},
+			expected: resources("50m", "1Gi"),
+		},
+		{
+			name: "the wildcard policy takes precedence",
+			policies: []vpa_types.ContainerResourcePolicy{
+				{ContainerName: vpa_types.DefaultContainerResourcePolicy, MaxAllowed: resources("", "4Gi")},
+			},
+			expected: resources("100m", "2Gi"),
+		},
+		{
+			name: "the policy of the container takes precedence over the wildcard policy",
+			policies: []vpa_types.ContainerResourcePolicy{
+				{ContainerName: vpa_types.DefaultContainerResourcePolicy, MaxAllowed: resources("", "4Gi")},
+				{ContainerName: "ctr-name", MaxAllowed: resources("", "1500Mi")},
+			},
+			expected: resources("100m", "1500Mi"),
+		},
+		{
+			name: "defaults conflicting with the policy are dropped",
+			policies: []vpa_types.ContainerResourcePolicy{
+				{ContainerName: "ctr-name", MinAllowed: resources("", "3Gi"), MaxAllowed: resources("50m", "")},
+			},
+			expected: resources("50m", "3Gi"),
+		},
+		{
+			name: "containers with scaling turned off are left alone",
+			policies: []vpa_types.ContainerResourcePolicy{
+				{ContainerName: "ctr-name", Mode: ptr.To(vpa_types.ContainerScalingModeOff)},
+			},
+			expected: resources("50m", "2Gi"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+			vpa := test.VerticalPodAutoscaler().WithContainer("ctr-name").WithTarget("50m", "2Gi").Get()
+			if tc.policies != nil {
+				vpa.Spec.ResourcePolicy = &vpa_types.PodResourcePolicy{ContainerPolicies: tc.policies}
+			}
+			original := vpa.DeepCopy()
+
+			processed, _, err := NewCappingRecommendationProcessorWithDefaults(&fakeLimitRangeCalculator{}, defaults).Apply(vpa, pod)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, processed.ContainerRecommendations[0].Target)
+			assert.Equal(t, original, vpa, "the VPA is not modified")
+		})
+	}
+}
+
+func TestDefaultResourcePolicySet(t *testing.T) {
+	defaults := &DefaultResourcePolicy{}
+	defaults.Set(map[string]string{
+		DefaultMinAllowedKey: `{"cpu": "100m"}`,
+		DefaultMaxAllowedKey: `not json`,
+	})
+	policy := defaults.Apply(nil, test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get())
+	if assert.Len(t, policy.ContainerPolicies, 1) {
+		assert.Equal(t, apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m")}, policy.ContainerPolicies[0].MinAllowed)
+		assert.Nil(t, policy.ContainerPolicies[0].MaxAllowed, "invalid values are ignored")
+	}
+
+	defaults.Set(nil)
+	assert.Nil(t, defaults.Apply(nil, test.Pod().WithName("pod1").Get()), "there are no defaults once the ConfigMap is gone")
+
+	var disabled *DefaultResourcePolicy
+	assert.Nil(t, disabled.Apply(nil, test.Pod().WithName("pod1").Get()))
+}
@@ -42,7 +42,15 @@ func GetProportionalLimit(originalLimit, originalRequest, recommendation, defaul
 	if annotation != "" {
 		annotations = append(annotations, annotation)
 	}
-	if memLimit == nil && cpuLimit == nil {
+	// Ephemeral storage is only managed if it's recommended, e.g. by a custom recommender.
+	var storageLimit *resource.Quantity
+	if recommendedStorage, found := recommendation[core.ResourceEphemeralStorage]; found {
+		storageLimit, annotation = getProportionalResourceLimit(core.ResourceEphemeralStorage, originalLimit.StorageEphemeral(), originalRequest.StorageEphemeral(), &recommendedStorage, defaultLimit.StorageEphemeral())
+		if annotation != "" {
+			annotations = append(annotations, annotation)
+		}
+	}
+	if memLimit == nil && cpuLimit == nil && storageLimit == nil {
 		return nil, []string{}
 	}
 	result := core.ResourceList{}
@@ -52,6 +60,9 @@ func GetProportionalLimit(originalLimit, originalRequest, recommendation, defaul
 	if memLimit != nil {
 		result[core.ResourceMemory] = *memLimit
 	}
+	if storageLimit != nil {
+		result[core.ResourceEphemeralStorage] = *storageLimit
+	}
 	return result, annotations
 }
 
@@ -195,3 +195,28 @@ func TestGetProportionalResourceLimitMem(t *testing.T) {
 		})
 	}
 }
+
+func TestGetProportionalLimitEphemeralStorage(t *testing.T) {
+	originalRequest := core.ResourceList{
+		core.ResourceCPU:              resource.MustParse("1"),
+		core.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+	}
+	originalLimit := core.ResourceList{
+		core.ResourceCPU:              resource.MustParse("2"),
+		core.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+	}
+
+	limits, annotations := GetProportionalLimit(originalLimit, originalRequest, core.ResourceList{
+		core.ResourceCPU:              resource.MustParse("2"),
+		core.ResourceEphemeralStorage: resource.MustParse("3Gi"),
+	}, core.ResourceList{})
+	assert.Equal(t, []string{"memory: limit NOT set since originalLimit is nil or 0"}, annotations)
+	assert.Equal(t, int64(4000), limits.Cpu().MilliValue())
+	assert.Equal(t, mustParseToPointer("6Gi").Value(), limits.StorageEphemeral().Value())
+
+	limits, _ = GetProportionalLimit(originalLimit, originalRequest, core.ResourceList{
+		core.ResourceCPU: resource.MustParse("2"),
+	}, core.ResourceList{})
+	_, found := limits[core.ResourceEphemeralStorage]
+	assert.False(t, found, "the limit isn't changed if ephemeral storage isn't recommended")
+}
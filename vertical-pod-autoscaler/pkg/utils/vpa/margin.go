@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewMarginRecommendationProcessor constructs a RecommendationProcessor which scales the
+// recommendation up by marginFraction before passing it to the given processor, so that
+// capping still applies to the scaled recommendation.
+func NewMarginRecommendationProcessor(processor RecommendationProcessor, marginFraction float64) RecommendationProcessor {
+	return &marginRecommendationProcessor{
+		processor:      processor,
+		marginFraction: marginFraction,
+	}
+}
+
+type marginRecommendationProcessor struct {
+	processor      RecommendationProcessor
+	marginFraction float64
+}
+
+// Apply scales the target and bounds of the recommendation and processes the result with the
+// underlying processor. The VPA object passed in is not modified.
+func (p *marginRecommendationProcessor) Apply(
+	vpa *vpa_types.VerticalPodAutoscaler,
+	pod *apiv1.Pod) (*vpa_types.RecommendedPodResources, ContainerToAnnotationsMap, error) {
+	if vpa == nil {
+		return nil, nil, errors.New("cannot process nil vpa")
+	}
+	if vpa.Status.Recommendation == nil {
+		return p.processor.Apply(vpa, pod)
+	}
+
+	scaledVpa := vpa.DeepCopy()
+	factor := 1 + p.marginFraction
+	for i := range scaledVpa.Status.Recommendation.ContainerRecommendations {
+		recommendation := &scaledVpa.Status.Recommendation.ContainerRecommendations[i]
+		recommendation.Target = scaleResources(recommendation.Target, factor)
+		recommendation.LowerBound = scaleResources(recommendation.LowerBound, factor)
+		recommendation.UpperBound = scaleResources(recommendation.UpperBound, factor)
+		recommendation.UncappedTarget = scaleResources(recommendation.UncappedTarget, factor)
+	}
+	return p.processor.Apply(scaledVpa, pod)
+}
+
+func scaleResources(resources apiv1.ResourceList, factor float64) apiv1.ResourceList {
+	if resources == nil {
+		return nil
+	}
+	scaled := make(apiv1.ResourceList, len(resources))
+	for name, quantity := range resources {
+		scaled[name] = scaleQuantity(name, quantity, factor)
+	}
+	return scaled
+}
+
+// scaleQuantity multiplies the quantity by factor, rounding up to whole millicores
+// for CPU and to whole units, e.g. bytes, for other resources.
+func scaleQuantity(name apiv1.ResourceName, quantity resource.Quantity, factor float64) resource.Quantity {
+	if name == apiv1.ResourceCPU {
+		return *resource.NewMilliQuantity(scaleUp(quantity.MilliValue(), factor), quantity.Format)
+	}
+	return *resource.NewQuantity(scaleUp(quantity.Value(), factor), quantity.Format)
+}
+
+// factorPrecision is the denominator the scaling factor is rounded to, so that the
+// scaling is done in integer arithmetic, e.g. 100m scaled by 1.15 is 115m rather than 116m.
+const factorPrecision = 1000000
+
+// scaleUp multiplies the value by factor, rounding up.
+func scaleUp(value int64, factor float64) int64 {
+	scaled := new(big.Int).Mul(big.NewInt(value), big.NewInt(int64(math.Round(factor*factorPrecision))))
+	quotient, remainder := new(big.Int).QuoRem(scaled, big.NewInt(factorPrecision), new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient.Int64()
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestMarginRecommendationProcessor(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer("ctr-name").
+		WithTarget("100m", "1000Mi").
+		WithLowerBound("10m", "100Mi").
+		WithUpperBound("333m", "1Gi").
+		WithMaxAllowed("ctr-name", "300m", "5Gi").Get()
+
+	processor := NewMarginRecommendationProcessor(NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}), 0.15)
+	res, _, err := processor.Apply(vpa, pod)
+	assert.NoError(t, err)
+
+	recommendation := res.ContainerRecommendations[0]
+	cpu := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceCPU]).String() }
+	memory := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceMemory]).String() }
+	assert.Equal(t, "115m", cpu(recommendation.Target))
+	assert.Equal(t, "1150Mi", memory(recommendation.Target))
+	assert.Equal(t, "12m", cpu(recommendation.LowerBound), "scaled values are rounded up")
+	assert.Equal(t, "1234803098", memory(recommendation.UpperBound), "scaled values are rounded up")
+	assert.Equal(t, "300m", cpu(recommendation.UpperBound), "scaled values are capped")
+	assert.Equal(t, "100m", cpu(vpa.Status.Recommendation.ContainerRecommendations[0].Target), "the VPA object isn't modified")
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// RecommendationOverrideAnnotationPrefix followed by a container name is the key of a pod
+// annotation pinning the requests of the container, e.g. "vpa-override.k8s.io/app: cpu=200m,memory=256Mi".
+// The given requests are applied instead of the recommendation, still subject to the resource policy.
+const RecommendationOverrideAnnotationPrefix = "vpa-override.k8s.io/"
+
+// NewOverrideRecommendationProcessor constructs a RecommendationProcessor which replaces the
+// recommendation of containers with the requests in the override annotations of the pod before
+// passing it to the given processor, so that capping still applies to the overrides.
+func NewOverrideRecommendationProcessor(processor RecommendationProcessor) RecommendationProcessor {
+	return &overrideRecommendationProcessor{processor: processor}
+}
+
+type overrideRecommendationProcessor struct {
+	processor RecommendationProcessor
+}
+
+// Apply replaces the target and bounds of overridden resources and processes the result with
+// the underlying processor. The VPA object passed in is not modified.
+func (p *overrideRecommendationProcessor) Apply(
+	vpa *vpa_types.VerticalPodAutoscaler,
+	pod *apiv1.Pod) (*vpa_types.RecommendedPodResources, ContainerToAnnotationsMap, error) {
+	if vpa == nil {
+		return nil, nil, errors.New("cannot process nil vpa")
+	}
+	overrides := GetRecommendationOverrides(pod)
+	if len(overrides) == 0 || vpa.Status.Recommendation == nil {
+		return p.processor.Apply(vpa, pod)
+	}
+
+	overriddenVpa := vpa.DeepCopy()
+	for i := range overriddenVpa.Status.Recommendation.ContainerRecommendations {
+		recommendation := &overriddenVpa.Status.Recommendation.ContainerRecommendations[i]
+		override, found := overrides[recommendation.ContainerName]
+		if !found {
+			continue
+		}
+		klog.V(4).InfoS("Overriding recommendation of container", "pod", klog.KObj(pod), "container", recommendation.ContainerName, "override", override)
+		recommendation.Target = withOverride(recommendation.Target, override)
+		recommendation.LowerBound = withOverride(recommendation.LowerBound, override)
+		recommendation.UpperBound = withOverride(recommendation.UpperBound, override)
+		recommendation.UncappedTarget = withOverride(recommendation.UncappedTarget, override)
+	}
+	return p.processor.Apply(overriddenVpa, pod)
+}
+
+func withOverride(resources apiv1.ResourceList, override apiv1.ResourceList) apiv1.ResourceList {
+	if resources == nil {
+		resources = make(apiv1.ResourceList, len(override))
+	}
+	for name, quantity := range override {
+		resources[name] = quantity.DeepCopy()
+	}
+	return resources
+}
+
+// GetRecommendationOverrides returns the requests pinned by the override annotations of the pod,
+// keyed by container name. Invalid annotations are ignored.
+func GetRecommendationOverrides(pod *apiv1.Pod) map[string]apiv1.ResourceList {
+	if pod == nil {
+		return nil
+	}
+	var overrides map[string]apiv1.ResourceList
+	for key, value := range pod.Annotations {
+		containerName, found := strings.CutPrefix(key, RecommendationOverrideAnnotationPrefix)
+		if !found || containerName == "" {
+			continue
+		}
+		override, err := ParseRecommendationOverride(value)
+		if err != nil {
+			klog.V(2).InfoS("Ignoring invalid recommendation override", "pod", klog.KObj(pod), "annotation", key, "error", err)
+			continue
+		}
+		if overrides == nil {
+			overrides = make(map[string]apiv1.ResourceList)
+		}
+		overrides[containerName] = override
+	}
+	return overrides
+}
+
+// ParseRecommendationOverride parses a comma-separated list of resource=quantity pairs,
+// e.g. "cpu=200m,memory=256Mi".
+func ParseRecommendationOverride(value string) (apiv1.ResourceList, error) {
+	override := make(apiv1.ResourceList)
+	for _, pair := range strings.Split(value, ",") {
+		name, quantity, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("%q is not in the resource=quantity format", pair)
+		}
+		resourceName := apiv1.ResourceName(strings.TrimSpace(name))
+		if resourceName != apiv1.ResourceCPU && resourceName != apiv1.ResourceMemory {
+			return nil, fmt.Errorf("unsupported resource %q", resourceName)
+		}
+		parsed, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of %s: %v", resourceName, err)
+		}
+		if parsed.Sign() <= 0 {
+			return nil, fmt.Errorf("quantity of %s has to be positive", resourceName)
+		}
+		override[resourceName] = parsed
+	}
+	return override, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestOverrideRecommendationProcessor(t *testing.T) {
+	cpu := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceCPU]).String() }
+	memory := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceMemory]).String() }
+
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedCPU    string
+		expectedMemory string
+	}{
+		{
+			name:           "no override",
+			expectedCPU:    "100m",
+			expectedMemory: "1000Mi",
+		},
+		{
+			name:           "valid override",
+			annotations:    map[string]string{RecommendationOverrideAnnotationPrefix + "ctr-name": "cpu=200m, memory=256Mi"},
+			expectedCPU:    "200m",
+			expectedMemory: "256Mi",
+		},
+		{
+			name:           "override of a single resource",
+			annotations:    map[string]string{RecommendationOverrideAnnotationPrefix + "ctr-name": "memory=256Mi"},
+			expectedCPU:    "100m",
+			expectedMemory: "256Mi",
+		},
+		{
+			name:           "override above maxAllowed is capped",
+			annotations:    map[string]string{RecommendationOverrideAnnotationPrefix + "ctr-name": "cpu=4,memory=10Gi"},
+			expectedCPU:    "300m",
+			expectedMemory: "5Gi",
+		},
+		{
+			name:           "override of another container",
+			annotations:    map[string]string{RecommendationOverrideAnnotationPrefix + "other": "cpu=200m"},
+			expectedCPU:    "100m",
+			expectedMemory: "1000Mi",
+		},
+		{
+			name:           "invalid override is ignored",
+			annotations:    map[string]string{RecommendationOverrideAnnotationPrefix + "ctr-name": "cpu=lots"},
+			expectedCPU:    "100m",
+			expectedMemory: "1000Mi",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).WithAnnotations(tc.annotations).Get()
+			vpa := test.VerticalPodAutoscaler().WithContainer("ctr-name").
+				WithTarget("100m", "1000Mi").
+				WithLowerBound("10m", "100Mi").
+				WithUpperBound("333m", "2Gi").
+				WithMaxAllowed("ctr-name", "300m", "5Gi").Get()
+
+			processor := NewOverrideRecommendationProcessor(NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}))
+			res, _, err := processor.Apply(vpa, pod)
+			assert.NoError(t, err)
+
+			recommendation := res.ContainerRecommendations[0]
+			assert.Equal(t, tc.expectedCPU, cpu(recommendation.Target))
+			assert.Equal(t, tc.expectedMemory, memory(recommendation.Target))
+			assert.Equal(t, "100m", cpu(vpa.Status.Recommendation.ContainerRecommendations[0].Target), "the VPA object isn't modified")
+		})
+	}
+}
+
+func TestParseRecommendationOverride(t *testing.T) {
+	override, err := ParseRecommendationOverride("cpu=200m,memory=256Mi")
+	assert.NoError(t, err)
+	assert.Equal(t, "200m", ptr.To(override[apiv1.ResourceCPU]).String())
+	assert.Equal(t, "256Mi", ptr.To(override[apiv1.ResourceMemory]).String())
+
+	for _, value := range []string{"", "cpu", "cpu=lots", "cpu=0", "cpu=-1", "storage=1Gi", "cpu=1,"} {
+		_, err := ParseRecommendationOverride(value)
+		assert.Error(t, err, "value %q", value)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	vpa_types "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// NewRoundingRecommendationProcessor constructs a RecommendationProcessor which rounds the
+// recommendation to multiples of the granularity of each resource, e.g. 50m CPU, before passing
+// it to the given processor, so that the min and max allowed still apply to the rounded values.
+// The target and upper bound are rounded up and the lower bound down, so that the target stays
+// within the bounds. Resources without a granularity are not rounded.
+func NewRoundingRecommendationProcessor(processor RecommendationProcessor, granularity apiv1.ResourceList) RecommendationProcessor {
+	return &roundingRecommendationProcessor{
+		processor:   processor,
+		granularity: granularity,
+	}
+}
+
+type roundingRecommendationProcessor struct {
+	processor   RecommendationProcessor
+	granularity apiv1.ResourceList
+}
+
+// Apply rounds the target and bounds of the recommendation and processes the result with the
+// underlying processor. The VPA object passed in is not modified.
+func (p *roundingRecommendationProcessor) Apply(
+	vpa *vpa_types.VerticalPodAutoscaler,
+	pod *apiv1.Pod) (*vpa_types.RecommendedPodResources, ContainerToAnnotationsMap, error) {
+	if vpa == nil {
+		return nil, nil, errors.New("cannot process nil vpa")
+	}
+	if vpa.Status.Recommendation == nil {
+		return p.processor.Apply(vpa, pod)
+	}
+
+	roundedVpa := vpa.DeepCopy()
+	for i := range roundedVpa.Status.Recommendation.ContainerRecommendations {
+		recommendation := &roundedVpa.Status.Recommendation.ContainerRecommendations[i]
+		recommendation.Target = p.roundResources(recommendation.Target, true)
+		recommendation.LowerBound = p.roundResources(recommendation.LowerBound, false)
+		recommendation.UpperBound = p.roundResources(recommendation.UpperBound, true)
+		recommendation.UncappedTarget = p.roundResources(recommendation.UncappedTarget, true)
+	}
+	return p.processor.Apply(roundedVpa, pod)
+}
+
+func (p *roundingRecommendationProcessor) roundResources(resources apiv1.ResourceList, up bool) apiv1.ResourceList {
+	if resources == nil {
+		return nil
+	}
+	rounded := make(apiv1.ResourceList, len(resources))
+	for name, quantity := range resources {
+		granularity, found := p.granularity[name]
+		if !found {
+			rounded[name] = quantity
+			continue
+		}
+		rounded[name] = roundQuantity(name, quantity, granularity, up)
+	}
+	return rounded
+}
+
+// roundQuantity rounds the quantity to a multiple of granularity, in millicores for CPU
+// and in whole units, e.g. bytes, for other resources.
+func roundQuantity(name apiv1.ResourceName, quantity, granularity resource.Quantity, up bool) resource.Quantity {
+	if name == apiv1.ResourceCPU {
+		return *resource.NewMilliQuantity(roundToMultiple(quantity.MilliValue(), granularity.MilliValue(), up), quantity.Format)
+	}
+	return *resource.NewQuantity(roundToMultiple(quantity.Value(), granularity.Value(), up), quantity.Format)
+}
+
+// roundToMultiple rounds value up or down to a multiple of step. A step of 0 or less leaves the value as is.
+func roundToMultiple(value, step int64, up bool) int64 {
+	if step <= 0 {
+		return value
+	}
+	remainder := value % step
+	if remainder == 0 {
+		return value
+	}
+	if up {
+		return value - remainder + step
+	}
+	return value - remainder
+}
+
+// ParseRecommendationGranularity parses the granularity of CPU and memory recommendations, e.g.
+// "50m" and "32Mi". Empty values leave the resource out, so that it isn't rounded.
+func ParseRecommendationGranularity(cpu, memory string) (apiv1.ResourceList, error) {
+	granularity := apiv1.ResourceList{}
+	for name, value := range map[apiv1.ResourceName]string{apiv1.ResourceCPU: cpu, apiv1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s granularity %q: %v", name, value, err)
+		}
+		if quantity.Sign() <= 0 {
+			return nil, fmt.Errorf("%s granularity %q has to be positive", name, value)
+		}
+		granularity[name] = quantity
+	}
+	return granularity, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"k8s.io/autoscaler/vertical-pod-autoscaler/pkg/utils/test"
+)
+
+func TestRoundingRecommendationProcessor(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer("ctr-name").
+		WithTarget("237m", "413Mi").
+		WithLowerBound("120m", "200Mi").
+		WithUpperBound("640m", "1000Mi").
+		WithMinAllowed("ctr-name", "130m", "100Mi").
+		WithMaxAllowed("ctr-name", "620m", "5Gi").Get()
+
+	granularity, err := ParseRecommendationGranularity("50m", "32Mi")
+	assert.NoError(t, err)
+	processor := NewRoundingRecommendationProcessor(NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}), granularity)
+	res, _, err := processor.Apply(vpa, pod)
+	assert.NoError(t, err)
+
+	recommendation := res.ContainerRecommendations[0]
+	cpu := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceCPU]).String() }
+	memory := func(resources apiv1.ResourceList) string { return ptr.To(resources[apiv1.ResourceMemory]).String() }
+	assert.Equal(t, "250m", cpu(recommendation.Target), "the target is rounded up")
+	assert.Equal(t, "416Mi", memory(recommendation.Target), "the target is rounded up")
+	assert.Equal(t, "192Mi", memory(recommendation.LowerBound), "the lower bound is rounded down")
+	assert.Equal(t, "1Gi", memory(recommendation.UpperBound), "the upper bound is rounded up")
+	assert.Equal(t, "130m", cpu(recommendation.LowerBound), "rounded values are capped by the min allowed")
+	assert.Equal(t, "620m", cpu(recommendation.UpperBound), "rounded values are capped by the max allowed")
+	assert.Equal(t, "237m", cpu(vpa.Status.Recommendation.ContainerRecommendations[0].Target), "the VPA object isn't modified")
+}
+
+func TestRoundingRecommendationProcessorWithoutGranularity(t *testing.T) {
+	pod := test.Pod().WithName("pod1").AddContainer(test.Container().WithName("ctr-name").Get()).Get()
+	vpa := test.VerticalPodAutoscaler().WithContainer("ctr-name").WithTarget("237m", "413Mi").Get()
+
+	granularity, err := ParseRecommendationGranularity("", "32Mi")
+	assert.NoError(t, err)
+	processor := NewRoundingRecommendationProcessor(NewCappingRecommendationProcessor(&fakeLimitRangeCalculator{}), granularity)
+	res, _, err := processor.Apply(vpa, pod)
+	assert.NoError(t, err)
+	target := res.ContainerRecommendations[0].Target
+	assert.Equal(t, "237m", ptr.To(target[apiv1.ResourceCPU]).String(), "resources without a granularity are not rounded")
+	assert.Equal(t, "416Mi", ptr.To(target[apiv1.ResourceMemory]).String())
+}
+
+func TestParseRecommendationGranularity(t *testing.T) {
+	granularity, err := ParseRecommendationGranularity("50m", "32Mi")
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("50m"),
+		apiv1.ResourceMemory: resource.MustParse("32Mi"),
+	}, granularity)
+
+	granularity, err = ParseRecommendationGranularity("", "")
+	assert.NoError(t, err)
+	assert.Empty(t, granularity)
+
+	_, err = ParseRecommendationGranularity("fast", "")
+	assert.Error(t, err)
+	_, err = ParseRecommendationGranularity("", "-32Mi")
+	assert.Error(t, err)
+}